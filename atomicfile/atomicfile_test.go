@@ -0,0 +1,60 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package atomicfile_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nelsam/vidar/atomicfile"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+func TestWriteFile(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	o.BeforeEach(func(t *testing.T) (expect.Expectation, string) {
+		dir, err := ioutil.TempDir("", "vidar-atomicfile")
+		if err != nil {
+			t.Fatalf("could not create temp dir: %s", err)
+		}
+		return expect.New(t), dir
+	})
+
+	o.AfterEach(func(expect expect.Expectation, dir string) {
+		os.RemoveAll(dir)
+	})
+
+	o.Spec("it replaces files, keeping their permissions", func(expect expect.Expectation, dir string) {
+		path := filepath.Join(dir, "foo.go")
+		expect(ioutil.WriteFile(path, []byte("old"), 0640)).To(not(haveOccurred()))
+		expect(atomicfile.WriteFile(path, []byte("new"), 0600)).To(not(haveOccurred()))
+
+		b, err := ioutil.ReadFile(path)
+		expect(err).To(not(haveOccurred()))
+		expect(string(b)).To(equal("new"))
+
+		info, err := os.Stat(path)
+		expect(err).To(not(haveOccurred()))
+		expect(info.Mode().Perm()).To(equal(os.FileMode(0640)))
+
+		infos, err := ioutil.ReadDir(dir)
+		expect(err).To(not(haveOccurred()))
+		expect(infos).To(haveLen(1))
+	})
+
+	o.Spec("it creates new files with the passed in permissions", func(expect expect.Expectation, dir string) {
+		path := filepath.Join(dir, "foo.go")
+		expect(atomicfile.WriteFile(path, []byte("new"), 0600)).To(not(haveOccurred()))
+
+		info, err := os.Stat(path)
+		expect(err).To(not(haveOccurred()))
+		expect(info.Mode().Perm()).To(equal(os.FileMode(0600)))
+	})
+}
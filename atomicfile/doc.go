@@ -0,0 +1,9 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+// Package atomicfile writes files so that readers never see them
+// partially written, even if vidar crashes while writing them.
+//
+// Like the dlv package, this package does not import any UI code.
+package atomicfile
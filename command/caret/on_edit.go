@@ -14,6 +14,11 @@ type Commander interface {
 	Execute(bind.Bindable)
 }
 
+// focuser is any editor that knows whether or not it has focus.
+type focuser interface {
+	HasFocus() bool
+}
+
 type OnEdit struct {
 	Commander Commander
 }
@@ -32,6 +37,12 @@ func (o *OnEdit) Applied(e text.Editor, edits []text.Edit) {
 	for _, e := range edits {
 		carets = o.moveCarets(carets, e)
 	}
+	if f, ok := e.(focuser); ok && !f.HasFocus() {
+		// The caret-movement command only moves the focused
+		// editor's carets.
+		h.SetCarets(carets...)
+		return
+	}
 	m := o.Commander.Bindable("caret-movement").(*Mover)
 	o.Commander.Execute(m.To(carets...))
 }
@@ -21,9 +21,17 @@ type BindPopper interface {
 	Pop() []bind.Bindable
 }
 
+// A FileCloser is a hook that needs to know when a file's editor is
+// closed.
+type FileCloser interface {
+	FileClosed(path string)
+}
+
 type CloseTab struct {
 	closer CurrentEditorCloser
 	binder BindPopper
+
+	closers []FileCloser
 }
 
 func NewCloseTab() *CloseTab {
@@ -45,6 +53,17 @@ func (s *CloseTab) Defaults() []fmt.Stringer {
 	}}
 }
 
+func (s *CloseTab) Bind(h bind.Bindable) (bind.HookedMultiOp, error) {
+	c, ok := h.(FileCloser)
+	if !ok {
+		return nil, fmt.Errorf("expected FileCloser; got %T", h)
+	}
+	newS := NewCloseTab()
+	newS.closers = append(newS.closers, s.closers...)
+	newS.closers = append(newS.closers, c)
+	return newS, nil
+}
+
 func (s *CloseTab) Reset() {
 	s.closer = nil
 	s.binder = nil
@@ -64,9 +83,15 @@ func (s *CloseTab) Store(target interface{}) bind.Status {
 }
 
 func (s *CloseTab) Exec() error {
-	s.closer.CloseCurrentEditor()
+	_, closed := s.closer.CloseCurrentEditor()
 	if s.closer.CurrentEditor() == nil {
 		s.binder.Pop()
 	}
+	if closed == nil {
+		return nil
+	}
+	for _, c := range s.closers {
+		c.FileClosed(closed.Filepath())
+	}
 	return nil
 }
@@ -8,10 +8,19 @@ import (
 	"github.com/nelsam/gxui"
 	"github.com/nelsam/gxui/themes/basic"
 	"github.com/nelsam/vidar/command/caret"
+	"github.com/nelsam/vidar/command/debug"
+	"github.com/nelsam/vidar/command/diffview"
 	"github.com/nelsam/vidar/command/focus"
+	"github.com/nelsam/vidar/command/gotask"
 	"github.com/nelsam/vidar/command/history"
+	"github.com/nelsam/vidar/command/jump"
+	"github.com/nelsam/vidar/command/macro"
+	"github.com/nelsam/vidar/command/multicursor"
 	"github.com/nelsam/vidar/command/project"
+	"github.com/nelsam/vidar/command/recovery"
 	"github.com/nelsam/vidar/command/scroll"
+	"github.com/nelsam/vidar/command/symbol"
+	"github.com/nelsam/vidar/command/vcs"
 	"github.com/nelsam/vidar/commander/bind"
 	"github.com/nelsam/vidar/plugin/command"
 )
@@ -20,10 +29,11 @@ import (
 // added to the menu.
 func Bindables(cmdr command.Commander, driver gxui.Driver, theme *basic.Theme) []bind.Bindable {
 	var b []bind.Bindable
-	b = append(b, project.Bindables(driver, theme)...)
+	b = append(b, project.Bindables(cmdr, driver, theme)...)
 	b = append(b,
 		NewFileOpener(driver, theme),
-		Quit{},
+		NewResolveConflict(theme),
+		Quit{Commander: cmdr},
 		Fullscreen{},
 		&caret.Mover{},
 		&scroll.Scroller{},
@@ -32,7 +42,17 @@ func Bindables(cmdr command.Commander, driver gxui.Driver, theme *basic.Theme) [
 		EditHook{Theme: theme, Driver: driver},
 		ViewHook{},
 		NavHook{Commander: cmdr},
+		multicursor.Hook{},
 	)
+	b = append(b, SessionBindables(cmdr, driver, theme)...)
 	b = append(b, history.Bindables(cmdr, driver, theme)...)
+	b = append(b, macro.Bindables(theme)...)
+	b = append(b, gotask.Bindables(cmdr, driver, theme)...)
+	b = append(b, vcs.Bindables(driver, theme)...)
+	b = append(b, diffview.Bindables(cmdr, driver, theme)...)
+	b = append(b, recovery.Bindables(cmdr, driver, theme)...)
+	b = append(b, debug.Bindables(cmdr, driver, theme)...)
+	b = append(b, symbol.Bindables(driver, theme)...)
+	b = append(b, jump.Bindables(cmdr, driver, theme)...)
 	return b
 }
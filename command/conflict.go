@@ -0,0 +1,192 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/diff"
+	"github.com/nelsam/vidar/editor"
+	"github.com/nelsam/vidar/plugin/status"
+)
+
+// A Conflicter is an editor whose file may have changed on disk while
+// it had unsaved changes.
+type Conflicter interface {
+	text.Editor
+	Conflict() (editor.Conflict, bool)
+	ResolveConflict()
+}
+
+// ResolveConflict is a command that resolves a conflict between an
+// editor's unsaved changes and changes that were written to its file
+// on disk, by keeping the editor's text, taking the text on disk, or
+// merging the two.
+type ResolveConflict struct {
+	status.General
+
+	prompt gxui.Label
+	choice gxui.TextBox
+	input  gxui.Focusable
+
+	editor  Conflicter
+	applier Applier
+}
+
+// NewResolveConflict returns a *ResolveConflict.
+func NewResolveConflict(theme gxui.Theme) *ResolveConflict {
+	r := &ResolveConflict{
+		prompt: theme.CreateLabel(),
+		choice: theme.CreateTextBox(),
+	}
+	r.Theme = theme
+	return r
+}
+
+func (r *ResolveConflict) Name() string {
+	return "resolve-conflict"
+}
+
+func (r *ResolveConflict) Menu() string {
+	return "File"
+}
+
+func (r *ResolveConflict) Defaults() []fmt.Stringer {
+	return nil
+}
+
+func (r *ResolveConflict) Start(control gxui.Control) gxui.Control {
+	r.choice.SetText("merge")
+	r.input = r.choice
+	name := "The file"
+	if e := findConflicter(control); e != nil {
+		name = filepath.Base(e.Filepath())
+	}
+	r.prompt.SetText(fmt.Sprintf("%s changed on disk; keep (mine), take (theirs), or merge?", name))
+	return r.prompt
+}
+
+func (r *ResolveConflict) Next() gxui.Focusable {
+	input := r.input
+	r.input = nil
+	return input
+}
+
+func (r *ResolveConflict) Reset() {
+	r.editor = nil
+	r.applier = nil
+}
+
+func (r *ResolveConflict) Store(elem interface{}) bind.Status {
+	switch src := elem.(type) {
+	case Conflicter:
+		r.editor = src
+	case Applier:
+		r.applier = src
+	}
+	if r.editor == nil || r.applier == nil {
+		return bind.Waiting
+	}
+	return bind.Done
+}
+
+func (r *ResolveConflict) Exec() error {
+	c, ok := r.editor.Conflict()
+	if !ok {
+		r.Info = fmt.Sprintf("%s does not conflict with the file on disk", filepath.Base(r.editor.Filepath()))
+		return nil
+	}
+	name := filepath.Base(r.editor.Filepath())
+	action, err := conflictAction(strings.ToLower(strings.TrimSpace(r.choice.Text())))
+	if err != nil {
+		r.Err = fmt.Sprintf("resolve-conflict: %s", err)
+		return errors.New(r.Err)
+	}
+	switch action {
+	case "keep":
+		r.Info = fmt.Sprintf("Kept the unsaved changes to %s; saving will overwrite the changes on disk", name)
+	case "take":
+		r.replace(c.Theirs)
+		r.Info = fmt.Sprintf("Reloaded %s from disk", name)
+	case "merge":
+		merged, conflicts := diff.Merge(c.Base, r.editor.Text(), c.Theirs)
+		r.replace(merged)
+		r.Info = fmt.Sprintf("Merged the changes on disk into %s", name)
+		if conflicts > 0 {
+			r.Warn = fmt.Sprintf("Merged %s with %d conflicts; they are marked with %q", name, conflicts, strings.TrimSpace(diff.MineMarker))
+		}
+	}
+	r.editor.ResolveConflict()
+	return nil
+}
+
+// conflictActions maps the words that resolve-conflict accepts to
+// the actions that they choose.
+var conflictActions = map[string]string{
+	"keep":   "keep",
+	"mine":   "keep",
+	"take":   "take",
+	"theirs": "take",
+	"merge":  "merge",
+}
+
+// conflictAction returns the action chosen by choice, which may be
+// any prefix of the accepted words that only one action starts with.
+func conflictAction(choice string) (string, error) {
+	if choice == "" {
+		return "", errors.New("no choice was made")
+	}
+	action := ""
+	for word, a := range conflictActions {
+		if !strings.HasPrefix(word, choice) {
+			continue
+		}
+		if action != "" && action != a {
+			return "", fmt.Errorf("%q could mean more than one of keep, take, or merge", choice)
+		}
+		action = a
+	}
+	if action == "" {
+		return "", fmt.Errorf("%q is not one of keep, take, or merge", choice)
+	}
+	return action, nil
+}
+
+// replace replaces the editor's text with newText, as a single edit
+// that can be undone.
+func (r *ResolveConflict) replace(newText string) {
+	current := r.editor.Text()
+	if current == newText {
+		return
+	}
+	r.applier.Apply(r.editor, text.Edit{
+		At:  0,
+		Old: []rune(current),
+		New: []rune(newText),
+	})
+}
+
+// findConflicter returns the first Conflicter in elem or its
+// elements.
+func findConflicter(elem interface{}) Conflicter {
+	switch src := elem.(type) {
+	case Conflicter:
+		return src
+	case commander.Elementer:
+		for _, child := range src.Elements() {
+			if c := findConflicter(child); c != nil {
+				return c
+			}
+		}
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/nelsam/gxui"
 	"github.com/nelsam/vidar/commander/bind"
@@ -54,6 +55,12 @@ func (c *Copy) Exec(target interface{}) bind.Status {
 	selections := editor.Controller().SelectionSlice()
 	var buffer bytes.Buffer
 	for i := 0; i < len(selections); i++ {
+		if i > 0 {
+			// Each caret's selection goes on its own line, so that
+			// pasting with the same number of carets can put each
+			// line back at its own caret.
+			buffer.WriteRune('\n')
+		}
 		buffer.WriteString(editor.Controller().SelectionText(i))
 	}
 
@@ -185,8 +192,13 @@ func (p *Paste) replaceSelections() {
 		p.Err = fmt.Sprintf("Error reading clipboard: %s", err)
 		return
 	}
-	replacement := []rune(contents)
-	for _, s := range p.editor.Controller().SelectionSlice() {
+	selections := p.editor.Controller().SelectionSlice()
+	lines := strings.Split(contents, "\n")
+	for i, s := range selections {
+		replacement := []rune(contents)
+		if len(selections) > 1 && len(lines) == len(selections) {
+			replacement = []rune(lines[i])
+		}
 		old := r[s.Start():s.End()]
 		edits = append(edits, text.Edit{
 			At:  s.Start(),
@@ -0,0 +1,140 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package debug
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/plugin/status"
+)
+
+// ToggleBreakpoint is a command that sets or removes a breakpoint on
+// the line under the caret.
+type ToggleBreakpoint struct {
+	status.General
+
+	debugger *Debugger
+	editor   CaretEditor
+}
+
+// NewToggleBreakpoint returns a *ToggleBreakpoint that toggles
+// breakpoints in d.
+func NewToggleBreakpoint(theme gxui.Theme, d *Debugger) *ToggleBreakpoint {
+	t := &ToggleBreakpoint{debugger: d}
+	t.Theme = theme
+	return t
+}
+
+func (t *ToggleBreakpoint) Name() string {
+	return "toggle-breakpoint"
+}
+
+func (t *ToggleBreakpoint) Menu() string {
+	return "Debug"
+}
+
+func (t *ToggleBreakpoint) Defaults() []fmt.Stringer {
+	return []fmt.Stringer{gxui.KeyboardEvent{
+		Key: gxui.KeyF9,
+	}}
+}
+
+func (t *ToggleBreakpoint) Reset() {
+	t.editor = nil
+}
+
+func (t *ToggleBreakpoint) Store(elem interface{}) bind.Status {
+	if e, ok := elem.(CaretEditor); ok {
+		t.editor = e
+		return bind.Done
+	}
+	return bind.Waiting
+}
+
+func (t *ToggleBreakpoint) Exec() error {
+	path := t.editor.Filepath()
+	if !strings.HasSuffix(path, ".go") {
+		t.Warn = fmt.Sprintf("toggle-breakpoint: %s is not a go file", filepath.Base(path))
+		return nil
+	}
+	carets := t.editor.Carets()
+	if len(carets) == 0 {
+		return nil
+	}
+	line := 0
+	for _, r := range t.editor.Runes()[:carets[0]] {
+		if r == '\n' {
+			line++
+		}
+	}
+	if err := t.debugger.Toggle(path, line); err != nil {
+		t.Err = fmt.Sprintf("toggle-breakpoint: %s", err)
+		return err
+	}
+	return nil
+}
+
+// LineHook binds the breakpoint display to go files as they are
+// focused.
+type LineHook struct {
+	debugger *Debugger
+}
+
+func (h LineHook) Name() string {
+	return "debug-line-hook"
+}
+
+func (h LineHook) OpName() string {
+	return "focus-location"
+}
+
+func (h LineHook) FileBindables(path string) []bind.Bindable {
+	if !strings.HasSuffix(path, ".go") {
+		return nil
+	}
+	return []bind.Bindable{lines{debugger: h.debugger}}
+}
+
+// lines displays the breakpoints in an editor, along with the line
+// that the debugged process is stopped at, and toggles breakpoints
+// when line numbers are clicked.
+type lines struct {
+	debugger *Debugger
+}
+
+func (l lines) Name() string {
+	return "debug-lines"
+}
+
+func (l lines) OpName() string {
+	return "input-handler"
+}
+
+func (l lines) Init(e text.Editor, _ []rune) {
+	l.debugger.addEditor(e)
+	if c, ok := e.(GutterClicker); ok {
+		c.OnGutterClick(func(line int) {
+			if err := l.debugger.Toggle(e.Filepath(), line); err != nil {
+				log.Printf("debug: could not toggle breakpoint: %s", err)
+			}
+		})
+	}
+}
+
+func (l lines) TextChanged(text.Editor, text.Edit) {
+}
+
+// Apply renders the lines again, since edits move the lines around
+// in the text.
+func (l lines) Apply(e text.Editor) error {
+	l.debugger.render(e.Filepath())
+	return nil
+}
@@ -0,0 +1,581 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+// Package debug contains commands that debug go programs and tests
+// with a headless delve (dlv) server, along with the hook that
+// displays breakpoints and the line the program is stopped at.
+// Goroutines, call stacks, and local variables are sent to any
+// element implementing GoroutineReporter, StackReporter, or
+// LocalsReporter.
+package debug
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/command/focus"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/dlv"
+	"github.com/nelsam/vidar/plugin/command"
+	"github.com/nelsam/vidar/setting"
+	"github.com/nelsam/vidar/task"
+	"github.com/nelsam/vidar/theme"
+)
+
+const (
+	breakpointsDirname = "breakpoints"
+
+	breakpointOverlayName = "breakpoints"
+	lineOverlayName       = "debug"
+
+	// connectTimeout is how long delve has to build the program
+	// and start listening.
+	connectTimeout = 2 * time.Minute
+
+	// stackDepth is the number of frames loaded for a call
+	// stack.
+	stackDepth = 50
+)
+
+// A GoroutineReporter displays the goroutines of a debugged
+// process.  ReportGoroutines may be called from any goroutine; it is
+// called with no goroutines when debugging ends.  Calling selected
+// with a goroutine's ID loads that goroutine's call stack.
+type GoroutineReporter interface {
+	ReportGoroutines(gs []dlv.Goroutine, current int64, selected func(id int64))
+}
+
+// A StackReporter displays the call stack of a goroutine in a
+// debugged process, innermost frame first.  ReportStack may be
+// called from any goroutine; it is called with no frames when
+// debugging ends.  Calling selected with the index of a frame loads
+// that frame's local variables.
+type StackReporter interface {
+	ReportStack(frames []dlv.Stackframe, selected func(frame int))
+}
+
+// A LocalsReporter displays the arguments and local variables of a
+// stack frame in a debugged process.  ReportLocals may be called
+// from any goroutine; it is called with no variables when debugging
+// ends.
+type LocalsReporter interface {
+	ReportLocals(vars []dlv.Variable)
+}
+
+// A Projecter is any element that knows which project is current.
+type Projecter interface {
+	Project() setting.Project
+}
+
+// A Locationer is a command that can focus a file.
+type Locationer interface {
+	For(...focus.Opt) bind.Bindable
+}
+
+// Overlayer is a type that can display layers on top of its
+// syntax layers.
+type Overlayer interface {
+	SetOverlay(name string, layers []text.SyntaxLayer)
+}
+
+// A GutterClicker is an editor that calls a func when a line number
+// in its gutter is clicked.
+type GutterClicker interface {
+	OnGutterClick(func(line int))
+}
+
+// Bindables returns the debugging commands, all sharing a single
+// *Debugger, and the hook that displays breakpoints.
+func Bindables(cmdr command.Commander, driver gxui.Driver, theme gxui.Theme) []bind.Bindable {
+	d := NewDebugger(cmdr, driver)
+	return []bind.Bindable{
+		LineHook{debugger: d},
+		NewStart(theme, d, Test),
+		NewStart(theme, d, Program),
+		NewStep(theme, d, Continue),
+		NewStep(theme, d, Next),
+		NewStep(theme, d, StepIn),
+		NewStep(theme, d, StepOut),
+		NewStop(theme, d),
+		NewToggleBreakpoint(theme, d),
+	}
+}
+
+// observers is a task.Observer which notifies several observers.
+type observers []task.Observer
+
+func (o observers) Started(r *task.Run) {
+	for _, obs := range o {
+		obs.Started(r)
+	}
+}
+
+func (o observers) Output(r *task.Run, l task.Line) {
+	for _, obs := range o {
+		obs.Output(r, l)
+	}
+}
+
+func (o observers) Finished(r *task.Run) {
+	for _, obs := range o {
+		obs.Finished(r)
+	}
+}
+
+// reporters is the set of elements that a debugging session reports
+// to.
+type reporters struct {
+	goroutines []GoroutineReporter
+	stacks     []StackReporter
+	locals     []LocalsReporter
+}
+
+func (r *reporters) store(elem interface{}) {
+	if g, ok := elem.(GoroutineReporter); ok {
+		r.goroutines = append(r.goroutines, g)
+	}
+	if s, ok := elem.(StackReporter); ok {
+		r.stacks = append(r.stacks, s)
+	}
+	if l, ok := elem.(LocalsReporter); ok {
+		r.locals = append(r.locals, l)
+	}
+}
+
+// session is a single run of delve.
+type session struct {
+	run    *task.Run
+	client *dlv.Client
+	rep    reporters
+
+	// ids maps breakpoints to the IDs that delve gave them.
+	ids map[dlv.FileLine]int
+
+	// running is true while a command is waiting for the process
+	// to stop.
+	running bool
+}
+
+// Debugger runs delve and keeps track of the breakpoints in each
+// project.  Only one program is debugged at a time.
+type Debugger struct {
+	cmdr   command.Commander
+	driver gxui.Driver
+	runner *task.Runner
+
+	mu          sync.Mutex
+	breakpoints map[string]*dlv.Breakpoints
+	editors     map[string]text.Editor
+	session     *session
+
+	// stopped is the line that the process is stopped at, if any.
+	stopped dlv.FileLine
+}
+
+// NewDebugger returns a *Debugger that uses cmdr to focus the lines
+// that the debugged process stops at.
+func NewDebugger(cmdr command.Commander, driver gxui.Driver) *Debugger {
+	return &Debugger{
+		cmdr:        cmdr,
+		driver:      driver,
+		runner:      &task.Runner{Max: setting.TaskHistory()},
+		breakpoints: make(map[string]*dlv.Breakpoints),
+		editors:     make(map[string]text.Editor),
+	}
+}
+
+// Breakpoints returns the breakpoints of the project that path is
+// in, loading them if they haven't been loaded yet.
+func (d *Debugger) Breakpoints(path string) (*dlv.Breakpoints, error) {
+	proj, ok := setting.ProjectFor(path)
+	if !ok {
+		proj = setting.DefaultProject
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if b, ok := d.breakpoints[proj.Path]; ok {
+		return b, nil
+	}
+	sum := sha256.Sum256([]byte(proj.Path))
+	name := hex.EncodeToString(sum[:])[:16] + ".json"
+	b, err := dlv.LoadBreakpoints(filepath.Join(setting.App.DataHome(), breakpointsDirname, name))
+	if err != nil {
+		return nil, err
+	}
+	d.breakpoints[proj.Path] = b
+	return b, nil
+}
+
+// Toggle sets or removes the breakpoint at line (0-based) of path,
+// updating the process being debugged, if there is one.
+func (d *Debugger) Toggle(path string, line int) error {
+	b, err := d.Breakpoints(path)
+	if err != nil {
+		return err
+	}
+	l := dlv.FileLine{File: path, Line: line + 1}
+	set, err := b.Toggle(l.File, l.Line)
+	if err != nil {
+		return err
+	}
+	d.render(path)
+
+	d.mu.Lock()
+	s := d.session
+	connected := s != nil && s.client != nil
+	d.mu.Unlock()
+	if !connected {
+		return nil
+	}
+	go func() {
+		if set {
+			d.createBreakpoint(s, l)
+			return
+		}
+		d.mu.Lock()
+		id, ok := s.ids[l]
+		delete(s.ids, l)
+		d.mu.Unlock()
+		if !ok {
+			return
+		}
+		if err := s.client.ClearBreakpoint(id); err != nil {
+			log.Printf("debug: could not clear breakpoint at %s:%d: %s", l.File, l.Line, err)
+		}
+	}()
+	return nil
+}
+
+func (d *Debugger) createBreakpoint(s *session, l dlv.FileLine) {
+	bp, err := s.client.CreateBreakpoint(l.File, l.Line)
+	if err != nil {
+		// Delve refuses breakpoints on lines without any code, which
+		// is not worth interrupting anyone over.
+		log.Printf("debug: could not set breakpoint at %s:%d: %s", l.File, l.Line, err)
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s.ids[l] = bp.ID
+}
+
+// Running returns whether a program is being debugged.
+func (d *Debugger) Running() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.session != nil
+}
+
+// start runs delve with args in dir, connecting to it at addr once
+// it is listening.
+func (d *Debugger) start(obs observers, rep reporters, name, dir string, env []string, addr string, args ...string) {
+	s := &session{rep: rep, ids: make(map[dlv.FileLine]int), running: true}
+	d.mu.Lock()
+	d.session = s
+	d.mu.Unlock()
+
+	obs = append(obs, &sessionObserver{debugger: d, session: s})
+	s.run = d.runner.Start(obs, name, dir, env, args...)
+	go d.connect(s, dir, addr)
+}
+
+func (d *Debugger) connect(s *session, dir, addr string) {
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+	go func() {
+		// There's no sense waiting for a server that has exited.
+		s.run.Wait()
+		cancel()
+	}()
+	client, err := dlv.Dial(ctx, addr)
+	if err != nil {
+		log.Printf("debug: could not connect to delve at %s: %s", addr, err)
+		s.run.Cancel()
+		return
+	}
+	d.mu.Lock()
+	if d.session != s {
+		// Debugging was stopped while delve was starting.
+		d.mu.Unlock()
+		client.Detach(true)
+		client.Close()
+		return
+	}
+	s.client = client
+	d.mu.Unlock()
+
+	b, err := d.Breakpoints(dir)
+	if err != nil {
+		log.Printf("debug: could not load breakpoints: %s", err)
+	} else {
+		for _, l := range b.All() {
+			d.createBreakpoint(s, l)
+		}
+	}
+	d.resume(s, client.Continue)
+}
+
+// Resume runs cmd (one of the methods of the *dlv.Client) in the
+// background, displaying where the process stops.  It returns false
+// if the process is already running.
+func (d *Debugger) Resume(cmd func(*dlv.Client) (dlv.State, error)) (bool, error) {
+	d.mu.Lock()
+	s := d.session
+	if s == nil || s.client == nil {
+		d.mu.Unlock()
+		return false, fmt.Errorf("nothing is being debugged")
+	}
+	if s.running {
+		d.mu.Unlock()
+		return false, nil
+	}
+	s.running = true
+	client := s.client
+	d.mu.Unlock()
+	go d.resume(s, func() (dlv.State, error) {
+		return cmd(client)
+	})
+	return true, nil
+}
+
+func (d *Debugger) resume(s *session, cmd func() (dlv.State, error)) {
+	d.setStopped(dlv.FileLine{})
+	state, err := cmd()
+	d.mu.Lock()
+	s.running = false
+	d.mu.Unlock()
+	if err != nil {
+		log.Printf("debug: %s", err)
+		if state, err = s.client.State(); err != nil {
+			return
+		}
+	}
+	if state.Exited {
+		d.Stop()
+		return
+	}
+	loc, ok := state.Location()
+	if !ok {
+		return
+	}
+	d.show(s, state.GoroutineID(), loc)
+}
+
+// show focuses loc and reports the goroutines, the call stack of
+// goroutine, and the locals of its innermost frame.
+func (d *Debugger) show(s *session, goroutine int64, loc dlv.Location) {
+	d.focus(loc)
+	gs, err := s.client.Goroutines()
+	if err != nil {
+		log.Printf("debug: could not list goroutines: %s", err)
+	}
+	for _, r := range s.rep.goroutines {
+		r.ReportGoroutines(gs, goroutine, func(id int64) {
+			go d.selectGoroutine(s, id, true)
+		})
+	}
+	d.selectGoroutine(s, goroutine, false)
+}
+
+// selectGoroutine reports the call stack of goroutine and the locals
+// of its innermost frame, focusing that frame if focusTop is true.
+func (d *Debugger) selectGoroutine(s *session, goroutine int64, focusTop bool) {
+	frames, err := s.client.Stacktrace(goroutine, stackDepth)
+	if err != nil {
+		log.Printf("debug: could not load the stack of goroutine %d: %s", goroutine, err)
+	}
+	for _, r := range s.rep.stacks {
+		r.ReportStack(frames, func(frame int) {
+			if frame < len(frames) {
+				d.focus(frames[frame].Location)
+			}
+			go d.selectFrame(s, goroutine, frame)
+		})
+	}
+	if focusTop && len(frames) > 0 {
+		d.focus(frames[0].Location)
+	}
+	d.selectFrame(s, goroutine, 0)
+}
+
+func (d *Debugger) selectFrame(s *session, goroutine int64, frame int) {
+	vars, err := s.client.Locals(goroutine, frame)
+	if err != nil {
+		log.Printf("debug: could not load locals: %s", err)
+	}
+	for _, r := range s.rep.locals {
+		r.ReportLocals(vars)
+	}
+}
+
+// focus opens loc in an editor and marks its line as the one that
+// the process is stopped at.
+func (d *Debugger) focus(loc dlv.Location) {
+	if loc.File == "" {
+		return
+	}
+	d.setStopped(dlv.FileLine{File: loc.File, Line: loc.Line})
+	d.driver.Call(func() {
+		opener, ok := d.cmdr.Bindable("focus-location").(Locationer)
+		if !ok {
+			log.Printf("debug: no focus-location command of type Locationer")
+			return
+		}
+		d.cmdr.Execute(opener.For(focus.Path(loc.File), focus.Line(loc.Line-1)))
+	})
+}
+
+func (d *Debugger) setStopped(l dlv.FileLine) {
+	d.mu.Lock()
+	old := d.stopped
+	d.stopped = l
+	d.mu.Unlock()
+	if old == l {
+		return
+	}
+	d.driver.Call(func() {
+		d.render(old.File)
+		d.render(l.File)
+	})
+}
+
+// Stop stops delve, killing the process being debugged.  It returns
+// false if nothing was being debugged.
+func (d *Debugger) Stop() bool {
+	d.mu.Lock()
+	s := d.session
+	d.session = nil
+	var (
+		client  *dlv.Client
+		running bool
+	)
+	if s != nil {
+		client, running = s.client, s.running
+	}
+	d.mu.Unlock()
+	if s == nil {
+		return false
+	}
+	d.setStopped(dlv.FileLine{})
+	for _, r := range s.rep.goroutines {
+		r.ReportGoroutines(nil, 0, nil)
+	}
+	for _, r := range s.rep.stacks {
+		r.ReportStack(nil, nil)
+	}
+	for _, r := range s.rep.locals {
+		r.ReportLocals(nil)
+	}
+	go func() {
+		if client != nil {
+			if running {
+				client.Halt()
+			}
+			if err := client.Detach(true); err != nil {
+				log.Printf("debug: could not detach from the process: %s", err)
+			}
+			client.Close()
+		}
+		s.run.Cancel()
+	}()
+	return true
+}
+
+// addEditor keeps track of e, so that breakpoints and the line the
+// process is stopped at can be displayed in it.
+func (d *Debugger) addEditor(e text.Editor) {
+	d.mu.Lock()
+	d.editors[e.Filepath()] = e
+	d.mu.Unlock()
+}
+
+// render displays the breakpoints in path and the line the process
+// is stopped at, if the file is open.  It must be called on the UI
+// goroutine.
+func (d *Debugger) render(path string) {
+	if path == "" {
+		return
+	}
+	d.mu.Lock()
+	e, ok := d.editors[path]
+	stopped := d.stopped
+	d.mu.Unlock()
+	if !ok {
+		return
+	}
+	o, ok := e.(Overlayer)
+	if !ok {
+		return
+	}
+	runes := e.Runes()
+	var lines []int
+	if b, err := d.Breakpoints(path); err == nil {
+		lines = b.Lines(path)
+	}
+	o.SetOverlay(breakpointOverlayName, lineLayers(runes, theme.Breakpoint, lines...))
+	if stopped.File != path {
+		o.SetOverlay(lineOverlayName, nil)
+		return
+	}
+	o.SetOverlay(lineOverlayName, lineLayers(runes, theme.DebugLine, stopped.Line))
+}
+
+// lineLayers returns a layer marking lines (1-based) in runes with
+// c, or nil if there are no lines to mark.
+func lineLayers(runes []rune, c theme.LanguageConstruct, lines ...int) []text.SyntaxLayer {
+	if len(lines) == 0 {
+		return nil
+	}
+	layer := text.SyntaxLayer{Construct: c}
+	line, start := 1, 0
+	next := 0
+	for i := 0; i <= len(runes) && next < len(lines); i++ {
+		if i < len(runes) && runes[i] != '\n' {
+			continue
+		}
+		for next < len(lines) && lines[next] < line {
+			next++
+		}
+		if next < len(lines) && lines[next] == line {
+			layer.Spans = append(layer.Spans, text.Span{Start: start, End: i})
+			next++
+		}
+		line++
+		start = i + 1
+	}
+	if len(layer.Spans) == 0 {
+		return nil
+	}
+	return []text.SyntaxLayer{layer}
+}
+
+// sessionObserver cleans up after delve exits.
+type sessionObserver struct {
+	debugger *Debugger
+	session  *session
+}
+
+func (o *sessionObserver) Started(*task.Run) {
+}
+
+func (o *sessionObserver) Output(*task.Run, task.Line) {
+}
+
+func (o *sessionObserver) Finished(*task.Run) {
+	d := o.debugger
+	d.mu.Lock()
+	current := d.session == o.session
+	d.mu.Unlock()
+	if current {
+		d.Stop()
+	}
+}
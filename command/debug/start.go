@@ -0,0 +1,153 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package debug
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/dlv"
+	"github.com/nelsam/vidar/plugin/status"
+	"github.com/nelsam/vidar/task"
+)
+
+// A CaretEditor is a text.Editor that has carets.
+type CaretEditor interface {
+	text.Editor
+	Carets() []int
+}
+
+// Target is the kind of program that a Start command debugs.
+type Target int
+
+const (
+	// Test debugs the test under the caret.
+	Test Target = iota
+
+	// Program debugs the main package of the focused file.
+	Program
+)
+
+// Start is a command that starts debugging the test under the caret
+// or the program in the focused file's package.
+type Start struct {
+	status.General
+
+	debugger *Debugger
+	target   Target
+
+	proj   Projecter
+	editor CaretEditor
+	obs    observers
+	rep    reporters
+}
+
+// NewStart returns a *Start that debugs target with d.
+func NewStart(theme gxui.Theme, d *Debugger, target Target) *Start {
+	s := &Start{debugger: d, target: target}
+	s.Theme = theme
+	return s
+}
+
+func (s *Start) Name() string {
+	if s.target == Program {
+		return "debug-package"
+	}
+	return "debug-test"
+}
+
+func (s *Start) Menu() string {
+	return "Debug"
+}
+
+func (s *Start) Defaults() []fmt.Stringer {
+	mod := gxui.ModControl
+	if s.target == Program {
+		mod |= gxui.ModShift
+	}
+	return []fmt.Stringer{gxui.KeyboardEvent{
+		Modifier: mod,
+		Key:      gxui.KeyF5,
+	}}
+}
+
+func (s *Start) Reset() {
+	s.proj = nil
+	s.editor = nil
+	s.obs = nil
+	s.rep = reporters{}
+}
+
+func (s *Start) Store(elem interface{}) bind.Status {
+	if p, ok := elem.(Projecter); ok {
+		s.proj = p
+	}
+	if e, ok := elem.(CaretEditor); ok {
+		s.editor = e
+	}
+	if o, ok := elem.(task.Observer); ok {
+		s.obs = append(s.obs, o)
+	}
+	s.rep.store(elem)
+	if s.proj == nil || s.editor == nil || len(s.obs) == 0 {
+		return bind.Waiting
+	}
+	return bind.Executing
+}
+
+func (s *Start) Exec() error {
+	if s.debugger.Running() {
+		s.Warn = fmt.Sprintf("%s: already debugging; use debug-stop first", s.Name())
+		return nil
+	}
+	path := s.editor.Filepath()
+	if !strings.HasSuffix(path, ".go") {
+		s.Warn = fmt.Sprintf("%s: %s is not a go file", s.Name(), filepath.Base(path))
+		return nil
+	}
+	addr, err := dlv.FreeAddr()
+	if err != nil {
+		s.Err = fmt.Sprintf("%s: %s", s.Name(), err)
+		return err
+	}
+	args := []string{"dlv", "debug", "--headless", "--api-version=2", "--listen=" + addr}
+	if s.target == Test {
+		f, ok := s.testAtCaret()
+		if !ok {
+			return nil
+		}
+		args[1] = "test"
+		args = append(args, "--", "-test.run", "^"+regexp.QuoteMeta(f.Name)+"$")
+	}
+	name := strings.Join(args[:2], " ")
+	if s.target == Test {
+		name += " " + args[len(args)-1]
+	}
+	s.debugger.start(s.obs, s.rep, name, filepath.Dir(path), s.proj.Project().Environ(), addr, args...)
+	s.Info = fmt.Sprintf("%s: starting %s", s.Name(), name)
+	return nil
+}
+
+func (s *Start) testAtCaret() (task.TestFunc, bool) {
+	if !strings.HasSuffix(s.editor.Filepath(), "_test.go") {
+		s.Warn = fmt.Sprintf("debug-test: %s is not a test file", filepath.Base(s.editor.Filepath()))
+		return task.TestFunc{}, false
+	}
+	carets := s.editor.Carets()
+	if len(carets) == 0 {
+		return task.TestFunc{}, false
+	}
+	f, ok := task.TestFuncAt(s.editor.Text(), carets[0])
+	if !ok || f.Kind != task.Test {
+		s.Warn = "debug-test: the caret is not inside a test"
+		return task.TestFunc{}, false
+	}
+	return f, true
+}
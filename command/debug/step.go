@@ -0,0 +1,147 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package debug
+
+import (
+	"fmt"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/dlv"
+	"github.com/nelsam/vidar/plugin/status"
+)
+
+// Motion is the way that a Step command moves through the debugged
+// process.
+type Motion int
+
+const (
+	// Continue runs until a breakpoint is hit or the process
+	// exits.
+	Continue Motion = iota
+
+	// Next steps over the current line.
+	Next
+
+	// StepIn steps into the function called on the current line.
+	StepIn
+
+	// StepOut steps out of the current function.
+	StepOut
+)
+
+// Step is a command that resumes the process being debugged until
+// it reaches a breakpoint or the next line, depending on its
+// Motion.
+type Step struct {
+	status.General
+
+	debugger *Debugger
+	motion   Motion
+}
+
+// NewStep returns a *Step that moves through the process that d is
+// debugging with m.
+func NewStep(theme gxui.Theme, d *Debugger, m Motion) *Step {
+	s := &Step{debugger: d, motion: m}
+	s.Theme = theme
+	return s
+}
+
+func (s *Step) Name() string {
+	switch s.motion {
+	case Next:
+		return "debug-next"
+	case StepIn:
+		return "debug-step"
+	case StepOut:
+		return "debug-step-out"
+	default:
+		return "debug-continue"
+	}
+}
+
+func (s *Step) Menu() string {
+	return "Debug"
+}
+
+func (s *Step) Defaults() []fmt.Stringer {
+	var event gxui.KeyboardEvent
+	switch s.motion {
+	case Next:
+		event.Key = gxui.KeyF10
+	case StepIn:
+		event.Modifier, event.Key = gxui.ModControl, gxui.KeyF10
+	case StepOut:
+		event.Modifier, event.Key = gxui.ModShift, gxui.KeyF10
+	default:
+		event.Key = gxui.KeyF5
+	}
+	return []fmt.Stringer{event}
+}
+
+func (s *Step) cmd() func(*dlv.Client) (dlv.State, error) {
+	switch s.motion {
+	case Next:
+		return (*dlv.Client).Next
+	case StepIn:
+		return (*dlv.Client).Step
+	case StepOut:
+		return (*dlv.Client).StepOut
+	default:
+		return (*dlv.Client).Continue
+	}
+}
+
+func (s *Step) Exec(interface{}) bind.Status {
+	ok, err := s.debugger.Resume(s.cmd())
+	if err != nil {
+		s.Warn = fmt.Sprintf("%s: %s", s.Name(), err)
+		return bind.Done
+	}
+	if !ok {
+		s.Warn = fmt.Sprintf("%s: the process is still running", s.Name())
+	}
+	return bind.Done
+}
+
+// Stop is a command that stops debugging, killing the process being
+// debugged.
+type Stop struct {
+	status.General
+
+	debugger *Debugger
+}
+
+// NewStop returns a *Stop that stops d.
+func NewStop(theme gxui.Theme, d *Debugger) *Stop {
+	s := &Stop{debugger: d}
+	s.Theme = theme
+	return s
+}
+
+func (s *Stop) Name() string {
+	return "debug-stop"
+}
+
+func (s *Stop) Menu() string {
+	return "Debug"
+}
+
+func (s *Stop) Defaults() []fmt.Stringer {
+	return []fmt.Stringer{gxui.KeyboardEvent{
+		Modifier: gxui.ModShift,
+		Key:      gxui.KeyF5,
+	}}
+}
+
+func (s *Stop) Exec(interface{}) bind.Status {
+	if !s.debugger.Stop() {
+		s.Info = "debug-stop: nothing is being debugged"
+		return bind.Done
+	}
+	s.Info = "debug-stop: stopped debugging"
+	return bind.Done
+}
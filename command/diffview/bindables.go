@@ -0,0 +1,24 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package diffview
+
+import (
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/gxui/themes/basic"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/plugin/command"
+)
+
+// Bindables returns the commands that open and work with diff views.
+func Bindables(cmdr command.Commander, driver gxui.Driver, theme *basic.Theme) []bind.Bindable {
+	return []bind.Bindable{
+		NewDiffWithSaved(cmdr, theme),
+		NewDiffWithRevision(cmdr, theme),
+		NewDiffWithFile(cmdr, driver, theme),
+		NewMoveChangeLeft(theme),
+		NewMoveChangeRight(theme),
+		NewToggleLayout(theme),
+	}
+}
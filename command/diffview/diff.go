@@ -0,0 +1,280 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package diffview
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/gxui/themes/basic"
+	"github.com/nelsam/vidar/command/focus"
+	"github.com/nelsam/vidar/command/fs"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/diff"
+	"github.com/nelsam/vidar/git"
+	"github.com/nelsam/vidar/plugin/command"
+	"github.com/nelsam/vidar/plugin/status"
+)
+
+// An Opener is any element that can open diff views and find the
+// open editors for files.
+type Opener interface {
+	OpenDiff(path string, left, right diff.Source) error
+	EditorFor(path string) text.Editor
+}
+
+// An Applier is any element that can apply edits to an editor.
+type Applier interface {
+	Apply(text.Editor, ...text.Edit)
+}
+
+// A Locationer is a command that can focus a file.
+type Locationer interface {
+	For(...focus.Opt) bind.Bindable
+}
+
+// other decides what a DiffWith command compares the focused file
+// with.
+type other int
+
+const (
+	saved other = iota
+	revision
+	file
+)
+
+// DiffWith is a command that opens a diff view comparing the focused
+// file with its saved version on disk, with its version at a git
+// revision, or with another file.  The focused file is always on the
+// right.
+type DiffWith struct {
+	status.General
+
+	cmdr  command.Commander
+	other other
+
+	rev   gxui.TextBox
+	file  *fs.Locator
+	input gxui.Focusable
+
+	opener Opener
+	editor text.Editor
+}
+
+func newDiffWith(cmdr command.Commander, theme gxui.Theme, o other) *DiffWith {
+	d := &DiffWith{cmdr: cmdr, other: o}
+	d.Theme = theme
+	return d
+}
+
+// NewDiffWithSaved returns a *DiffWith that compares the focused
+// file with its saved version on disk.
+func NewDiffWithSaved(cmdr command.Commander, theme gxui.Theme) *DiffWith {
+	return newDiffWith(cmdr, theme, saved)
+}
+
+// NewDiffWithRevision returns a *DiffWith that compares the focused
+// file with its version at a git revision, HEAD by default.
+func NewDiffWithRevision(cmdr command.Commander, theme gxui.Theme) *DiffWith {
+	d := newDiffWith(cmdr, theme, revision)
+	d.rev = theme.CreateTextBox()
+	return d
+}
+
+// NewDiffWithFile returns a *DiffWith that compares the focused file
+// with another file.
+func NewDiffWithFile(cmdr command.Commander, driver gxui.Driver, theme *basic.Theme) *DiffWith {
+	d := newDiffWith(cmdr, theme, file)
+	d.file = fs.NewLocator(driver, theme, fs.All)
+	return d
+}
+
+func (d *DiffWith) Name() string {
+	switch d.other {
+	case revision:
+		return "diff-with-revision"
+	case file:
+		return "diff-with-file"
+	default:
+		return "diff-with-saved"
+	}
+}
+
+func (d *DiffWith) Menu() string {
+	if d.other == revision {
+		return "Git"
+	}
+	return "View"
+}
+
+func (d *DiffWith) Defaults() []fmt.Stringer {
+	return nil
+}
+
+func (d *DiffWith) Start(control gxui.Control) gxui.Control {
+	switch d.other {
+	case revision:
+		d.rev.SetText("HEAD")
+		d.input = d.rev
+	case file:
+		d.file.LoadDir(control)
+		d.input = d.file
+	}
+	return nil
+}
+
+func (d *DiffWith) Next() gxui.Focusable {
+	input := d.input
+	d.input = nil
+	return input
+}
+
+func (d *DiffWith) Reset() {
+	d.opener = nil
+	d.editor = nil
+}
+
+func (d *DiffWith) Store(elem interface{}) bind.Status {
+	switch src := elem.(type) {
+	case Opener:
+		d.opener = src
+	case text.Editor:
+		d.editor = src
+	}
+	if d.opener == nil || d.editor == nil {
+		return bind.Waiting
+	}
+	return bind.Done
+}
+
+func (d *DiffWith) Exec() error {
+	if _, ok := d.editor.(View); ok {
+		d.Warn = fmt.Sprintf("%s: the focused editor is already a diff view", d.Name())
+		return nil
+	}
+	path := d.editor.Filepath()
+	left, name, err := d.left(path)
+	if err != nil {
+		d.Err = fmt.Sprintf("%s: %s", d.Name(), err)
+		return err
+	}
+	view := viewPath(path, name)
+	right := bufferSource(d.cmdr, d.editor, view)
+	if err := d.opener.OpenDiff(view, left, right); err != nil {
+		d.Err = fmt.Sprintf("%s: %s", d.Name(), err)
+		return err
+	}
+	return nil
+}
+
+// left returns the source to compare the focused file at path with,
+// and a short name for it.
+func (d *DiffWith) left(path string) (diff.Source, string, error) {
+	switch d.other {
+	case revision:
+		rev := strings.TrimSpace(d.rev.Text())
+		if rev == "" {
+			rev = "HEAD"
+		}
+		return diff.Source{
+			Name: fmt.Sprintf("%s at %s", filepath.Base(path), rev),
+			Load: func() (string, error) {
+				b, err := git.Show(path, rev)
+				return string(b), err
+			},
+		}, rev, nil
+	case file:
+		with := d.file.Path()
+		if with == "" {
+			return diff.Source{}, "", fmt.Errorf("no file path provided")
+		}
+		if finfo, err := os.Stat(with); err == nil && finfo.IsDir() {
+			return diff.Source{}, "", fmt.Errorf("can't compare with directory %s", with)
+		}
+		if with == path {
+			return diff.Source{}, "", fmt.Errorf("can't compare %s with itself", filepath.Base(path))
+		}
+		name := filepath.Base(with)
+		if e := d.opener.EditorFor(with); e != nil {
+			return bufferSource(d.cmdr, e, viewPath(path, name)), name, nil
+		}
+		return fileSource(with), name, nil
+	default:
+		s := fileSource(path)
+		s.Name += " (saved)"
+		// The editor refuses to save over a file that changed on
+		// disk, so the saved side must not be written to.
+		s.Save = nil
+		return s, "saved", nil
+	}
+}
+
+// viewPath returns the path of the diff view that compares the file
+// at path with the source called name.
+func viewPath(path, name string) string {
+	return fmt.Sprintf("%s ⇄ %s", path, name)
+}
+
+// bufferSource returns a diff.Source for the text in e.  It saves
+// by focusing e, so that the edit goes through the input handler
+// that is bound to e's file, then focusing the diff view at view
+// again.
+func bufferSource(cmdr command.Commander, e text.Editor, view string) diff.Source {
+	return diff.Source{
+		Name: filepath.Base(e.Filepath()),
+		Load: func() (string, error) {
+			return e.Text(), nil
+		},
+		Save: func(contents string) error {
+			opener, ok := cmdr.Bindable("focus-location").(Locationer)
+			if !ok {
+				return errors.New("no focus-location command found")
+			}
+			cmdr.Execute(opener.For(focus.Path(e.Filepath())))
+			defer cmdr.Execute(opener.For(focus.Path(view)))
+			a, ok := cmdr.Bindable("input-handler").(Applier)
+			if !ok {
+				return errors.New("no input handler found")
+			}
+			a.Apply(e, edit(e.Runes(), []rune(contents)))
+			return nil
+		},
+	}
+}
+
+// fileSource returns a diff.Source for the file at path on disk.
+func fileSource(path string) diff.Source {
+	return diff.Source{
+		Name: filepath.Base(path),
+		Load: func() (string, error) {
+			b, err := ioutil.ReadFile(path)
+			return string(b), err
+		},
+		Save: func(contents string) error {
+			return ioutil.WriteFile(path, []byte(contents), 0644)
+		},
+	}
+}
+
+// edit returns the edit that replaces old with new, leaving out the
+// runes at the start and end that they have in common.
+func edit(old, new []rune) text.Edit {
+	start := 0
+	for start < len(old) && start < len(new) && old[start] == new[start] {
+		start++
+	}
+	oldEnd, newEnd := len(old), len(new)
+	for oldEnd > start && newEnd > start && old[oldEnd-1] == new[newEnd-1] {
+		oldEnd--
+		newEnd--
+	}
+	return text.Edit{At: start, Old: old[start:oldEnd], New: new[start:newEnd]}
+}
@@ -0,0 +1,137 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package diffview
+
+import (
+	"fmt"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/plugin/status"
+)
+
+// A View is an editor that displays the differences between two
+// sources.
+type View interface {
+	MoveChange(toRight bool) error
+	ToggleLayout()
+	Inline() bool
+}
+
+// MoveChange is a command that copies the change under the caret in
+// a diff view from one side to the other.
+type MoveChange struct {
+	status.General
+
+	toRight bool
+	view    View
+}
+
+// NewMoveChangeLeft returns a *MoveChange that copies changes from
+// the right side of a diff view to the left.
+func NewMoveChangeLeft(theme gxui.Theme) *MoveChange {
+	m := &MoveChange{}
+	m.Theme = theme
+	return m
+}
+
+// NewMoveChangeRight returns a *MoveChange that copies changes from
+// the left side of a diff view to the right.
+func NewMoveChangeRight(theme gxui.Theme) *MoveChange {
+	m := &MoveChange{toRight: true}
+	m.Theme = theme
+	return m
+}
+
+func (m *MoveChange) Name() string {
+	if m.toRight {
+		return "move-change-right"
+	}
+	return "move-change-left"
+}
+
+func (m *MoveChange) Menu() string {
+	return "View"
+}
+
+func (m *MoveChange) Defaults() []fmt.Stringer {
+	key := gxui.KeyLeft
+	if m.toRight {
+		key = gxui.KeyRight
+	}
+	return []fmt.Stringer{gxui.KeyboardEvent{
+		Modifier: gxui.ModControl | gxui.ModAlt | gxui.ModShift,
+		Key:      key,
+	}}
+}
+
+func (m *MoveChange) Reset() {
+	m.view = nil
+}
+
+func (m *MoveChange) Store(elem interface{}) bind.Status {
+	if v, ok := elem.(View); ok {
+		m.view = v
+		return bind.Done
+	}
+	return bind.Waiting
+}
+
+func (m *MoveChange) Exec() error {
+	if err := m.view.MoveChange(m.toRight); err != nil {
+		m.Err = fmt.Sprintf("%s: %s", m.Name(), err)
+		return err
+	}
+	return nil
+}
+
+// ToggleLayout is a command that switches a diff view between its
+// side by side and inline layouts.
+type ToggleLayout struct {
+	status.General
+
+	view View
+}
+
+// NewToggleLayout returns a *ToggleLayout.
+func NewToggleLayout(theme gxui.Theme) *ToggleLayout {
+	t := &ToggleLayout{}
+	t.Theme = theme
+	return t
+}
+
+func (t *ToggleLayout) Name() string {
+	return "toggle-diff-layout"
+}
+
+func (t *ToggleLayout) Menu() string {
+	return "View"
+}
+
+func (t *ToggleLayout) Defaults() []fmt.Stringer {
+	return nil
+}
+
+func (t *ToggleLayout) Reset() {
+	t.view = nil
+}
+
+func (t *ToggleLayout) Store(elem interface{}) bind.Status {
+	if v, ok := elem.(View); ok {
+		t.view = v
+		return bind.Done
+	}
+	return bind.Waiting
+}
+
+func (t *ToggleLayout) Exec() error {
+	t.view.ToggleLayout()
+	if t.view.Inline() {
+		t.Info = "toggle-diff-layout: showing the diff inline"
+		return nil
+	}
+	t.Info = "toggle-diff-layout: showing the diff side by side"
+	return nil
+}
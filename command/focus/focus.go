@@ -58,6 +58,20 @@ type FileChanger interface {
 	FileChanged(oldPath, newPath string)
 }
 
+// A Jumper is a type that needs to be called when focus-location
+// moves from one place to another.
+type Jumper interface {
+	// Jumped will be called with the file and caret offset that
+	// were focused before focus-location executed and those that
+	// will be focused after it, just before the carets are moved.
+	Jumped(fromPath string, fromOffset int, toPath string, toOffset int)
+}
+
+// Careter represents a type that knows where its carets are.
+type Careter interface {
+	Carets() []int
+}
+
 // A Binder is a type which can bind bindables
 type Binder interface {
 	Push(...bind.Bindable)
@@ -150,6 +164,7 @@ type Location struct {
 
 	binders  []FileBinder
 	changers []FileChanger
+	jumpers  []Jumper
 }
 
 // NewLocation returns a *Location bound to the passed in driver.
@@ -170,6 +185,7 @@ func (l *Location) For(opts ...Opt) bind.Bindable {
 	}
 	newL.binders = append(newL.binders, l.binders...)
 	newL.changers = append(newL.changers, l.changers...)
+	newL.jumpers = append(newL.jumpers, l.jumpers...)
 	for _, o := range opts {
 		if err := o(newL); err != nil {
 			if len(newL.Warn) != 0 {
@@ -227,7 +243,11 @@ func (l *Location) moverReady() bool {
 // error if it encounters any problems.
 func (l *Location) Exec() error {
 	var oldPath string
+	fromPath, fromOffset := "", -1
 	e := l.opener.CurrentEditor()
+	if e != nil {
+		fromPath, fromOffset = e.Filepath(), firstCaret(e)
+	}
 	if !l.skipUnbind && e != nil {
 		oldPath = e.Filepath()
 		l.binder.Pop()
@@ -257,18 +277,30 @@ func (l *Location) Exec() error {
 	// Let the editor finish loading its text before we try
 	// to load the start of a line.
 	l.driver.Call(func() {
-		l.moveCarets(e.(LineStarter))
+		offset, move := l.target(e.(LineStarter))
+		if !move {
+			offset = firstCaret(e)
+		}
+		if fromOffset >= 0 && offset >= 0 {
+			for _, j := range l.jumpers {
+				j.Jumped(fromPath, fromOffset, path, offset)
+			}
+		}
+		if move {
+			l.binder.Execute(l.mover.To(offset))
+		}
 	})
 	return nil
 }
 
-func (l *Location) moveCarets(s LineStarter) {
+// target returns the offset that l should move the carets to.  The
+// returned bool is false if l doesn't move the carets.
+func (l *Location) target(s LineStarter) (int, bool) {
 	if l.offset == nil && l.line == nil && l.col == nil {
-		return
+		return 0, false
 	}
 	if l.offset != nil {
-		l.binder.Execute(l.mover.To(*l.offset))
-		return
+		return *l.offset, true
 	}
 	offset := 0
 	if l.line != nil {
@@ -277,7 +309,21 @@ func (l *Location) moveCarets(s LineStarter) {
 	if l.col != nil {
 		offset += *l.col
 	}
-	l.binder.Execute(l.mover.To(offset))
+	return offset, true
+}
+
+// firstCaret returns the offset of e's first caret, or -1 if e
+// doesn't know where its carets are.
+func firstCaret(e interface{}) int {
+	c, ok := e.(Careter)
+	if !ok {
+		return -1
+	}
+	carets := c.Carets()
+	if len(carets) == 0 {
+		return -1
+	}
+	return carets[0]
 }
 
 // Bind binds hooks to l.
@@ -288,8 +334,10 @@ func (l *Location) Bind(h bind.Bindable) (bind.HookedMultiOp, error) {
 		newF.binders = append(newF.binders, src)
 	case FileChanger:
 		newF.changers = append(newF.changers, src)
+	case Jumper:
+		newF.jumpers = append(newF.jumpers, src)
 	default:
-		return nil, fmt.Errorf("expected hook to be FileBinder or FileChanger, was %T", h)
+		return nil, fmt.Errorf("expected hook to be FileBinder, FileChanger, or Jumper, was %T", h)
 	}
 	return newF, nil
 }
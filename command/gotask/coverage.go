@@ -0,0 +1,410 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package gotask
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/coverage"
+	"github.com/nelsam/vidar/plugin/status"
+	"github.com/nelsam/vidar/task"
+	"github.com/nelsam/vidar/theme"
+)
+
+const coverageOverlayName = "coverage"
+
+// Overlayer is a type that can display layers on top of its
+// syntax layers.
+type Overlayer interface {
+	SetOverlay(name string, layers []text.SyntaxLayer)
+}
+
+// A StatusShower is any type that can display a status after the
+// command that it belongs to has finished executing.
+type StatusShower interface {
+	ShowStatus(commander.Statuser)
+}
+
+// CoverageHook binds the coverage overlay to go files as they are
+// focused, and removes their editors from it when they are closed.
+type CoverageHook struct {
+	overlay *CoverageOverlay
+}
+
+func (h CoverageHook) Name() string {
+	return "coverage-hook"
+}
+
+func (h CoverageHook) OpNames() []string {
+	return []string{"focus-location", "close-current-tab"}
+}
+
+func (h CoverageHook) FileBindables(path string) []bind.Bindable {
+	if !strings.HasSuffix(path, ".go") {
+		return nil
+	}
+	return []bind.Bindable{h.overlay}
+}
+
+func (h CoverageHook) FileClosed(path string) {
+	h.overlay.mu.Lock()
+	defer h.overlay.mu.Unlock()
+	delete(h.overlay.editors, path)
+}
+
+// CoverageOverlay displays the coverage in a coverage.Store in the
+// editors that it is bound to.
+type CoverageOverlay struct {
+	driver gxui.Driver
+	store  *coverage.Store
+
+	mu      sync.Mutex
+	editors map[string]text.Editor
+}
+
+// NewCoverageOverlay returns a *CoverageOverlay that displays
+// coverage from s.
+func NewCoverageOverlay(d gxui.Driver, s *coverage.Store) *CoverageOverlay {
+	o := &CoverageOverlay{
+		driver:  d,
+		store:   s,
+		editors: make(map[string]text.Editor),
+	}
+	s.OnChange(o.changed)
+	return o
+}
+
+func (o *CoverageOverlay) Name() string {
+	return "coverage-overlay"
+}
+
+func (o *CoverageOverlay) OpName() string {
+	return "input-handler"
+}
+
+func (o *CoverageOverlay) Init(e text.Editor, _ []rune) {
+	o.mu.Lock()
+	o.editors[e.Filepath()] = e
+	o.mu.Unlock()
+	o.render(e)
+}
+
+func (o *CoverageOverlay) TextChanged(text.Editor, text.Edit) {
+}
+
+func (o *CoverageOverlay) Apply(text.Editor) error {
+	return nil
+}
+
+// Applied discards the coverage for any blocks that edits touched,
+// since it is out of date until the tests are run again, and moves
+// the rest to match edits.
+func (o *CoverageOverlay) Applied(e text.Editor, edits []text.Edit) {
+	path := e.Filepath()
+	for _, edit := range edits {
+		o.store.Edit(path, edit.At, len(edit.Old), len(edit.New))
+	}
+	o.render(e)
+}
+
+func (o *CoverageOverlay) changed(path string) {
+	o.mu.Lock()
+	e, ok := o.editors[path]
+	o.mu.Unlock()
+	if !ok {
+		return
+	}
+	o.driver.Call(func() {
+		o.render(e)
+	})
+}
+
+// matches returns whether the editor for path, if path is open,
+// contains src.  It must not be called on the UI goroutine.
+func (o *CoverageOverlay) matches(path string, src []byte) bool {
+	o.mu.Lock()
+	e, ok := o.editors[path]
+	o.mu.Unlock()
+	if !ok {
+		return true
+	}
+	var text string
+	o.driver.CallSync(func() {
+		text = string(e.Runes())
+	})
+	return text == string(src)
+}
+
+func (o *CoverageOverlay) render(e text.Editor) {
+	overlayer, ok := e.(Overlayer)
+	if !ok {
+		return
+	}
+	spans := o.store.For(e.Filepath())
+	if len(spans) == 0 {
+		overlayer.SetOverlay(coverageOverlayName, nil)
+		return
+	}
+	covered := text.SyntaxLayer{Construct: theme.Covered}
+	uncovered := text.SyntaxLayer{Construct: theme.Uncovered}
+	for _, s := range spans {
+		span := text.Span{Start: s.Start, End: s.End}
+		if s.Covered {
+			covered.Spans = append(covered.Spans, span)
+			continue
+		}
+		uncovered.Spans = append(uncovered.Spans, span)
+	}
+	overlayer.SetOverlay(coverageOverlayName, []text.SyntaxLayer{covered, uncovered})
+}
+
+// coverageStatus is the status that show-coverage displays once
+// its tests finish.
+type coverageStatus struct {
+	status.General
+}
+
+func (s *coverageStatus) Name() string {
+	return "show-coverage"
+}
+
+// coverageObserver is a task.Observer that loads the coverage
+// profile written by a go test run into a coverage.Store once the
+// run finishes, then shows the percentage of statements in path
+// that were covered.  Files whose editors no longer contain the
+// text that was tested are skipped, since their coverage would be
+// drawn over the wrong text.
+type coverageObserver struct {
+	store   *coverage.Store
+	overlay *CoverageOverlay
+	dir     string
+	profile string
+
+	path   string
+	theme  status.LabelCreator
+	shower StatusShower
+}
+
+func (o *coverageObserver) Started(*task.Run) {
+}
+
+func (o *coverageObserver) Output(*task.Run, task.Line) {
+}
+
+func (o *coverageObserver) Finished(r *task.Run) {
+	defer os.Remove(o.profile)
+	if r.Status() == task.Cancelled {
+		return
+	}
+	f, err := os.Open(o.profile)
+	if err != nil {
+		// The package probably failed to build, which the output
+		// will explain.
+		return
+	}
+	defer f.Close()
+	profiles, err := coverage.ParseProfile(f)
+	if err != nil {
+		log.Printf("show-coverage: %s", err)
+		return
+	}
+	stale := false
+	for _, p := range profiles {
+		// The profile names files by import path, but the package
+		// was tested in o.dir, so its files are all there.
+		path := filepath.Join(o.dir, filepath.Base(p.FileName))
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Printf("show-coverage: could not read %s: %s", path, err)
+			continue
+		}
+		if !o.overlay.matches(path, src) {
+			stale = stale || path == o.path
+			continue
+		}
+		o.store.Set(path, coverage.Spans(src, p.Blocks))
+	}
+	if o.shower == nil {
+		return
+	}
+	s := &coverageStatus{}
+	s.Theme = o.theme
+	if stale {
+		s.Warn = fmt.Sprintf("show-coverage: %s has unsaved changes; save it and run the tests again", filepath.Base(o.path))
+	} else if pct, ok := o.store.Percent(o.path); ok {
+		s.Info = fmt.Sprintf("show-coverage: %.1f%% of statements in %s covered", pct, filepath.Base(o.path))
+	} else {
+		s.Warn = fmt.Sprintf("show-coverage: there is no coverage for %s", filepath.Base(o.path))
+	}
+	o.shower.ShowStatus(s)
+}
+
+// Coverage is a command that runs go test with a coverage profile
+// for the package of the focused file, displaying the coverage in
+// the package's files when it finishes.
+type Coverage struct {
+	status.General
+
+	runner  *task.Runner
+	overlay *CoverageOverlay
+	shower  StatusShower
+
+	proj   Projecter
+	editor text.Editor
+	obs    observers
+}
+
+// NewCoverage returns a *Coverage that runs tests with r and loads
+// their coverage into o.  If shower is non-nil, the coverage of the
+// focused file is shown with it when the tests finish.
+func NewCoverage(theme gxui.Theme, r *task.Runner, o *CoverageOverlay, shower StatusShower) *Coverage {
+	c := &Coverage{runner: r, overlay: o, shower: shower}
+	c.Theme = theme
+	return c
+}
+
+func (c *Coverage) Name() string {
+	return "show-coverage"
+}
+
+func (c *Coverage) Menu() string {
+	return "Golang"
+}
+
+func (c *Coverage) Defaults() []fmt.Stringer {
+	return []fmt.Stringer{gxui.KeyboardEvent{
+		Modifier: gxui.ModControl | gxui.ModAlt,
+		Key:      gxui.KeyV,
+	}}
+}
+
+func (c *Coverage) Reset() {
+	c.proj = nil
+	c.editor = nil
+	c.obs = nil
+}
+
+func (c *Coverage) Store(elem interface{}) bind.Status {
+	switch src := elem.(type) {
+	case Projecter:
+		c.proj = src
+	case text.Editor:
+		c.editor = src
+	case task.Observer:
+		c.obs = append(c.obs, src)
+	}
+	if c.proj == nil || c.editor == nil {
+		return bind.Waiting
+	}
+	return bind.Executing
+}
+
+func (c *Coverage) Exec() error {
+	path := c.editor.Filepath()
+	if !strings.HasSuffix(path, ".go") {
+		c.Warn = "show-coverage: the focused file is not a go file"
+		return nil
+	}
+	f, err := ioutil.TempFile("", "vidar-cover")
+	if err != nil {
+		return fmt.Errorf("show-coverage: could not create profile: %s", err)
+	}
+	profile := f.Name()
+	f.Close()
+
+	dir := filepath.Dir(path)
+	obs := append(c.obs, &coverageObserver{
+		store:   c.overlay.store,
+		overlay: c.overlay,
+		dir:     dir,
+		profile: profile,
+		path:    path,
+		theme:   c.Theme,
+		shower:  c.shower,
+	})
+	name := "go test -cover ."
+	c.runner.Start(obs, name, dir, c.proj.Project().Environ(), "go", "test", "-coverprofile", profile, ".")
+	c.overlay.store.SetHidden(false)
+	c.Info = fmt.Sprintf("show-coverage: running %s in %s", name, dir)
+	if pct, ok := c.overlay.store.Percent(path); ok {
+		c.Info = fmt.Sprintf("%s (was %.1f%% of statements in %s)", c.Info, pct, filepath.Base(path))
+	}
+	return nil
+}
+
+// ToggleCoverage is a command that shows or hides coverage, showing
+// the percentage of statements in the focused file that were
+// covered.
+type ToggleCoverage struct {
+	status.General
+
+	store  *coverage.Store
+	editor text.Editor
+}
+
+// NewToggleCoverage returns a *ToggleCoverage that shows or hides
+// the coverage in s.
+func NewToggleCoverage(theme gxui.Theme, s *coverage.Store) *ToggleCoverage {
+	t := &ToggleCoverage{store: s}
+	t.Theme = theme
+	return t
+}
+
+func (t *ToggleCoverage) Name() string {
+	return "toggle-coverage"
+}
+
+func (t *ToggleCoverage) Menu() string {
+	return "Golang"
+}
+
+func (t *ToggleCoverage) Defaults() []fmt.Stringer {
+	return []fmt.Stringer{gxui.KeyboardEvent{
+		Modifier: gxui.ModControl | gxui.ModAlt | gxui.ModShift,
+		Key:      gxui.KeyV,
+	}}
+}
+
+func (t *ToggleCoverage) Reset() {
+	t.editor = nil
+}
+
+func (t *ToggleCoverage) Store(elem interface{}) bind.Status {
+	if e, ok := elem.(text.Editor); ok {
+		t.editor = e
+	}
+	return bind.Executing
+}
+
+func (t *ToggleCoverage) Exec() error {
+	hide := !t.store.Hidden()
+	t.store.SetHidden(hide)
+	if hide {
+		t.Info = "toggle-coverage: coverage hidden"
+		return nil
+	}
+	t.Info = "toggle-coverage: coverage shown"
+	if t.editor == nil {
+		return nil
+	}
+	path := t.editor.Filepath()
+	pct, ok := t.store.Percent(path)
+	if !ok {
+		t.Warn = fmt.Sprintf("toggle-coverage: there is no coverage for %s; run show-coverage first", filepath.Base(path))
+		return nil
+	}
+	t.Info = fmt.Sprintf("toggle-coverage: %.1f%% of statements in %s covered", pct, filepath.Base(path))
+	return nil
+}
@@ -0,0 +1,261 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+// Package gotask contains commands that run the go tool (go build,
+// go test, and go vet) for the current package or project.  Output
+// is streamed to any element implementing task.Observer, and the
+// results of individual tests to any element implementing
+// TestReporter.
+package gotask
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/coverage"
+	"github.com/nelsam/vidar/plugin/command"
+	"github.com/nelsam/vidar/plugin/status"
+	"github.com/nelsam/vidar/setting"
+	"github.com/nelsam/vidar/task"
+)
+
+// A Projecter is any element that knows which project is current.
+type Projecter interface {
+	Project() setting.Project
+}
+
+// Scope is the set of packages that a go tool command runs against.
+type Scope int
+
+const (
+	// Package runs against the package of the focused file.
+	Package Scope = iota
+
+	// Project runs against every package in the current project.
+	Project
+)
+
+// Bindables returns the commands in this package, sharing a single
+// task.Runner, along with the hook that displays test coverage.
+func Bindables(cmdr command.Commander, driver gxui.Driver, theme gxui.Theme) []bind.Bindable {
+	r := &task.Runner{Max: setting.TaskHistory()}
+	var b []bind.Bindable
+	for _, tool := range []string{"build", "test", "vet"} {
+		b = append(b, NewGo(theme, r, tool, Package), NewGo(theme, r, tool, Project))
+	}
+	last := &lastTest{}
+	b = append(b, newTest(theme, r, last, atCaret), newTest(theme, r, last, benchmarksInFile), newRerunTest(theme, r, last))
+	cov := coverage.NewStore()
+	shower, _ := cmdr.(StatusShower)
+	overlay := NewCoverageOverlay(driver, cov)
+	b = append(b, CoverageHook{overlay: overlay}, NewCoverage(theme, r, overlay, shower), NewToggleCoverage(theme, cov))
+	return append(b, NewCancel(theme, r), NewRerun(theme, r))
+}
+
+// observers is a task.Observer which notifies several observers.
+type observers []task.Observer
+
+func (o observers) Started(r *task.Run) {
+	for _, obs := range o {
+		obs.Started(r)
+	}
+}
+
+func (o observers) Output(r *task.Run, l task.Line) {
+	for _, obs := range o {
+		obs.Output(r, l)
+	}
+}
+
+func (o observers) Finished(r *task.Run) {
+	for _, obs := range o {
+		obs.Finished(r)
+	}
+}
+
+// Go is a command that runs a go tool command (e.g. go build) for
+// the current package or project.
+type Go struct {
+	status.General
+
+	runner *task.Runner
+	tool   string
+	scope  Scope
+
+	proj   Projecter
+	editor text.Editor
+	obs    observers
+}
+
+// NewGo returns a *Go that runs "go <tool>" against scope.
+func NewGo(theme gxui.Theme, r *task.Runner, tool string, scope Scope) *Go {
+	g := &Go{runner: r, tool: tool, scope: scope}
+	g.Theme = theme
+	return g
+}
+
+func (g *Go) Name() string {
+	if g.scope == Project {
+		return fmt.Sprintf("go-%s-project", g.tool)
+	}
+	return fmt.Sprintf("go-%s-package", g.tool)
+}
+
+func (g *Go) Menu() string {
+	return "Golang"
+}
+
+func (g *Go) Defaults() []fmt.Stringer {
+	var key gxui.KeyboardKey
+	switch g.tool {
+	case "build":
+		key = gxui.KeyB
+	case "test":
+		key = gxui.KeyT
+	default:
+		return nil
+	}
+	mod := gxui.ModControl
+	if g.scope == Project {
+		mod |= gxui.ModShift
+	}
+	return []fmt.Stringer{gxui.KeyboardEvent{
+		Modifier: mod,
+		Key:      key,
+	}}
+}
+
+func (g *Go) Reset() {
+	g.proj = nil
+	g.editor = nil
+	g.obs = nil
+}
+
+func (g *Go) Store(elem interface{}) bind.Status {
+	switch src := elem.(type) {
+	case Projecter:
+		g.proj = src
+	case text.Editor:
+		g.editor = src
+	case task.Observer:
+		g.obs = append(g.obs, src)
+	}
+	if g.proj == nil || len(g.obs) == 0 {
+		return bind.Waiting
+	}
+	return bind.Executing
+}
+
+func (g *Go) Exec() error {
+	proj := g.proj.Project()
+	dir, pkgs := proj.Path, "./..."
+	if g.scope == Package {
+		if g.editor == nil || g.editor.Filepath() == "" {
+			g.Warn = fmt.Sprintf("%s: no file is focused", g.Name())
+			return nil
+		}
+		dir, pkgs = filepath.Dir(g.editor.Filepath()), "."
+	}
+	name := fmt.Sprintf("go %s %s", g.tool, pkgs)
+	g.runner.Start(g.obs, name, dir, proj.Environ(), "go", g.tool, pkgs)
+	g.Info = fmt.Sprintf("%s: running in %s", name, dir)
+	return nil
+}
+
+// Cancel is a command that cancels all running tasks.
+type Cancel struct {
+	status.General
+
+	runner *task.Runner
+}
+
+// NewCancel returns a *Cancel that cancels tasks started by r.
+func NewCancel(theme gxui.Theme, r *task.Runner) *Cancel {
+	c := &Cancel{runner: r}
+	c.Theme = theme
+	return c
+}
+
+func (c *Cancel) Name() string {
+	return "cancel-tasks"
+}
+
+func (c *Cancel) Menu() string {
+	return "Golang"
+}
+
+func (c *Cancel) Defaults() []fmt.Stringer {
+	return []fmt.Stringer{gxui.KeyboardEvent{
+		Modifier: gxui.ModControl | gxui.ModAlt,
+		Key:      gxui.KeyC,
+	}}
+}
+
+func (c *Cancel) Exec(interface{}) bind.Status {
+	n := c.runner.CancelAll()
+	if n == 0 {
+		c.Info = "cancel-tasks: nothing is running"
+		return bind.Done
+	}
+	c.Info = fmt.Sprintf("cancel-tasks: cancelled %d tasks", n)
+	return bind.Done
+}
+
+// Rerun is a command that runs the most recent task again.
+type Rerun struct {
+	status.General
+
+	runner *task.Runner
+	obs    observers
+}
+
+// NewRerun returns a *Rerun that reruns tasks started by r.
+func NewRerun(theme gxui.Theme, r *task.Runner) *Rerun {
+	re := &Rerun{runner: r}
+	re.Theme = theme
+	return re
+}
+
+func (r *Rerun) Name() string {
+	return "rerun-last-task"
+}
+
+func (r *Rerun) Menu() string {
+	return "Golang"
+}
+
+func (r *Rerun) Defaults() []fmt.Stringer {
+	return []fmt.Stringer{gxui.KeyboardEvent{
+		Modifier: gxui.ModControl | gxui.ModAlt,
+		Key:      gxui.KeyR,
+	}}
+}
+
+func (r *Rerun) Reset() {
+	r.obs = nil
+}
+
+func (r *Rerun) Store(elem interface{}) bind.Status {
+	if obs, ok := elem.(task.Observer); ok {
+		r.obs = append(r.obs, obs)
+	}
+	if len(r.obs) == 0 {
+		return bind.Waiting
+	}
+	return bind.Executing
+}
+
+func (r *Rerun) Exec() error {
+	last := r.runner.Last()
+	if last == nil {
+		r.Warn = "rerun-last-task: nothing has been run yet"
+		return nil
+	}
+	r.runner.Start(r.obs, last.Name, last.Dir, last.Env, last.Args...)
+	r.Info = fmt.Sprintf("rerun-last-task: running %s", last.Name)
+	return nil
+}
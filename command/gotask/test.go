@@ -0,0 +1,255 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package gotask
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/plugin/status"
+	"github.com/nelsam/vidar/task"
+)
+
+// A TestReporter displays the results of test runs.  ReportTests is
+// called each time the results of r change, and may be called from
+// any goroutine.
+type TestReporter interface {
+	ReportTests(r *task.Run, results []*task.TestResult)
+}
+
+// A CaretEditor is a text.Editor that has carets.
+type CaretEditor interface {
+	text.Editor
+	Carets() []int
+}
+
+// testObserver is a task.Observer that parses the output of go test
+// and sends the results to reporters.
+type testObserver struct {
+	reporters []TestReporter
+	results   task.TestResults
+}
+
+func (o *testObserver) Started(r *task.Run) {
+	o.report(r)
+}
+
+func (o *testObserver) Output(r *task.Run, l task.Line) {
+	if o.results.Add(l) {
+		o.report(r)
+	}
+}
+
+func (o *testObserver) Finished(r *task.Run) {
+	o.report(r)
+}
+
+func (o *testObserver) report(r *task.Run) {
+	results := o.results.Snapshot()
+	for _, rep := range o.reporters {
+		rep.ReportTests(r, results)
+	}
+}
+
+// lastTest remembers the most recent test run, so that it can be run
+// again.
+type lastTest struct {
+	run *task.Run
+}
+
+// testTarget decides which funcs a Test command runs.
+type testTarget int
+
+const (
+	atCaret testTarget = iota
+	benchmarksInFile
+)
+
+// Test is a command that runs tests from the focused _test.go file,
+// reporting the result of each test.
+type Test struct {
+	status.General
+
+	runner *task.Runner
+	last   *lastTest
+	target testTarget
+
+	proj      Projecter
+	editor    CaretEditor
+	obs       observers
+	reporters []TestReporter
+}
+
+func newTest(theme gxui.Theme, r *task.Runner, last *lastTest, target testTarget) *Test {
+	t := &Test{runner: r, last: last, target: target}
+	t.Theme = theme
+	return t
+}
+
+func (t *Test) Name() string {
+	if t.target == benchmarksInFile {
+		return "run-benchmarks-in-file"
+	}
+	return "run-test-at-caret"
+}
+
+func (t *Test) Menu() string {
+	return "Golang"
+}
+
+func (t *Test) Defaults() []fmt.Stringer {
+	key := gxui.KeyT
+	if t.target == benchmarksInFile {
+		key = gxui.KeyB
+	}
+	return []fmt.Stringer{gxui.KeyboardEvent{
+		Modifier: gxui.ModControl | gxui.ModAlt,
+		Key:      key,
+	}}
+}
+
+func (t *Test) Reset() {
+	t.proj = nil
+	t.editor = nil
+	t.obs = nil
+	t.reporters = nil
+}
+
+func (t *Test) Store(elem interface{}) bind.Status {
+	if p, ok := elem.(Projecter); ok {
+		t.proj = p
+	}
+	if e, ok := elem.(CaretEditor); ok {
+		t.editor = e
+	}
+	if o, ok := elem.(task.Observer); ok {
+		t.obs = append(t.obs, o)
+	}
+	if r, ok := elem.(TestReporter); ok {
+		t.reporters = append(t.reporters, r)
+	}
+	if t.proj == nil || t.editor == nil || len(t.obs)+len(t.reporters) == 0 {
+		return bind.Waiting
+	}
+	return bind.Executing
+}
+
+func (t *Test) Exec() error {
+	path := t.editor.Filepath()
+	if !strings.HasSuffix(path, "_test.go") {
+		t.Warn = fmt.Sprintf("%s: %s is not a test file", t.Name(), filepath.Base(path))
+		return nil
+	}
+	funcs, err := t.funcs()
+	if err != nil {
+		return err
+	}
+	if len(funcs) == 0 {
+		return nil
+	}
+	args := task.TestArgs(funcs...)
+	name := "go " + strings.Join(args, " ")
+	obs := append(t.obs, &testObserver{reporters: t.reporters})
+	dir := filepath.Dir(path)
+	t.last.run = t.runner.Start(obs, name, dir, t.proj.Project().Environ(), append([]string{"go"}, args...)...)
+	t.Info = fmt.Sprintf("%s: running %s", t.Name(), name)
+	return nil
+}
+
+func (t *Test) funcs() ([]task.TestFunc, error) {
+	src := t.editor.Text()
+	if t.target == atCaret {
+		carets := t.editor.Carets()
+		if len(carets) == 0 {
+			return nil, nil
+		}
+		f, ok := task.TestFuncAt(src, carets[0])
+		if !ok {
+			t.Warn = "run-test-at-caret: the caret is not inside a test, benchmark, or example"
+			return nil, nil
+		}
+		return []task.TestFunc{f}, nil
+	}
+	all, err := task.TestFuncs(src)
+	if err != nil && len(all) == 0 {
+		return nil, fmt.Errorf("run-benchmarks-in-file: could not parse file: %s", err)
+	}
+	var benchmarks []task.TestFunc
+	for _, f := range all {
+		if f.Kind == task.Benchmark {
+			benchmarks = append(benchmarks, f)
+		}
+	}
+	if len(benchmarks) == 0 {
+		t.Warn = "run-benchmarks-in-file: there are no benchmarks in this file"
+	}
+	return benchmarks, nil
+}
+
+// RerunTest is a command that runs the most recent test run again.
+type RerunTest struct {
+	status.General
+
+	runner *task.Runner
+	last   *lastTest
+
+	obs       observers
+	reporters []TestReporter
+}
+
+func newRerunTest(theme gxui.Theme, r *task.Runner, last *lastTest) *RerunTest {
+	re := &RerunTest{runner: r, last: last}
+	re.Theme = theme
+	return re
+}
+
+func (r *RerunTest) Name() string {
+	return "rerun-last-test"
+}
+
+func (r *RerunTest) Menu() string {
+	return "Golang"
+}
+
+func (r *RerunTest) Defaults() []fmt.Stringer {
+	return []fmt.Stringer{gxui.KeyboardEvent{
+		Modifier: gxui.ModControl | gxui.ModAlt | gxui.ModShift,
+		Key:      gxui.KeyT,
+	}}
+}
+
+func (r *RerunTest) Reset() {
+	r.obs = nil
+	r.reporters = nil
+}
+
+func (r *RerunTest) Store(elem interface{}) bind.Status {
+	if o, ok := elem.(task.Observer); ok {
+		r.obs = append(r.obs, o)
+	}
+	if rep, ok := elem.(TestReporter); ok {
+		r.reporters = append(r.reporters, rep)
+	}
+	if len(r.obs)+len(r.reporters) == 0 {
+		return bind.Waiting
+	}
+	return bind.Executing
+}
+
+func (r *RerunTest) Exec() error {
+	last := r.last.run
+	if last == nil {
+		r.Warn = "rerun-last-test: no tests have been run yet"
+		return nil
+	}
+	obs := append(r.obs, &testObserver{reporters: r.reporters})
+	r.last.run = r.runner.Start(obs, last.Name, last.Dir, last.Env, last.Args...)
+	r.Info = fmt.Sprintf("rerun-last-test: running %s", last.Name)
+	return nil
+}
@@ -8,6 +8,9 @@ import "github.com/poy/onpar/matchers"
 
 // matcher aliases to avoid dot-importing matchers.
 var (
-	not   = matchers.Not
-	equal = matchers.Equal
+	not          = matchers.Not
+	equal        = matchers.Equal
+	haveOccurred = matchers.HaveOccurred
+	haveLen      = matchers.HaveLen
+	beTrue       = matchers.BeTrue
 )
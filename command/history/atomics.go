@@ -6,6 +6,7 @@ package history
 
 import (
 	"sync/atomic"
+	"time"
 	"unsafe"
 
 	"github.com/nelsam/vidar/commander/text"
@@ -63,6 +64,11 @@ type branch struct {
 	// the above should be kept first in the struct for byte alignment.
 
 	edit text.Edit
+	when time.Time
+
+	// joined is true if this edit is in the same undo group as its
+	// parent.
+	joined bool
 }
 
 // prev performs atomic incantations to load b.prevP and return
@@ -96,9 +102,10 @@ func (b *branch) siblings() []*branch {
 	return *sibs
 }
 
-// push adds e to the next empty child branch of b.
-func (b *branch) push(e text.Edit) *branch {
-	next := &branch{edit: e, prevP: unsafe.Pointer(b)}
+// push adds e to the next empty child branch of b.  If joined is
+// true, e will be in the same undo group as b.
+func (b *branch) push(e text.Edit, joined bool) *branch {
+	next := &branch{edit: e, when: time.Now(), joined: joined, prevP: unsafe.Pointer(b)}
 	np := unsafe.Pointer(next)
 	done := atomic.CompareAndSwapPointer(&b.nextP, nil, np)
 	if !done {
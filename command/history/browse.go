@@ -0,0 +1,183 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package history
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/gxui/themes/basic"
+	"github.com/nelsam/vidar/commander"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/plugin/status"
+)
+
+// previewContext is the number of lines before and after an edit
+// that are shown in the preview.
+const previewContext = 2
+
+// Browse is a command which displays the undo tree for the current
+// file.  The text at the selected node is previewed, and executing
+// the command moves the history (and the text) to that node.
+type Browse struct {
+	status.General
+
+	history *History
+	nodes   gxui.List
+	adapter *gxui.DefaultAdapter
+	preview gxui.Label
+	input   gxui.Focusable
+	text    []rune
+
+	editor  text.Editor
+	applier Applier
+}
+
+func newBrowse(theme *basic.Theme, h *History) *Browse {
+	b := &Browse{
+		history: h,
+		nodes:   theme.CreateList(),
+		adapter: gxui.CreateDefaultAdapter(),
+		preview: theme.CreateLabel(),
+	}
+	b.Theme = theme
+	b.preview.SetMultiline(true)
+	b.nodes.SetAdapter(b.adapter)
+	b.nodes.OnSelectionChanged(func(item gxui.AdapterItem) {
+		if n, ok := item.(*Node); ok {
+			b.showPreview(n)
+		}
+	})
+	return b
+}
+
+func (b *Browse) Name() string {
+	return "browse-history"
+}
+
+func (b *Browse) Menu() string {
+	return "Edit"
+}
+
+func (b *Browse) Defaults() []fmt.Stringer {
+	return []fmt.Stringer{gxui.KeyboardEvent{
+		Modifier: gxui.ModControl | gxui.ModAlt,
+		Key:      gxui.KeyZ,
+	}}
+}
+
+func (b *Browse) Start(control gxui.Control) gxui.Control {
+	editor := findEditor(control)
+	if editor == nil {
+		return nil
+	}
+	b.text = editor.Runes()
+	nodes := b.history.Nodes()
+	items := make([]*Node, 0, len(nodes))
+	var current *Node
+	for i := range nodes {
+		items = append(items, &nodes[i])
+		if nodes[i].Current {
+			current = &nodes[i]
+		}
+	}
+	b.adapter.SetItems(items)
+	b.nodes.Select(current)
+	b.input = b.nodes
+	return b.preview
+}
+
+func (b *Browse) Next() gxui.Focusable {
+	input := b.input
+	b.input = nil
+	return input
+}
+
+func (b *Browse) Reset() {
+	b.editor = nil
+	b.applier = nil
+}
+
+func (b *Browse) Store(target interface{}) bind.Status {
+	switch src := target.(type) {
+	case Applier:
+		b.applier = src
+	case text.Editor:
+		b.editor = src
+	}
+	if b.applier != nil && b.editor != nil {
+		return bind.Done
+	}
+	return bind.Waiting
+}
+
+func (b *Browse) Exec() error {
+	n, ok := b.nodes.Selected().(*Node)
+	if !ok {
+		b.Warn = "browse-history: no edit selected"
+		return nil
+	}
+	edits, err := b.history.Jump(*n)
+	if err != nil {
+		b.Err = fmt.Sprintf("browse-history: %s", err)
+		return err
+	}
+	if len(edits) == 0 {
+		b.Info = "browse-history: already at the selected edit"
+		return nil
+	}
+	for _, e := range edits {
+		b.applier.Apply(b.editor, e)
+	}
+	b.Info = fmt.Sprintf("browse-history: applied %d edits", len(edits))
+	return nil
+}
+
+// showPreview displays the lines surrounding n's edit, as they
+// would be if the history were moved to n.
+func (b *Browse) showPreview(n *Node) {
+	txt, err := b.history.Preview(*n, b.text)
+	if err != nil {
+		b.preview.SetText(err.Error())
+		return
+	}
+	b.preview.SetText(excerpt(txt, n.Edit.At+len(n.Edit.New)))
+}
+
+// excerpt returns the line containing pos in r, along with
+// previewContext lines on either side of it.
+func excerpt(r []rune, pos int) string {
+	lines := strings.Split(string(r), "\n")
+	line := strings.Count(string(r[:clamp(pos, len(r))]), "\n")
+	start := clamp(line-previewContext, len(lines))
+	end := clamp(line+previewContext+1, len(lines))
+	return strings.Join(lines[start:end], "\n")
+}
+
+func clamp(v, max int) int {
+	if v < 0 {
+		return 0
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+func findEditor(elem interface{}) text.Editor {
+	switch src := elem.(type) {
+	case text.Editor:
+		return src
+	case commander.Elementer:
+		for _, child := range src.Elements() {
+			if editor := findEditor(child); editor != nil {
+				return editor
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package history
+
+import (
+	"time"
+	"unicode"
+
+	"github.com/nelsam/vidar/commander/text"
+)
+
+// DefaultPause is the default value of History.Pause.
+const DefaultPause = time.Second
+
+// batch tracks the edits from a single call to a text.Handler's
+// Apply method.
+type batch struct {
+	size, seen int
+}
+
+// Applied implements input.AppliedChangeHook.  It is called
+// synchronously for every call to the input handler's Apply method,
+// before TextChanged is called for any of the edits, so h uses it to
+// find out which edits were applied together.
+func (h *History) Applied(_ text.Editor, edits []text.Edit) {
+	if len(edits) == 0 {
+		return
+	}
+	h.batchMu.Lock()
+	defer h.batchMu.Unlock()
+	h.batches = append(h.batches, batch{size: len(edits)})
+}
+
+// nextInBatch consumes the next edit from the oldest batch that
+// TextChanged has not finished, returning the edit's position in the
+// batch and the size of the batch.  Edits that were not reported to
+// Applied are treated as a batch of one.
+func (h *History) nextInBatch() (pos, size int) {
+	h.batchMu.Lock()
+	defer h.batchMu.Unlock()
+	if len(h.batches) == 0 {
+		return 0, 1
+	}
+	b := &h.batches[0]
+	pos, size = b.seen, b.size
+	b.seen++
+	if b.seen >= b.size {
+		h.batches = h.batches[1:]
+	}
+	return pos, size
+}
+
+// coalesce reports whether e, which was typed immediately after
+// prev, should be in the same undo group as prev.  Contiguous
+// inserts are grouped until a new word or line is started or the
+// user pauses for longer than h.Pause.
+func (h *History) coalesce(prev *branch, e text.Edit) bool {
+	if prev.prev() == nil || prev.next(0) != nil {
+		// Edits are never grouped with the root, and a new branch
+		// always starts a new group.
+		return false
+	}
+	if h.Pause > 0 && time.Since(prev.when) > h.Pause {
+		return false
+	}
+	p := prev.edit
+	if len(p.Old) != 0 || len(e.Old) != 0 || len(p.New) == 0 || len(e.New) == 0 {
+		return false
+	}
+	if e.At != p.At+len(p.New) {
+		return false
+	}
+	last, first := p.New[len(p.New)-1], e.New[0]
+	if first == '\n' {
+		return false
+	}
+	return !isWord(first) || isWord(last)
+}
+
+func isWord(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// RewindGroup rewinds h by a whole undo group, returning the edits
+// that need to be applied to rewind the text.  The edits must be
+// applied one at a time, in order.  If there is nothing to rewind,
+// RewindGroup returns nil.
+func (h *History) RewindGroup() []text.Edit {
+	var edits []text.Edit
+	for {
+		curr := h.current.trunk()
+		e := h.Rewind()
+		if e.At == -1 {
+			return edits
+		}
+		edits = append(edits, e)
+		if !curr.joined {
+			return edits
+		}
+	}
+}
+
+// FastForwardGroup fast forwards h by a whole undo group, starting
+// with branch, returning the edits that need to be applied to fast
+// forward the text.  The edits must be applied one at a time, in
+// order.  If there is nothing to fast forward, FastForwardGroup
+// returns nil.
+func (h *History) FastForwardGroup(branch uint) []text.Edit {
+	e := h.FastForward(branch)
+	if e.At == -1 {
+		return nil
+	}
+	edits := []text.Edit{e}
+	for {
+		next := h.current.trunk().next(0)
+		if next == nil || !next.joined {
+			return edits
+		}
+		edits = append(edits, h.FastForward(0))
+	}
+}
@@ -0,0 +1,119 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package history_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nelsam/vidar/command/history"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+func TestGroups(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	// typeText sends s to h one rune at a time, starting at pos, the
+	// same way that the input handler does when the user types.
+	typeText := func(h *history.History, pos int, s string) {
+		for i, r := range []rune(s) {
+			e := text.Edit{At: pos + i, New: []rune{r}}
+			h.Applied(nil, []text.Edit{e})
+			h.TextChanged(nil, e)
+		}
+	}
+
+	o.BeforeEach(func(t *testing.T) (expect.Expectation, *history.History) {
+		return expect.New(t), history.New(nil)
+	})
+
+	o.Spec("it groups contiguous inserts", func(expect expect.Expectation, h *history.History) {
+		typeText(h, 0, "foo")
+		expect(h.RewindGroup()).To(haveLen(3))
+		expect(h.RewindGroup()).To(haveLen(0))
+	})
+
+	o.Spec("it starts a new group at word boundaries", func(expect expect.Expectation, h *history.History) {
+		typeText(h, 0, "foo bar")
+		expect(h.RewindGroup()).To(equal([]text.Edit{
+			{At: 6, Old: []rune("r")},
+			{At: 5, Old: []rune("a")},
+			{At: 4, Old: []rune("b")},
+		}))
+		expect(h.RewindGroup()).To(haveLen(4))
+	})
+
+	o.Spec("it records edits to unfocused files in their own history", func(expect expect.Expectation, h *history.History) {
+		h.FileChanged("", "/a.go")
+		edits := []text.Edit{{At: 0, New: []rune("x")}, {At: 5, New: []rune("y")}}
+		ed := pathEditor{path: "/b.go"}
+		h.Applied(ed, edits)
+		for _, e := range edits {
+			h.TextChanged(ed, e)
+		}
+		expect(h.RewindGroup()).To(haveLen(0))
+		h.FileChanged("/a.go", "/b.go")
+		expect(h.RewindGroup()).To(haveLen(2))
+	})
+
+	o.Spec("it starts a new group for non-contiguous edits", func(expect expect.Expectation, h *history.History) {
+		typeText(h, 0, "foo")
+		typeText(h, 1, "x")
+		expect(h.RewindGroup()).To(haveLen(1))
+		expect(h.RewindGroup()).To(haveLen(3))
+	})
+
+	o.Spec("it starts a new group after a pause", func(expect expect.Expectation, h *history.History) {
+		h.Pause = time.Millisecond
+		typeText(h, 0, "fo")
+		time.Sleep(2 * time.Millisecond)
+		typeText(h, 2, "o")
+		expect(h.RewindGroup()).To(haveLen(1))
+		expect(h.RewindGroup()).To(haveLen(2))
+	})
+
+	o.Spec("it groups edits that were applied together", func(expect expect.Expectation, h *history.History) {
+		typeText(h, 0, "foo")
+		edits := []text.Edit{
+			{At: 0, New: []rune("// ")},
+			{At: 10, Old: []rune("bar"), New: []rune("baz")},
+		}
+		h.Applied(nil, edits)
+		for _, e := range edits {
+			h.TextChanged(nil, e)
+		}
+		expect(h.RewindGroup()).To(haveLen(2))
+		expect(h.RewindGroup()).To(haveLen(3))
+	})
+
+	o.Spec("it fast forwards whole groups", func(expect expect.Expectation, h *history.History) {
+		typeText(h, 0, "foo bar")
+		for _, e := range h.RewindGroup() {
+			h.Applied(nil, []text.Edit{e})
+			h.TextChanged(nil, e)
+		}
+		expect(h.FastForwardGroup(0)).To(equal([]text.Edit{
+			{At: 4, New: []rune("b")},
+			{At: 5, New: []rune("a")},
+			{At: 6, New: []rune("r")},
+		}))
+		expect(h.FastForwardGroup(0)).To(haveLen(0))
+	})
+
+	o.Spec("it does not group a new branch with its parent", func(expect expect.Expectation, h *history.History) {
+		typeText(h, 0, "foo")
+		for _, e := range h.RewindGroup() {
+			h.Applied(nil, []text.Edit{e})
+			h.TextChanged(nil, e)
+		}
+		typeText(h, 0, "f")
+		typeText(h, 1, "o")
+		expect(h.RewindGroup()).To(haveLen(2))
+		expect(h.Branches()).To(equal(uint(2)))
+	})
+}
@@ -7,6 +7,7 @@ package history_test
 import (
 	"github.com/nelsam/vidar/command/history"
 	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
 )
 
 type T interface {
@@ -24,3 +25,14 @@ func findHistory(t T, all []bind.Bindable) *history.History {
 	t.Fatal("could not find *history.History type")
 	return nil
 }
+
+// pathEditor is a text.Editor that only knows its path.
+type pathEditor struct {
+	text.Editor
+
+	path string
+}
+
+func (e pathEditor) Filepath() string {
+	return e.path
+}
@@ -5,33 +5,62 @@
 package history
 
 import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/nelsam/gxui"
 	"github.com/nelsam/gxui/themes/basic"
 	"github.com/nelsam/vidar/commander/bind"
 	"github.com/nelsam/vidar/commander/text"
 	"github.com/nelsam/vidar/plugin/command"
+	"github.com/nelsam/vidar/setting"
 )
 
+const storeDirname = "history"
+
 // Bindables returns the slice of bind.Bindable types that is implemented
 // by this package.
 func Bindables(_ command.Commander, _ gxui.Driver, theme *basic.Theme) []bind.Bindable {
-	h := History{all: make(map[string]*branch)}
+	h := New(NewStore(filepath.Join(setting.App.DataHome(), storeDirname)))
+	h.Pause = setting.UndoGroupPause()
+	onOpen := OnOpen{theme: theme, history: h}
+	return []bind.Bindable{h, &onOpen}
+}
+
+// New returns a History which persists history using store.  If
+// store is nil, history will only be kept in memory.
+func New(store *Store) *History {
+	h := &History{all: make(map[string]*branch), store: store, Pause: DefaultPause}
 	h.resetCurrent("")
-	onOpen := OnOpen{theme: theme}
-	return []bind.Bindable{&h, &onOpen}
+	return h
 }
 
 // History keeps track of change history for a file.
 type History struct {
 	current tree
 	skip    node
+	path    string
+	store   *Store
+
+	// Pause is the longest pause between typed edits that will still
+	// be grouped into a single undo step.  If it is zero, typed edits
+	// are grouped regardless of how long the pauses between them are.
+	Pause time.Duration
+
+	// batches holds the sizes of edit batches which have been
+	// applied but not yet seen by TextChanged.
+	batches []batch
+	batchMu sync.Mutex
 
 	// all stores history for all files - it is used when the open
 	// file is changed, to store the history for the previously open
 	// file.  We're just using a mutex to synchronize access to it
 	// because it will only be accessed when the open file is changed.
+	// The mutex also guards path, which changes at the same time.
 	all   map[string]*branch
 	allMu sync.Mutex
 }
@@ -44,7 +73,7 @@ func (h *History) Name() string {
 // OpNames returns the name of bind.Op types that
 // h needs to bind to.
 func (h *History) OpNames() []string {
-	return []string{"input-handler", "focus-location"}
+	return []string{"input-handler", "focus-location", "save-current-file"}
 }
 
 // Init implements input.ChangeHook
@@ -53,13 +82,38 @@ func (h *History) Init(text.Editor, []rune) {}
 // resetCurrent resets h.current.trunk to a previous history (if one
 // exists for path) or a new empty branch.
 func (h *History) resetCurrent(path string) {
+	h.path = path
 	if n, ok := h.all[path]; ok {
 		h.current.setTrunk(n)
 		return
 	}
+	if n := h.restore(path); n != nil {
+		h.current.setTrunk(n)
+		return
+	}
 	h.current.setTrunk(&branch{})
 }
 
+// restore loads the persisted history for path, if there is any
+// that was saved when the file had its current contents.
+func (h *History) restore(path string) *branch {
+	if path == "" || h.store == nil {
+		return nil
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	n, err := h.store.load(path, contents)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Could not restore history for %s: %s", path, err)
+		}
+		return nil
+	}
+	return n
+}
+
 // addSkip takes an edit that has been returned by h (from either
 // Rewind or FastForward) and adds it to h.skipP, so that h will
 // ignore e when it is triggered by TextChanged.
@@ -87,12 +141,38 @@ func (h *History) shouldSkip(e text.Edit) bool {
 
 // TextChanged hooks into the input handler to trigger off of changes
 // in the editor so that h can track the history of those changes.
-func (h *History) TextChanged(_ text.Editor, e text.Edit) {
+func (h *History) TextChanged(editor text.Editor, e text.Edit) {
+	pos, size := h.nextInBatch()
+	h.allMu.Lock()
+	path := h.path
+	h.allMu.Unlock()
+	if editor != nil && editor.Filepath() != path {
+		h.pushTo(editor.Filepath(), e, pos > 0)
+		return
+	}
 	if h.shouldSkip(e) {
 		h.skip.setNext(h.skip.next().next())
 		return
 	}
-	h.current.setTrunk(h.current.trunk().push(e))
+	curr := h.current.trunk()
+	joined := pos > 0 || (size == 1 && h.coalesce(curr, e))
+	h.current.setTrunk(curr.push(e, joined))
+}
+
+// pushTo records e in the history for path, which is not the
+// focused file.  This happens when edits are applied to a file that
+// is open but not focused, e.g. by a project-wide replace.
+func (h *History) pushTo(path string, e text.Edit, joined bool) {
+	h.allMu.Lock()
+	defer h.allMu.Unlock()
+	n, ok := h.all[path]
+	if !ok {
+		n = h.restore(path)
+	}
+	if n == nil {
+		n = &branch{}
+	}
+	h.all[path] = n.push(e, joined)
 }
 
 // Rewind tells h to rewind its current state and return the
@@ -142,6 +222,24 @@ func (h *History) FastForward(branch uint) text.Edit {
 // that is needed.
 func (h *History) Apply(text.Editor) error { return nil }
 
+// AfterSave persists the history for path, so that it can be
+// restored if path is opened again with the same contents.
+func (h *History) AfterSave(_ setting.Project, path, contents string) error {
+	if h.store == nil {
+		return nil
+	}
+	h.allMu.Lock()
+	n := h.all[path]
+	if path == h.path {
+		n = h.current.trunk()
+	}
+	h.allMu.Unlock()
+	if n == nil {
+		return nil
+	}
+	return h.store.save(path, contents, n)
+}
+
 // FileChanged updates the current history when the focused
 // file is changed.
 func (h *History) FileChanged(oldPath, newPath string) {
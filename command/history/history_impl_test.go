@@ -11,6 +11,7 @@ import (
 )
 
 var (
-	_ input.ChangeHook  = &history.History{}
-	_ focus.FileChanger = &history.History{}
+	_ input.ChangeHook        = &history.History{}
+	_ input.AppliedChangeHook = &history.History{}
+	_ focus.FileChanger       = &history.History{}
 )
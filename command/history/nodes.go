@@ -0,0 +1,198 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package history
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nelsam/vidar/commander/text"
+)
+
+// summaryLen is the maximum number of runes of an edit's text that
+// will be included in a Node's summary.
+const summaryLen = 20
+
+// A Node is a snapshot of a single entry in a History's tree.
+type Node struct {
+	// Depth is the number of edits between the root of the tree and
+	// this node.  The root itself has a depth of 0 and an empty
+	// Edit.
+	Depth int
+
+	Edit text.Edit
+	When time.Time
+
+	// Current reports whether this node was the current state of
+	// the history when the snapshot was taken.
+	Current bool
+
+	b *branch
+}
+
+// String returns a single line describing n, indented by its depth.
+func (n Node) String() string {
+	mark := " "
+	if n.Current {
+		mark = "*"
+	}
+	if n.Depth == 0 {
+		return mark + " (original)"
+	}
+	when := "--:--:--"
+	if !n.When.IsZero() {
+		when = n.When.Format("15:04:05")
+	}
+	return fmt.Sprintf("%s %s%s %s", mark, strings.Repeat("  ", n.Depth-1), when, summary(n.Edit))
+}
+
+// summary returns a short description of e.
+func summary(e text.Edit) string {
+	switch {
+	case len(e.Old) == 0:
+		return fmt.Sprintf("+%q @%d", clip(e.New), e.At)
+	case len(e.New) == 0:
+		return fmt.Sprintf("-%q @%d", clip(e.Old), e.At)
+	default:
+		return fmt.Sprintf("%q -> %q @%d", clip(e.Old), clip(e.New), e.At)
+	}
+}
+
+// clip returns r as a string, truncated to summaryLen runes.
+func clip(r []rune) string {
+	if len(r) <= summaryLen {
+		return string(r)
+	}
+	return string(r[:summaryLen]) + "…"
+}
+
+// Nodes returns a snapshot of every node in the history tree for
+// the current file, starting with the root.  Nodes are in depth-first
+// order, with branches in the same order that FastForward uses.
+func (h *History) Nodes() []Node {
+	curr := h.current.trunk()
+	root := curr
+	for root.prev() != nil {
+		root = root.prev()
+	}
+	var nodes []Node
+	var walk func(b *branch, depth int)
+	walk = func(b *branch, depth int) {
+		nodes = append(nodes, Node{
+			Depth:   depth,
+			Edit:    b.edit,
+			When:    b.when,
+			Current: b == curr,
+			b:       b,
+		})
+		for _, c := range children(b) {
+			walk(c, depth+1)
+		}
+	}
+	walk(root, 0)
+	return nodes
+}
+
+// route returns the branches that must be rewound, starting at from,
+// and the branches that must be fast forwarded, ending at to, in
+// order to move from one to the other.  It returns false if from and
+// to are not in the same tree.
+func route(from, to *branch) (up, down []*branch, ok bool) {
+	if to == nil {
+		return nil, nil, false
+	}
+	depth := func(b *branch) (d int) {
+		for p := b.prev(); p != nil; p = p.prev() {
+			d++
+		}
+		return d
+	}
+	fd, td := depth(from), depth(to)
+	for ; fd > td; fd-- {
+		up = append(up, from)
+		from = from.prev()
+	}
+	for ; td > fd; td-- {
+		down = append(down, to)
+		to = to.prev()
+	}
+	for from != to {
+		up = append(up, from)
+		down = append(down, to)
+		from, to = from.prev(), to.prev()
+	}
+	if from == nil {
+		return nil, nil, false
+	}
+	for i, j := 0, len(down)-1; i < j; i, j = i+1, j-1 {
+		down[i], down[j] = down[j], down[i]
+	}
+	return up, down, true
+}
+
+// index returns the index of b in its parent's children, suitable
+// for passing to FastForward.
+func index(b *branch) uint {
+	for i, c := range children(b.prev()) {
+		if c == b {
+			return uint(i)
+		}
+	}
+	return 0
+}
+
+var errNotInTree = errors.New("history: node is not in the current history")
+
+// Preview returns the text that would result from moving the history
+// to n, given that curr is the text at the current state of the
+// history.  It does not change the state of h.
+func (h *History) Preview(n Node, curr []rune) ([]rune, error) {
+	up, down, ok := route(h.current.trunk(), n.b)
+	if !ok {
+		return nil, errNotInTree
+	}
+	result := append([]rune(nil), curr...)
+	for _, b := range up {
+		result = apply(result, text.Edit{At: b.edit.At, Old: b.edit.New, New: b.edit.Old})
+	}
+	for _, b := range down {
+		result = apply(result, b.edit)
+	}
+	return result, nil
+}
+
+// Jump moves the history to n, returning the edits that need to be
+// applied, in order, to move the text along with it.  Each edit must
+// be applied separately, since every edit is relative to the text
+// that the previous edit produced.
+func (h *History) Jump(n Node) ([]text.Edit, error) {
+	up, down, ok := route(h.current.trunk(), n.b)
+	if !ok {
+		return nil, errNotInTree
+	}
+	edits := make([]text.Edit, 0, len(up)+len(down))
+	for range up {
+		edits = append(edits, h.Rewind())
+	}
+	for _, b := range down {
+		edits = append(edits, h.FastForward(index(b)))
+	}
+	return edits, nil
+}
+
+// apply returns the result of applying e to r.  It does not modify
+// r.
+func apply(r []rune, e text.Edit) []rune {
+	end := e.At + len(e.Old)
+	if e.At < 0 || end > len(r) {
+		return r
+	}
+	result := make([]rune, 0, len(r)-len(e.Old)+len(e.New))
+	result = append(result, r[:e.At]...)
+	result = append(result, e.New...)
+	return append(result, r[end:]...)
+}
@@ -0,0 +1,88 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package history_test
+
+import (
+	"testing"
+
+	"github.com/nelsam/vidar/command/history"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+func TestNodes(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	// The text starts as "ab".  Inserting "c" and then "d" gives
+	// "abcd"; undoing both and inserting "x" at the start gives "xab".
+	c := text.Edit{At: 2, New: []rune("c")}
+	d := text.Edit{At: 3, New: []rune("d")}
+	x := text.Edit{At: 0, New: []rune("x")}
+
+	o.BeforeEach(func(t *testing.T) (expect.Expectation, *history.History) {
+		h := history.New(nil)
+		h.TextChanged(nil, c)
+		h.TextChanged(nil, d)
+		h.TextChanged(nil, h.Rewind())
+		h.TextChanged(nil, h.Rewind())
+		h.TextChanged(nil, x)
+		return expect.New(t), h
+	})
+
+	o.Spec("it lists every node in depth-first order", func(expect expect.Expectation, h *history.History) {
+		nodes := h.Nodes()
+		expect(nodes).To(haveLen(4))
+		expect(nodes[0].Depth).To(equal(0))
+		expect(nodes[1].Edit).To(equal(c))
+		expect(nodes[1].Depth).To(equal(1))
+		expect(nodes[2].Edit).To(equal(d))
+		expect(nodes[2].Depth).To(equal(2))
+		expect(nodes[3].Edit).To(equal(x))
+		expect(nodes[3].Depth).To(equal(1))
+		expect(nodes[3].Current).To(beTrue())
+		expect(nodes[3].When.IsZero()).To(equal(false))
+	})
+
+	o.Spec("it previews the text at another branch", func(expect expect.Expectation, h *history.History) {
+		preview, err := h.Preview(h.Nodes()[2], []rune("xab"))
+		expect(err).To(not(haveOccurred()))
+		expect(string(preview)).To(equal("abcd"))
+
+		preview, err = h.Preview(h.Nodes()[0], []rune("xab"))
+		expect(err).To(not(haveOccurred()))
+		expect(string(preview)).To(equal("ab"))
+	})
+
+	o.Spec("it does not move the history when previewing", func(expect expect.Expectation, h *history.History) {
+		_, err := h.Preview(h.Nodes()[2], []rune("xab"))
+		expect(err).To(not(haveOccurred()))
+		expect(h.Nodes()[3].Current).To(beTrue())
+	})
+
+	o.Spec("it jumps to another branch", func(expect expect.Expectation, h *history.History) {
+		edits, err := h.Jump(h.Nodes()[2])
+		expect(err).To(not(haveOccurred()))
+		expect(edits).To(equal([]text.Edit{
+			{At: 0, Old: []rune("x")},
+			c,
+			d,
+		}))
+		expect(h.Nodes()[2].Current).To(beTrue())
+
+		for _, e := range edits {
+			h.TextChanged(nil, e)
+		}
+		expect(h.Rewind()).To(equal(text.Edit{At: 3, Old: []rune("d")}))
+	})
+
+	o.Spec("it refuses to jump to a node from another file's history", func(expect expect.Expectation, h *history.History) {
+		n := h.Nodes()[2]
+		h.FileChanged("", "foo")
+		_, err := h.Jump(n)
+		expect(err).To(haveOccurred())
+	})
+}
@@ -0,0 +1,322 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package history
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nelsam/vidar/commander/text"
+)
+
+const (
+	// DefaultMaxBytes is the default total size of all history files
+	// in a Store.
+	DefaultMaxBytes = 64 << 20
+
+	// DefaultMaxAge is the default age at which history files are
+	// removed from a Store.
+	DefaultMaxAge = 30 * 24 * time.Hour
+
+	// DefaultMaxEdits is the default number of edits that will be
+	// persisted for a single file.
+	DefaultMaxEdits = 10000
+
+	historyExt = ".json"
+)
+
+// Store persists history to disk, so that it can be restored after
+// vidar is restarted.  History is keyed by both the path of the file
+// and the hash of the file's contents, so that it is only restored
+// if the file has not changed since the history was saved.
+type Store struct {
+	// Dir is the directory that history files are stored in.
+	Dir string
+
+	// MaxBytes is the maximum total size of the files in Dir.  When
+	// it is exceeded, the least recently saved files are removed.
+	MaxBytes int64
+
+	// MaxAge is the maximum amount of time since a history file was
+	// last saved before it will be removed.
+	MaxAge time.Duration
+
+	// MaxEdits is the maximum number of edits that will be saved for
+	// any one file.  When a file's history is larger than MaxEdits,
+	// the oldest edits are dropped.
+	MaxEdits int
+}
+
+// NewStore returns a Store that persists history to dir, using the
+// default limits.
+func NewStore(dir string) *Store {
+	return &Store{
+		Dir:      dir,
+		MaxBytes: DefaultMaxBytes,
+		MaxAge:   DefaultMaxAge,
+		MaxEdits: DefaultMaxEdits,
+	}
+}
+
+// persistedEdit is a single edit in a persisted history tree.
+type persistedEdit struct {
+	// Parent is the index of the parent edit, or -1 if the edit is a
+	// child of the (empty) root of the tree.
+	Parent int       `json:"parent"`
+	At     int       `json:"at"`
+	Old    string    `json:"old,omitempty"`
+	New    string    `json:"new,omitempty"`
+	When   time.Time `json:"when"`
+
+	// Joined is true if the edit is in the same undo group as its
+	// parent.
+	Joined bool `json:"joined,omitempty"`
+}
+
+// persisted is the on-disk format of a history tree.  Edits are in
+// breadth-first order, so each edit's parent is always before it,
+// and the order of sibling branches is preserved.
+type persisted struct {
+	Path  string          `json:"path"`
+	Hash  string          `json:"hash"`
+	Saved time.Time       `json:"saved"`
+	Edits []persistedEdit `json:"edits"`
+
+	// Current is the index of the edit that was last applied, or -1
+	// if every edit in the tree was undone.
+	Current int `json:"current"`
+}
+
+func hash(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Store) prefix(path string) string {
+	return hash([]byte(path)) + "-"
+}
+
+func (s *Store) filename(path, contentHash string) string {
+	return filepath.Join(s.Dir, s.prefix(path)+contentHash+historyExt)
+}
+
+// save writes the history tree containing current to disk, keyed by
+// path and contents.  Any history previously saved for path is
+// removed.
+func (s *Store) save(path, contents string, current *branch) error {
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return err
+	}
+	p := persisted{
+		Path:  path,
+		Hash:  hash([]byte(contents)),
+		Saved: time.Now(),
+	}
+	p.Edits, p.Current = flatten(s.trim(current), current, s.MaxEdits)
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	name := s.filename(path, p.Hash)
+	old, err := filepath.Glob(filepath.Join(s.Dir, s.prefix(path)+"*"+historyExt))
+	if err != nil {
+		return err
+	}
+	for _, o := range old {
+		if o != name {
+			os.Remove(o)
+		}
+	}
+	if err := ioutil.WriteFile(name, b, 0600); err != nil {
+		return err
+	}
+	return s.prune(name)
+}
+
+// load reads the history tree that was saved for path when its
+// contents matched contents.  It returns the branch that was current
+// when the history was saved.  If no such history exists, the error
+// will satisfy os.IsNotExist.
+func (s *Store) load(path string, contents []byte) (*branch, error) {
+	b, err := ioutil.ReadFile(s.filename(path, hash(contents)))
+	if err != nil {
+		return nil, err
+	}
+	var p persisted
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, err
+	}
+	if p.Path != path {
+		return nil, os.ErrNotExist
+	}
+	return unflatten(p.Edits, p.Current)
+}
+
+// prune removes history files which are older than s.MaxAge, then
+// removes the least recently saved files until the total size of
+// the remaining files is under s.MaxBytes.  The file at keep is
+// never removed.
+func (s *Store) prune(keep string) error {
+	dir, err := os.Open(s.Dir)
+	if err != nil {
+		return err
+	}
+	finfos, err := dir.Readdir(-1)
+	dir.Close()
+	if err != nil {
+		return err
+	}
+	sort.Slice(finfos, func(i, j int) bool {
+		return finfos[i].ModTime().After(finfos[j].ModTime())
+	})
+	var total int64
+	for _, finfo := range finfos {
+		if !strings.HasSuffix(finfo.Name(), historyExt) {
+			continue
+		}
+		path := filepath.Join(s.Dir, finfo.Name())
+		if path == keep {
+			total += finfo.Size()
+			continue
+		}
+		tooOld := s.MaxAge > 0 && time.Since(finfo.ModTime()) > s.MaxAge
+		tooBig := s.MaxBytes > 0 && total+finfo.Size() > s.MaxBytes
+		if tooOld || tooBig {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			continue
+		}
+		total += finfo.Size()
+	}
+	return nil
+}
+
+// children returns the child branches of b, in order.
+func children(b *branch) []*branch {
+	first := b.next(0)
+	if first == nil {
+		return nil
+	}
+	return append([]*branch{first}, first.siblings()...)
+}
+
+// trim returns the branch that should be used as the root of the
+// persisted tree containing current.  It walks up from current for
+// as long as the tree under the parent branch has no more than
+// s.MaxEdits edits.
+func (s *Store) trim(current *branch) *branch {
+	root := current
+	for root.prev() != nil {
+		root = root.prev()
+	}
+	if s.MaxEdits <= 0 {
+		return root
+	}
+	sizes := make(map[*branch]int)
+	order := []*branch{root}
+	for i := 0; i < len(order); i++ {
+		order = append(order, children(order[i])...)
+	}
+	for i := len(order) - 1; i >= 0; i-- {
+		b := order[i]
+		sizes[b]++
+		if p := b.prev(); p != nil {
+			sizes[p] += sizes[b]
+		}
+	}
+	top := current
+	for p := top.prev(); p != nil && sizes[p]-1 <= s.MaxEdits; p = p.prev() {
+		top = p
+	}
+	return top
+}
+
+// flatten converts the tree under root to a slice of persistedEdit
+// values, returning them along with the index of current.  If max
+// is positive, no more than max edits will be returned; edits
+// nearest to root are kept.
+func flatten(root, current *branch, max int) (edits []persistedEdit, curr int) {
+	curr = -1
+	type entry struct {
+		b      *branch
+		parent int
+	}
+	queue := []entry{}
+	for _, c := range children(root) {
+		queue = append(queue, entry{b: c, parent: -1})
+	}
+	if max > 0 && len(queue) > max {
+		queue = queue[:max]
+	}
+	for i := 0; i < len(queue); i++ {
+		e := queue[i]
+		if e.b == current {
+			curr = i
+		}
+		edits = append(edits, persistedEdit{
+			Parent: e.parent,
+			At:     e.b.edit.At,
+			Old:    string(e.b.edit.Old),
+			New:    string(e.b.edit.New),
+			When:   e.b.when,
+			Joined: e.b.joined,
+		})
+		for _, c := range children(e.b) {
+			if max > 0 && len(queue) >= max {
+				break
+			}
+			queue = append(queue, entry{b: c, parent: i})
+		}
+	}
+	return edits, curr
+}
+
+// unflatten rebuilds a tree from edits, returning the branch at
+// index curr.
+func unflatten(edits []persistedEdit, curr int) (*branch, error) {
+	root := &branch{}
+	branches := make([]*branch, 0, len(edits))
+	for i, e := range edits {
+		parent := root
+		if e.Parent >= 0 {
+			if e.Parent >= i {
+				return nil, errors.New("history: persisted edit has an invalid parent")
+			}
+			parent = branches[e.Parent]
+		}
+		b := parent.push(text.Edit{
+			At:  e.At,
+			Old: runes(e.Old),
+			New: runes(e.New),
+		}, e.Joined)
+		b.when = e.When
+		branches = append(branches, b)
+	}
+	if curr < 0 {
+		return root, nil
+	}
+	if curr >= len(branches) {
+		return nil, errors.New("history: persisted current edit is out of range")
+	}
+	return branches[curr], nil
+}
+
+// runes converts s to a []rune, returning nil for empty strings to
+// match edits which were never persisted.
+func runes(s string) []rune {
+	if s == "" {
+		return nil
+	}
+	return []rune(s)
+}
@@ -0,0 +1,134 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package history_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nelsam/vidar/command/history"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/setting"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+func TestPersist(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	var errEdit = text.Edit{At: -1}
+
+	type testCtx struct {
+		expect expect.Expectation
+		store  *history.Store
+		path   string
+		hist   *history.History
+		edits  []text.Edit
+	}
+
+	o.BeforeEach(func(t *testing.T) testCtx {
+		dir, err := ioutil.TempDir("", "vidar-history")
+		if err != nil {
+			t.Fatalf("could not create temp dir: %s", err)
+		}
+		path := filepath.Join(dir, "foo.go")
+		store := history.NewStore(filepath.Join(dir, "store"))
+		h := history.New(store)
+		h.FileChanged("", path)
+		edits := []text.Edit{
+			{At: 0, New: []rune("foo")},
+			{At: 3, New: []rune("bar")},
+		}
+		for _, e := range edits {
+			h.TextChanged(nil, e)
+		}
+		return testCtx{
+			expect: expect.New(t),
+			store:  store,
+			path:   path,
+			hist:   h,
+			edits:  edits,
+		}
+	})
+
+	o.AfterEach(func(tt testCtx) {
+		os.RemoveAll(filepath.Dir(tt.path))
+	})
+
+	save := func(tt testCtx, contents string) {
+		err := tt.hist.AfterSave(setting.Project{}, tt.path, contents)
+		tt.expect(err).To(not(haveOccurred()))
+		err = ioutil.WriteFile(tt.path, []byte(contents), 0600)
+		tt.expect(err).To(not(haveOccurred()))
+	}
+
+	o.Spec("it restores history when the file is unchanged", func(tt testCtx) {
+		save(tt, "foobar")
+
+		h := history.New(tt.store)
+		h.FileChanged("", tt.path)
+		tt.expect(h.Rewind()).To(equal(text.Edit{At: 3, Old: []rune("bar")}))
+		tt.expect(h.Rewind()).To(equal(text.Edit{At: 0, Old: []rune("foo")}))
+		tt.expect(h.Rewind()).To(equal(errEdit))
+	})
+
+	o.Spec("it does not restore history when the file has changed", func(tt testCtx) {
+		save(tt, "foobar")
+		err := ioutil.WriteFile(tt.path, []byte("something else"), 0600)
+		tt.expect(err).To(not(haveOccurred()))
+
+		h := history.New(tt.store)
+		h.FileChanged("", tt.path)
+		tt.expect(h.Rewind()).To(equal(errEdit))
+	})
+
+	o.Spec("it restores sibling branches and the current position", func(tt testCtx) {
+		tt.hist.TextChanged(nil, tt.hist.Rewind())
+		branch := text.Edit{At: 3, New: []rune("baz")}
+		tt.hist.TextChanged(nil, branch)
+		tt.hist.TextChanged(nil, tt.hist.Rewind())
+		save(tt, "foo")
+
+		h := history.New(tt.store)
+		h.FileChanged("", tt.path)
+		tt.expect(h.Branches()).To(equal(uint(2)))
+		tt.expect(h.FastForward(0)).To(equal(tt.edits[1]))
+		h.Rewind()
+		tt.expect(h.FastForward(1)).To(equal(branch))
+	})
+
+	o.Spec("it drops the oldest edits when there are too many", func(tt testCtx) {
+		tt.store.MaxEdits = 1
+		save(tt, "foobar")
+
+		h := history.New(tt.store)
+		h.FileChanged("", tt.path)
+		tt.expect(h.Rewind()).To(equal(text.Edit{At: 3, Old: []rune("bar")}))
+		tt.expect(h.Rewind()).To(equal(errEdit))
+	})
+
+	o.Spec("it prunes the least recently saved history", func(tt testCtx) {
+		save(tt, "foobar")
+		tt.store.MaxBytes = 1
+
+		other := filepath.Join(filepath.Dir(tt.path), "bar.go")
+		tt.hist.FileChanged(tt.path, other)
+		tt.hist.TextChanged(nil, text.Edit{At: 0, New: []rune("baz")})
+		err := tt.hist.AfterSave(setting.Project{}, other, "baz")
+		tt.expect(err).To(not(haveOccurred()))
+		err = ioutil.WriteFile(other, []byte("baz"), 0600)
+		tt.expect(err).To(not(haveOccurred()))
+
+		h := history.New(tt.store)
+		h.FileChanged("", tt.path)
+		tt.expect(h.Rewind()).To(equal(errEdit))
+
+		h.FileChanged(tt.path, other)
+		tt.expect(h.Rewind()).To(equal(text.Edit{At: 0, Old: []rune("baz")}))
+	})
+}
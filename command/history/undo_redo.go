@@ -19,7 +19,8 @@ type Applier interface {
 }
 
 type OnOpen struct {
-	theme *basic.Theme
+	theme   *basic.Theme
+	history *History
 }
 
 func (o OnOpen) Name() string {
@@ -35,7 +36,7 @@ func (o OnOpen) FileBindables(string) []bind.Bindable {
 	u.Theme = o.theme
 	r := &Redo{}
 	r.Theme = o.theme
-	return []bind.Bindable{u, r}
+	return []bind.Bindable{u, r, newBrowse(o.theme, o.history)}
 }
 
 // An Undo is a command which undoes an action.
@@ -84,12 +85,14 @@ func (u *Undo) Store(target interface{}) bind.Status {
 }
 
 func (u *Undo) Exec() error {
-	edit := u.history.Rewind()
-	if edit.At == -1 {
+	edits := u.history.RewindGroup()
+	if len(edits) == 0 {
 		u.Warn = "undo: nothing to undo"
 		return nil
 	}
-	u.applier.Apply(u.editor, edit)
+	for _, e := range edits {
+		u.applier.Apply(u.editor, e)
+	}
 	return nil
 }
 
@@ -141,11 +144,13 @@ func (r *Redo) Store(target interface{}) bind.Status {
 func (r *Redo) Exec() error {
 	// Overflow will just result in a high number, so no need to
 	// check for it.
-	edit := r.history.FastForward(r.history.Branches() - 1)
-	if edit.At == -1 {
+	edits := r.history.FastForwardGroup(r.history.Branches() - 1)
+	if len(edits) == 0 {
 		r.Warn = "redo: nothing to redo"
 		return nil
 	}
-	r.applier.Apply(r.editor, edit)
+	for _, e := range edits {
+		r.applier.Apply(r.editor, e)
+	}
 	return nil
 }
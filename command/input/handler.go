@@ -106,7 +106,12 @@ func (e *Handler) Init(newEditor text.Editor, contents []rune) {
 }
 
 func (h *Handler) Apply(e text.Editor, edits ...text.Edit) {
-	editor := e.(*editor.CodeEditor)
+	editor, ok := e.(*editor.CodeEditor)
+	if !ok {
+		// Views that aren't backed by a CodeEditor (e.g. diff views)
+		// don't take edits.
+		return
+	}
 	c := editor.Controller()
 	text := c.TextRunes()
 	delta := 0
@@ -150,7 +155,10 @@ func (e *Handler) HandleEvent(focused text.Editor, ev gxui.KeyboardEvent) {
 		// This Handler, at least for now, doesn't handle key bindings.
 		return
 	}
-	editor := focused.(*editor.CodeEditor)
+	editor, ok := focused.(*editor.CodeEditor)
+	if !ok || editor.ReadOnly() {
+		return
+	}
 	ctrl := editor.Controller()
 	switch ev.Key {
 	case gxui.KeyEnter:
@@ -213,7 +221,10 @@ func (e *Handler) HandleInput(focused text.Editor, ev gxui.KeyStrokeEvent) {
 	if ev.Modifier&^gxui.ModShift != 0 {
 		return
 	}
-	editor := focused.(*editor.CodeEditor)
+	editor, ok := focused.(*editor.CodeEditor)
+	if !ok || editor.ReadOnly() {
+		return
+	}
 	ctrl := editor.Controller()
 	var edits []text.Edit
 	for _, s := range editor.Controller().SelectionSlice() {
@@ -0,0 +1,91 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+// Package jump contains the commands that go back and forward
+// through the locations that the caret has jumped between, and the
+// hook that records those jumps.  Each split keeps its own history
+// of locations.
+package jump
+
+import (
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/jumplist"
+	"github.com/nelsam/vidar/plugin/command"
+)
+
+// A Historian is any element that keeps a history of locations that
+// have been jumped between.
+type Historian interface {
+	Jumps() *jumplist.History
+}
+
+// A Careter is any element that knows where its carets are.
+type Careter interface {
+	Carets() []int
+}
+
+// An EditorFinder is any element that can find the open editor for
+// a file.
+type EditorFinder interface {
+	EditorFor(path string) text.Editor
+}
+
+// Bindables returns the commands and hooks in this package.
+func Bindables(cmdr command.Commander, driver gxui.Driver, theme gxui.Theme) []bind.Bindable {
+	known := &histories{}
+	return []bind.Bindable{
+		&Recorder{Commander: cmdr, known: known},
+		NewNavigate(cmdr, theme, Back),
+		NewNavigate(cmdr, theme, Forward),
+		NewPick(cmdr, theme),
+	}
+}
+
+// histories keeps track of every history that a jump has been
+// recorded in, so that all of them can be adjusted when text is
+// edited.  It is only used on the UI goroutine.
+type histories struct {
+	all []*jumplist.History
+}
+
+func (h *histories) add(hist *jumplist.History) {
+	for _, known := range h.all {
+		if known == hist {
+			return
+		}
+	}
+	h.all = append(h.all, hist)
+}
+
+func (h *histories) adjust(path string, edits []text.Edit) {
+	for _, hist := range h.all {
+		for _, e := range edits {
+			hist.Adjust(path, e.At, len(e.Old), len(e.New))
+		}
+	}
+}
+
+// here returns the location of e's first caret.
+func here(e text.Editor) jumplist.Location {
+	if e == nil {
+		return jumplist.Location{}
+	}
+	return jumplist.Location{Path: e.Filepath(), Offset: firstCaret(e)}
+}
+
+// firstCaret returns the offset of e's first caret, or 0 if e
+// doesn't know where its carets are.
+func firstCaret(e interface{}) int {
+	c, ok := e.(Careter)
+	if !ok {
+		return 0
+	}
+	carets := c.Carets()
+	if len(carets) == 0 {
+		return 0
+	}
+	return carets[0]
+}
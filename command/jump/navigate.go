@@ -0,0 +1,281 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package jump
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/command/focus"
+	"github.com/nelsam/vidar/commander"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/jumplist"
+	"github.com/nelsam/vidar/plugin/command"
+	"github.com/nelsam/vidar/plugin/status"
+)
+
+// maxPicked is the most locations that Pick will list.
+const maxPicked = 20
+
+// A Locationer is a command that can focus a file.
+type Locationer interface {
+	For(...focus.Opt) bind.Bindable
+}
+
+// Direction is the direction that a Navigate command moves through
+// the history.
+type Direction int
+
+const (
+	// Back moves to the location before the current one.
+	Back Direction = iota
+
+	// Forward moves to the location after the current one.
+	Forward
+)
+
+// Navigate is a command that goes back or forward through the
+// locations that the focused split has jumped between.
+type Navigate struct {
+	status.General
+
+	cmdr      command.Commander
+	direction Direction
+
+	hist   Historian
+	editor text.Editor
+}
+
+// NewNavigate returns a *Navigate that moves in direction.
+func NewNavigate(cmdr command.Commander, theme gxui.Theme, direction Direction) *Navigate {
+	n := &Navigate{cmdr: cmdr, direction: direction}
+	n.Theme = theme
+	return n
+}
+
+func (n *Navigate) Name() string {
+	if n.direction == Forward {
+		return "go-forward"
+	}
+	return "go-back"
+}
+
+func (n *Navigate) Menu() string {
+	return "Navigation"
+}
+
+func (n *Navigate) Defaults() []fmt.Stringer {
+	key := gxui.KeyLeft
+	if n.direction == Forward {
+		key = gxui.KeyRight
+	}
+	return []fmt.Stringer{gxui.KeyboardEvent{
+		Modifier: gxui.ModControl | gxui.ModAlt,
+		Key:      key,
+	}}
+}
+
+func (n *Navigate) Reset() {
+	n.hist = nil
+	n.editor = nil
+}
+
+func (n *Navigate) Store(elem interface{}) bind.Status {
+	switch src := elem.(type) {
+	case Historian:
+		n.hist = src
+	case text.Editor:
+		n.editor = src
+	}
+	if n.hist == nil {
+		return bind.Waiting
+	}
+	// The editor is inside of the split, so keep looking for it.
+	return bind.Executing
+}
+
+func (n *Navigate) Exec() error {
+	jumps := n.hist.Jumps()
+	move, none := jumps.Back, "earlier"
+	if n.direction == Forward {
+		move, none = jumps.Forward, "later"
+	}
+	l, ok := move(here(n.editor))
+	if !ok {
+		n.Info = fmt.Sprintf("%s: there are no %s locations", n.Name(), none)
+		return nil
+	}
+	return focusOn(n.cmdr, l)
+}
+
+// Pick is a command that lists the locations that the focused split
+// has jumped between, most recent first, and moves to the one that
+// is chosen.
+type Pick struct {
+	status.General
+
+	cmdr command.Commander
+
+	prompt gxui.Label
+	choice gxui.TextBox
+	input  gxui.Focusable
+
+	// listed is the index in the history of each location that
+	// was listed by Start.
+	listed []int
+
+	hist Historian
+}
+
+// NewPick returns a *Pick.
+func NewPick(cmdr command.Commander, theme gxui.Theme) *Pick {
+	p := &Pick{
+		cmdr:   cmdr,
+		prompt: theme.CreateLabel(),
+		choice: theme.CreateTextBox(),
+	}
+	p.prompt.SetMultiline(true)
+	p.Theme = theme
+	return p
+}
+
+func (p *Pick) Name() string {
+	return "recent-locations"
+}
+
+func (p *Pick) Menu() string {
+	return "Navigation"
+}
+
+func (p *Pick) Defaults() []fmt.Stringer {
+	return []fmt.Stringer{gxui.KeyboardEvent{
+		Modifier: gxui.ModControl | gxui.ModAlt,
+		Key:      gxui.KeyJ,
+	}}
+}
+
+func (p *Pick) Start(control gxui.Control) gxui.Control {
+	p.listed = nil
+	p.choice.SetText("")
+	p.input = p.choice
+	hist := findHistorian(control)
+	if hist == nil {
+		p.prompt.SetText("no recent locations")
+		return p.prompt
+	}
+	locs, current := hist.Jumps().Locations()
+	finder := findEditorFinder(control)
+	lines := []string{"recent locations (enter a number):"}
+	for i := len(locs) - 1; i >= 0 && len(p.listed) < maxPicked; i-- {
+		p.listed = append(p.listed, i)
+		mark := " "
+		if i == current {
+			mark = "*"
+		}
+		l := locs[i]
+		lines = append(lines, fmt.Sprintf("%s%d: %s:%d", mark, len(p.listed), filepath.Base(l.Path), lineOf(finder, l)+1))
+	}
+	if len(p.listed) == 0 {
+		p.prompt.SetText("no recent locations")
+		return p.prompt
+	}
+	p.choice.SetText("1")
+	p.prompt.SetText(strings.Join(lines, "\n"))
+	return p.prompt
+}
+
+func (p *Pick) Next() gxui.Focusable {
+	input := p.input
+	p.input = nil
+	return input
+}
+
+func (p *Pick) Reset() {
+	p.hist = nil
+}
+
+func (p *Pick) Store(elem interface{}) bind.Status {
+	if h, ok := elem.(Historian); ok {
+		p.hist = h
+		return bind.Done
+	}
+	return bind.Waiting
+}
+
+func (p *Pick) Exec() error {
+	choice := strings.TrimSpace(p.choice.Text())
+	n, err := strconv.Atoi(choice)
+	if err != nil || n < 1 || n > len(p.listed) {
+		p.Err = fmt.Sprintf("recent-locations: %q is not one of the listed locations", choice)
+		return fmt.Errorf("recent-locations: invalid choice %q", choice)
+	}
+	l, ok := p.hist.Jumps().Go(p.listed[n-1])
+	if !ok {
+		p.Err = "recent-locations: the location is no longer in the history"
+		return fmt.Errorf("recent-locations: location %d is gone", n)
+	}
+	return focusOn(p.cmdr, l)
+}
+
+// focusOn executes focus-location to move to l.
+func focusOn(cmdr command.Commander, l jumplist.Location) error {
+	opener, ok := cmdr.Bindable("focus-location").(Locationer)
+	if !ok {
+		return fmt.Errorf("focus-location is not a Locationer")
+	}
+	cmdr.Execute(opener.For(focus.Path(l.Path), focus.Offset(l.Offset)))
+	return nil
+}
+
+// lineOf returns the line that l is on, using the text of the open
+// editor for l's file if there is one.
+func lineOf(finder EditorFinder, l jumplist.Location) int {
+	if finder != nil {
+		if e := finder.EditorFor(l.Path); e != nil {
+			return jumplist.Line(e.Runes(), l.Offset)
+		}
+	}
+	b, err := ioutil.ReadFile(l.Path)
+	if err != nil {
+		return 0
+	}
+	return jumplist.Line([]rune(string(b)), l.Offset)
+}
+
+// findHistorian returns the first Historian in elem or its elements.
+func findHistorian(elem interface{}) Historian {
+	switch src := elem.(type) {
+	case Historian:
+		return src
+	case commander.Elementer:
+		for _, child := range src.Elements() {
+			if h := findHistorian(child); h != nil {
+				return h
+			}
+		}
+	}
+	return nil
+}
+
+// findEditorFinder returns the outermost EditorFinder in elem or its
+// elements, which can find editors in every project and split.
+func findEditorFinder(elem interface{}) EditorFinder {
+	switch src := elem.(type) {
+	case EditorFinder:
+		return src
+	case commander.Elementer:
+		for _, child := range src.Elements() {
+			if f := findEditorFinder(child); f != nil {
+				return f
+			}
+		}
+	}
+	return nil
+}
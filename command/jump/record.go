@@ -0,0 +1,104 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package jump
+
+import (
+	"github.com/nelsam/vidar/command/caret"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/jumplist"
+	"github.com/nelsam/vidar/plugin/command"
+)
+
+// Recorder is a hook that records a jump in the focused split's
+// history whenever focus-location executes or the caret moves more
+// than jumplist.FarLines lines, and adjusts the locations in every
+// history as text is edited.
+type Recorder struct {
+	Commander command.Commander
+
+	known *histories
+
+	// moving and from are the editor whose carets are moving and
+	// where its first caret was before they moved.
+	moving text.Editor
+	from   int
+}
+
+func (r *Recorder) Name() string {
+	return "jump-recorder"
+}
+
+func (r *Recorder) OpNames() []string {
+	return []string{"focus-location", "caret-movement", "input-handler"}
+}
+
+// Jumped implements focus.Jumper.
+func (r *Recorder) Jumped(fromPath string, fromOffset int, toPath string, toOffset int) {
+	r.record(jumplist.Location{Path: fromPath, Offset: fromOffset}, jumplist.Location{Path: toPath, Offset: toOffset})
+}
+
+// Moving implements caret.MovingHook.  It only remembers where the
+// carets were, for Moved.
+func (r *Recorder) Moving(e text.Editor, d caret.Direction, m caret.Mod, carets []int) (caret.Direction, caret.Mod, []int) {
+	r.moving, r.from = e, firstCaret(e)
+	return d, m, carets
+}
+
+// Moved implements caret.MovedHook.
+func (r *Recorder) Moved(e text.Editor, carets []int) {
+	if e != r.moving || len(carets) == 0 {
+		return
+	}
+	r.moving = nil
+	if !jumplist.Far(e.Runes(), r.from, carets[0]) {
+		return
+	}
+	r.record(jumplist.Location{Path: e.Filepath(), Offset: r.from}, jumplist.Location{Path: e.Filepath(), Offset: carets[0]})
+}
+
+// Applied implements input.AppliedChangeHook.
+func (r *Recorder) Applied(e text.Editor, edits []text.Edit) {
+	r.known.adjust(e.Filepath(), edits)
+}
+
+func (r *Recorder) record(from, to jumplist.Location) {
+	if from.Path == "" || to.Path == "" {
+		return
+	}
+	r.Commander.Execute(&record{known: r.known, from: from, to: to})
+}
+
+// record is an op that records a jump in the focused split's
+// history.
+type record struct {
+	known    *histories
+	from, to jumplist.Location
+
+	hist Historian
+}
+
+func (r *record) Name() string {
+	return "record-jump"
+}
+
+func (r *record) Reset() {
+	r.hist = nil
+}
+
+func (r *record) Store(elem interface{}) bind.Status {
+	if h, ok := elem.(Historian); ok {
+		r.hist = h
+		return bind.Done
+	}
+	return bind.Waiting
+}
+
+func (r *record) Exec() error {
+	jumps := r.hist.Jumps()
+	r.known.add(jumps)
+	jumps.Jump(r.from, r.to)
+	return nil
+}
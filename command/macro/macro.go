@@ -0,0 +1,91 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+// Package macro contains commands for recording keyboard macros and
+// playing them back.  Macros are saved to the macros config file, so
+// they are kept across restarts.
+package macro
+
+import (
+	"fmt"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/gxui/themes/basic"
+	"github.com/nelsam/vidar/commander"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/setting"
+)
+
+// Target is a type that macros can be played against.
+type Target interface {
+	KeyPress(gxui.KeyboardEvent) (consume bool)
+	KeyStroke(gxui.KeyStrokeEvent) (consume bool)
+	Bindable(name string) bind.Bindable
+	Execute(bind.Bindable)
+}
+
+// Commander is the type that macros are recorded from and played
+// against.
+type Commander interface {
+	Target
+	Record(commander.Recorder)
+	Recorder() commander.Recorder
+}
+
+// KeyPresser is a type that handles key presses before they reach
+// the Commander.
+type KeyPresser interface {
+	KeyPress(gxui.KeyboardEvent) (consume bool)
+}
+
+// Editor is the editor that macros are played in.
+type Editor interface {
+	text.Editor
+	gxui.Focusable
+	KeyPresser
+}
+
+// state is shared between the macro commands.
+type state struct {
+	recording *Recording
+	last      string
+}
+
+// Bindables returns the slice of bind.Bindable types that is implemented
+// by this package.
+func Bindables(theme *basic.Theme) []bind.Bindable {
+	s := &state{}
+	return []bind.Bindable{newRecord(theme, s), newPlay(theme, s)}
+}
+
+// Replay plays steps against t.  Key presses are sent to p first,
+// if it is non-nil, the same way that gxui sends key presses to the
+// focused editor before the Commander.
+func Replay(t Target, p KeyPresser, steps []setting.MacroStep) error {
+	for _, s := range steps {
+		switch {
+		case s.Command != "":
+			b := t.Bindable(s.Command)
+			if b == nil {
+				return fmt.Errorf("no command named %s", s.Command)
+			}
+			t.Execute(b)
+		case s.Text != "":
+			for _, r := range s.Text {
+				t.KeyStroke(gxui.KeyStrokeEvent{Character: r})
+			}
+		default:
+			ev, ok := s.KeyEvent()
+			if !ok {
+				return fmt.Errorf("could not parse key %s", s.Key)
+			}
+			if p != nil && p.KeyPress(ev) {
+				continue
+			}
+			t.KeyPress(ev)
+		}
+	}
+	return nil
+}
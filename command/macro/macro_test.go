@@ -0,0 +1,126 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package macro_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/command/macro"
+	"github.com/nelsam/vidar/commander"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/setting"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+var _ macro.Commander = &commander.Commander{}
+
+type command string
+
+func (c command) Name() string             { return string(c) }
+func (c command) Menu() string             { return "Test" }
+func (c command) Defaults() []fmt.Stringer { return nil }
+
+type target struct {
+	events   []interface{}
+	commands map[string]bind.Bindable
+}
+
+func (t *target) KeyPress(ev gxui.KeyboardEvent) bool {
+	t.events = append(t.events, ev)
+	return true
+}
+
+func (t *target) KeyStroke(ev gxui.KeyStrokeEvent) bool {
+	t.events = append(t.events, ev)
+	return true
+}
+
+func (t *target) Bindable(name string) bind.Bindable {
+	return t.commands[name]
+}
+
+func (t *target) Execute(b bind.Bindable) {
+	t.events = append(t.events, b.Name())
+}
+
+func TestRecording(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	o.BeforeEach(func(t *testing.T) (expect.Expectation, *macro.Recording) {
+		return expect.New(t), macro.NewRecording("foo")
+	})
+
+	o.Spec("it merges typed text", func(expect expect.Expectation, r *macro.Recording) {
+		r.RecordKeyStroke(gxui.KeyStrokeEvent{Character: 'f'})
+		r.RecordKeyPress(gxui.KeyboardEvent{Key: gxui.KeyF})
+		r.RecordKeyStroke(gxui.KeyStrokeEvent{Character: 'O', Modifier: gxui.ModShift})
+		r.RecordKeyPress(gxui.KeyboardEvent{Key: gxui.KeyO, Modifier: gxui.ModShift})
+
+		m := r.Macro()
+		expect(m.Name).To(equal("foo"))
+		expect(m.Steps).To(equal([]setting.MacroStep{{Text: "fO"}}))
+	})
+
+	o.Spec("it records keys that do not type text", func(expect expect.Expectation, r *macro.Recording) {
+		enter := gxui.KeyboardEvent{Key: gxui.KeyEnter}
+		r.RecordKeyStroke(gxui.KeyStrokeEvent{Character: 'f'})
+		r.RecordKeyPress(enter)
+		r.RecordKeyStroke(gxui.KeyStrokeEvent{Character: 'o'})
+
+		expect(r.Macro().Steps).To(equal([]setting.MacroStep{
+			{Text: "f"},
+			{Key: enter.String()},
+			{Text: "o"},
+		}))
+	})
+
+	o.Spec("it records key presses with modifiers", func(expect expect.Expectation, r *macro.Recording) {
+		ctrl := gxui.KeyboardEvent{Key: gxui.KeyA, Modifier: gxui.ModControl}
+		r.RecordKeyPress(gxui.KeyboardEvent{Key: gxui.KeyA, Modifier: gxui.ModSuper})
+		expect(r.Macro().Steps).To(equal([]setting.MacroStep{{Key: ctrl.String()}}))
+	})
+
+	o.Spec("it records commands by name", func(expect expect.Expectation, r *macro.Recording) {
+		r.RecordCommand(command("save-current-file"))
+		expect(r.Macro().Steps).To(equal([]setting.MacroStep{{Command: "save-current-file"}}))
+	})
+}
+
+func TestReplay(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	o.BeforeEach(func(t *testing.T) (expect.Expectation, *target) {
+		return expect.New(t), &target{commands: map[string]bind.Bindable{
+			"save-current-file": command("save-current-file"),
+		}}
+	})
+
+	o.Spec("it replays text and commands", func(expect expect.Expectation, tgt *target) {
+		err := macro.Replay(tgt, nil, []setting.MacroStep{
+			{Text: "hi"},
+			{Command: "save-current-file"},
+		})
+		expect(err).To(not(haveOccurred()))
+		expect(tgt.events).To(equal([]interface{}{
+			gxui.KeyStrokeEvent{Character: 'h'},
+			gxui.KeyStrokeEvent{Character: 'i'},
+			"save-current-file",
+		}))
+	})
+
+	o.Spec("it fails on unknown commands", func(expect expect.Expectation, tgt *target) {
+		err := macro.Replay(tgt, nil, []setting.MacroStep{
+			{Command: "foo"},
+			{Text: "hi"},
+		})
+		expect(err).To(haveOccurred())
+		expect(tgt.events).To(haveLen(0))
+	})
+}
@@ -0,0 +1,136 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package macro
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/gxui/themes/basic"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/plugin/status"
+	"github.com/nelsam/vidar/setting"
+)
+
+// Play is a command that plays a saved macro a number of times
+// against the current editor.
+type Play struct {
+	status.General
+
+	state *state
+	name  gxui.TextBox
+	count gxui.TextBox
+	input []gxui.Focusable
+
+	cmdr   Commander
+	editor Editor
+}
+
+// newPlay returns a new Play command.
+func newPlay(theme *basic.Theme, s *state) *Play {
+	p := &Play{
+		state: s,
+		name:  theme.CreateTextBox(),
+		count: theme.CreateTextBox(),
+	}
+	p.Theme = theme
+	return p
+}
+
+func (p *Play) Name() string {
+	return "play-macro"
+}
+
+func (p *Play) Menu() string {
+	return "Edit"
+}
+
+func (p *Play) Defaults() []fmt.Stringer {
+	return []fmt.Stringer{gxui.KeyboardEvent{
+		Modifier: gxui.ModControl | gxui.ModShift,
+		Key:      gxui.KeyP,
+	}}
+}
+
+func (p *Play) Start(gxui.Control) gxui.Control {
+	// Default to the most recent macro, so that playing it again
+	// is a matter of pressing enter twice.
+	p.name.SetText(p.state.last)
+	p.count.SetText("")
+	p.input = []gxui.Focusable{p.name, p.count}
+	return nil
+}
+
+func (p *Play) Next() gxui.Focusable {
+	if len(p.input) == 0 {
+		return nil
+	}
+	next := p.input[0]
+	p.input = p.input[1:]
+	return next
+}
+
+func (p *Play) Reset() {
+	p.cmdr = nil
+	p.editor = nil
+}
+
+func (p *Play) Store(elem interface{}) bind.Status {
+	switch src := elem.(type) {
+	case Commander:
+		p.cmdr = src
+	case Editor:
+		p.editor = src
+	}
+	if p.cmdr != nil && p.editor != nil {
+		return bind.Done
+	}
+	return bind.Waiting
+}
+
+func (p *Play) Exec() error {
+	name := p.name.Text()
+	if name == "" {
+		p.Warn = "No macro name provided"
+		return nil
+	}
+	m, ok := setting.MacroNamed(name)
+	if !ok {
+		p.Err = fmt.Sprintf("No macro named %s", name)
+		return nil
+	}
+	count := 1
+	if c := p.count.Text(); c != "" {
+		var err error
+		count, err = strconv.Atoi(c)
+		if err != nil || count < 1 {
+			p.Err = fmt.Sprintf("%s is not a valid count", c)
+			return nil
+		}
+	}
+	p.state.last = name
+
+	// Key events are only handled by the input handler when the
+	// editor has focus, which the command box currently has.
+	gxui.SetFocus(p.editor)
+
+	// Replayed input would be recorded a second time, so recording
+	// is paused and the macro's steps are added directly.
+	rec := p.cmdr.Recorder()
+	p.cmdr.Record(nil)
+	defer p.cmdr.Record(rec)
+	for i := 0; i < count; i++ {
+		if err := Replay(p.cmdr, p.editor, m.Steps); err != nil {
+			p.Err = fmt.Sprintf("Macro %s failed: %s", name, err)
+			return err
+		}
+		if r, ok := rec.(*Recording); ok {
+			r.add(m.Steps)
+		}
+	}
+	p.Info = fmt.Sprintf("Played macro %s", name)
+	return nil
+}
@@ -0,0 +1,110 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package macro
+
+import (
+	"fmt"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/gxui/themes/basic"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/plugin/status"
+	"github.com/nelsam/vidar/setting"
+)
+
+// Record is a command that starts recording a macro, asking for its
+// name, or stops and saves the macro that is being recorded.
+type Record struct {
+	status.General
+
+	state *state
+	name  gxui.TextBox
+	input gxui.Focusable
+
+	cmdr Commander
+}
+
+// newRecord returns a new Record command.
+func newRecord(theme *basic.Theme, s *state) *Record {
+	r := &Record{state: s, name: theme.CreateTextBox()}
+	r.Theme = theme
+	return r
+}
+
+func (r *Record) Name() string {
+	return "record-macro"
+}
+
+func (r *Record) Menu() string {
+	return "Edit"
+}
+
+func (r *Record) Defaults() []fmt.Stringer {
+	return []fmt.Stringer{gxui.KeyboardEvent{
+		Modifier: gxui.ModControl | gxui.ModShift,
+		Key:      gxui.KeyR,
+	}}
+}
+
+func (r *Record) Start(gxui.Control) gxui.Control {
+	r.input = nil
+	if r.state.recording != nil {
+		// Stopping a recording doesn't need a name.
+		return nil
+	}
+	r.name.SetText("")
+	r.input = r.name
+	return nil
+}
+
+func (r *Record) Next() gxui.Focusable {
+	input := r.input
+	r.input = nil
+	return input
+}
+
+func (r *Record) Reset() {
+	r.cmdr = nil
+}
+
+func (r *Record) Store(elem interface{}) bind.Status {
+	if c, ok := elem.(Commander); ok {
+		r.cmdr = c
+		return bind.Done
+	}
+	return bind.Waiting
+}
+
+func (r *Record) Exec() error {
+	if rec := r.state.recording; rec != nil {
+		r.state.recording = nil
+		r.cmdr.Record(nil)
+		return r.save(rec.Macro())
+	}
+	name := r.name.Text()
+	if name == "" {
+		r.Warn = "No macro name provided"
+		return nil
+	}
+	rec := NewRecording(name)
+	r.state.recording = rec
+	r.cmdr.Record(rec)
+	r.Info = fmt.Sprintf("Recording macro %s", name)
+	return nil
+}
+
+func (r *Record) save(m setting.Macro) error {
+	if len(m.Steps) == 0 {
+		r.Warn = fmt.Sprintf("Macro %s is empty; not saving it", m.Name)
+		return nil
+	}
+	if err := setting.SaveMacro(m); err != nil {
+		r.Err = fmt.Sprintf("Failed to save macro %s: %s", m.Name, err)
+		return err
+	}
+	r.state.last = m.Name
+	r.Info = fmt.Sprintf("Saved macro %s", m.Name)
+	return nil
+}
@@ -0,0 +1,62 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package macro
+
+import (
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/setting"
+)
+
+// Recording is a commander.Recorder which records input as macro
+// steps.
+type Recording struct {
+	name  string
+	steps []setting.MacroStep
+}
+
+// NewRecording returns a Recording for a macro named name.
+func NewRecording(name string) *Recording {
+	return &Recording{name: name}
+}
+
+// Macro returns the macro that r has recorded.
+func (r *Recording) Macro() setting.Macro {
+	return setting.Macro{Name: r.name, Steps: r.steps}
+}
+
+// RecordKeyPress records ev, unless it is a key that types text.
+// Typed text is recorded by RecordKeyStroke, so recording presses
+// for the same keys would only bloat the macro.
+func (r *Recording) RecordKeyPress(ev gxui.KeyboardEvent) {
+	// gxui orders all of the keys that type text before KeyEscape.
+	if ev.Modifier&^gxui.ModShift == 0 && ev.Key < gxui.KeyEscape {
+		return
+	}
+	if ev.Modifier.Super() {
+		// Key bindings use ctrl for both ctrl and cmd, so do the same.
+		ev.Modifier = ev.Modifier&^gxui.ModSuper | gxui.ModControl
+	}
+	r.steps = append(r.steps, setting.MacroStep{Key: ev.String()})
+}
+
+// RecordKeyStroke records the character typed by ev, adding it to
+// the previous step if that step also recorded typed text.
+func (r *Recording) RecordKeyStroke(ev gxui.KeyStrokeEvent) {
+	if n := len(r.steps); n > 0 && r.steps[n-1].Text != "" {
+		r.steps[n-1].Text += string(ev.Character)
+		return
+	}
+	r.steps = append(r.steps, setting.MacroStep{Text: string(ev.Character)})
+}
+
+// RecordCommand records the name of cmd.
+func (r *Recording) RecordCommand(cmd bind.Command) {
+	r.steps = append(r.steps, setting.MacroStep{Command: cmd.Name()})
+}
+
+func (r *Recording) add(steps []setting.MacroStep) {
+	r.steps = append(r.steps, steps...)
+}
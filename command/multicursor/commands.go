@@ -0,0 +1,228 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package multicursor
+
+import (
+	"fmt"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+)
+
+// Editor is the type of editor that multicursor commands operate on.
+type Editor interface {
+	text.Editor
+	Controller() *gxui.TextBoxController
+}
+
+// RuneScroller is a type that can scroll to show a rune.
+type RuneScroller interface {
+	ScrollToRune(int)
+}
+
+// Hook is a hook that adds the multicursor commands to every opened
+// file.
+type Hook struct{}
+
+func (h Hook) Name() string {
+	return "multicursor-hook"
+}
+
+func (h Hook) OpName() string {
+	return "focus-location"
+}
+
+func (h Hook) FileBindables(string) []bind.Bindable {
+	return []bind.Bindable{
+		&AddCaret{name: "add-caret-above", delta: -1},
+		&AddCaret{name: "add-caret-below", delta: 1},
+		&SelectNext{},
+		&SelectAllOccurrences{},
+		&SplitSelection{},
+	}
+}
+
+// AddCaret is a command that adds a caret on the line above or
+// below each existing caret.
+type AddCaret struct {
+	name  string
+	delta int
+}
+
+func (a *AddCaret) Name() string {
+	return a.name
+}
+
+func (a *AddCaret) Menu() string {
+	return "Edit"
+}
+
+func (a *AddCaret) Defaults() []fmt.Stringer {
+	key := gxui.KeyDown
+	if a.delta < 0 {
+		key = gxui.KeyUp
+	}
+	return []fmt.Stringer{gxui.KeyboardEvent{
+		Modifier: gxui.ModControl | gxui.ModAlt,
+		Key:      key,
+	}}
+}
+
+func (a *AddCaret) Exec(target interface{}) bind.Status {
+	editor, ok := target.(Editor)
+	if !ok {
+		return bind.Waiting
+	}
+	ctrl := editor.Controller()
+	carets := Vertical(ctrl.TextRunes(), ctrl.Carets(), a.delta)
+	var sel []gxui.TextSelection
+	for _, c := range carets {
+		sel = append(sel, gxui.CreateTextSelection(c, c, false))
+	}
+	ctrl.SetSelections(sel)
+	if s, ok := target.(RuneScroller); ok {
+		idx := 0
+		if a.delta > 0 {
+			idx = len(carets) - 1
+		}
+		s.ScrollToRune(carets[idx])
+	}
+	return bind.Done
+}
+
+// SelectNext is a command that adds a selection for the next
+// occurrence of the selected text.  If any of the carets do not have
+// a selection, they are expanded to select the word they are in,
+// instead.
+type SelectNext struct{}
+
+func (s *SelectNext) Name() string {
+	return "select-next-occurrence"
+}
+
+func (s *SelectNext) Menu() string {
+	return "Edit"
+}
+
+func (s *SelectNext) Defaults() []fmt.Stringer {
+	return []fmt.Stringer{gxui.KeyboardEvent{
+		Modifier: gxui.ModControl,
+		Key:      gxui.KeyD,
+	}}
+}
+
+func (s *SelectNext) Exec(target interface{}) bind.Status {
+	editor, ok := target.(Editor)
+	if !ok {
+		return bind.Waiting
+	}
+	ctrl := editor.Controller()
+	runes := ctrl.TextRunes()
+	sels := spans(ctrl)
+	if hasEmpty(sels) {
+		setSpans(ctrl, ExpandToWords(runes, sels))
+		return bind.Done
+	}
+	next, ok := NextOccurrence(runes, sels)
+	if !ok {
+		return bind.Done
+	}
+	setSpans(ctrl, append(sels, next))
+	if scroller, ok := target.(RuneScroller); ok {
+		scroller.ScrollToRune(next.Start)
+	}
+	return bind.Done
+}
+
+// SelectAllOccurrences is a command that selects every occurrence of
+// the selected text (or the word at the caret).
+type SelectAllOccurrences struct{}
+
+func (s *SelectAllOccurrences) Name() string {
+	return "select-all-occurrences"
+}
+
+func (s *SelectAllOccurrences) Menu() string {
+	return "Edit"
+}
+
+func (s *SelectAllOccurrences) Defaults() []fmt.Stringer {
+	return []fmt.Stringer{gxui.KeyboardEvent{
+		Modifier: gxui.ModControl | gxui.ModShift,
+		Key:      gxui.KeyD,
+	}}
+}
+
+func (s *SelectAllOccurrences) Exec(target interface{}) bind.Status {
+	editor, ok := target.(Editor)
+	if !ok {
+		return bind.Waiting
+	}
+	ctrl := editor.Controller()
+	runes := ctrl.TextRunes()
+	sels := ExpandToWords(runes, spans(ctrl))
+	if len(sels) == 0 {
+		return bind.Done
+	}
+	if all := AllOccurrences(runes, sels[len(sels)-1]); len(all) > 0 {
+		setSpans(ctrl, all)
+	}
+	return bind.Done
+}
+
+// SplitSelection is a command that splits each selection spanning
+// multiple lines into one selection per line.
+type SplitSelection struct{}
+
+func (s *SplitSelection) Name() string {
+	return "split-selection-into-lines"
+}
+
+func (s *SplitSelection) Menu() string {
+	return "Edit"
+}
+
+func (s *SplitSelection) Defaults() []fmt.Stringer {
+	return []fmt.Stringer{gxui.KeyboardEvent{
+		Modifier: gxui.ModControl | gxui.ModAlt,
+		Key:      gxui.KeyL,
+	}}
+}
+
+func (s *SplitSelection) Exec(target interface{}) bind.Status {
+	editor, ok := target.(Editor)
+	if !ok {
+		return bind.Waiting
+	}
+	ctrl := editor.Controller()
+	setSpans(ctrl, SplitLines(ctrl.TextRunes(), spans(ctrl)))
+	return bind.Done
+}
+
+func spans(ctrl *gxui.TextBoxController) []text.Span {
+	var sels []text.Span
+	for _, s := range ctrl.SelectionSlice() {
+		sels = append(sels, text.Span{Start: s.Start(), End: s.End()})
+	}
+	return sels
+}
+
+func setSpans(ctrl *gxui.TextBoxController, sels []text.Span) {
+	var sel []gxui.TextSelection
+	for _, s := range sels {
+		sel = append(sel, gxui.CreateTextSelection(s.Start, s.End, false))
+	}
+	ctrl.SetSelections(sel)
+}
+
+func hasEmpty(sels []text.Span) bool {
+	for _, s := range sels {
+		if s.Start == s.End {
+			return true
+		}
+	}
+	return false
+}
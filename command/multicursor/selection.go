@@ -0,0 +1,215 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package multicursor
+
+import (
+	"sort"
+	"unicode"
+
+	"github.com/nelsam/vidar/commander/text"
+)
+
+// lineStarts returns the offset of the first rune of each line in
+// runes.
+func lineStarts(runes []rune) []int {
+	starts := []int{0}
+	for i, r := range runes {
+		if r == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// lineOf returns the index of the line containing pos.
+func lineOf(starts []int, pos int) int {
+	return sort.Search(len(starts), func(i int) bool {
+		return starts[i] > pos
+	}) - 1
+}
+
+// lineEnd returns the offset of the newline (or end of text) that
+// ends line.
+func lineEnd(runes []rune, starts []int, line int) int {
+	if line+1 < len(starts) {
+		return starts[line+1] - 1
+	}
+	return len(runes)
+}
+
+// Vertical returns carets with a new caret added delta lines away
+// from each of them, at the same column when the target line is long
+// enough.  Carets that would be moved past the first or last line
+// are not duplicated.
+func Vertical(runes []rune, carets []int, delta int) []int {
+	starts := lineStarts(runes)
+	all := append([]int(nil), carets...)
+	for _, c := range carets {
+		line := lineOf(starts, c)
+		target := line + delta
+		if target < 0 || target >= len(starts) {
+			continue
+		}
+		pos := starts[target] + c - starts[line]
+		if end := lineEnd(runes, starts, target); pos > end {
+			pos = end
+		}
+		all = append(all, pos)
+	}
+	return uniqueInts(all)
+}
+
+// ExpandToWords returns sels with each empty selection expanded to
+// cover the word that it is in.  Empty selections that are not
+// touching a word are left alone.
+func ExpandToWords(runes []rune, sels []text.Span) []text.Span {
+	expanded := make([]text.Span, 0, len(sels))
+	for _, s := range sels {
+		if s.Start == s.End {
+			for s.Start > 0 && isWord(runes[s.Start-1]) {
+				s.Start--
+			}
+			for s.End < len(runes) && isWord(runes[s.End]) {
+				s.End++
+			}
+		}
+		expanded = append(expanded, s)
+	}
+	return expanded
+}
+
+// NextOccurrence returns the next occurrence of the text in the last
+// of sels that is not already selected, wrapping around to the start
+// of runes if needed.
+func NextOccurrence(runes []rune, sels []text.Span) (next text.Span, ok bool) {
+	if len(sels) == 0 {
+		return text.Span{}, false
+	}
+	last := sels[len(sels)-1]
+	needle := runes[last.Start:last.End]
+	if len(needle) == 0 {
+		return text.Span{}, false
+	}
+	found := occurrences(runes, needle)
+	if len(found) == 0 {
+		return text.Span{}, false
+	}
+	i := sort.Search(len(found), func(i int) bool {
+		return found[i].Start >= last.End
+	})
+	for n := 0; n < len(found); n++ {
+		s := found[(i+n)%len(found)]
+		if !overlaps(s, sels) {
+			return s, true
+		}
+	}
+	return text.Span{}, false
+}
+
+// AllOccurrences returns a selection for every non-overlapping
+// occurrence of the text in sel.
+func AllOccurrences(runes []rune, sel text.Span) []text.Span {
+	needle := runes[sel.Start:sel.End]
+	if len(needle) == 0 {
+		return nil
+	}
+	return occurrences(runes, needle)
+}
+
+// SplitLines returns sels with every selection that spans more than
+// one line split into one selection per line.  The newlines
+// themselves are not selected.
+func SplitLines(runes []rune, sels []text.Span) []text.Span {
+	starts := lineStarts(runes)
+	var split []text.Span
+	for _, s := range sels {
+		first, last := lineOf(starts, s.Start), lineOf(starts, s.End)
+		if first == last {
+			split = append(split, s)
+			continue
+		}
+		for line := first; line <= last; line++ {
+			start, end := starts[line], lineEnd(runes, starts, line)
+			if line == first {
+				start = s.Start
+			}
+			if line == last {
+				if s.End == start {
+					break
+				}
+				end = s.End
+			}
+			split = append(split, text.Span{Start: start, End: end})
+		}
+	}
+	return split
+}
+
+// Box returns one selection per line between anchor and head,
+// selecting the same columns on each line.  Lines that are too short
+// to reach the box get an empty selection at their end.
+func Box(runes []rune, anchor, head int) []text.Span {
+	starts := lineStarts(runes)
+	aLine, hLine := lineOf(starts, anchor), lineOf(starts, head)
+	aCol, hCol := anchor-starts[aLine], head-starts[hLine]
+	first, last := aLine, hLine
+	if first > last {
+		first, last = last, first
+	}
+	left, right := aCol, hCol
+	if left > right {
+		left, right = right, left
+	}
+	var box []text.Span
+	for line := first; line <= last; line++ {
+		end := lineEnd(runes, starts, line)
+		s := text.Span{Start: starts[line] + left, End: starts[line] + right}
+		if s.Start > end {
+			s.Start = end
+		}
+		if s.End > end {
+			s.End = end
+		}
+		box = append(box, s)
+	}
+	return box
+}
+
+func occurrences(runes, needle []rune) []text.Span {
+	var found []text.Span
+	for i := 0; i+len(needle) <= len(runes); i++ {
+		if string(runes[i:i+len(needle)]) != string(needle) {
+			continue
+		}
+		found = append(found, text.Span{Start: i, End: i + len(needle)})
+		i += len(needle) - 1
+	}
+	return found
+}
+
+func overlaps(s text.Span, sels []text.Span) bool {
+	for _, o := range sels {
+		if s.Start < o.End && o.Start < s.End {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueInts(v []int) []int {
+	sort.Ints(v)
+	unique := v[:0]
+	for i, n := range v {
+		if i > 0 && n == v[i-1] {
+			continue
+		}
+		unique = append(unique, n)
+	}
+	return unique
+}
+
+func isWord(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,77 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package multicursor_test
+
+import (
+	"testing"
+
+	"github.com/nelsam/vidar/command/multicursor"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+func TestSelection(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	runes := []rune("foo := bar\nx\nfoo(bar, foo)\n")
+
+	o.BeforeEach(func(t *testing.T) expect.Expectation {
+		return expect.New(t)
+	})
+
+	o.Spec("it adds carets on adjacent lines", func(expect expect.Expectation) {
+		expect(multicursor.Vertical(runes, []int{15}, -1)).To(equal([]int{12, 15}))
+		expect(multicursor.Vertical(runes, []int{12}, -1)).To(equal([]int{1, 12}))
+		expect(multicursor.Vertical(runes, []int{4}, -1)).To(equal([]int{4}))
+		expect(multicursor.Vertical(runes, []int{4, 12}, 1)).To(equal([]int{4, 12, 14}))
+	})
+
+	o.Spec("it expands empty selections to words", func(expect expect.Expectation) {
+		sels := multicursor.ExpandToWords(runes, []text.Span{{Start: 1, End: 1}, {Start: 4, End: 4}})
+		expect(sels).To(equal([]text.Span{{Start: 0, End: 3}, {Start: 4, End: 4}}))
+	})
+
+	o.Spec("it finds the next unselected occurrence", func(expect expect.Expectation) {
+		next, ok := multicursor.NextOccurrence(runes, []text.Span{{Start: 0, End: 3}})
+		expect(ok).To(beTrue())
+		expect(next).To(equal(text.Span{Start: 13, End: 16}))
+
+		next, ok = multicursor.NextOccurrence(runes, []text.Span{{Start: 13, End: 16}, {Start: 22, End: 25}})
+		expect(ok).To(beTrue())
+		expect(next).To(equal(text.Span{Start: 0, End: 3}))
+
+		_, ok = multicursor.NextOccurrence(runes, []text.Span{{Start: 0, End: 3}, {Start: 13, End: 16}, {Start: 22, End: 25}})
+		expect(ok).To(beFalse())
+	})
+
+	o.Spec("it finds all occurrences", func(expect expect.Expectation) {
+		expect(multicursor.AllOccurrences(runes, text.Span{Start: 7, End: 10})).To(equal([]text.Span{
+			{Start: 7, End: 10},
+			{Start: 17, End: 20},
+		}))
+	})
+
+	o.Spec("it splits selections into lines", func(expect expect.Expectation) {
+		expect(multicursor.SplitLines(runes, []text.Span{{Start: 4, End: 13}})).To(equal([]text.Span{
+			{Start: 4, End: 10},
+			{Start: 11, End: 12},
+		}))
+		expect(multicursor.SplitLines(runes, []text.Span{{Start: 4, End: 15}})).To(equal([]text.Span{
+			{Start: 4, End: 10},
+			{Start: 11, End: 12},
+			{Start: 13, End: 15},
+		}))
+	})
+
+	o.Spec("it selects a box of columns", func(expect expect.Expectation) {
+		expect(multicursor.Box(runes, 17, 1)).To(equal([]text.Span{
+			{Start: 1, End: 4},
+			{Start: 12, End: 12},
+			{Start: 14, End: 17},
+		}))
+	})
+}
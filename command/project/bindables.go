@@ -8,13 +8,18 @@ import (
 	"github.com/nelsam/gxui"
 	"github.com/nelsam/gxui/themes/basic"
 	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/plugin/command"
 )
 
 // Bindables returns all bindables that relate to projects.
-func Bindables(driver gxui.Driver, theme *basic.Theme) []bind.Bindable {
+func Bindables(cmdr command.Commander, driver gxui.Driver, theme *basic.Theme) []bind.Bindable {
 	return []bind.Bindable{
 		&Open{},
 		NewAdd(driver, theme),
 		NewFind(theme),
+		NewFindIn(theme),
+		NewRegexFindIn(theme),
+		NewReplaceIn(driver, theme),
+		NewApplyReplace(cmdr, theme),
 	}
 }
@@ -0,0 +1,163 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package project
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/gxui/math"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/plugin/status"
+	"github.com/nelsam/vidar/search"
+	"github.com/nelsam/vidar/setting"
+)
+
+// A Projecter is any element that knows which project is current.
+type Projecter interface {
+	Project() setting.Project
+}
+
+// A ResultsPane displays the results of a project-wide search.
+// Start returns a token that identifies the search in the calls to
+// Add and Finish that follow it.
+type ResultsPane interface {
+	Start(root, pattern string) (run int64)
+	Add(run int64, r search.Result)
+	Finish(run int64, err error)
+}
+
+// FindIn is a command that searches every file in the current
+// project, streaming the matches to a ResultsPane.
+type FindIn struct {
+	status.General
+
+	regex   bool
+	pattern gxui.TextBox
+	input   <-chan gxui.Focusable
+
+	// cancel stops the search that is currently running, if any.
+	cancel func()
+
+	proj  Projecter
+	panes []ResultsPane
+}
+
+// NewFindIn returns a *FindIn that searches for literal text.
+func NewFindIn(theme gxui.Theme) *FindIn {
+	return newFindIn(theme, false)
+}
+
+// NewRegexFindIn returns a *FindIn that searches for a regular
+// expression.
+func NewRegexFindIn(theme gxui.Theme) *FindIn {
+	return newFindIn(theme, true)
+}
+
+func newFindIn(theme gxui.Theme, regex bool) *FindIn {
+	f := &FindIn{regex: regex}
+	f.Theme = theme
+	f.pattern = theme.CreateTextBox()
+	f.pattern.SetDesiredWidth(math.MaxSize.W)
+	return f
+}
+
+func (f *FindIn) Name() string {
+	if f.regex {
+		return "regex-find-in-project"
+	}
+	return "find-in-project"
+}
+
+func (f *FindIn) Menu() string {
+	return "Edit"
+}
+
+func (f *FindIn) Defaults() []fmt.Stringer {
+	if f.regex {
+		return nil
+	}
+	return []fmt.Stringer{gxui.KeyboardEvent{
+		Modifier: gxui.ModControl | gxui.ModShift | gxui.ModAlt,
+		Key:      gxui.KeyF,
+	}}
+}
+
+func (f *FindIn) Start(gxui.Control) gxui.Control {
+	input := make(chan gxui.Focusable, 1)
+	input <- f.pattern
+	f.input = input
+	close(input)
+	return nil
+}
+
+func (f *FindIn) Next() gxui.Focusable {
+	return <-f.input
+}
+
+func (f *FindIn) Reset() {
+	f.proj = nil
+	f.panes = nil
+}
+
+func (f *FindIn) Store(elem interface{}) bind.Status {
+	switch src := elem.(type) {
+	case Projecter:
+		f.proj = src
+	case ResultsPane:
+		f.panes = append(f.panes, src)
+	}
+	if f.proj == nil || len(f.panes) == 0 {
+		return bind.Waiting
+	}
+	return bind.Executing
+}
+
+func (f *FindIn) Exec() error {
+	text := f.pattern.Text()
+	if text == "" {
+		f.Warn = "find-in-project: no pattern provided"
+		return nil
+	}
+	expr := regexp.QuoteMeta(text)
+	if f.regex {
+		expr = text
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		f.Err = fmt.Sprintf("find-in-project: %s", err)
+		return err
+	}
+	root := f.proj.Project().Path
+	if f.cancel != nil {
+		f.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	f.cancel = cancel
+
+	panes := f.panes
+	runs := make([]int64, len(panes))
+	for i, p := range panes {
+		runs[i] = p.Start(root, text)
+	}
+	finder := search.Finder{Excludes: setting.SearchExcludes()}
+	results := finder.Find(ctx, root, re)
+	go func() {
+		for r := range results {
+			for i, p := range panes {
+				p.Add(runs[i], r)
+			}
+		}
+		err := ctx.Err()
+		cancel()
+		for i, p := range panes {
+			p.Finish(runs[i], err)
+		}
+	}()
+	f.Info = fmt.Sprintf("find-in-project: searching %s", root)
+	return nil
+}
@@ -0,0 +1,363 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package project
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/gxui/math"
+	"github.com/nelsam/vidar/atomicfile"
+	"github.com/nelsam/vidar/command/focus"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/plugin/command"
+	"github.com/nelsam/vidar/plugin/status"
+	"github.com/nelsam/vidar/search"
+	"github.com/nelsam/vidar/setting"
+)
+
+// A ReplacePane previews the replacements that a project-wide
+// replace will make, so that they can be excluded before they are
+// applied.
+type ReplacePane interface {
+	// StartReplace returns a token that identifies the search in
+	// the calls to AddReplacements and FinishReplace that follow it.
+	StartReplace(root, pattern, template string) (run int64)
+	AddReplacements(run int64, path string, reps []search.Replacement)
+	FinishReplace(run int64, err error)
+}
+
+// A PendingReplacer is any element that is holding replacements
+// which are waiting to be applied.
+type PendingReplacer interface {
+	Included() map[string][]search.Replacement
+	ClearReplace()
+}
+
+// An Applier applies edits to an editor.
+type Applier interface {
+	Apply(text.Editor, ...text.Edit)
+}
+
+// A Locationer is a command that can focus a file.
+type Locationer interface {
+	For(...focus.Opt) bind.Bindable
+}
+
+// An EditorFinder is any element that can find the open editor for
+// a file.  Editors are nested, and only the outermost one can find
+// files in every project and split, so commands keep the first
+// EditorFinder that they are given.
+type EditorFinder interface {
+	EditorFor(path string) text.Editor
+}
+
+// ReplaceIn is a command that finds every match of a regular
+// expression in the current project and sends the replacements that
+// would be made to a ReplacePane.  Nothing is changed until the
+// replacements are applied with ApplyReplace.
+type ReplaceIn struct {
+	status.General
+
+	driver            gxui.Driver
+	pattern, template gxui.TextBox
+	input             <-chan gxui.Focusable
+
+	// cancel stops the search that is currently running, if any.
+	cancel func()
+
+	proj   Projecter
+	finder EditorFinder
+	panes  []ReplacePane
+}
+
+// NewReplaceIn returns a new *ReplaceIn.
+func NewReplaceIn(driver gxui.Driver, theme gxui.Theme) *ReplaceIn {
+	r := &ReplaceIn{driver: driver}
+	r.Theme = theme
+	r.pattern = theme.CreateTextBox()
+	r.pattern.SetDesiredWidth(math.MaxSize.W)
+	r.template = theme.CreateTextBox()
+	r.template.SetDesiredWidth(math.MaxSize.W)
+	return r
+}
+
+func (r *ReplaceIn) Name() string {
+	return "replace-in-project"
+}
+
+func (r *ReplaceIn) Menu() string {
+	return "Edit"
+}
+
+func (r *ReplaceIn) Defaults() []fmt.Stringer {
+	return []fmt.Stringer{gxui.KeyboardEvent{
+		Modifier: gxui.ModControl | gxui.ModShift | gxui.ModAlt,
+		Key:      gxui.KeyR,
+	}}
+}
+
+func (r *ReplaceIn) Start(gxui.Control) gxui.Control {
+	input := make(chan gxui.Focusable, 2)
+	input <- r.pattern
+	input <- r.template
+	r.input = input
+	close(input)
+	return nil
+}
+
+func (r *ReplaceIn) Next() gxui.Focusable {
+	return <-r.input
+}
+
+func (r *ReplaceIn) Reset() {
+	r.proj = nil
+	r.finder = nil
+	r.panes = nil
+}
+
+func (r *ReplaceIn) Store(elem interface{}) bind.Status {
+	switch src := elem.(type) {
+	case Projecter:
+		r.proj = src
+	case ReplacePane:
+		r.panes = append(r.panes, src)
+	}
+	if e, ok := elem.(EditorFinder); ok && r.finder == nil {
+		r.finder = e
+	}
+	if r.proj == nil || r.finder == nil || len(r.panes) == 0 {
+		return bind.Waiting
+	}
+	return bind.Executing
+}
+
+func (r *ReplaceIn) Exec() error {
+	pattern, template := r.pattern.Text(), r.template.Text()
+	if pattern == "" {
+		r.Warn = "replace-in-project: no pattern provided"
+		return nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		r.Err = fmt.Sprintf("replace-in-project: %s", err)
+		return err
+	}
+	root := r.proj.Project().Path
+	if r.cancel != nil {
+		r.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	driver, editors, panes := r.driver, r.finder, r.panes
+	runs := make([]int64, len(panes))
+	for i, p := range panes {
+		runs[i] = p.StartReplace(root, pattern, template)
+	}
+	finder := search.Finder{Excludes: setting.SearchExcludes()}
+	results := finder.Find(ctx, root, re)
+	go func() {
+		for res := range results {
+			src, err := contents(driver, editors, res.Path)
+			if err != nil {
+				continue
+			}
+			reps := search.Replacements(src, re, template)
+			for i, p := range panes {
+				p.AddReplacements(runs[i], res.Path, reps)
+			}
+		}
+		err := ctx.Err()
+		cancel()
+		for i, p := range panes {
+			p.FinishReplace(runs[i], err)
+		}
+	}()
+	r.Info = fmt.Sprintf("replace-in-project: searching %s", root)
+	return nil
+}
+
+// contents returns the text of the file at path.  If the file is
+// open, the text in its editor is used, since ApplyReplace checks
+// replacements against that text before applying them.
+func contents(driver gxui.Driver, editors EditorFinder, path string) (string, error) {
+	var (
+		src  string
+		open bool
+	)
+	driver.CallSync(func() {
+		if e := editors.EditorFor(path); e != nil {
+			src, open = string(e.Runes()), true
+		}
+	})
+	if open {
+		return src, nil
+	}
+	b, err := ioutil.ReadFile(path)
+	return string(b), err
+}
+
+// ApplyReplace is a command that applies the replacements that are
+// pending in a PendingReplacer.  Files that are open are focused and
+// edited through their own input handler, so that their hooks see
+// the edits and the replacements in each file can be undone as a
+// single step; all other files are written to disk.
+type ApplyReplace struct {
+	status.General
+
+	cmdr command.Commander
+
+	pending PendingReplacer
+	current text.Editor
+	editors EditorFinder
+}
+
+// NewApplyReplace returns a new *ApplyReplace.
+func NewApplyReplace(cmdr command.Commander, theme gxui.Theme) *ApplyReplace {
+	a := &ApplyReplace{cmdr: cmdr}
+	a.Theme = theme
+	return a
+}
+
+func (a *ApplyReplace) Name() string {
+	return "apply-project-replace"
+}
+
+func (a *ApplyReplace) Menu() string {
+	return "Edit"
+}
+
+func (a *ApplyReplace) Defaults() []fmt.Stringer {
+	return nil
+}
+
+func (a *ApplyReplace) Reset() {
+	a.pending = nil
+	a.current = nil
+	a.editors = nil
+}
+
+func (a *ApplyReplace) Store(elem interface{}) bind.Status {
+	switch src := elem.(type) {
+	case PendingReplacer:
+		a.pending = src
+	case text.Editor:
+		a.current = src
+	}
+	if e, ok := elem.(EditorFinder); ok && a.editors == nil {
+		a.editors = e
+	}
+	if a.pending == nil || a.editors == nil {
+		return bind.Waiting
+	}
+	if a.current == nil {
+		// There may not be a focused editor, but if there is, we
+		// need it to restore focus after editing other files.
+		return bind.Executing
+	}
+	return bind.Done
+}
+
+func (a *ApplyReplace) Exec() error {
+	opener, ok := a.cmdr.Bindable("focus-location").(Locationer)
+	if !ok {
+		a.Err = "apply-project-replace: no focus-location command found"
+		return errors.New(a.Err)
+	}
+	focused := ""
+	if a.current != nil {
+		focused = a.current.Filepath()
+	}
+	refocus := false
+	var files, count, skipped int
+	var errs []string
+	for path, reps := range a.pending.Included() {
+		var n, s int
+		if ed := a.editors.EditorFor(path); ed != nil {
+			if path != focused {
+				a.cmdr.Execute(opener.For(focus.Path(path)))
+				refocus = true
+			}
+			n, s = a.applyOpen(ed, reps)
+		} else {
+			var err error
+			n, s, err = applyClosed(path, reps)
+			if err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+		}
+		skipped += s
+		if n == 0 {
+			continue
+		}
+		files++
+		count += n
+	}
+	if refocus && focused != "" {
+		a.cmdr.Execute(opener.For(focus.Path(focused)))
+	}
+	a.pending.ClearReplace()
+	a.Info = fmt.Sprintf("apply-project-replace: made %d replacements in %d files", count, files)
+	if skipped > 0 {
+		a.Warn = fmt.Sprintf("apply-project-replace: skipped %d replacements that no longer matched", skipped)
+	}
+	if len(errs) > 0 {
+		a.Err = fmt.Sprintf("apply-project-replace: %d files could not be written: %v", len(errs), errs)
+	}
+	return nil
+}
+
+// applyOpen applies reps to ed in a single call to the input handler
+// that is bound to ed, so that they are undone together.  ed must be
+// the focused editor.
+func (a *ApplyReplace) applyOpen(ed text.Editor, reps []search.Replacement) (applied, skipped int) {
+	applier, ok := a.cmdr.Bindable("input-handler").(Applier)
+	if !ok {
+		return 0, len(reps)
+	}
+	runes := ed.Runes()
+	var edits []text.Edit
+	for _, r := range reps {
+		old := []rune(r.Old)
+		end := r.Offset + len(old)
+		if end > len(runes) || string(runes[r.Offset:end]) != r.Old {
+			skipped++
+			continue
+		}
+		edits = append(edits, text.Edit{
+			At:  r.Offset,
+			Old: old,
+			New: []rune(r.New),
+		})
+	}
+	if len(edits) > 0 {
+		applier.Apply(ed, edits...)
+	}
+	return len(edits), skipped
+}
+
+// applyClosed applies reps to the file at path, which is not open
+// in an editor.
+func applyClosed(path string, reps []search.Replacement) (applied, skipped int, err error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	result, skipped := search.Replace(string(b), reps)
+	if skipped == len(reps) {
+		return 0, skipped, nil
+	}
+	if err := atomicfile.WriteFile(path, []byte(result), 0644); err != nil {
+		return 0, 0, err
+	}
+	return len(reps) - skipped, skipped, nil
+}
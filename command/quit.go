@@ -12,7 +12,18 @@ import (
 	"github.com/nelsam/vidar/commander/bind"
 )
 
+// A BeforeQuitter is a hook that needs to clean up before vidar
+// exits.
+type BeforeQuitter interface {
+	BeforeQuit()
+}
+
 type Quit struct {
+	// Commander, if set, is used to save the session in use before
+	// quitting.
+	Commander BindManager
+
+	hooks []BeforeQuitter
 }
 
 func (q Quit) Name() string {
@@ -30,8 +41,34 @@ func (q Quit) Defaults() []fmt.Stringer {
 	}}
 }
 
+func (q Quit) Bind(h bind.Bindable) (bind.HookedOp, error) {
+	b, ok := h.(BeforeQuitter)
+	if !ok {
+		return nil, fmt.Errorf("expected BeforeQuitter; got %T", h)
+	}
+	newQ := Quit{Commander: q.Commander}
+	newQ.hooks = append(newQ.hooks, q.hooks...)
+	newQ.hooks = append(newQ.hooks, b)
+	return newQ, nil
+}
+
 func (q Quit) Exec(interface{}) bind.Status {
 	// TODO: ask for confirmation if there are changes
+	q.Prepare()
 	os.Exit(0)
 	return bind.Done
 }
+
+// Prepare saves the session in use and runs q's hooks, without
+// exiting.  It is for when vidar is exiting some other way, e.g.
+// because its window was closed.
+func (q Quit) Prepare() {
+	if q.Commander != nil {
+		if saver, ok := q.Commander.Bindable("save-session").(SessionSaver); ok {
+			q.Commander.Execute(saver.Autosave())
+		}
+	}
+	for _, h := range q.hooks {
+		h.BeforeQuit()
+	}
+}
@@ -0,0 +1,27 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package recovery
+
+import (
+	"path/filepath"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/plugin/command"
+	"github.com/nelsam/vidar/setting"
+	"github.com/nelsam/vidar/swap"
+)
+
+const storeDirname = "swap"
+
+// Bindables returns the hook that writes swap files for unsaved
+// changes and the command that recovers them after a crash.
+func Bindables(cmdr command.Commander, driver gxui.Driver, theme gxui.Theme) []bind.Bindable {
+	store := swap.NewStore(filepath.Join(setting.App.DataHome(), storeDirname))
+	return []bind.Bindable{
+		NewSwapper(driver, store),
+		NewRecover(driver, theme, cmdr, store),
+	}
+}
@@ -0,0 +1,229 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package recovery
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/command/focus"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/diff"
+	"github.com/nelsam/vidar/plugin/command"
+	"github.com/nelsam/vidar/plugin/status"
+	"github.com/nelsam/vidar/swap"
+)
+
+// An Opener is any element that can open diff views and find the
+// open editors for files.
+type Opener interface {
+	OpenDiff(path string, left, right diff.Source) error
+	EditorFor(path string) text.Editor
+}
+
+// An Applier is any element that can apply edits to an editor.
+type Applier interface {
+	Apply(text.Editor, ...text.Edit)
+}
+
+// A Locationer is a command that can focus a file.
+type Locationer interface {
+	For(...focus.Opt) bind.Bindable
+}
+
+// Recover is a command that offers to recover the unsaved text in
+// swap files left behind by vidar processes that crashed.  For each
+// swap file, it asks whether to restore the text to its file's
+// editor, to open a diff view comparing the text with the file on
+// disk, or to discard it.
+type Recover struct {
+	status.General
+
+	driver gxui.Driver
+	cmdr   command.Commander
+	store  *swap.Store
+
+	prompt  gxui.Label
+	orphans []swap.Swap
+	choices []gxui.TextBox
+	next    int
+
+	opener Opener
+}
+
+// NewRecover returns a *Recover that recovers orphaned swap files
+// from store.
+func NewRecover(driver gxui.Driver, theme gxui.Theme, cmdr command.Commander, store *swap.Store) *Recover {
+	r := &Recover{
+		driver: driver,
+		cmdr:   cmdr,
+		store:  store,
+		prompt: theme.CreateLabel(),
+	}
+	r.Theme = theme
+	return r
+}
+
+func (r *Recover) Name() string {
+	return "recover-files"
+}
+
+func (r *Recover) Menu() string {
+	return "File"
+}
+
+func (r *Recover) Defaults() []fmt.Stringer {
+	return nil
+}
+
+// Pending reports whether there are any swap files to recover.
+func (r *Recover) Pending() bool {
+	orphans, err := r.store.Orphans()
+	if err != nil {
+		log.Printf("Could not load swap files: %s", err)
+		return false
+	}
+	return len(orphans) > 0
+}
+
+func (r *Recover) Start(gxui.Control) gxui.Control {
+	orphans, err := r.store.Orphans()
+	if err != nil {
+		log.Printf("Could not load swap files: %s", err)
+	}
+	r.orphans = orphans
+	r.choices = r.choices[:0]
+	r.next = 0
+	r.prompt.SetText("There are no unsaved changes to recover")
+	return r.prompt
+}
+
+func (r *Recover) Next() gxui.Focusable {
+	if r.next >= len(r.orphans) {
+		return nil
+	}
+	sw := r.orphans[r.next]
+	r.next++
+	r.prompt.SetText(fmt.Sprintf("(%d/%d) %s has unsaved changes from %s; restore, diff, or discard?",
+		r.next, len(r.orphans), filepath.Base(sw.Path), sw.Saved.Format("Jan 2 15:04")))
+	choice := r.Theme.CreateTextBox()
+	choice.SetText("restore")
+	r.choices = append(r.choices, choice)
+	return choice
+}
+
+func (r *Recover) Reset() {
+	r.opener = nil
+}
+
+func (r *Recover) Store(elem interface{}) bind.Status {
+	if o, ok := elem.(Opener); ok {
+		r.opener = o
+	}
+	if r.opener == nil {
+		return bind.Waiting
+	}
+	return bind.Done
+}
+
+func (r *Recover) Exec() error {
+	if len(r.orphans) == 0 {
+		r.Info = "recover-files: there are no unsaved changes to recover"
+		return nil
+	}
+	var restored, diffed, discarded int
+	var errs []string
+	for i, choice := range r.choices {
+		sw := r.orphans[i]
+		var err error
+		switch c := strings.ToLower(strings.TrimSpace(choice.Text())); {
+		case c == "":
+			err = fmt.Errorf("no choice for %s", filepath.Base(sw.Path))
+		case strings.HasPrefix("restore", c):
+			if err = r.restore(sw); err == nil {
+				restored++
+			}
+		case strings.HasPrefix("diff", c):
+			if err = r.diff(sw); err == nil {
+				diffed++
+			}
+		case strings.HasPrefix("discard", c):
+			if err = sw.Discard(); err == nil {
+				discarded++
+			}
+		default:
+			err = fmt.Errorf("%q is not one of restore, diff, or discard", c)
+		}
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	r.Info = fmt.Sprintf("recover-files: restored %d, diffed %d, and discarded %d files", restored, diffed, discarded)
+	if len(errs) > 0 {
+		r.Err = fmt.Sprintf("recover-files: %s", strings.Join(errs, "; "))
+		return errors.New(r.Err)
+	}
+	return nil
+}
+
+// restore opens the file that sw belongs to and replaces its text
+// with sw's contents, then discards sw.
+func (r *Recover) restore(sw swap.Swap) error {
+	opener, ok := r.cmdr.Bindable("focus-location").(Locationer)
+	if !ok {
+		return errors.New("no focus-location command found")
+	}
+	r.cmdr.Execute(opener.For(focus.Path(sw.Path)))
+	e := r.opener.EditorFor(sw.Path)
+	if e == nil {
+		return fmt.Errorf("could not open %s", sw.Path)
+	}
+	// Now that the file is focused, the input handler is the one
+	// that is bound to it.
+	applier, ok := r.cmdr.Bindable("input-handler").(Applier)
+	if !ok {
+		return errors.New("no input handler found")
+	}
+	// The editor loads its file's text with a driver.Call, so the
+	// edit has to be queued up behind it.
+	r.driver.Call(func() {
+		current := e.Text()
+		if current == sw.Contents {
+			return
+		}
+		applier.Apply(e, text.Edit{
+			At:  0,
+			Old: []rune(current),
+			New: []rune(sw.Contents),
+		})
+	})
+	return sw.Discard()
+}
+
+// diff opens a read only diff view comparing the file that sw
+// belongs to with sw's contents.  The swap file is kept, so that it
+// can still be restored later.
+func (r *Recover) diff(sw swap.Swap) error {
+	disk := diff.Source{
+		Name: filepath.Base(sw.Path),
+		Load: func() (string, error) {
+			b, err := ioutil.ReadFile(sw.Path)
+			return string(b), err
+		},
+	}
+	recovered := diff.Source{
+		Name: fmt.Sprintf("%s (recovered)", filepath.Base(sw.Path)),
+		Load: func() (string, error) {
+			return sw.Contents, nil
+		},
+	}
+	return r.opener.OpenDiff(fmt.Sprintf("%s ⇄ recovered", sw.Path), disk, recovered)
+}
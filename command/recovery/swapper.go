@@ -0,0 +1,173 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package recovery
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/setting"
+	"github.com/nelsam/vidar/swap"
+)
+
+// DefaultDelay is the default amount of time that a Swapper waits
+// after the last change to a file before writing its swap file.
+const DefaultDelay = 2 * time.Second
+
+// A Changer is an editor that knows whether its text differs from
+// the text that was last loaded or saved.
+type Changer interface {
+	text.Editor
+	HasChanges() bool
+}
+
+// Swapper is a hook that writes the text of editors with unsaved
+// changes to swap files, so that the changes can be recovered if
+// vidar crashes.  It removes an editor's swap file once the editor
+// no longer has unsaved changes or is closed.
+type Swapper struct {
+	driver gxui.Driver
+	store  *swap.Store
+
+	// Delay is how long s waits after the last change to a file
+	// before writing its swap file.
+	Delay time.Duration
+
+	mu      sync.Mutex
+	editors map[string]Changer
+	timers  map[string]*time.Timer
+}
+
+// NewSwapper returns a *Swapper that writes swap files to store.
+func NewSwapper(driver gxui.Driver, store *swap.Store) *Swapper {
+	return &Swapper{
+		driver:  driver,
+		store:   store,
+		Delay:   DefaultDelay,
+		editors: make(map[string]Changer),
+		timers:  make(map[string]*time.Timer),
+	}
+}
+
+func (s *Swapper) Name() string {
+	return "swap-writer"
+}
+
+func (s *Swapper) OpNames() []string {
+	return []string{"input-handler", "save-current-file", "close-current-tab"}
+}
+
+// Init implements input.ChangeHook.
+func (s *Swapper) Init(e text.Editor, _ []rune) {
+	s.track(e)
+}
+
+// TextChanged implements input.ChangeHook.  Swap files are only
+// written after a break in changes, so it does nothing.
+func (s *Swapper) TextChanged(text.Editor, text.Edit) {}
+
+// Apply implements input.ChangeHook.  It (re)starts the timer which
+// writes the swap file for e.
+func (s *Swapper) Apply(e text.Editor) error {
+	c, ok := s.track(e)
+	if !ok {
+		return nil
+	}
+	path := c.Filepath()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.timers[path]; ok {
+		t.Stop()
+	}
+	s.timers[path] = time.AfterFunc(s.Delay, func() {
+		s.driver.Call(func() {
+			s.mu.Lock()
+			delete(s.timers, path)
+			tracked := s.editors[path] == c
+			s.mu.Unlock()
+			if tracked {
+				s.write(c)
+			}
+		})
+	})
+	return nil
+}
+
+// AfterSave implements command.AfterSaver.  It removes the swap file
+// for the saved file, since its changes are now on disk.
+func (s *Swapper) AfterSave(_ setting.Project, path, _ string) error {
+	s.mu.Lock()
+	if t, ok := s.timers[path]; ok {
+		t.Stop()
+		delete(s.timers, path)
+	}
+	s.mu.Unlock()
+	return s.store.Remove(path)
+}
+
+// FileClosed implements command.FileCloser.  It stops tracking the
+// editor for path and removes its swap file, since any unsaved
+// changes in it were thrown away.
+func (s *Swapper) FileClosed(path string) {
+	s.mu.Lock()
+	if t, ok := s.timers[path]; ok {
+		t.Stop()
+		delete(s.timers, path)
+	}
+	delete(s.editors, path)
+	s.mu.Unlock()
+	if err := s.store.Remove(path); err != nil {
+		log.Printf("Could not remove swap file for %s: %s", path, err)
+	}
+}
+
+// Flush immediately writes swap files for every editor with unsaved
+// changes.  It must be called on the UI goroutine.
+func (s *Swapper) Flush() {
+	s.mu.Lock()
+	for path, t := range s.timers {
+		t.Stop()
+		delete(s.timers, path)
+	}
+	editors := make([]Changer, 0, len(s.editors))
+	for _, c := range s.editors {
+		editors = append(editors, c)
+	}
+	s.mu.Unlock()
+	for _, c := range editors {
+		s.write(c)
+	}
+}
+
+// track starts keeping track of e, if it is a Changer with a file
+// path.
+func (s *Swapper) track(e text.Editor) (Changer, bool) {
+	c, ok := e.(Changer)
+	if !ok || c.Filepath() == "" {
+		return nil, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.editors[c.Filepath()] = c
+	return c, true
+}
+
+// write writes the swap file for c if it has unsaved changes, and
+// removes it otherwise.  It must be called on the UI goroutine.
+func (s *Swapper) write(c Changer) {
+	path := c.Filepath()
+	if !c.HasChanges() {
+		if err := s.store.Remove(path); err != nil {
+			log.Printf("Could not remove swap file for %s: %s", path, err)
+		}
+		return
+	}
+	if err := s.store.Write(path, c.Text()); err != nil {
+		log.Printf("Could not write swap file for %s: %s", path, err)
+	}
+}
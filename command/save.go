@@ -123,8 +123,7 @@ func (s *SaveCurrent) Exec() error {
 			return err
 		}
 		if finfo.ModTime().After(s.editor.LastKnownMTime()) {
-			// TODO: prompt for override
-			s.Err = fmt.Sprintf("File %s changed on disk.  Cowardly refusing to overwrite.", filepath)
+			s.Err = fmt.Sprintf("File %s changed on disk.  Cowardly refusing to overwrite; use resolve-conflict first.", filepath)
 			return err
 		}
 	}
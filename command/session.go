@@ -0,0 +1,354 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package command
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/command/focus"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/plugin/command"
+	"github.com/nelsam/vidar/plugin/status"
+	"github.com/nelsam/vidar/session"
+	"github.com/nelsam/vidar/setting"
+)
+
+const sessionDirname = "sessions"
+
+// A Sessioner is an element that can save and restore the layout of
+// its editors.
+type Sessioner interface {
+	Session() session.Session
+	RestoreSession(session.Session) error
+}
+
+// A ProjectSetter is any element that needs to be informed about the
+// project being set.
+type ProjectSetter interface {
+	SetProject(setting.Project)
+}
+
+// sessions keeps track of the session that is in use, so that it
+// can be saved without asking for its name.
+type sessions struct {
+	store *session.Store
+
+	mu      sync.Mutex
+	current string
+}
+
+func (s *sessions) name() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current == "" {
+		return s.store.Latest()
+	}
+	return s.current
+}
+
+func (s *sessions) setName(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = name
+}
+
+// SessionBindables returns the commands that save and open sessions
+// and the hook that saves the session in use whenever the focused
+// file changes.
+func SessionBindables(cmdr command.Commander, driver gxui.Driver, theme gxui.Theme) []bind.Bindable {
+	s := &sessions{store: session.NewStore(filepath.Join(setting.App.DataHome(), sessionDirname))}
+	return []bind.Bindable{
+		newSaveSession(theme, s),
+		newOpenSession(theme, s),
+		SessionHook{Commander: cmdr, Driver: driver},
+	}
+}
+
+// A SessionSaver is a command that can save the session in use
+// without asking for its name.
+type SessionSaver interface {
+	bind.Bindable
+	Autosave() bind.Bindable
+}
+
+// SaveSession is a command that saves the layout of the open
+// projects, splits, and tabs as a named session.
+type SaveSession struct {
+	status.General
+
+	sessions *sessions
+	auto     bool
+
+	name  gxui.TextBox
+	input gxui.Focusable
+
+	sessioner Sessioner
+}
+
+// newSaveSession returns a *SaveSession that saves to s.
+func newSaveSession(theme gxui.Theme, s *sessions) *SaveSession {
+	save := &SaveSession{
+		sessions: s,
+		name:     theme.CreateTextBox(),
+	}
+	save.Theme = theme
+	return save
+}
+
+// Autosave returns a copy of s that saves the session in use without
+// asking for a name.
+func (s *SaveSession) Autosave() bind.Bindable {
+	return &SaveSession{
+		General:  status.General{Theme: s.Theme},
+		sessions: s.sessions,
+		auto:     true,
+	}
+}
+
+func (s *SaveSession) Name() string {
+	return "save-session"
+}
+
+func (s *SaveSession) Menu() string {
+	return "File"
+}
+
+func (s *SaveSession) Defaults() []fmt.Stringer {
+	return nil
+}
+
+func (s *SaveSession) Start(gxui.Control) gxui.Control {
+	s.name.SetText(s.sessions.name())
+	s.input = s.name
+	return nil
+}
+
+func (s *SaveSession) Next() gxui.Focusable {
+	input := s.input
+	s.input = nil
+	return input
+}
+
+func (s *SaveSession) Reset() {
+	s.sessioner = nil
+}
+
+func (s *SaveSession) Store(elem interface{}) bind.Status {
+	if sessioner, ok := elem.(Sessioner); ok {
+		s.sessioner = sessioner
+		return bind.Done
+	}
+	return bind.Waiting
+}
+
+func (s *SaveSession) Exec() error {
+	name := s.sessions.name()
+	if !s.auto {
+		name = strings.TrimSpace(s.name.Text())
+	}
+	sess := s.sessioner.Session()
+	sess.Name = name
+	if err := s.sessions.store.Save(sess); err != nil {
+		s.Err = fmt.Sprintf("save-session: %s", err)
+		return err
+	}
+	s.sessions.setName(name)
+	if !s.auto {
+		s.Info = fmt.Sprintf("Saved session %s", name)
+	}
+	return nil
+}
+
+// OpenSession is a command that replaces the open projects, splits,
+// and tabs with those in a saved session.
+type OpenSession struct {
+	status.General
+
+	sessions *sessions
+	fixed    string
+
+	prompt gxui.Label
+	name   gxui.TextBox
+	input  gxui.Focusable
+
+	sessioner Sessioner
+	setters   []ProjectSetter
+	focuser   Focuser
+	binder    Binder
+	hadFile   bool
+}
+
+// A Binder is a type which can bind bindables.
+type Binder interface {
+	Pop() []bind.Bindable
+	Execute(bind.Bindable)
+}
+
+// newOpenSession returns an *OpenSession that opens sessions saved
+// in s.
+func newOpenSession(theme gxui.Theme, s *sessions) *OpenSession {
+	o := &OpenSession{
+		sessions: s,
+		prompt:   theme.CreateLabel(),
+		name:     theme.CreateTextBox(),
+	}
+	o.Theme = theme
+	return o
+}
+
+// For returns a copy of o that opens the session called name without
+// asking for a name.
+func (o *OpenSession) For(name string) bind.Bindable {
+	return &OpenSession{
+		General:  status.General{Theme: o.Theme},
+		sessions: o.sessions,
+		fixed:    name,
+	}
+}
+
+// Latest returns a copy of o that opens the most recently saved
+// session, and whether any session has been saved.
+func (o *OpenSession) Latest() (bind.Bindable, bool) {
+	names, err := o.sessions.store.Names()
+	if err != nil {
+		log.Printf("Could not list sessions: %s", err)
+		return nil, false
+	}
+	if len(names) == 0 {
+		return nil, false
+	}
+	return o.For(names[0]), true
+}
+
+func (o *OpenSession) Name() string {
+	return "open-session"
+}
+
+func (o *OpenSession) Menu() string {
+	return "File"
+}
+
+func (o *OpenSession) Defaults() []fmt.Stringer {
+	return nil
+}
+
+func (o *OpenSession) Start(gxui.Control) gxui.Control {
+	names, err := o.sessions.store.Names()
+	if err != nil {
+		log.Printf("Could not list sessions: %s", err)
+	}
+	o.name.SetText(o.sessions.name())
+	o.input = o.name
+	if len(names) == 0 {
+		o.prompt.SetText("no saved sessions")
+		return o.prompt
+	}
+	o.prompt.SetText(fmt.Sprintf("sessions: %s", strings.Join(names, ", ")))
+	return o.prompt
+}
+
+func (o *OpenSession) Next() gxui.Focusable {
+	input := o.input
+	o.input = nil
+	return input
+}
+
+func (o *OpenSession) Reset() {
+	o.sessioner = nil
+	o.setters = nil
+	o.focuser = nil
+	o.binder = nil
+	o.hadFile = false
+}
+
+func (o *OpenSession) Store(elem interface{}) bind.Status {
+	switch src := elem.(type) {
+	case text.Editor:
+		o.hadFile = true
+	case Binder:
+		o.binder = src
+	case Focuser:
+		o.focuser = src
+	}
+	// The MultiProjectEditor is both a Sessioner and a
+	// ProjectSetter, so these are checked separately.
+	if src, ok := elem.(Sessioner); ok {
+		o.sessioner = src
+	}
+	if src, ok := elem.(ProjectSetter); ok {
+		o.setters = append(o.setters, src)
+	}
+	if o.sessioner == nil || o.binder == nil || o.focuser == nil || len(o.setters) == 0 {
+		return bind.Waiting
+	}
+	return bind.Executing
+}
+
+func (o *OpenSession) Exec() error {
+	name := o.fixed
+	if name == "" {
+		name = strings.TrimSpace(o.name.Text())
+	}
+	sess, err := o.sessions.store.Load(name)
+	if err != nil {
+		o.Err = fmt.Sprintf("open-session: %s", err)
+		return err
+	}
+	if err := o.sessioner.RestoreSession(sess); err != nil {
+		o.Err = fmt.Sprintf("open-session: %s", err)
+		return err
+	}
+	o.sessions.setName(name)
+	proj := setting.DefaultProject
+	for _, p := range setting.Projects() {
+		if p.Name == sess.Project {
+			proj = p
+			break
+		}
+	}
+	if o.hadFile {
+		o.binder.Pop()
+	}
+	for _, setter := range o.setters {
+		setter.SetProject(proj)
+	}
+	o.binder.Execute(o.focuser.For(focus.SkipUnbind()))
+	o.Info = fmt.Sprintf("Opened session %s", name)
+	return nil
+}
+
+// SessionHook is a hook that saves the session in use whenever the
+// focused file changes.
+type SessionHook struct {
+	Commander command.Commander
+	Driver    gxui.Driver
+}
+
+func (h SessionHook) Name() string {
+	return "session-autosave"
+}
+
+func (h SessionHook) OpName() string {
+	return "focus-location"
+}
+
+// FileChanged implements focus.FileChanger.
+func (h SessionHook) FileChanged(oldPath, newPath string) {
+	// The file is still being focused, so the session is saved
+	// once that's done.
+	h.Driver.Call(func() {
+		saver, ok := h.Commander.Bindable("save-session").(SessionSaver)
+		if !ok {
+			return
+		}
+		h.Commander.Execute(saver.Autosave())
+	})
+}
@@ -0,0 +1,196 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package symbol
+
+import (
+	"context"
+	"fmt"
+	"go/types"
+	"strings"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/goref"
+	"github.com/nelsam/vidar/plugin/status"
+)
+
+// A ReferencePane displays the results of a lookup.  StartLookup
+// returns a token that identifies the lookup in the report of its
+// results.
+type ReferencePane interface {
+	StartLookup(root, title string) (run int64)
+	ReportReferences(run int64, refs []goref.Ref, err error)
+	ReportCalls(run int64, calls []goref.Call, err error)
+}
+
+// Lookup is a type of lookup that a Find command performs.
+type Lookup int
+
+const (
+	// References finds every reference to the identifier under the
+	// caret.
+	References Lookup = iota
+
+	// Callers finds the functions that call the function under the
+	// caret.
+	Callers
+
+	// Callees finds the functions that the function under the caret
+	// calls.
+	Callees
+)
+
+// Find is a command that looks up the references to the go
+// identifier under the caret, or the callers or callees of the go
+// function under the caret, and sends the results to a
+// ReferencePane.
+type Find struct {
+	status.General
+
+	driver gxui.Driver
+	lookup Lookup
+
+	// cancel stops the lookup that is currently running, if any.
+	cancel func()
+
+	proj   Projecter
+	editor text.Editor
+	ctrl   CaretController
+	finder EditorFinder
+	panes  []ReferencePane
+}
+
+// NewFind returns a new *Find that performs lookup.
+func NewFind(driver gxui.Driver, theme gxui.Theme, lookup Lookup) *Find {
+	f := &Find{driver: driver, lookup: lookup}
+	f.Theme = theme
+	return f
+}
+
+func (f *Find) Name() string {
+	switch f.lookup {
+	case Callers:
+		return "find-callers"
+	case Callees:
+		return "find-callees"
+	default:
+		return "find-references"
+	}
+}
+
+func (f *Find) Menu() string {
+	return "Golang"
+}
+
+func (f *Find) Defaults() []fmt.Stringer {
+	switch f.lookup {
+	case Callers:
+		return []fmt.Stringer{gxui.KeyboardEvent{
+			Modifier: gxui.ModControl | gxui.ModShift,
+			Key:      gxui.KeyF12,
+		}}
+	case Callees:
+		return []fmt.Stringer{gxui.KeyboardEvent{
+			Modifier: gxui.ModControl | gxui.ModAlt,
+			Key:      gxui.KeyF12,
+		}}
+	default:
+		return []fmt.Stringer{gxui.KeyboardEvent{
+			Modifier: gxui.ModShift,
+			Key:      gxui.KeyF12,
+		}}
+	}
+}
+
+func (f *Find) Reset() {
+	f.proj = nil
+	f.editor = nil
+	f.ctrl = nil
+	f.finder = nil
+	f.panes = nil
+}
+
+func (f *Find) Store(elem interface{}) bind.Status {
+	switch src := elem.(type) {
+	case Projecter:
+		f.proj = src
+	case text.Editor:
+		f.editor = src
+	case CaretController:
+		f.ctrl = src
+	case ReferencePane:
+		f.panes = append(f.panes, src)
+	}
+	if e, ok := elem.(EditorFinder); ok && f.finder == nil {
+		f.finder = e
+	}
+	if f.proj == nil || f.editor == nil || f.ctrl == nil || f.finder == nil || len(f.panes) == 0 {
+		return bind.Waiting
+	}
+	return bind.Executing
+}
+
+func (f *Find) Exec() error {
+	path := f.editor.Filepath()
+	if !strings.HasSuffix(path, ".go") {
+		f.Warn = fmt.Sprintf("%s: the focused file is not a go file", f.Name())
+		return nil
+	}
+	if f.cancel != nil {
+		f.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	f.cancel = cancel
+
+	proj := f.proj.Project()
+	offset := f.ctrl.LastCaret()
+	ident := identAt(f.editor.Runes(), offset)
+	var title string
+	switch f.lookup {
+	case Callers:
+		title = fmt.Sprintf("callers of %s", ident)
+	case Callees:
+		title = fmt.Sprintf("calls made by %s", ident)
+	default:
+		title = fmt.Sprintf("references to %s", ident)
+	}
+	driver, finder, panes, lookup := f.driver, f.finder, f.panes, f.lookup
+	runs := make([]int64, len(panes))
+	for i, p := range panes {
+		runs[i] = p.StartLookup(proj.Path, title)
+	}
+	go func() {
+		defer cancel()
+		p, err := load(ctx, driver, finder, proj)
+		var obj types.Object
+		if err == nil {
+			obj, err = p.ObjectAt(path, offset)
+		}
+		if lookup == References {
+			var refs []goref.Ref
+			if err == nil {
+				refs = p.References(obj)
+			}
+			for i, pane := range panes {
+				pane.ReportReferences(runs[i], refs, err)
+			}
+			return
+		}
+		var calls []goref.Call
+		if err == nil {
+			if lookup == Callers {
+				calls, err = p.Callers(obj)
+			} else {
+				calls, err = p.Callees(obj)
+			}
+		}
+		for i, pane := range panes {
+			pane.ReportCalls(runs[i], calls, err)
+		}
+	}()
+	f.Info = fmt.Sprintf("%s: finding %s", f.Name(), title)
+	return nil
+}
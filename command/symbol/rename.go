@@ -0,0 +1,219 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package symbol
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/gxui/math"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/goref"
+	"github.com/nelsam/vidar/plugin/status"
+	"github.com/nelsam/vidar/search"
+	"github.com/nelsam/vidar/setting"
+)
+
+// A ReplacePane previews replacements before they are applied.
+type ReplacePane interface {
+	StartReplace(root, pattern, template string) (run int64)
+	AddReplacements(run int64, path string, reps []search.Replacement)
+	FinishReplace(run int64, err error)
+}
+
+// Rename is a command that renames the go identifier under the caret
+// everywhere that it is used in the project.  The renames are sent
+// to a ReplacePane to be previewed; nothing is changed until they are
+// applied with apply-project-replace, which edits open files as a
+// single undoable change each and atomically writes the rest.
+type Rename struct {
+	status.General
+
+	driver gxui.Driver
+	name   gxui.TextBox
+	input  gxui.Focusable
+
+	// cancel stops the rename that is currently being worked out,
+	// if any.
+	cancel func()
+
+	proj   Projecter
+	editor text.Editor
+	ctrl   CaretController
+	finder EditorFinder
+	panes  []ReplacePane
+}
+
+// NewRename returns a new *Rename.
+func NewRename(driver gxui.Driver, theme gxui.Theme) *Rename {
+	r := &Rename{driver: driver}
+	r.Theme = theme
+	r.name = theme.CreateTextBox()
+	r.name.SetDesiredWidth(math.MaxSize.W)
+	return r
+}
+
+func (r *Rename) Name() string {
+	return "rename-symbol"
+}
+
+func (r *Rename) Menu() string {
+	return "Golang"
+}
+
+func (r *Rename) Defaults() []fmt.Stringer {
+	return []fmt.Stringer{gxui.KeyboardEvent{
+		Key: gxui.KeyF2,
+	}}
+}
+
+func (r *Rename) Start(gxui.Control) gxui.Control {
+	r.name.SetText("")
+	r.input = r.name
+	return nil
+}
+
+func (r *Rename) Next() gxui.Focusable {
+	input := r.input
+	r.input = nil
+	return input
+}
+
+func (r *Rename) Reset() {
+	r.proj = nil
+	r.editor = nil
+	r.ctrl = nil
+	r.finder = nil
+	r.panes = nil
+}
+
+func (r *Rename) Store(elem interface{}) bind.Status {
+	switch src := elem.(type) {
+	case Projecter:
+		r.proj = src
+	case text.Editor:
+		r.editor = src
+	case CaretController:
+		r.ctrl = src
+	case ReplacePane:
+		r.panes = append(r.panes, src)
+	}
+	if f, ok := elem.(EditorFinder); ok && r.finder == nil {
+		r.finder = f
+	}
+	if r.proj == nil || r.editor == nil || r.ctrl == nil || r.finder == nil || len(r.panes) == 0 {
+		return bind.Waiting
+	}
+	return bind.Executing
+}
+
+func (r *Rename) Exec() error {
+	newName := strings.TrimSpace(r.name.Text())
+	if newName == "" {
+		r.Warn = "rename-symbol: no name provided"
+		return nil
+	}
+	path := r.editor.Filepath()
+	if !strings.HasSuffix(path, ".go") {
+		r.Warn = "rename-symbol: the focused file is not a go file"
+		return nil
+	}
+	if r.cancel != nil {
+		r.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	proj := r.proj.Project()
+	offset := r.ctrl.LastCaret()
+	driver, finder, panes := r.driver, r.finder, r.panes
+	runs := make([]int64, len(panes))
+	for i, p := range panes {
+		runs[i] = p.StartReplace(proj.Path, identAt(r.editor.Runes(), offset), newName)
+	}
+	go func() {
+		defer cancel()
+		refs, err := rename(ctx, driver, finder, proj, path, offset, newName)
+		for _, rep := range replacements(refs, newName) {
+			for i, p := range panes {
+				p.AddReplacements(runs[i], rep.path, rep.reps)
+			}
+		}
+		for i, p := range panes {
+			p.FinishReplace(runs[i], err)
+		}
+	}()
+	r.Info = fmt.Sprintf("rename-symbol: finding references in %s", proj.Path)
+	return nil
+}
+
+func rename(ctx context.Context, driver gxui.Driver, finder EditorFinder, proj setting.Project, path string, offset int, newName string) ([]goref.Ref, error) {
+	p, err := load(ctx, driver, finder, proj)
+	if err != nil {
+		return nil, err
+	}
+	return p.Rename(path, offset, newName)
+}
+
+type fileReplacements struct {
+	path string
+	reps []search.Replacement
+}
+
+// replacements converts refs to the replacements that rename each of
+// them to newName, grouped by file.
+func replacements(refs []goref.Ref, newName string) []fileReplacements {
+	byPath := make(map[string][]search.Replacement)
+	var paths []string
+	for _, ref := range refs {
+		if _, ok := byPath[ref.Path]; !ok {
+			paths = append(paths, ref.Path)
+		}
+		line := []rune(ref.Text)
+		end := ref.Column + len([]rune(ref.Name))
+		byPath[ref.Path] = append(byPath[ref.Path], search.Replacement{
+			Match: search.Match{
+				Line:   ref.Line,
+				Column: ref.Column,
+				Length: len([]rune(ref.Name)),
+				Text:   ref.Text,
+			},
+			Offset:  ref.Offset,
+			Old:     ref.Name,
+			New:     newName,
+			NewText: string(line[:ref.Column]) + newName + string(line[end:]),
+		})
+	}
+	sort.Strings(paths)
+	reps := make([]fileReplacements, 0, len(paths))
+	for _, p := range paths {
+		reps = append(reps, fileReplacements{path: p, reps: byPath[p]})
+	}
+	return reps
+}
+
+// identAt returns the identifier around offset in runes, for
+// display.
+func identAt(runes []rune, offset int) string {
+	isIdent := func(r rune) bool {
+		return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+	if offset > len(runes) {
+		offset = len(runes)
+	}
+	start, end := offset, offset
+	for start > 0 && isIdent(runes[start-1]) {
+		start--
+	}
+	for end < len(runes) && isIdent(runes[end]) {
+		end++
+	}
+	return string(runes[start:end])
+}
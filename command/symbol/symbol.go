@@ -0,0 +1,69 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+// Package symbol contains commands that work with the go identifier
+// under the caret across the whole project, using the goref package
+// to type check the project's packages.
+package symbol
+
+import (
+	"context"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/goref"
+	"github.com/nelsam/vidar/setting"
+)
+
+// A Projecter is any element that knows which project is current.
+type Projecter interface {
+	Project() setting.Project
+}
+
+// A CaretController is any element that knows where the last caret
+// is.
+type CaretController interface {
+	LastCaret() int
+}
+
+// An EditorFinder is any element that can find the open editor for
+// a file.  Commands in this package use the first EditorFinder
+// they are given, which is the outermost editor and the only one
+// that can see files in every project and split.
+type EditorFinder interface {
+	EditorFor(path string) text.Editor
+}
+
+// Bindables returns the commands in this package.
+func Bindables(driver gxui.Driver, theme gxui.Theme) []bind.Bindable {
+	return []bind.Bindable{
+		NewRename(driver, theme),
+		NewFind(driver, theme, References),
+		NewFind(driver, theme, Callers),
+		NewFind(driver, theme, Callees),
+	}
+}
+
+// load type checks the project at root, using the text of any files
+// that are open in editors found by finder instead of the text on
+// disk.  It must not be called on the UI goroutine.
+func load(ctx context.Context, driver gxui.Driver, finder EditorFinder, proj setting.Project) (*goref.Project, error) {
+	files, err := goref.GoFiles(proj.Path)
+	if err != nil {
+		return nil, err
+	}
+	overlay := make(map[string]string)
+	done := make(chan struct{})
+	driver.Call(func() {
+		defer close(done)
+		for _, f := range files {
+			if e := finder.EditorFor(f); e != nil {
+				overlay[f] = e.Text()
+			}
+		}
+	})
+	<-done
+	return goref.Load(ctx, proj.Path, proj.Environ(), overlay)
+}
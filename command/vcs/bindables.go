@@ -0,0 +1,33 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package vcs
+
+import (
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander/bind"
+)
+
+// Bindables returns the git hooks and commands.  The ones that need
+// the files at HEAD share a single cache of them.
+func Bindables(driver gxui.Driver, theme gxui.Theme) []bind.Bindable {
+	b := NewBases()
+	g := NewGutter(driver, b)
+	return []bind.Bindable{
+		GutterHook{gutter: g},
+		NewNextHunk(theme, b),
+		NewPrevHunk(theme, b),
+		NewRevertHunk(theme, b),
+		NewRefreshStatus(theme),
+		NewToggleIgnored(theme),
+		NewStageFile(theme),
+		NewUnstageFile(theme),
+		NewStageHunk(theme),
+		NewUnstageHunk(theme, b),
+		NewCommit(theme, b, g),
+		NewAmend(theme, b, g),
+		NewBlameLine(theme),
+		NewBlameFile(theme),
+	}
+}
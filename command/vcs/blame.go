@@ -0,0 +1,184 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package vcs
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/git"
+	"github.com/nelsam/vidar/plugin/status"
+)
+
+var (
+	blameColor       = gxui.Gray60
+	uncommittedColor = gxui.Gray40
+)
+
+// An Annotator is an editor that can display annotations next to
+// its lines.
+type Annotator interface {
+	text.Editor
+	SetAnnotations([]text.Annotation)
+	Annotations() []text.Annotation
+}
+
+// A CommitViewer displays commits.  ShowCommit may be called from
+// any goroutine.
+type CommitViewer interface {
+	ShowCommit(title, desc string)
+}
+
+// Blame is a command that shows which commit last changed the line
+// under the caret, or every line in the focused file, in the
+// editor's side gutter.  Clicking a commit in the gutter shows it in
+// the commit viewer.  Running the command again hides the gutter.
+type Blame struct {
+	status.General
+
+	file bool
+
+	editor Annotator
+	ctrl   CaretController
+	viewer CommitViewer
+}
+
+// NewBlameLine returns a *Blame that blames the line under the
+// caret.
+func NewBlameLine(theme gxui.Theme) *Blame {
+	b := &Blame{}
+	b.Theme = theme
+	return b
+}
+
+// NewBlameFile returns a *Blame that blames every line in the
+// focused file.
+func NewBlameFile(theme gxui.Theme) *Blame {
+	b := &Blame{file: true}
+	b.Theme = theme
+	return b
+}
+
+func (b *Blame) Name() string {
+	if b.file {
+		return "blame-file"
+	}
+	return "blame-line"
+}
+
+func (b *Blame) Menu() string {
+	return "Git"
+}
+
+func (b *Blame) Defaults() []fmt.Stringer {
+	return nil
+}
+
+func (b *Blame) Reset() {
+	b.editor = nil
+	b.ctrl = nil
+	b.viewer = nil
+}
+
+func (b *Blame) Store(target interface{}) bind.Status {
+	switch src := target.(type) {
+	case Annotator:
+		b.editor = src
+	case CaretController:
+		b.ctrl = src
+	case CommitViewer:
+		b.viewer = src
+	}
+	if b.editor == nil || b.ctrl == nil {
+		return bind.Waiting
+	}
+	return bind.Done
+}
+
+func (b *Blame) Exec() error {
+	src := b.editor.Runes()
+	line := lineOf(src, b.ctrl.LastCaret())
+	if b.shown(line) {
+		b.editor.SetAnnotations(nil)
+		return nil
+	}
+	path := b.editor.Filepath()
+	commits, err := git.Blame(path, []byte(string(src)))
+	if err != nil {
+		b.Err = fmt.Sprintf("%s: %s", b.Name(), err)
+		return err
+	}
+	if line >= len(commits) {
+		b.Warn = "There is nothing to blame on this line"
+		return nil
+	}
+	var annotations []text.Annotation
+	for i, c := range commits {
+		if !b.file && i != line {
+			continue
+		}
+		annotations = append(annotations, b.annotation(path, i, c))
+	}
+	b.editor.SetAnnotations(annotations)
+	c := commits[line]
+	if c.Committed() {
+		b.Info = fmt.Sprintf("%s %s, %s: %s", c.Short(), c.Author, c.Time.Format("2006-01-02"), c.Summary)
+	}
+	return nil
+}
+
+// lineOf returns the index of the line that offset is on in src.
+func lineOf(src []rune, offset int) int {
+	if offset > len(src) {
+		offset = len(src)
+	}
+	line := 0
+	for _, r := range src[:offset] {
+		if r == '\n' {
+			line++
+		}
+	}
+	return line
+}
+
+// shown returns whether the blame that b would display is already
+// displayed.
+func (b *Blame) shown(line int) bool {
+	annotations := b.editor.Annotations()
+	if b.file {
+		return len(annotations) > 1
+	}
+	return len(annotations) == 1 && annotations[0].Line == line
+}
+
+func (b *Blame) annotation(path string, line int, c *git.BlameCommit) text.Annotation {
+	if !c.Committed() {
+		return text.Annotation{Line: line, Text: "not committed yet", Color: uncommittedColor}
+	}
+	a := text.Annotation{
+		Line:  line,
+		Text:  fmt.Sprintf("%s %.16s %s", c.Short(), c.Author, c.Time.Format("2006-01-02")),
+		Color: blameColor,
+	}
+	if viewer := b.viewer; viewer != nil {
+		a.OnClick = func() {
+			go showCommit(viewer, filepath.Dir(path), c)
+		}
+	}
+	return a
+}
+
+func showCommit(viewer CommitViewer, dir string, c *git.BlameCommit) {
+	desc, err := git.ShowCommit(dir, c.Hash)
+	if err != nil {
+		log.Printf("blame: could not show commit %s: %s", c.Short(), err)
+		return
+	}
+	viewer.ShowCommit(fmt.Sprintf("%s %s", c.Short(), c.Summary), string(desc))
+}
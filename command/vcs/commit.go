@@ -0,0 +1,195 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package vcs
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/gxui/math"
+	"github.com/nelsam/vidar/commander"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/git"
+	"github.com/nelsam/vidar/plugin/status"
+	"github.com/nelsam/vidar/setting"
+)
+
+// A Projecter is any element that knows which project is current.
+type Projecter interface {
+	Project() setting.Project
+}
+
+// messageBox is a multi-line text box for commit messages.  Enter
+// starts a new line, so the message is completed with ctrl-enter.
+type messageBox struct {
+	gxui.TextBox
+}
+
+func newMessageBox(theme gxui.Theme) messageBox {
+	box := messageBox{TextBox: theme.CreateTextBox()}
+	box.SetMultiline(true)
+	box.SetDesiredWidth(math.MaxSize.W)
+	return box
+}
+
+func (b messageBox) Complete(event gxui.KeyboardEvent) bool {
+	return event.Modifier == gxui.ModControl && event.Key == gxui.KeyEnter
+}
+
+func (b messageBox) KeyPress(event gxui.KeyboardEvent) bool {
+	if b.Complete(event) {
+		return false
+	}
+	return b.TextBox.KeyPress(event)
+}
+
+// Commit is a command that commits the index with a message read
+// from the user, or amends HEAD.
+type Commit struct {
+	status.General
+
+	bases  *Bases
+	gutter *Gutter
+	amend  bool
+
+	prompt  gxui.Label
+	message messageBox
+	input   gxui.Focusable
+
+	editor    text.Editor
+	proj      Projecter
+	refresher StatusRefresher
+}
+
+func newCommit(theme gxui.Theme, b *Bases, g *Gutter, amend bool) *Commit {
+	c := &Commit{
+		bases:   b,
+		gutter:  g,
+		amend:   amend,
+		prompt:  theme.CreateLabel(),
+		message: newMessageBox(theme),
+	}
+	c.Theme = theme
+	return c
+}
+
+// NewCommit returns a *Commit that makes a new commit.  Since HEAD
+// moves, b is cleared and g is refreshed after each commit.
+func NewCommit(theme gxui.Theme, b *Bases, g *Gutter) *Commit {
+	return newCommit(theme, b, g, false)
+}
+
+// NewAmend returns a *Commit that amends HEAD.  Since HEAD moves, b
+// is cleared and g is refreshed after each commit.
+func NewAmend(theme gxui.Theme, b *Bases, g *Gutter) *Commit {
+	return newCommit(theme, b, g, true)
+}
+
+func (c *Commit) Name() string {
+	if c.amend {
+		return "amend-commit"
+	}
+	return "commit"
+}
+
+func (c *Commit) Menu() string {
+	return "Git"
+}
+
+func (c *Commit) Defaults() []fmt.Stringer {
+	return nil
+}
+
+func (c *Commit) Start(control gxui.Control) gxui.Control {
+	c.message.SetText("")
+	c.prompt.SetText("Message (ctrl-enter to commit):")
+	if c.amend {
+		c.prompt.SetText("Message (ctrl-enter to amend):")
+		if path := findFile(control); path != "" {
+			msg, err := git.LastMessage(filepath.Dir(path))
+			if err != nil {
+				log.Printf("amend-commit: could not read the message of HEAD: %s", err)
+			}
+			c.message.SetText(msg)
+		}
+	}
+	c.input = c.message
+	return c.prompt
+}
+
+func (c *Commit) Next() gxui.Focusable {
+	input := c.input
+	c.input = nil
+	return input
+}
+
+func (c *Commit) Reset() {
+	c.editor = nil
+	c.proj = nil
+	c.refresher = nil
+}
+
+func (c *Commit) Store(target interface{}) bind.Status {
+	switch src := target.(type) {
+	case text.Editor:
+		c.editor = src
+	case Projecter:
+		c.proj = src
+	case StatusRefresher:
+		c.refresher = src
+	}
+	if c.editor == nil && c.proj == nil {
+		return bind.Waiting
+	}
+	return bind.Executing
+}
+
+func (c *Commit) Exec() error {
+	msg := strings.TrimSpace(c.message.Text())
+	if msg == "" {
+		c.Warn = fmt.Sprintf("%s: aborted because the message is empty", c.Name())
+		return nil
+	}
+	dir := ""
+	switch {
+	case c.editor != nil:
+		dir = filepath.Dir(c.editor.Filepath())
+	case c.proj != nil:
+		dir = c.proj.Project().Path
+	}
+	hash, err := git.Commit(dir, msg, c.amend)
+	if err != nil {
+		c.Err = fmt.Sprintf("%s: %s", c.Name(), err)
+		return err
+	}
+	c.bases.Clear()
+	c.gutter.Refresh()
+	if c.refresher != nil {
+		c.refresher.RefreshStatus()
+	}
+	subject := strings.SplitN(msg, "\n", 2)[0]
+	c.Info = fmt.Sprintf("Committed %s: %s", hash, subject)
+	return nil
+}
+
+// findFile returns the path of the first text.Editor in elem or its
+// elements.
+func findFile(elem interface{}) string {
+	switch src := elem.(type) {
+	case text.Editor:
+		return src.Filepath()
+	case commander.Elementer:
+		for _, child := range src.Elements() {
+			if path := findFile(child); path != "" {
+				return path
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,222 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+// Package vcs contains commands and hooks that integrate the editor
+// with git, using the git command line tool against local
+// repositories.
+package vcs
+
+import (
+	"context"
+	"sync"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/diff"
+	"github.com/nelsam/vidar/git"
+	"github.com/nelsam/vidar/theme"
+)
+
+const gutterOverlayName = "git"
+
+// Overlayer is a type that can display layers on top of its
+// syntax layers.
+type Overlayer interface {
+	SetOverlay(name string, layers []text.SyntaxLayer)
+}
+
+// Bases keeps track of the version of each file at HEAD, which
+// buffers are compared against.  It is safe for concurrent use.
+type Bases struct {
+	mu    sync.Mutex
+	files map[string]base
+}
+
+type base struct {
+	text    string
+	tracked bool
+}
+
+// NewBases returns an empty *Bases.
+func NewBases() *Bases {
+	return &Bases{files: make(map[string]base)}
+}
+
+// Get returns the contents of path at HEAD, loading them with git if
+// they have not been loaded yet.  Files that are in a repository but
+// have never been committed are returned as empty.  The returned
+// bool is false if path is not in a repository or is ignored.
+func (b *Bases) Get(path string) (string, bool) {
+	b.mu.Lock()
+	f, ok := b.files[path]
+	b.mu.Unlock()
+	if ok {
+		return f.text, f.tracked
+	}
+	f = load(path)
+	b.mu.Lock()
+	b.files[path] = f
+	b.mu.Unlock()
+	return f.text, f.tracked
+}
+
+// Forget drops the cached contents of path, so that they are loaded
+// again the next time they are needed (e.g. after a commit).
+func (b *Bases) Forget(path string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.files, path)
+}
+
+// Clear drops the cached contents of every file, for when HEAD
+// moves.
+func (b *Bases) Clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.files = make(map[string]base)
+}
+
+func load(path string) base {
+	contents, err := git.Show(path, "HEAD")
+	switch err {
+	case nil:
+		return base{text: string(contents), tracked: true}
+	case git.ErrNotInRev:
+		return base{tracked: !git.IsIgnored(path)}
+	default:
+		return base{}
+	}
+}
+
+// GutterHook binds the git gutter to files as they are opened.
+type GutterHook struct {
+	gutter *Gutter
+}
+
+func (h GutterHook) Name() string {
+	return "git-gutter-hook"
+}
+
+func (h GutterHook) OpName() string {
+	return "focus-location"
+}
+
+func (h GutterHook) FileBindables(string) []bind.Bindable {
+	return []bind.Bindable{h.gutter}
+}
+
+// Gutter marks the lines in each editor that have been added,
+// modified, or deleted since HEAD.
+type Gutter struct {
+	driver gxui.Driver
+	bases  *Bases
+
+	mu      sync.Mutex
+	diffs   map[string]diff.LineDiff
+	editors map[string]text.Editor
+}
+
+// NewGutter returns a *Gutter that compares editors against the
+// files in b.
+func NewGutter(driver gxui.Driver, b *Bases) *Gutter {
+	return &Gutter{
+		driver:  driver,
+		bases:   b,
+		diffs:   make(map[string]diff.LineDiff),
+		editors: make(map[string]text.Editor),
+	}
+}
+
+func (g *Gutter) Name() string {
+	return "git-gutter"
+}
+
+func (g *Gutter) OpName() string {
+	return "input-handler"
+}
+
+func (g *Gutter) Init(e text.Editor, contents []rune) {
+	g.mu.Lock()
+	g.editors[e.Filepath()] = e
+	g.mu.Unlock()
+
+	// Loading the file from git runs a few processes, so it is kept
+	// off of the UI goroutine.
+	src := string(contents)
+	go func() {
+		if !g.update(context.Background(), e.Filepath(), src) {
+			return
+		}
+		g.driver.Call(func() {
+			g.render(e)
+		})
+	}()
+}
+
+// Refresh compares every editor that g is bound to against HEAD
+// again, e.g. after a commit.  It must be called on the UI
+// goroutine.
+func (g *Gutter) Refresh() {
+	g.mu.Lock()
+	editors := make([]text.Editor, 0, len(g.editors))
+	for _, e := range g.editors {
+		editors = append(editors, e)
+	}
+	g.mu.Unlock()
+	for _, e := range editors {
+		g.Init(e, e.Runes())
+	}
+}
+
+func (g *Gutter) TextChanged(ctx context.Context, e text.Editor, _ []text.Edit) {
+	g.update(ctx, e.Filepath(), e.Text())
+}
+
+func (g *Gutter) Apply(e text.Editor) error {
+	g.render(e)
+	return nil
+}
+
+func (g *Gutter) update(ctx context.Context, path, src string) bool {
+	base, ok := g.bases.Get(path)
+	if !ok {
+		return false
+	}
+	d := diff.NewLineDiff(base, src)
+	select {
+	case <-ctx.Done():
+		return false
+	default:
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.diffs[path] = d
+	return true
+}
+
+func (g *Gutter) render(e text.Editor) {
+	overlayer, ok := e.(Overlayer)
+	if !ok {
+		return
+	}
+	g.mu.Lock()
+	d, ok := g.diffs[e.Filepath()]
+	g.mu.Unlock()
+	if !ok || len(d.Hunks) == 0 {
+		overlayer.SetOverlay(gutterOverlayName, nil)
+		return
+	}
+	layers := []text.SyntaxLayer{
+		{Construct: theme.Added},
+		{Construct: theme.Modified},
+		{Construct: theme.Deleted},
+	}
+	for _, h := range d.Hunks {
+		start, end := d.Span(h)
+		i := int(h.Kind())
+		layers[i].Spans = append(layers[i].Spans, text.Span{Start: start, End: end})
+	}
+	overlayer.SetOverlay(gutterOverlayName, layers)
+}
@@ -0,0 +1,217 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package vcs
+
+import (
+	"fmt"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/diff"
+	"github.com/nelsam/vidar/plugin/status"
+)
+
+// Editor is the type of editor that hunk commands operate on.
+type Editor interface {
+	text.Editor
+	ScrollToRune(int)
+}
+
+type CaretController interface {
+	LastCaret() int
+	SetCaret(int)
+}
+
+type Applier interface {
+	Apply(text.Editor, ...text.Edit)
+}
+
+// lineDiff returns the difference between e and its version at
+// HEAD, and the line that the caret is on.
+func lineDiff(bases *Bases, e text.Editor, caret int) (d diff.LineDiff, line int, ok bool) {
+	base, ok := bases.Get(e.Filepath())
+	if !ok {
+		return diff.LineDiff{}, 0, false
+	}
+	d = diff.NewLineDiff(base, e.Text())
+	return d, d.Line(caret), true
+}
+
+func describe(d diff.LineDiff, h diff.Hunk) string {
+	idx := 0
+	for i, other := range d.Hunks {
+		if other == h {
+			idx = i
+		}
+	}
+	lines := h.NewLen
+	if h.Kind() == diff.Deleted {
+		lines = h.OldLen
+	}
+	return fmt.Sprintf("hunk %d of %d: %d lines %s", idx+1, len(d.Hunks), lines, h.Kind())
+}
+
+// HunkJump is a command that moves the caret to the next or previous
+// hunk that differs from HEAD in the current file.
+type HunkJump struct {
+	status.General
+
+	bases    *Bases
+	name     string
+	find     func(d diff.LineDiff, line int) (diff.Hunk, bool)
+	defaults []fmt.Stringer
+
+	editor Editor
+	ctrl   CaretController
+}
+
+// NewNextHunk returns a *HunkJump that moves to the next hunk.
+func NewNextHunk(theme gxui.Theme, b *Bases) *HunkJump {
+	j := &HunkJump{
+		bases: b,
+		name:  "next-hunk",
+		find:  diff.LineDiff.Next,
+		defaults: []fmt.Stringer{gxui.KeyboardEvent{
+			Modifier: gxui.ModControl,
+			Key:      gxui.KeyF8,
+		}},
+	}
+	j.Theme = theme
+	return j
+}
+
+// NewPrevHunk returns a *HunkJump that moves to the previous hunk.
+func NewPrevHunk(theme gxui.Theme, b *Bases) *HunkJump {
+	j := &HunkJump{
+		bases: b,
+		name:  "prev-hunk",
+		find:  diff.LineDiff.Prev,
+		defaults: []fmt.Stringer{gxui.KeyboardEvent{
+			Modifier: gxui.ModControl | gxui.ModShift,
+			Key:      gxui.KeyF8,
+		}},
+	}
+	j.Theme = theme
+	return j
+}
+
+func (j *HunkJump) Name() string {
+	return j.name
+}
+
+func (j *HunkJump) Menu() string {
+	return "Navigation"
+}
+
+func (j *HunkJump) Defaults() []fmt.Stringer {
+	return j.defaults
+}
+
+func (j *HunkJump) Reset() {
+	j.editor = nil
+	j.ctrl = nil
+}
+
+func (j *HunkJump) Store(target interface{}) bind.Status {
+	switch src := target.(type) {
+	case Editor:
+		j.editor = src
+	case CaretController:
+		j.ctrl = src
+	}
+	if j.editor != nil && j.ctrl != nil {
+		return bind.Done
+	}
+	return bind.Waiting
+}
+
+func (j *HunkJump) Exec() error {
+	d, line, ok := lineDiff(j.bases, j.editor, j.ctrl.LastCaret())
+	if !ok {
+		j.Warn = "This file is not in a git repository"
+		return nil
+	}
+	h, ok := j.find(d, line)
+	if !ok {
+		j.Info = "No changes since HEAD"
+		return nil
+	}
+	start := d.Offset(h.NewStart)
+	j.ctrl.SetCaret(start)
+	j.editor.ScrollToRune(start)
+	j.Info = describe(d, h)
+	return nil
+}
+
+// RevertHunk is a command that reverts the hunk under the caret to
+// its contents at HEAD.
+type RevertHunk struct {
+	status.General
+
+	bases *Bases
+
+	editor  Editor
+	ctrl    CaretController
+	applier Applier
+}
+
+// NewRevertHunk returns a *RevertHunk that reverts hunks to their
+// contents in b.
+func NewRevertHunk(theme gxui.Theme, b *Bases) *RevertHunk {
+	r := &RevertHunk{bases: b}
+	r.Theme = theme
+	return r
+}
+
+func (r *RevertHunk) Name() string {
+	return "revert-hunk"
+}
+
+func (r *RevertHunk) Menu() string {
+	return "Git"
+}
+
+func (r *RevertHunk) Defaults() []fmt.Stringer {
+	return nil
+}
+
+func (r *RevertHunk) Reset() {
+	r.editor = nil
+	r.ctrl = nil
+	r.applier = nil
+}
+
+func (r *RevertHunk) Store(target interface{}) bind.Status {
+	switch src := target.(type) {
+	case Editor:
+		r.editor = src
+	case CaretController:
+		r.ctrl = src
+	case Applier:
+		r.applier = src
+	}
+	if r.editor != nil && r.ctrl != nil && r.applier != nil {
+		return bind.Done
+	}
+	return bind.Waiting
+}
+
+func (r *RevertHunk) Exec() error {
+	d, line, ok := lineDiff(r.bases, r.editor, r.ctrl.LastCaret())
+	if !ok {
+		r.Warn = "This file is not in a git repository"
+		return nil
+	}
+	h, ok := d.At(line)
+	if !ok {
+		r.Warn = "The caret is not on a changed line"
+		return nil
+	}
+	at, old, new := d.Revert(h)
+	r.applier.Apply(r.editor, text.Edit{At: at, Old: old, New: new})
+	r.Info = fmt.Sprintf("Reverted %s", describe(d, h))
+	return nil
+}
@@ -0,0 +1,199 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package vcs
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/diff"
+	"github.com/nelsam/vidar/git"
+	"github.com/nelsam/vidar/plugin/status"
+)
+
+// A Changer is an editor that knows whether it has unsaved changes.
+type Changer interface {
+	HasChanges() bool
+}
+
+// Stage is a command that adds the focused file, or the hunk under
+// the caret, to the index, or takes it back out.  Files are staged
+// as they are on disk, but hunks are staged from the editor's text,
+// saved or not.
+type Stage struct {
+	status.General
+
+	bases   *Bases
+	hunk    bool
+	unstage bool
+
+	editor    text.Editor
+	ctrl      CaretController
+	refresher StatusRefresher
+}
+
+func newStage(theme gxui.Theme, b *Bases, hunk, unstage bool) *Stage {
+	s := &Stage{bases: b, hunk: hunk, unstage: unstage}
+	s.Theme = theme
+	return s
+}
+
+// NewStageFile returns a *Stage that stages the focused file.
+func NewStageFile(theme gxui.Theme) *Stage {
+	return newStage(theme, nil, false, false)
+}
+
+// NewUnstageFile returns a *Stage that unstages the focused file.
+func NewUnstageFile(theme gxui.Theme) *Stage {
+	return newStage(theme, nil, false, true)
+}
+
+// NewStageHunk returns a *Stage that stages the hunk under the
+// caret.
+func NewStageHunk(theme gxui.Theme) *Stage {
+	return newStage(theme, nil, true, false)
+}
+
+// NewUnstageHunk returns a *Stage that unstages the hunk under the
+// caret, using b to find what the hunk looked like at HEAD.
+func NewUnstageHunk(theme gxui.Theme, b *Bases) *Stage {
+	return newStage(theme, b, true, true)
+}
+
+func (s *Stage) Name() string {
+	name := "stage"
+	if s.unstage {
+		name = "unstage"
+	}
+	if s.hunk {
+		return name + "-hunk"
+	}
+	return name + "-file"
+}
+
+func (s *Stage) Menu() string {
+	return "Git"
+}
+
+func (s *Stage) Defaults() []fmt.Stringer {
+	return nil
+}
+
+func (s *Stage) Reset() {
+	s.editor = nil
+	s.ctrl = nil
+	s.refresher = nil
+}
+
+func (s *Stage) Store(target interface{}) bind.Status {
+	switch src := target.(type) {
+	case text.Editor:
+		s.editor = src
+	case CaretController:
+		s.ctrl = src
+	case StatusRefresher:
+		s.refresher = src
+	}
+	if s.editor == nil || (s.hunk && s.ctrl == nil) {
+		return bind.Waiting
+	}
+	return bind.Done
+}
+
+func (s *Stage) Exec() error {
+	var err error
+	switch {
+	case s.hunk && s.unstage:
+		err = s.unstageHunk()
+	case s.hunk:
+		err = s.stageHunk()
+	case s.unstage:
+		err = git.Unstage(s.editor.Filepath())
+		s.Info = fmt.Sprintf("Unstaged %s", filepath.Base(s.editor.Filepath()))
+	default:
+		err = s.stageFile()
+	}
+	if err != nil {
+		s.Err = fmt.Sprintf("%s: %s", s.Name(), err)
+		return err
+	}
+	if s.refresher != nil {
+		s.refresher.RefreshStatus()
+	}
+	return nil
+}
+
+func (s *Stage) stageFile() error {
+	path := s.editor.Filepath()
+	if err := git.Stage(path); err != nil {
+		return err
+	}
+	s.Info = fmt.Sprintf("Staged %s", filepath.Base(path))
+	if c, ok := s.editor.(Changer); ok && c.HasChanges() {
+		s.Warn = fmt.Sprintf("Staged %s as it is on disk; unsaved changes were not staged", filepath.Base(path))
+	}
+	return nil
+}
+
+// index returns the contents of the focused file in the index, which
+// are empty if the file has never been staged.
+func (s *Stage) index() (string, error) {
+	b, err := git.Show(s.editor.Filepath(), "")
+	if err == git.ErrNotInRev {
+		return "", nil
+	}
+	return string(b), err
+}
+
+func (s *Stage) stageHunk() error {
+	index, err := s.index()
+	if err != nil {
+		return err
+	}
+	d := diff.NewLineDiff(index, s.editor.Text())
+	h, ok := d.At(d.Line(s.ctrl.LastCaret()))
+	if !ok {
+		s.Warn = "The caret is not on an unstaged change"
+		return nil
+	}
+	if err := git.SetIndex(s.editor.Filepath(), []byte(d.Apply(h))); err != nil {
+		return err
+	}
+	s.Info = fmt.Sprintf("Staged %s", describe(d, h))
+	return nil
+}
+
+func (s *Stage) unstageHunk() error {
+	head, ok := s.bases.Get(s.editor.Filepath())
+	if !ok {
+		s.Warn = "This file is not in a git repository"
+		return nil
+	}
+	index, err := s.index()
+	if err != nil {
+		return err
+	}
+	// The caret is on a line in the editor, which has to be found
+	// in the index before the staged hunk under it can be.
+	unstaged := diff.NewLineDiff(index, s.editor.Text())
+	line := unstaged.OldLine(unstaged.Line(s.ctrl.LastCaret()))
+	staged := diff.NewLineDiff(head, index)
+	h, ok := staged.At(line)
+	if !ok {
+		s.Warn = "The caret is not on a staged change"
+		return nil
+	}
+	at, old, new := staged.Revert(h)
+	runes := []rune(index)
+	reverted := append(append(append([]rune(nil), runes[:at]...), new...), runes[at+len(old):]...)
+	if err := git.SetIndex(s.editor.Filepath(), []byte(string(reverted))); err != nil {
+		return err
+	}
+	s.Info = fmt.Sprintf("Unstaged %s", describe(staged, h))
+	return nil
+}
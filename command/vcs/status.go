@@ -0,0 +1,99 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package vcs
+
+import (
+	"fmt"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/plugin/status"
+)
+
+// A StatusRefresher is a type that displays git status and can
+// reload it.
+type StatusRefresher interface {
+	RefreshStatus()
+}
+
+// An IgnoreHider is a type that can hide files that git ignores.
+type IgnoreHider interface {
+	HideIgnored() bool
+	SetHideIgnored(bool)
+}
+
+// RefreshStatus is a command that reloads git status, for when
+// something changes that the filesystem watcher can't see.
+type RefreshStatus struct {
+	status.General
+}
+
+// NewRefreshStatus returns a new *RefreshStatus.
+func NewRefreshStatus(theme gxui.Theme) *RefreshStatus {
+	r := &RefreshStatus{}
+	r.Theme = theme
+	return r
+}
+
+func (r *RefreshStatus) Name() string {
+	return "refresh-git-status"
+}
+
+func (r *RefreshStatus) Menu() string {
+	return "Git"
+}
+
+func (r *RefreshStatus) Defaults() []fmt.Stringer {
+	return nil
+}
+
+func (r *RefreshStatus) Exec(target interface{}) bind.Status {
+	refresher, ok := target.(StatusRefresher)
+	if !ok {
+		return bind.Waiting
+	}
+	refresher.RefreshStatus()
+	r.Info = "refresh-git-status: reloading git status"
+	return bind.Done
+}
+
+// ToggleIgnored is a command that shows or hides files that git
+// ignores.
+type ToggleIgnored struct {
+	status.General
+}
+
+// NewToggleIgnored returns a new *ToggleIgnored.
+func NewToggleIgnored(theme gxui.Theme) *ToggleIgnored {
+	t := &ToggleIgnored{}
+	t.Theme = theme
+	return t
+}
+
+func (t *ToggleIgnored) Name() string {
+	return "toggle-ignored-files"
+}
+
+func (t *ToggleIgnored) Menu() string {
+	return "View"
+}
+
+func (t *ToggleIgnored) Defaults() []fmt.Stringer {
+	return nil
+}
+
+func (t *ToggleIgnored) Exec(target interface{}) bind.Status {
+	hider, ok := target.(IgnoreHider)
+	if !ok {
+		return bind.Waiting
+	}
+	hide := !hider.HideIgnored()
+	hider.SetHideIgnored(hide)
+	t.Info = "toggle-ignored-files: showing ignored files"
+	if hide {
+		t.Info = "toggle-ignored-files: hiding ignored files"
+	}
+	return bind.Done
+}
@@ -45,8 +45,6 @@ type InputQueue interface {
 // This allows them to consume enter events as newlines or trigger
 // completeness off of key presses other than enter.
 type Completer interface {
-	bind.Command
-
 	// Complete returns whether or not the event signals a completion
 	// of the input.
 	Complete(gxui.KeyboardEvent) bool
@@ -67,23 +67,33 @@ func (b *commandBox) Finish() {
 		}
 	}()
 	statuser, ok := b.current.(Statuser)
-	if !ok {
+	if !ok || !b.showStatus(statuser) {
 		b.Clear()
-		return
 	}
-	b.status = statuser.Status()
-	if b.status == nil {
-		b.Clear()
-		return
+}
+
+// showStatus displays the status of s until it is cleared or
+// maxStatusAge passes, returning false if s has no status.
+func (b *commandBox) showStatus(s Statuser) bool {
+	status := s.Status()
+	if status == nil {
+		return false
+	}
+	if b.statusTimer != nil {
+		b.statusTimer.Stop()
 	}
 	b.clearDisplay()
 	b.clearInput()
+	b.clearStatus()
+	b.label.SetText(s.Name())
+	b.status = status
 	b.AddChild(b.status)
 	b.statusTimer = time.AfterFunc(maxStatusAge, func() {
 		b.driver.CallSync(func() {
 			b.Clear()
 		})
 	})
+	return true
 }
 
 func (b *commandBox) Clear() {
@@ -107,6 +117,9 @@ func (b *commandBox) Run(command bind.Command) (needsInput bool) {
 }
 
 func (b *commandBox) Finished(event gxui.KeyboardEvent) bool {
+	if completer, ok := b.input.(Completer); ok {
+		return completer.Complete(event)
+	}
 	return event.Modifier == 0 && event.Key == gxui.KeyEnter
 }
 
@@ -133,8 +146,7 @@ func (b *commandBox) KeyPress(event gxui.KeyboardEvent) (consume bool) {
 	if event.Modifier == 0 && event.Key == gxui.KeyEscape {
 		return false
 	}
-	isEnter := event.Modifier == 0 && event.Key == gxui.KeyEnter
-	complete := isEnter
+	complete := event.Modifier == 0 && event.Key == gxui.KeyEnter
 	if completer, ok := b.input.(Completer); ok {
 		complete = completer.Complete(event)
 	}
@@ -142,7 +154,7 @@ func (b *commandBox) KeyPress(event gxui.KeyboardEvent) (consume bool) {
 		hasMore := b.nextInput()
 		complete = !hasMore
 	}
-	return !(complete && isEnter)
+	return !complete
 }
 
 func (b *commandBox) HasFocus() bool {
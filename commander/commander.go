@@ -46,6 +46,7 @@ type Commander struct {
 	box        *commandBox
 
 	inputHandler text.Handler
+	recorder     Recorder
 
 	lock sync.RWMutex
 
@@ -92,6 +93,19 @@ func (c *Commander) InputHandler() text.Handler {
 	return c.inputHandler
 }
 
+// ShowStatus displays the status of s in the command box, for
+// bindables that finish their work after they are executed.  It may
+// be called from any goroutine.  The status is dropped if a command
+// is waiting for input.
+func (c *Commander) ShowStatus(s Statuser) {
+	c.driver.Call(func() {
+		if c.box.input != nil {
+			return
+		}
+		c.box.showStatus(s)
+	})
+}
+
 func (c *Commander) DesiredSize(_, max math.Size) math.Size {
 	return max
 }
@@ -276,6 +290,7 @@ func (c *Commander) KeyPress(event gxui.KeyboardEvent) (consume bool) {
 			// TODO: display this in the UI
 			log.Printf("ERR: panic while handling key event: %v", r)
 			log.Printf("Stack trace:\n%s", debug.Stack())
+			c.flush()
 		}
 	}()
 	editor := c.controller.Editor()
@@ -285,17 +300,22 @@ func (c *Commander) KeyPress(event gxui.KeyboardEvent) (consume bool) {
 			gxui.SetFocus(e.(gxui.Focusable))
 		}
 	}
+	command := c.Binding(event)
 	codeEditor := editor.CurrentEditor()
 	if codeEditor != nil && codeEditor.(gxui.Focusable).HasFocus() {
 		c.inputHandler.HandleEvent(codeEditor, event)
+		if r := c.Recorder(); r != nil && command == nil {
+			r.RecordKeyPress(event)
+		}
 	}
-	if command := c.Binding(event); command != nil {
+	if command != nil {
 		c.box.Clear()
 		if c.box.Run(command) {
 			return true
 		}
 		c.Execute(c.box.Current())
 		c.box.Finish()
+		c.recordCommand(command)
 		return true
 	}
 	if !c.box.HasFocus() {
@@ -314,6 +334,7 @@ func (c *Commander) KeyStroke(event gxui.KeyStrokeEvent) (consume bool) {
 			// TODO: display this in the UI
 			log.Printf("ERR: panic while handling key stroke: %v", r)
 			log.Printf("Stack trace:\n%s", debug.Stack())
+			c.flush()
 		}
 	}()
 	if event.Modifier&^gxui.ModShift != 0 {
@@ -324,15 +345,32 @@ func (c *Commander) KeyStroke(event gxui.KeyStrokeEvent) (consume bool) {
 		return false
 	}
 	c.inputHandler.HandleInput(e, event)
+	if r := c.Recorder(); r != nil {
+		r.RecordKeyStroke(event)
+	}
 	return true
 }
 
+// Run runs command in the command box, the same way it would be run
+// from the menu: if command needs input, the command box is focused
+// to ask for it; otherwise, command is executed right away.
+func (c *Commander) Run(command bind.Command) {
+	if c.box.Run(command) {
+		gxui.SetFocus(c.box.input)
+		return
+	}
+	c.Execute(command)
+	c.box.Finish()
+	c.recordCommand(command)
+}
+
 func (c *Commander) Execute(e bind.Bindable) {
 	defer func() {
 		// Mitigate the potential for plugins to cause the editor to panic
 		if r := recover(); r != nil {
 			log.Printf("ERR: panic while executing bindable %T: %v", e, r)
 			log.Printf("Stack trace:\n%s", debug.Stack())
+			c.flush()
 		}
 	}()
 	if before, ok := e.(BeforeExecutor); ok {
@@ -369,6 +407,21 @@ func (c *Commander) Elements() []interface{} {
 	return all
 }
 
+// flush calls Flush on every bound Flusher.  It doesn't lock c,
+// because it is called while recovering from panics, which may
+// happen while c is locked; it is only called on the UI goroutine,
+// which is the only goroutine that changes c's bindings.
+func (c *Commander) flush() {
+	if len(c.stack) == 0 {
+		return
+	}
+	for _, b := range c.stack[len(c.stack)-1] {
+		if f, ok := b.(Flusher); ok {
+			f.Flush()
+		}
+	}
+}
+
 func bindNames(m map[string]bind.Bindable, h bind.Bindable, opNames ...string) {
 	for _, name := range opNames {
 		b := m[name]
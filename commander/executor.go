@@ -14,3 +14,10 @@ type BeforeExecutor interface {
 type Elementer interface {
 	Elements() []interface{}
 }
+
+// A Flusher is a Bindable which holds data that should be written
+// out right away when a panic is recovered, in case the panic leaves
+// vidar unusable.
+type Flusher interface {
+	Flush()
+}
@@ -98,12 +98,7 @@ func (m *menu) Add(command bind.Command, bindings ...gxui.KeyboardEvent) {
 	item := newMenuItem(m.theme, command.Name(), bindings...)
 	m.AddChild(item)
 	item.OnClick(func(gxui.MouseEvent) {
-		if m.commander.box.Run(command) {
-			gxui.SetFocus(m.commander.box.input)
-			return
-		}
-		m.commander.Execute(command)
-		m.commander.box.Finish()
+		m.commander.Run(command)
 	})
 }
 
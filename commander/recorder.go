@@ -0,0 +1,45 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package commander
+
+import (
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander/bind"
+)
+
+// A Recorder is a type that records the input that the Commander
+// handles, so that it can be replayed later.
+//
+// Key presses and key strokes are only recorded when they are sent
+// to the input handler.  Commands are recorded after they execute,
+// unless they needed input from the command box, since that input
+// can't be replayed.
+type Recorder interface {
+	RecordKeyPress(gxui.KeyboardEvent)
+	RecordKeyStroke(gxui.KeyStrokeEvent)
+	RecordCommand(bind.Command)
+}
+
+// Record starts sending input to r, replacing any previous Recorder.
+// Passing nil stops recording.
+func (c *Commander) Record(r Recorder) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.recorder = r
+}
+
+// Recorder returns the Recorder that c is currently sending input
+// to, or nil if c is not recording.
+func (c *Commander) Recorder() Recorder {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.recorder
+}
+
+func (c *Commander) recordCommand(cmd bind.Command) {
+	if r := c.Recorder(); r != nil {
+		r.RecordCommand(cmd)
+	}
+}
@@ -0,0 +1,20 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package text
+
+import "github.com/nelsam/gxui"
+
+// An Annotation is a short note about a line, displayed in a side
+// gutter to the left of the editor's line numbers (e.g. the commit
+// that last changed the line).
+type Annotation struct {
+	Line  int
+	Text  string
+	Color gxui.Color
+
+	// OnClick, if non-nil, is called when the annotation is
+	// clicked.
+	OnClick func()
+}
@@ -0,0 +1,12 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+// Package coverage parses the profiles written by go test
+// -coverprofile and keeps track of which parts of the files being
+// edited were covered, moving and invalidating blocks as the files
+// are edited.
+//
+// Like the diagnostic package, this package does not import any UI
+// code.
+package coverage
@@ -0,0 +1,113 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nelsam/vidar/diagnostic"
+)
+
+// A Block is a block of statements from a coverage profile.  Lines
+// and columns are 1-based, and columns are counted in bytes, the
+// way that the go tool reports them.
+type Block struct {
+	StartLine, StartCol int
+	EndLine, EndCol     int
+	NumStmt             int
+	Count               int
+}
+
+// A Profile is the coverage of a single file.
+type Profile struct {
+	// FileName is the name of the file as it appears in the
+	// profile, which is usually its import path followed by its
+	// base name.
+	FileName string
+	Mode     string
+	Blocks   []Block
+}
+
+var blockLine = regexp.MustCompile(`^(.+):(\d+)\.(\d+),(\d+)\.(\d+) (\d+) (\d+)$`)
+
+// ParseProfile parses a coverage profile from r, returning a
+// Profile for each file in it, in the order that they first appear.
+// Blocks that appear more than once (e.g. from profiles that were
+// concatenated) have their counts added together.
+func ParseProfile(r io.Reader) ([]Profile, error) {
+	var (
+		mode     string
+		profiles []Profile
+		index    = make(map[string]int)
+		seen     = make(map[string]map[Block]int)
+	)
+	s := bufio.NewScanner(r)
+	for lineNum := 1; s.Scan(); lineNum++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "mode: ") {
+			mode = strings.TrimPrefix(line, "mode: ")
+			continue
+		}
+		m := blockLine.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("coverage: line %d: unexpected format: %q", lineNum, line)
+		}
+		var nums [6]int
+		for i := range nums {
+			n, err := strconv.Atoi(m[i+2])
+			if err != nil {
+				return nil, fmt.Errorf("coverage: line %d: %s", lineNum, err)
+			}
+			nums[i] = n
+		}
+		name := m[1]
+		i, ok := index[name]
+		if !ok {
+			i = len(profiles)
+			index[name] = i
+			seen[name] = make(map[Block]int)
+			profiles = append(profiles, Profile{FileName: name, Mode: mode})
+		}
+		b := Block{
+			StartLine: nums[0],
+			StartCol:  nums[1],
+			EndLine:   nums[2],
+			EndCol:    nums[3],
+			NumStmt:   nums[4],
+		}
+		key := b
+		b.Count = nums[5]
+		if j, ok := seen[name][key]; ok {
+			profiles[i].Blocks[j].Count += b.Count
+			continue
+		}
+		seen[name][key] = len(profiles[i].Blocks)
+		profiles[i].Blocks = append(profiles[i].Blocks, b)
+	}
+	return profiles, s.Err()
+}
+
+// Spans converts blocks to Spans in src, the contents of the file
+// that blocks were reported for.
+func Spans(src []byte, blocks []Block) []Span {
+	spans := make([]Span, 0, len(blocks))
+	for _, b := range blocks {
+		spans = append(spans, Span{
+			Start:      diagnostic.Offset(src, b.StartLine, b.StartCol),
+			End:        diagnostic.Offset(src, b.EndLine, b.EndCol),
+			Statements: b.NumStmt,
+			Covered:    b.Count > 0,
+		})
+	}
+	return spans
+}
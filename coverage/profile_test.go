@@ -0,0 +1,58 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package coverage_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nelsam/vidar/coverage"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+const profile = `mode: set
+example.com/foo/foo.go:3.14,5.2 1 1
+example.com/foo/foo.go:7.14,9.2 1 0
+example.com/foo/bar.go:3.14,4.2 2 0
+example.com/foo/foo.go:7.14,9.2 1 1
+`
+
+func TestParseProfile(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	o.BeforeEach(func(t *testing.T) expect.Expectation {
+		return expect.New(t)
+	})
+
+	o.Spec("it groups blocks by file", func(expect expect.Expectation) {
+		profiles, err := coverage.ParseProfile(strings.NewReader(profile))
+		expect(err).To(beNil())
+		expect(profiles).To(haveLen(2))
+		expect(profiles[0].FileName).To(equal("example.com/foo/foo.go"))
+		expect(profiles[0].Mode).To(equal("set"))
+		expect(profiles[0].Blocks).To(equal([]coverage.Block{
+			{StartLine: 3, StartCol: 14, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 1},
+			{StartLine: 7, StartCol: 14, EndLine: 9, EndCol: 2, NumStmt: 1, Count: 1},
+		}))
+		expect(profiles[1].FileName).To(equal("example.com/foo/bar.go"))
+	})
+
+	o.Spec("it fails on malformed lines", func(expect expect.Expectation) {
+		_, err := coverage.ParseProfile(strings.NewReader("mode: set\nfoo.go:bad\n"))
+		expect(err).To(not(beNil()))
+	})
+
+	o.Spec("it converts blocks to rune offsets", func(expect expect.Expectation) {
+		src := []byte("package foo\n\nfunc Foo() {\n\tprintln(\"é\")\n}\n")
+		spans := coverage.Spans(src, []coverage.Block{
+			{StartLine: 3, StartCol: 12, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 1},
+		})
+		expect(spans).To(equal([]coverage.Span{
+			{Start: 24, End: 41, Statements: 1, Covered: true},
+		}))
+	})
+}
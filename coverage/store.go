@@ -0,0 +1,156 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package coverage
+
+import (
+	"sort"
+	"sync"
+)
+
+// A Span is a block of statements in a file.  Start and End are
+// rune offsets in the file's text.
+type Span struct {
+	Start, End int
+	Statements int
+	Covered    bool
+}
+
+// Store keeps track of the coverage of each file.  It is safe for
+// concurrent use.
+type Store struct {
+	mu        sync.RWMutex
+	files     map[string][]Span
+	hidden    bool
+	listeners []func(path string)
+}
+
+// NewStore returns a new, empty *Store.
+func NewStore() *Store {
+	return &Store{files: make(map[string][]Span)}
+}
+
+// OnChange registers fn to be called with a file's path whenever
+// the coverage for that file is set, or the store is shown or
+// hidden.  fn is called on the goroutine that made the change.
+func (s *Store) OnChange(fn func(path string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// Set replaces the coverage for path with spans.  A nil or empty
+// spans clears it.
+func (s *Store) Set(path string, spans []Span) {
+	s.mu.Lock()
+	if len(spans) == 0 {
+		delete(s.files, path)
+	} else {
+		spans = append([]Span(nil), spans...)
+		sort.Slice(spans, func(i, j int) bool {
+			return spans[i].Start < spans[j].Start
+		})
+		s.files[path] = spans
+	}
+	listeners := s.listeners
+	s.mu.Unlock()
+
+	for _, l := range listeners {
+		l(path)
+	}
+}
+
+// For returns the coverage for path, sorted by position in the
+// file.  If s is hidden, For returns nil.
+func (s *Store) For(path string) []Span {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.hidden {
+		return nil
+	}
+	return append([]Span(nil), s.files[path]...)
+}
+
+// Percent returns the percentage of statements in path that were
+// covered, and whether there is any coverage for path at all.
+// Hidden coverage is still counted.
+func (s *Store) Percent(path string) (float64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	total, covered := 0, 0
+	for _, sp := range s.files[path] {
+		total += sp.Statements
+		if sp.Covered {
+			covered += sp.Statements
+		}
+	}
+	if total == 0 {
+		return 0, false
+	}
+	return 100 * float64(covered) / float64(total), true
+}
+
+// Hidden returns whether s is hidden.
+func (s *Store) Hidden() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hidden
+}
+
+// SetHidden shows or hides all of the coverage in s, without
+// discarding it.
+func (s *Store) SetHidden(hidden bool) {
+	s.mu.Lock()
+	s.hidden = hidden
+	paths := make([]string, 0, len(s.files))
+	for path := range s.files {
+		paths = append(paths, path)
+	}
+	listeners := s.listeners
+	s.mu.Unlock()
+
+	for _, path := range paths {
+		for _, l := range listeners {
+			l(path)
+		}
+	}
+}
+
+// Edit updates the coverage for path after oldLen runes at offset
+// have been replaced with newLen runes.  Spans that the edit
+// touches are discarded, since the coverage of the code in them is
+// no longer known, and spans after the edit are shifted.  Listeners
+// are not notified, since the caller is the one making the edit.
+func (s *Store) Edit(path string, at, oldLen, newLen int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	spans, ok := s.files[path]
+	if !ok {
+		return
+	}
+	delta := newLen - oldLen
+	kept := spans[:0]
+	for _, sp := range spans {
+		if touches(sp, at, oldLen) {
+			continue
+		}
+		if sp.Start >= at+oldLen {
+			sp.Start += delta
+			sp.End += delta
+		}
+		kept = append(kept, sp)
+	}
+	if len(kept) == 0 {
+		delete(s.files, path)
+		return
+	}
+	s.files[path] = kept
+}
+
+func touches(sp Span, at, oldLen int) bool {
+	if oldLen == 0 {
+		return sp.Start < at && at < sp.End
+	}
+	return sp.Start < at+oldLen && at < sp.End
+}
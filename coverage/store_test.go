@@ -0,0 +1,88 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package coverage_test
+
+import (
+	"testing"
+
+	"github.com/nelsam/vidar/coverage"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+func TestStore(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	const path = "/tmp/foo.go"
+
+	o.BeforeEach(func(t *testing.T) (expect.Expectation, *coverage.Store) {
+		s := coverage.NewStore()
+		s.Set(path, []coverage.Span{
+			{Start: 30, End: 40, Statements: 1},
+			{Start: 10, End: 20, Statements: 3, Covered: true},
+		})
+		return expect.New(t), s
+	})
+
+	o.Spec("it sorts spans", func(expect expect.Expectation, s *coverage.Store) {
+		spans := s.For(path)
+		expect(spans).To(haveLen(2))
+		expect(spans[0].Start).To(equal(10))
+		expect(spans[1].Start).To(equal(30))
+	})
+
+	o.Spec("it reports the percentage of covered statements", func(expect expect.Expectation, s *coverage.Store) {
+		pct, ok := s.Percent(path)
+		expect(ok).To(beTrue())
+		expect(pct).To(equal(75.0))
+
+		_, ok = s.Percent("/tmp/bar.go")
+		expect(ok).To(beFalse())
+	})
+
+	o.Spec("it hides and shows coverage", func(expect expect.Expectation, s *coverage.Store) {
+		var changed []string
+		s.OnChange(func(path string) {
+			changed = append(changed, path)
+		})
+		s.SetHidden(true)
+		expect(s.Hidden()).To(beTrue())
+		expect(s.For(path)).To(haveLen(0))
+		expect(changed).To(equal([]string{path}))
+
+		_, ok := s.Percent(path)
+		expect(ok).To(beTrue())
+
+		s.SetHidden(false)
+		expect(s.For(path)).To(haveLen(2))
+	})
+
+	o.Spec("it discards spans that are edited and moves later spans", func(expect expect.Expectation, s *coverage.Store) {
+		// Insert three runes inside of the first span.
+		s.Edit(path, 15, 0, 3)
+		spans := s.For(path)
+		expect(spans).To(haveLen(1))
+		expect(spans[0].Start).To(equal(33))
+		expect(spans[0].End).To(equal(43))
+
+		pct, _ := s.Percent(path)
+		expect(pct).To(equal(0.0))
+	})
+
+	o.Spec("it keeps spans that an edit only borders", func(expect expect.Expectation, s *coverage.Store) {
+		s.Edit(path, 20, 5, 0)
+		spans := s.For(path)
+		expect(spans).To(haveLen(2))
+		expect(spans[0].End).To(equal(20))
+		expect(spans[1].Start).To(equal(25))
+	})
+
+	o.Spec("it drops a file once every span is discarded", func(expect expect.Expectation, s *coverage.Store) {
+		s.Edit(path, 0, 50, 0)
+		_, ok := s.Percent(path)
+		expect(ok).To(beFalse())
+	})
+}
@@ -0,0 +1,90 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package diagnostic
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Severity is the severity of a Diagnostic.  The values match the
+// values used by the language server protocol.
+type Severity int
+
+const (
+	Error Severity = 1 + iota
+	Warning
+	Info
+	Hint
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	case Info:
+		return "info"
+	case Hint:
+		return "hint"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// Diagnostic is a message about a span of text in a file.  Start
+// and End are rune offsets in the file's text.
+type Diagnostic struct {
+	Start, End int
+	Severity   Severity
+	Source     string
+	Message    string
+}
+
+func (d Diagnostic) String() string {
+	if d.Source == "" {
+		return d.Message
+	}
+	return fmt.Sprintf("%s: %s", d.Source, d.Message)
+}
+
+// Contains returns whether or not offset is within d.
+func (d Diagnostic) Contains(offset int) bool {
+	return d.Start <= offset && offset <= d.End
+}
+
+// Offset returns the rune offset in src of the 1-based line and
+// column.  Columns are counted in bytes, the way that go/token and
+// the go tool report them.  Positions past the end of a line are
+// clamped to the end of that line, and positions past the end of
+// src are clamped to the end of src.
+func Offset(src []byte, line, col int) int {
+	offset := 0
+	for i := 0; i < len(src) && line > 1; {
+		r, size := utf8.DecodeRune(src[i:])
+		i += size
+		offset++
+		if r == '\n' {
+			line--
+		}
+		if line == 1 {
+			src = src[i:]
+			break
+		}
+	}
+	if line > 1 {
+		return offset
+	}
+	for i := 0; i < len(src) && i < col-1; {
+		r, size := utf8.DecodeRune(src[i:])
+		if r == '\n' {
+			break
+		}
+		i += size
+		offset++
+	}
+	return offset
+}
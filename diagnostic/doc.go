@@ -0,0 +1,12 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+// Package diagnostic keeps track of messages from compilers and
+// linters about files that are being edited.  Sources (type
+// checkers, linters, language servers) push diagnostics for a file
+// into a Store, and the UI reads them back out to display them.
+//
+// This package does not import any UI code, so that sources can
+// push diagnostics without pulling in the rest of the editor.
+package diagnostic
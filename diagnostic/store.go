@@ -0,0 +1,156 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package diagnostic
+
+import (
+	"sort"
+	"sync"
+)
+
+// Default is the Store that vidar's plugins share.  Sources should
+// push their diagnostics here unless they have a reason not to.
+var Default = NewStore()
+
+// Store keeps track of the diagnostics for each file, grouped by
+// the source that reported them.  It is safe for concurrent use.
+type Store struct {
+	mu        sync.RWMutex
+	files     map[string]map[string][]Diagnostic
+	listeners []func(path string)
+}
+
+// NewStore returns a new, empty *Store.
+func NewStore() *Store {
+	return &Store{files: make(map[string]map[string][]Diagnostic)}
+}
+
+// OnChange registers fn to be called with a file's path whenever
+// a source sets the diagnostics for that file.  fn is called on
+// the goroutine that called Set.
+func (s *Store) OnChange(fn func(path string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// Set replaces all diagnostics that source has reported for path
+// with diags.  A nil or empty diags clears them.
+func (s *Store) Set(path, source string, diags []Diagnostic) {
+	s.mu.Lock()
+	sources, ok := s.files[path]
+	if !ok {
+		sources = make(map[string][]Diagnostic)
+		s.files[path] = sources
+	}
+	if len(diags) == 0 {
+		delete(sources, source)
+	} else {
+		sources[source] = append([]Diagnostic(nil), diags...)
+	}
+	if len(sources) == 0 {
+		delete(s.files, path)
+	}
+	listeners := s.listeners
+	s.mu.Unlock()
+
+	for _, l := range listeners {
+		l(path)
+	}
+}
+
+// For returns the diagnostics for path from all sources, sorted by
+// their position in the file.
+func (s *Store) For(path string) []Diagnostic {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var diags []Diagnostic
+	for _, d := range s.files[path] {
+		diags = append(diags, d...)
+	}
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].Start != diags[j].Start {
+			return diags[i].Start < diags[j].Start
+		}
+		return diags[i].Severity < diags[j].Severity
+	})
+	return diags
+}
+
+// At returns the diagnostics for path that contain offset.
+func (s *Store) At(path string, offset int) []Diagnostic {
+	var at []Diagnostic
+	for _, d := range s.For(path) {
+		if d.Contains(offset) {
+			at = append(at, d)
+		}
+	}
+	return at
+}
+
+// Next returns the first diagnostic for path that starts after
+// offset, wrapping around to the start of the file if there are
+// none after offset.
+func (s *Store) Next(path string, offset int) (Diagnostic, bool) {
+	diags := s.For(path)
+	if len(diags) == 0 {
+		return Diagnostic{}, false
+	}
+	for _, d := range diags {
+		if d.Start > offset {
+			return d, true
+		}
+	}
+	return diags[0], true
+}
+
+// Prev returns the last diagnostic for path that starts before
+// offset, wrapping around to the end of the file if there are
+// none before offset.
+func (s *Store) Prev(path string, offset int) (Diagnostic, bool) {
+	diags := s.For(path)
+	if len(diags) == 0 {
+		return Diagnostic{}, false
+	}
+	for i := len(diags) - 1; i >= 0; i-- {
+		if diags[i].Start < offset {
+			return diags[i], true
+		}
+	}
+	return diags[len(diags)-1], true
+}
+
+// Move updates the positions of the diagnostics for path after
+// oldLen runes at offset have been replaced with newLen runes.
+// Diagnostics that end before the edit are left alone, and
+// diagnostics that start after it are shifted.  Listeners are not
+// notified, since the caller is the one making the edit.
+func (s *Store) Move(path string, at, oldLen, newLen int) {
+	delta := newLen - oldLen
+	if delta == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, diags := range s.files[path] {
+		for i, d := range diags {
+			diags[i].Start = move(d.Start, at, oldLen, newLen)
+			diags[i].End = move(d.End, at, oldLen, newLen)
+		}
+	}
+}
+
+func move(offset, at, oldLen, newLen int) int {
+	switch {
+	case offset <= at:
+		return offset
+	case offset >= at+oldLen:
+		return offset + newLen - oldLen
+	case offset > at+newLen:
+		// offset was inside of replaced text that shrank.
+		return at + newLen
+	default:
+		return offset
+	}
+}
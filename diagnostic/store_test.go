@@ -0,0 +1,124 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package diagnostic_test
+
+import (
+	"testing"
+
+	"github.com/nelsam/vidar/diagnostic"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+func TestStore(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	const path = "/tmp/foo.go"
+
+	o.BeforeEach(func(t *testing.T) (expect.Expectation, *diagnostic.Store) {
+		s := diagnostic.NewStore()
+		s.Set(path, "vet", []diagnostic.Diagnostic{
+			{Start: 20, End: 25, Severity: diagnostic.Warning, Message: "unreachable code"},
+		})
+		s.Set(path, "types", []diagnostic.Diagnostic{
+			{Start: 5, End: 8, Severity: diagnostic.Error, Message: "undeclared name: foo"},
+		})
+		return expect.New(t), s
+	})
+
+	o.Spec("it merges and sorts diagnostics from all sources", func(expect expect.Expectation, s *diagnostic.Store) {
+		diags := s.For(path)
+		expect(diags).To(haveLen(2))
+		expect(diags[0].Message).To(equal("undeclared name: foo"))
+		expect(diags[1].Message).To(equal("unreachable code"))
+	})
+
+	o.Spec("it replaces diagnostics per source", func(expect expect.Expectation, s *diagnostic.Store) {
+		s.Set(path, "types", nil)
+		diags := s.For(path)
+		expect(diags).To(haveLen(1))
+		expect(diags[0].Message).To(equal("unreachable code"))
+
+		s.Set(path, "vet", nil)
+		expect(s.For(path)).To(beNil())
+	})
+
+	o.Spec("it notifies listeners of changes", func(expect expect.Expectation, s *diagnostic.Store) {
+		var changed []string
+		s.OnChange(func(path string) {
+			changed = append(changed, path)
+		})
+		s.Set(path, "vet", nil)
+		expect(changed).To(equal([]string{path}))
+	})
+
+	o.Spec("it finds diagnostics at an offset", func(expect expect.Expectation, s *diagnostic.Store) {
+		expect(s.At(path, 6)).To(haveLen(1))
+		expect(s.At(path, 8)).To(haveLen(1))
+		expect(s.At(path, 9)).To(haveLen(0))
+	})
+
+	o.Spec("it finds the next and previous diagnostics", func(expect expect.Expectation, s *diagnostic.Store) {
+		d, ok := s.Next(path, 5)
+		expect(ok).To(beTrue())
+		expect(d.Start).To(equal(20))
+
+		d, ok = s.Next(path, 20)
+		expect(ok).To(beTrue())
+		expect(d.Start).To(equal(5))
+
+		d, ok = s.Prev(path, 20)
+		expect(ok).To(beTrue())
+		expect(d.Start).To(equal(5))
+
+		d, ok = s.Prev(path, 5)
+		expect(ok).To(beTrue())
+		expect(d.Start).To(equal(20))
+
+		_, ok = s.Next("/tmp/bar.go", 0)
+		expect(ok).To(beFalse())
+	})
+
+	o.Spec("it moves diagnostics when text is edited", func(expect expect.Expectation, s *diagnostic.Store) {
+		// Insert three runes between the two diagnostics.
+		s.Move(path, 10, 0, 3)
+		diags := s.For(path)
+		expect(diags[0].Start).To(equal(5))
+		expect(diags[0].End).To(equal(8))
+		expect(diags[1].Start).To(equal(23))
+		expect(diags[1].End).To(equal(28))
+
+		// Remove text from inside of the second diagnostic through
+		// the end of it.
+		s.Move(path, 25, 5, 1)
+		diags = s.For(path)
+		expect(diags[1].Start).To(equal(23))
+		expect(diags[1].End).To(equal(26))
+	})
+}
+
+func TestOffset(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	o.BeforeEach(func(t *testing.T) expect.Expectation {
+		return expect.New(t)
+	})
+
+	src := []byte("package foo\n\n// ☃ snow\nvar x = 1\n")
+
+	o.Spec("it converts lines and byte columns to rune offsets", func(expect expect.Expectation) {
+		expect(diagnostic.Offset(src, 1, 1)).To(equal(0))
+		expect(diagnostic.Offset(src, 1, 9)).To(equal(8))
+		expect(diagnostic.Offset(src, 3, 4)).To(equal(len([]rune("package foo\n\n// "))))
+		expect(diagnostic.Offset(src, 3, 8)).To(equal(len([]rune("package foo\n\n// ☃ "))))
+	})
+
+	o.Spec("it clamps positions past the end of a line or the text", func(expect expect.Expectation) {
+		expect(diagnostic.Offset(src, 1, 100)).To(equal(len("package foo")))
+		expect(diagnostic.Offset(src, 100, 1)).To(equal(len([]rune(string(src)))))
+	})
+}
@@ -0,0 +1,216 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+// Package diff finds the differences between two sequences (usually
+// the lines of two versions of a file), using Myers' algorithm.
+package diff
+
+import "strings"
+
+// maxCost is the largest number of insertions and deletions that
+// Compute will search for before giving up and reporting the
+// remaining differences as a single Hunk.  It bounds the time and
+// memory spent on sequences that have almost nothing in common.
+const maxCost = 1000
+
+// A Hunk is a range of elements in the old sequence that was
+// replaced by a range of elements in the new sequence.  Starts are
+// 0-based indexes.  Either length may be zero, for pure insertions
+// and deletions.
+type Hunk struct {
+	OldStart, OldLen int
+	NewStart, NewLen int
+}
+
+// Kind returns the kind of change that h makes.
+func (h Hunk) Kind() Kind {
+	switch {
+	case h.OldLen == 0:
+		return Added
+	case h.NewLen == 0:
+		return Deleted
+	default:
+		return Modified
+	}
+}
+
+// Kind is the kind of change that a Hunk makes.
+type Kind int
+
+const (
+	Added Kind = iota
+	Modified
+	Deleted
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Modified:
+		return "modified"
+	case Deleted:
+		return "deleted"
+	default:
+		return "unknown"
+	}
+}
+
+// Compute returns the Hunks that turn an old sequence of n elements
+// into a new sequence of m elements, in order.  eq reports whether
+// old element i is equal to new element j.
+func Compute(n, m int, eq func(i, j int) bool) []Hunk {
+	pre := 0
+	for pre < n && pre < m && eq(pre, pre) {
+		pre++
+	}
+	suf := 0
+	for suf < n-pre && suf < m-pre && eq(n-1-suf, m-1-suf) {
+		suf++
+	}
+	hunks := myers(n-pre-suf, m-pre-suf, func(i, j int) bool {
+		return eq(i+pre, j+pre)
+	})
+	for i := range hunks {
+		hunks[i].OldStart += pre
+		hunks[i].NewStart += pre
+	}
+	return hunks
+}
+
+// Strings returns the Hunks that turn old into new.
+func Strings(old, new []string) []Hunk {
+	return Compute(len(old), len(new), func(i, j int) bool {
+		return old[i] == new[j]
+	})
+}
+
+// Runes returns the Hunks that turn old into new.
+func Runes(old, new []rune) []Hunk {
+	return Compute(len(old), len(new), func(i, j int) bool {
+		return old[i] == new[j]
+	})
+}
+
+// Lines returns the Hunks that turn the lines of old into the lines
+// of new.
+func Lines(old, new string) []Hunk {
+	return Strings(SplitLines(old), SplitLines(new))
+}
+
+// SplitLines splits s into lines, keeping the newline at the end of
+// each line so that joining them recreates s exactly.
+func SplitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func myers(n, m int, eq func(i, j int) bool) []Hunk {
+	if n == 0 && m == 0 {
+		return nil
+	}
+	if n == 0 || m == 0 {
+		return []Hunk{{OldLen: n, NewLen: m}}
+	}
+	max := n + m
+	off := max + 1
+	v := make([]int, 2*max+3)
+
+	// trace[d] holds the furthest x reached on each diagonal k in
+	// [-(d-1), d-1] before step d, which is all that is needed to
+	// walk back from the end.
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		if d > maxCost {
+			return []Hunk{{OldLen: n, NewLen: m}}
+		}
+		if d > 0 {
+			trace = append(trace, append([]int(nil), v[off-d+1:off+d]...))
+		} else {
+			trace = append(trace, nil)
+		}
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && eq(x, y) {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				return hunks(backtrack(trace, d, n, m), n, m)
+			}
+		}
+	}
+	return []Hunk{{OldLen: n, NewLen: m}}
+}
+
+type match struct {
+	i, j int
+}
+
+// backtrack walks back from (n, m) through trace, returning the
+// elements that matched in reverse order.
+func backtrack(trace [][]int, d, n, m int) []match {
+	var matches []match
+	x, y := n, m
+	for ; d > 0; d-- {
+		prev := func(k int) int {
+			return trace[d][k+d-1]
+		}
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && prev(k-1) < prev(k+1)) {
+			prevK = k + 1
+		}
+		prevX := prev(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			matches = append(matches, match{i: x, j: y})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		matches = append(matches, match{i: x, j: y})
+	}
+	return matches
+}
+
+// hunks converts the gaps between matches (in reverse order) into
+// Hunks.
+func hunks(matches []match, n, m int) []Hunk {
+	var hunks []Hunk
+	pi, pj := -1, -1
+	add := func(i, j int) {
+		if i > pi+1 || j > pj+1 {
+			hunks = append(hunks, Hunk{
+				OldStart: pi + 1,
+				OldLen:   i - pi - 1,
+				NewStart: pj + 1,
+				NewLen:   j - pj - 1,
+			})
+		}
+		pi, pj = i, j
+	}
+	for idx := len(matches) - 1; idx >= 0; idx-- {
+		add(matches[idx].i, matches[idx].j)
+	}
+	add(n, m)
+	return hunks
+}
@@ -0,0 +1,114 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package diff_test
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/nelsam/vidar/diff"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+// apply applies hunks to old, using new for the replacement
+// elements, returning the result.
+func apply(old, new []rune, hunks []diff.Hunk) []rune {
+	var result []rune
+	i := 0
+	for _, h := range hunks {
+		result = append(result, old[i:h.OldStart]...)
+		result = append(result, new[h.NewStart:h.NewStart+h.NewLen]...)
+		i = h.OldStart + h.OldLen
+	}
+	return append(result, old[i:]...)
+}
+
+// lcs returns the length of the longest common subsequence of a and
+// b.
+func lcs(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				cur[j+1] = prev[j] + 1
+			case prev[j+1] > cur[j]:
+				cur[j+1] = prev[j+1]
+			default:
+				cur[j+1] = cur[j]
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func TestDiff(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	o.BeforeEach(func(t *testing.T) expect.Expectation {
+		return expect.New(t)
+	})
+
+	o.Spec("it finds added, modified, and deleted lines", func(expect expect.Expectation) {
+		old := "a\nb\nc\nd\ne\n"
+		new := "a\nx\nb\nc\nD\n"
+		hunks := diff.Lines(old, new)
+		expect(hunks).To(equal([]diff.Hunk{
+			{OldStart: 1, OldLen: 0, NewStart: 1, NewLen: 1},
+			{OldStart: 3, OldLen: 2, NewStart: 4, NewLen: 1},
+		}))
+		expect(hunks[0].Kind()).To(equal(diff.Added))
+		expect(hunks[1].Kind()).To(equal(diff.Modified))
+
+		hunks = diff.Lines(old, "a\nd\ne\n")
+		expect(hunks).To(equal([]diff.Hunk{
+			{OldStart: 1, OldLen: 2, NewStart: 1, NewLen: 0},
+		}))
+		expect(hunks[0].Kind()).To(equal(diff.Deleted))
+	})
+
+	o.Spec("it handles empty sequences", func(expect expect.Expectation) {
+		expect(diff.Lines("", "")).To(haveLen(0))
+		expect(diff.Lines("", "a\nb\n")).To(equal([]diff.Hunk{{NewLen: 2}}))
+		expect(diff.Lines("a\n", "")).To(equal([]diff.Hunk{{OldLen: 1}}))
+	})
+
+	o.Spec("it keeps newlines when splitting lines", func(expect expect.Expectation) {
+		expect(diff.SplitLines("a\nb")).To(equal([]string{"a\n", "b"}))
+		expect(diff.SplitLines("a\n\n")).To(equal([]string{"a\n", "\n"}))
+	})
+
+	o.Spec("it finds minimal diffs", func(expect expect.Expectation) {
+		r := rand.New(rand.NewSource(1))
+		gen := func() []rune {
+			s := make([]rune, r.Intn(30))
+			for i := range s {
+				s[i] = rune('a' + r.Intn(4))
+			}
+			return s
+		}
+		for i := 0; i < 500; i++ {
+			old, new := gen(), gen()
+			hunks := diff.Runes(old, new)
+			expect(string(apply(old, new, hunks))).To(equal(string(new)))
+			cost := 0
+			for _, h := range hunks {
+				cost += h.OldLen + h.NewLen
+			}
+			expect(cost).To(equal(len(old) + len(new) - 2*lcs(old, new)))
+		}
+	})
+
+	o.Spec("it gives up on very different sequences", func(expect expect.Expectation) {
+		old := []rune(strings.Repeat("a", 2000))
+		new := []rune(strings.Repeat("b", 2000))
+		expect(diff.Runes(old, new)).To(equal([]diff.Hunk{{OldLen: 2000, NewLen: 2000}}))
+	})
+}
@@ -0,0 +1,175 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package diff
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// A LineDiff is the line by line difference between an old and a new
+// version of some text.
+type LineDiff struct {
+	Old, New []string
+	Hunks    []Hunk
+
+	// starts holds the rune offset of each line in New, plus the
+	// offset of the end of the text.
+	starts []int
+}
+
+// NewLineDiff returns the LineDiff between old and new.
+func NewLineDiff(old, new string) LineDiff {
+	d := LineDiff{
+		Old: SplitLines(old),
+		New: SplitLines(new),
+	}
+	d.Hunks = Strings(d.Old, d.New)
+	d.starts = make([]int, 0, len(d.New)+1)
+	offset := 0
+	for _, l := range d.New {
+		d.starts = append(d.starts, offset)
+		offset += utf8.RuneCountInString(l)
+	}
+	d.starts = append(d.starts, offset)
+	return d
+}
+
+// Line returns the index of the line in New that the rune offset is
+// on.
+func (d LineDiff) Line(offset int) int {
+	for i := 1; i < len(d.starts); i++ {
+		if offset < d.starts[i] {
+			return i - 1
+		}
+	}
+	if len(d.New) == 0 {
+		return 0
+	}
+	if last := d.New[len(d.New)-1]; last[len(last)-1] == '\n' {
+		// The offset is on the empty line after the final newline.
+		return len(d.New)
+	}
+	return len(d.New) - 1
+}
+
+// Offset returns the rune offset in New of the start of line.
+func (d LineDiff) Offset(line int) int {
+	if line >= len(d.starts) {
+		line = len(d.starts) - 1
+	}
+	return d.starts[line]
+}
+
+// Span returns the rune offsets in New of the start and end of the
+// lines that h changed, not including the final newline.  For a
+// deleted Hunk, both are the start of the line after the deletion.
+func (d LineDiff) Span(h Hunk) (start, end int) {
+	start = d.Offset(h.NewStart)
+	if h.NewLen == 0 {
+		return start, start
+	}
+	end = d.Offset(h.NewStart + h.NewLen)
+	if last := d.New[h.NewStart+h.NewLen-1]; last[len(last)-1] == '\n' {
+		end--
+	}
+	return start, end
+}
+
+// At returns the Hunk that touches line in New.  Deleted Hunks touch
+// the lines both before and after the deletion.
+func (d LineDiff) At(line int) (Hunk, bool) {
+	for _, h := range d.Hunks {
+		if h.NewLen == 0 {
+			if line == h.NewStart || line == h.NewStart-1 {
+				return h, true
+			}
+			continue
+		}
+		if line >= h.NewStart && line < h.NewStart+h.NewLen {
+			return h, true
+		}
+	}
+	return Hunk{}, false
+}
+
+// Next returns the first Hunk that starts after line, wrapping
+// around to the first Hunk if there are none after line.
+func (d LineDiff) Next(line int) (Hunk, bool) {
+	if len(d.Hunks) == 0 {
+		return Hunk{}, false
+	}
+	for _, h := range d.Hunks {
+		if h.NewStart > line {
+			return h, true
+		}
+	}
+	return d.Hunks[0], true
+}
+
+// Prev returns the last Hunk that ends before line, wrapping around
+// to the last Hunk if there are none before line.
+func (d LineDiff) Prev(line int) (Hunk, bool) {
+	if len(d.Hunks) == 0 {
+		return Hunk{}, false
+	}
+	for i := len(d.Hunks) - 1; i >= 0; i-- {
+		h := d.Hunks[i]
+		end := h.NewStart + h.NewLen - 1
+		if h.NewLen == 0 {
+			end = h.NewStart
+		}
+		if end < line {
+			return h, true
+		}
+	}
+	return d.Hunks[len(d.Hunks)-1], true
+}
+
+// Revert returns the edit that undoes h: the runes at offset at in
+// New that should be replaced, and what they should be replaced
+// with.
+func (d LineDiff) Revert(h Hunk) (at int, old, new []rune) {
+	at = d.Offset(h.NewStart)
+	for _, l := range d.New[h.NewStart : h.NewStart+h.NewLen] {
+		old = append(old, []rune(l)...)
+	}
+	for _, l := range d.Old[h.OldStart : h.OldStart+h.OldLen] {
+		new = append(new, []rune(l)...)
+	}
+	return at, old, new
+}
+
+// Apply returns Old with only h applied to it.
+func (d LineDiff) Apply(h Hunk) string {
+	var b strings.Builder
+	for _, l := range d.Old[:h.OldStart] {
+		b.WriteString(l)
+	}
+	for _, l := range d.New[h.NewStart : h.NewStart+h.NewLen] {
+		b.WriteString(l)
+	}
+	for _, l := range d.Old[h.OldStart+h.OldLen:] {
+		b.WriteString(l)
+	}
+	return b.String()
+}
+
+// OldLine returns the line in Old that line in New corresponds to.
+// Lines that a Hunk added or changed correspond to the start of the
+// lines that the Hunk replaced.
+func (d LineDiff) OldLine(line int) int {
+	shift := 0
+	for _, h := range d.Hunks {
+		if line < h.NewStart {
+			break
+		}
+		if line < h.NewStart+h.NewLen {
+			return h.OldStart
+		}
+		shift = (h.OldStart + h.OldLen) - (h.NewStart + h.NewLen)
+	}
+	return line + shift
+}
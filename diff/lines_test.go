@@ -0,0 +1,89 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/nelsam/vidar/diff"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+func TestLineDiff(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	const (
+		old = "a\nb\nc\nd\ne\n"
+		new = "a\nx\nb\nc\nD\n"
+	)
+
+	o.BeforeEach(func(t *testing.T) (expect.Expectation, diff.LineDiff) {
+		return expect.New(t), diff.NewLineDiff(old, new)
+	})
+
+	o.Spec("it converts between lines and offsets", func(expect expect.Expectation, d diff.LineDiff) {
+		expect(d.Line(0)).To(equal(0))
+		expect(d.Line(2)).To(equal(1))
+		expect(d.Line(3)).To(equal(1))
+		expect(d.Line(10)).To(equal(5))
+		expect(d.Offset(4)).To(equal(8))
+	})
+
+	o.Spec("it finds the span of a hunk", func(expect expect.Expectation, d diff.LineDiff) {
+		start, end := d.Span(d.Hunks[1])
+		expect(start).To(equal(8))
+		expect(end).To(equal(9))
+
+		del := diff.NewLineDiff("a\nb\nc\n", "a\nc\n")
+		start, end = del.Span(del.Hunks[0])
+		expect(start).To(equal(2))
+		expect(end).To(equal(2))
+	})
+
+	o.Spec("it finds hunks by line", func(expect expect.Expectation, d diff.LineDiff) {
+		h, ok := d.At(1)
+		expect(ok).To(beTrue())
+		expect(h).To(equal(d.Hunks[0]))
+		_, ok = d.At(2)
+		expect(ok).To(beFalse())
+
+		h, _ = d.Next(1)
+		expect(h).To(equal(d.Hunks[1]))
+		h, _ = d.Next(4)
+		expect(h).To(equal(d.Hunks[0]))
+		h, _ = d.Prev(4)
+		expect(h).To(equal(d.Hunks[0]))
+		h, _ = d.Prev(0)
+		expect(h).To(equal(d.Hunks[1]))
+	})
+
+	o.Spec("it reverts hunks", func(expect expect.Expectation, d diff.LineDiff) {
+		at, oldRunes, newRunes := d.Revert(d.Hunks[1])
+		expect(at).To(equal(8))
+		expect(string(oldRunes)).To(equal("D\n"))
+		expect(string(newRunes)).To(equal("d\ne\n"))
+
+		at, oldRunes, newRunes = d.Revert(d.Hunks[0])
+		expect(at).To(equal(2))
+		expect(string(oldRunes)).To(equal("x\n"))
+		expect(string(newRunes)).To(equal(""))
+	})
+
+	o.Spec("it applies single hunks", func(expect expect.Expectation, d diff.LineDiff) {
+		expect(d.Apply(d.Hunks[0])).To(equal("a\nx\nb\nc\nd\ne\n"))
+		expect(d.Apply(d.Hunks[1])).To(equal("a\nb\nc\nD\n"))
+	})
+
+	o.Spec("it maps lines back to old", func(expect expect.Expectation, d diff.LineDiff) {
+		expect(d.OldLine(0)).To(equal(0))
+		expect(d.OldLine(1)).To(equal(1))
+		expect(d.OldLine(2)).To(equal(1))
+		expect(d.OldLine(3)).To(equal(2))
+		expect(d.OldLine(4)).To(equal(3))
+		expect(d.OldLine(5)).To(equal(5))
+	})
+}
@@ -0,0 +1,111 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package diff
+
+import "strings"
+
+// Conflict markers are written around the lines that Merge could not
+// merge.
+const (
+	MineMarker      = "<<<<<<< mine\n"
+	SeparatorMarker = "=======\n"
+	TheirsMarker    = ">>>>>>> theirs\n"
+)
+
+// Merge performs a line by line three-way merge of the changes that
+// mine and theirs each made to base.  Changes that touch the same (or
+// neighbouring) lines of base are merged only if they made the same
+// change; otherwise both versions are written between conflict
+// markers, and counted in conflicts.
+func Merge(base, mine, theirs string) (merged string, conflicts int) {
+	b, m, t := SplitLines(base), SplitLines(mine), SplitLines(theirs)
+	mh, th := Strings(b, m), Strings(b, t)
+	var out strings.Builder
+	pos := 0
+	for len(mh) > 0 || len(th) > 0 {
+		var (
+			first  Hunk
+			mc, tc []Hunk
+		)
+		if len(th) == 0 || (len(mh) > 0 && mh[0].OldStart <= th[0].OldStart) {
+			first, mh = mh[0], mh[1:]
+			mc = []Hunk{first}
+		} else {
+			first, th = th[0], th[1:]
+			tc = []Hunk{first}
+		}
+		start, end := first.OldStart, first.OldStart+first.OldLen
+		for {
+			if len(mh) > 0 && mh[0].OldStart <= end {
+				mc = append(mc, mh[0])
+				end = maxInt(end, mh[0].OldStart+mh[0].OldLen)
+				mh = mh[1:]
+				continue
+			}
+			if len(th) > 0 && th[0].OldStart <= end {
+				tc = append(tc, th[0])
+				end = maxInt(end, th[0].OldStart+th[0].OldLen)
+				th = th[1:]
+				continue
+			}
+			break
+		}
+		writeLines(&out, b[pos:start])
+		mineText := applyChunk(b, m, mc, start, end)
+		theirText := applyChunk(b, t, tc, start, end)
+		switch {
+		case len(tc) == 0 || mineText == theirText:
+			out.WriteString(mineText)
+		case len(mc) == 0:
+			out.WriteString(theirText)
+		default:
+			conflicts++
+			out.WriteString(MineMarker)
+			writeTerminated(&out, mineText)
+			out.WriteString(SeparatorMarker)
+			writeTerminated(&out, theirText)
+			out.WriteString(TheirsMarker)
+		}
+		pos = end
+	}
+	writeLines(&out, b[pos:])
+	return out.String(), conflicts
+}
+
+// applyChunk returns the lines start through end of base, with hunks
+// (which changed base into changed) applied.
+func applyChunk(base, changed []string, hunks []Hunk, start, end int) string {
+	var b strings.Builder
+	cur := start
+	for _, h := range hunks {
+		writeLines(&b, base[cur:h.OldStart])
+		writeLines(&b, changed[h.NewStart:h.NewStart+h.NewLen])
+		cur = h.OldStart + h.OldLen
+	}
+	writeLines(&b, base[cur:end])
+	return b.String()
+}
+
+func writeLines(b *strings.Builder, lines []string) {
+	for _, l := range lines {
+		b.WriteString(l)
+	}
+}
+
+// writeTerminated writes s, adding a newline if s doesn't end with
+// one, so that a conflict marker after it starts on its own line.
+func writeTerminated(b *strings.Builder, s string) {
+	b.WriteString(s)
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		b.WriteString("\n")
+	}
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,61 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/nelsam/vidar/diff"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+func TestMerge(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	o.BeforeEach(func(t *testing.T) expect.Expectation {
+		return expect.New(t)
+	})
+
+	const base = "a\nb\nc\nd\ne\nf\n"
+
+	o.Spec("it merges changes to different lines", func(expect expect.Expectation) {
+		merged, conflicts := diff.Merge(base, "a\nB\nc\nd\ne\nf\n", "a\nb\nc\nd\nE\nf\ng\n")
+		expect(conflicts).To(equal(0))
+		expect(merged).To(equal("a\nB\nc\nd\nE\nf\ng\n"))
+	})
+
+	o.Spec("it keeps changes that only one side made", func(expect expect.Expectation) {
+		merged, conflicts := diff.Merge(base, base, "a\nc\nd\ne\nf\n")
+		expect(conflicts).To(equal(0))
+		expect(merged).To(equal("a\nc\nd\ne\nf\n"))
+	})
+
+	o.Spec("it merges identical changes once", func(expect expect.Expectation) {
+		merged, conflicts := diff.Merge(base, "a\nx\nc\nd\ne\nf\n", "a\nx\nc\nd\ne\nf\n")
+		expect(conflicts).To(equal(0))
+		expect(merged).To(equal("a\nx\nc\nd\ne\nf\n"))
+	})
+
+	o.Spec("it marks conflicting changes", func(expect expect.Expectation) {
+		merged, conflicts := diff.Merge(base, "a\nmine\nc\nd\ne\nf\n", "a\ntheirs\nc\nd\nE\nf\n")
+		expect(conflicts).To(equal(1))
+		expect(merged).To(equal("a\n" +
+			diff.MineMarker + "mine\n" +
+			diff.SeparatorMarker + "theirs\n" +
+			diff.TheirsMarker +
+			"c\nd\nE\nf\n"))
+	})
+
+	o.Spec("it terminates conflicts at the end of the text", func(expect expect.Expectation) {
+		merged, conflicts := diff.Merge("a\nb", "a\nmine", "a\ntheirs")
+		expect(conflicts).To(equal(1))
+		expect(merged).To(equal("a\n" +
+			diff.MineMarker + "mine\n" +
+			diff.SeparatorMarker + "theirs\n" +
+			diff.TheirsMarker))
+	})
+}
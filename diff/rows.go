@@ -0,0 +1,98 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package diff
+
+import "strings"
+
+// A Row is a line in a view of a LineDiff.  Left and Right are the
+// indexes of the lines in Old and New that are displayed on the
+// row, or -1 if the row has no line from that side.  Hunk is the
+// index of the Hunk in the LineDiff that the row belongs to, or -1
+// if the row is unchanged.
+type Row struct {
+	Left, Right int
+	Hunk        int
+}
+
+// A Span is a range of runes, from Start up to (but not including)
+// End.
+type Span struct {
+	Start, End int
+}
+
+// SideBySide returns the rows that display d with Old and New next
+// to each other.  Unchanged lines share a row, and so do the lines
+// that each Hunk changed, in order; whichever side of a Hunk is
+// shorter is padded with rows that have no line from that side, so
+// that the lines after the Hunk share rows again.
+func (d LineDiff) SideBySide() []Row {
+	var rows []Row
+	left, right := 0, 0
+	for i, h := range d.Hunks {
+		rows = d.unchanged(rows, left, right, h.OldStart)
+		for j := 0; j < h.OldLen || j < h.NewLen; j++ {
+			r := Row{Left: -1, Right: -1, Hunk: i}
+			if j < h.OldLen {
+				r.Left = h.OldStart + j
+			}
+			if j < h.NewLen {
+				r.Right = h.NewStart + j
+			}
+			rows = append(rows, r)
+		}
+		left, right = h.OldStart+h.OldLen, h.NewStart+h.NewLen
+	}
+	return d.unchanged(rows, left, right, len(d.Old))
+}
+
+// Inline returns the rows that display d as a single text.
+// Unchanged lines share a row, and each Hunk is displayed as the
+// lines that it removed from Old followed by the lines that it added
+// to New.
+func (d LineDiff) Inline() []Row {
+	var rows []Row
+	left, right := 0, 0
+	for i, h := range d.Hunks {
+		rows = d.unchanged(rows, left, right, h.OldStart)
+		for j := 0; j < h.OldLen; j++ {
+			rows = append(rows, Row{Left: h.OldStart + j, Right: -1, Hunk: i})
+		}
+		for j := 0; j < h.NewLen; j++ {
+			rows = append(rows, Row{Left: -1, Right: h.NewStart + j, Hunk: i})
+		}
+		left, right = h.OldStart+h.OldLen, h.NewStart+h.NewLen
+	}
+	return d.unchanged(rows, left, right, len(d.Old))
+}
+
+// unchanged appends rows for the unchanged lines from left and right
+// up to the line end in Old.
+func (d LineDiff) unchanged(rows []Row, left, right, end int) []Row {
+	for ; left < end; left, right = left+1, right+1 {
+		rows = append(rows, Row{Left: left, Right: right, Hunk: -1})
+	}
+	return rows
+}
+
+// Changed returns the runes in old and new that differ, for
+// highlighting the changes within a line that was modified.
+func Changed(old, new string) (oldSpans, newSpans []Span) {
+	o, n := []rune(old), []rune(new)
+	for _, h := range Runes(o, n) {
+		if h.OldLen > 0 {
+			oldSpans = append(oldSpans, Span{Start: h.OldStart, End: h.OldStart + h.OldLen})
+		}
+		if h.NewLen > 0 {
+			newSpans = append(newSpans, Span{Start: h.NewStart, End: h.NewStart + h.NewLen})
+		}
+	}
+	return oldSpans, newSpans
+}
+
+// TrimNewline returns line without its trailing newline, for
+// displaying lines returned by SplitLines.
+func TrimNewline(line string) string {
+	return strings.TrimSuffix(line, "\n")
+}
@@ -0,0 +1,61 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/nelsam/vidar/diff"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+func TestRows(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	o.BeforeEach(func(t *testing.T) (expect.Expectation, diff.LineDiff) {
+		return expect.New(t), diff.NewLineDiff("a\nb\nc\nd\ne\n", "a\nx\nb\nc\nD\n")
+	})
+
+	o.Spec("it lines up both sides", func(expect expect.Expectation, d diff.LineDiff) {
+		expect(d.SideBySide()).To(equal([]diff.Row{
+			{Left: 0, Right: 0, Hunk: -1},
+			{Left: -1, Right: 1, Hunk: 0},
+			{Left: 1, Right: 2, Hunk: -1},
+			{Left: 2, Right: 3, Hunk: -1},
+			{Left: 3, Right: 4, Hunk: 1},
+			{Left: 4, Right: -1, Hunk: 1},
+		}))
+	})
+
+	o.Spec("it interleaves changes inline", func(expect expect.Expectation, d diff.LineDiff) {
+		expect(d.Inline()).To(equal([]diff.Row{
+			{Left: 0, Right: 0, Hunk: -1},
+			{Left: -1, Right: 1, Hunk: 0},
+			{Left: 1, Right: 2, Hunk: -1},
+			{Left: 2, Right: 3, Hunk: -1},
+			{Left: 3, Right: -1, Hunk: 1},
+			{Left: 4, Right: -1, Hunk: 1},
+			{Left: -1, Right: 4, Hunk: 1},
+		}))
+	})
+
+	o.Spec("it has a row for every line when nothing changed", func(expect expect.Expectation, _ diff.LineDiff) {
+		d := diff.NewLineDiff("a\nb\n", "a\nb\n")
+		expect(d.SideBySide()).To(haveLen(2))
+		expect(d.Inline()).To(haveLen(2))
+	})
+
+	o.Spec("it finds changes within lines", func(expect expect.Expectation, _ diff.LineDiff) {
+		oldSpans, newSpans := diff.Changed("hello world", "hello there world")
+		expect(oldSpans).To(haveLen(0))
+		expect(newSpans).To(equal([]diff.Span{{Start: 6, End: 12}}))
+
+		oldSpans, newSpans = diff.Changed("x := 1", "x := 2")
+		expect(oldSpans).To(equal([]diff.Span{{Start: 5, End: 6}}))
+		expect(newSpans).To(equal([]diff.Span{{Start: 5, End: 6}}))
+	})
+}
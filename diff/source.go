@@ -0,0 +1,19 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package diff
+
+// A Source is one side of a comparison, e.g. an editor's buffer, a
+// file on disk, or a file at some revision.
+type Source struct {
+	// Name describes the source to the user.
+	Name string
+
+	// Load returns the current text of the source.
+	Load func() (string, error)
+
+	// Save, if non-nil, replaces the text of the source.  Sources
+	// without Save are read-only.
+	Save func(string) error
+}
@@ -0,0 +1,134 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package dlv
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/nelsam/vidar/atomicfile"
+)
+
+// A FileLine is a line of a file, as it is saved.  Line is 1-based,
+// to match delve.
+type FileLine struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// Breakpoints is the set of breakpoints in a project's files, saved
+// to a file so that they survive restarts.  A Breakpoints is safe to
+// use from multiple goroutines.
+type Breakpoints struct {
+	path string
+
+	mu    sync.Mutex
+	lines map[string]map[int]struct{}
+}
+
+// LoadBreakpoints loads the breakpoints saved at path.  If nothing
+// has been saved at path, the returned *Breakpoints is empty.
+func LoadBreakpoints(path string) (*Breakpoints, error) {
+	b := &Breakpoints{path: path, lines: make(map[string]map[int]struct{})}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var saved []FileLine
+	if err := json.NewDecoder(f).Decode(&saved); err != nil {
+		return nil, err
+	}
+	for _, l := range saved {
+		b.add(l.File, l.Line)
+	}
+	return b, nil
+}
+
+func (b *Breakpoints) add(file string, line int) {
+	lines, ok := b.lines[file]
+	if !ok {
+		lines = make(map[int]struct{})
+		b.lines[file] = lines
+	}
+	lines[line] = struct{}{}
+}
+
+// Toggle sets a breakpoint at line (1-based) of file if there isn't
+// one, or removes it if there is, and saves the result.  It returns
+// whether a breakpoint is now set at line.
+func (b *Breakpoints) Toggle(file string, line int) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	set := true
+	if _, ok := b.lines[file][line]; ok {
+		set = false
+		delete(b.lines[file], line)
+		if len(b.lines[file]) == 0 {
+			delete(b.lines, file)
+		}
+	} else {
+		b.add(file, line)
+	}
+	return set, b.save()
+}
+
+// Lines returns the lines (1-based) of file that have breakpoints,
+// in order.
+func (b *Breakpoints) Lines(file string) []int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var lines []int
+	for l := range b.lines[file] {
+		lines = append(lines, l)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// All returns every breakpoint, sorted by file and then line.
+func (b *Breakpoints) All() []FileLine {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.all()
+}
+
+func (b *Breakpoints) all() []FileLine {
+	var all []FileLine
+	for f, lines := range b.lines {
+		for l := range lines {
+			all = append(all, FileLine{File: f, Line: l})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].File != all[j].File {
+			return all[i].File < all[j].File
+		}
+		return all[i].Line < all[j].Line
+	})
+	return all
+}
+
+func (b *Breakpoints) save() error {
+	dir := filepath.Dir(b.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	all := b.all()
+	if all == nil {
+		all = []FileLine{}
+	}
+	buf, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(b.path, buf, 0600)
+}
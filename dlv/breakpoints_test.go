@@ -0,0 +1,73 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package dlv_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nelsam/vidar/dlv"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+func TestBreakpoints(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	o.BeforeEach(func(t *testing.T) (expect.Expectation, string) {
+		dir, err := ioutil.TempDir("", "vidar-dlv")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return expect.New(t), filepath.Join(dir, "breakpoints", "project.json")
+	})
+
+	o.AfterEach(func(_ expect.Expectation, path string) {
+		os.RemoveAll(filepath.Dir(filepath.Dir(path)))
+	})
+
+	o.Spec("it starts empty when nothing has been saved", func(expect expect.Expectation, path string) {
+		b, err := dlv.LoadBreakpoints(path)
+		expect(err).To(beNil())
+		expect(b.All()).To(haveLen(0))
+	})
+
+	o.Spec("it toggles breakpoints on and off", func(expect expect.Expectation, path string) {
+		b, err := dlv.LoadBreakpoints(path)
+		expect(err).To(beNil())
+
+		set, err := b.Toggle("/foo/foo.go", 12)
+		expect(err).To(beNil())
+		expect(set).To(beTrue())
+		set, err = b.Toggle("/foo/foo.go", 3)
+		expect(err).To(beNil())
+		expect(set).To(beTrue())
+		expect(b.Lines("/foo/foo.go")).To(equal([]int{3, 12}))
+
+		set, err = b.Toggle("/foo/foo.go", 12)
+		expect(err).To(beNil())
+		expect(set).To(beFalse())
+		expect(b.Lines("/foo/foo.go")).To(equal([]int{3}))
+	})
+
+	o.Spec("it saves breakpoints across loads", func(expect expect.Expectation, path string) {
+		b, err := dlv.LoadBreakpoints(path)
+		expect(err).To(beNil())
+		_, err = b.Toggle("/foo/foo.go", 12)
+		expect(err).To(beNil())
+		_, err = b.Toggle("/bar/bar.go", 1)
+		expect(err).To(beNil())
+
+		loaded, err := dlv.LoadBreakpoints(path)
+		expect(err).To(beNil())
+		expect(loaded.All()).To(equal([]dlv.FileLine{
+			{File: "/bar/bar.go", Line: 1},
+			{File: "/foo/foo.go", Line: 12},
+		}))
+	})
+}
@@ -0,0 +1,264 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package dlv
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"time"
+)
+
+// Names of delve's commands for moving through the debugged process.
+const (
+	cmdContinue = "continue"
+	cmdNext     = "next"
+	cmdStep     = "step"
+	cmdStepOut  = "stepOut"
+	cmdHalt     = "halt"
+)
+
+const dialRetry = 100 * time.Millisecond
+
+// Client is a client for delve's JSON-RPC API.  Its methods may be
+// called from any goroutine, and Halt may be called while another
+// command is waiting for the process to stop.
+type Client struct {
+	rpc *rpc.Client
+}
+
+// NewClient returns a *Client that talks to delve over conn.
+func NewClient(conn io.ReadWriteCloser) *Client {
+	return &Client{rpc: jsonrpc.NewClient(conn)}
+}
+
+// Dial connects to the delve server listening at addr.  Since a
+// delve server has to build the program before it starts listening,
+// Dial keeps trying until it connects or ctx is done.
+func Dial(ctx context.Context, addr string) (*Client, error) {
+	var d net.Dialer
+	for {
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			return NewClient(conn), nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(dialRetry):
+		}
+	}
+}
+
+// FreeAddr returns a local address that nothing is listening on,
+// for a delve server to listen on.
+func FreeAddr() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer l.Close()
+	return l.Addr().String(), nil
+}
+
+func (c *Client) call(method string, in, out interface{}) error {
+	return c.rpc.Call("RPCServer."+method, in, out)
+}
+
+// Close closes c's connection, leaving the delve server running.
+func (c *Client) Close() error {
+	return c.rpc.Close()
+}
+
+type createBreakpointIn struct {
+	Breakpoint Breakpoint
+}
+
+type createBreakpointOut struct {
+	Breakpoint Breakpoint
+}
+
+// CreateBreakpoint sets a breakpoint at line (1-based) of file.
+func (c *Client) CreateBreakpoint(file string, line int) (Breakpoint, error) {
+	var out createBreakpointOut
+	err := c.call("CreateBreakpoint", createBreakpointIn{Breakpoint: Breakpoint{File: file, Line: line}}, &out)
+	return out.Breakpoint, err
+}
+
+type clearBreakpointIn struct {
+	Id int
+}
+
+type clearBreakpointOut struct {
+	Breakpoint *Breakpoint
+}
+
+// ClearBreakpoint removes the breakpoint with the passed in ID.
+func (c *Client) ClearBreakpoint(id int) error {
+	var out clearBreakpointOut
+	return c.call("ClearBreakpoint", clearBreakpointIn{Id: id}, &out)
+}
+
+type listBreakpointsIn struct{}
+
+type listBreakpointsOut struct {
+	Breakpoints []*Breakpoint
+}
+
+// Breakpoints returns the breakpoints that are set.  Delve sets some
+// breakpoints of its own (e.g. for unrecovered panics); they have
+// negative IDs.
+func (c *Client) Breakpoints() ([]Breakpoint, error) {
+	var out listBreakpointsOut
+	if err := c.call("ListBreakpoints", listBreakpointsIn{}, &out); err != nil {
+		return nil, err
+	}
+	bps := make([]Breakpoint, 0, len(out.Breakpoints))
+	for _, bp := range out.Breakpoints {
+		bps = append(bps, *bp)
+	}
+	return bps, nil
+}
+
+type debuggerCommand struct {
+	Name string `json:"name"`
+}
+
+type commandOut struct {
+	State State
+}
+
+func (c *Client) command(name string) (State, error) {
+	var out commandOut
+	err := c.call("Command", debuggerCommand{Name: name}, &out)
+	return out.State, err
+}
+
+// Continue resumes the process, returning once it stops.
+func (c *Client) Continue() (State, error) {
+	return c.command(cmdContinue)
+}
+
+// Next steps over the current line, returning once the process
+// stops.
+func (c *Client) Next() (State, error) {
+	return c.command(cmdNext)
+}
+
+// Step steps into the current line, returning once the process
+// stops.
+func (c *Client) Step() (State, error) {
+	return c.command(cmdStep)
+}
+
+// StepOut steps out of the current function, returning once the
+// process stops.
+func (c *Client) StepOut() (State, error) {
+	return c.command(cmdStepOut)
+}
+
+// Halt stops the process if it is running.
+func (c *Client) Halt() (State, error) {
+	return c.command(cmdHalt)
+}
+
+type stateIn struct {
+	NonBlocking bool
+}
+
+type stateOut struct {
+	State State
+}
+
+// State returns the current state of the debugger, without waiting
+// for a running process to stop.
+func (c *Client) State() (State, error) {
+	var out stateOut
+	err := c.call("State", stateIn{NonBlocking: true}, &out)
+	return out.State, err
+}
+
+type listGoroutinesIn struct {
+	Start, Count int
+}
+
+type listGoroutinesOut struct {
+	Goroutines []*Goroutine
+	Nextg      int
+}
+
+// Goroutines returns every goroutine in the process.
+func (c *Client) Goroutines() ([]Goroutine, error) {
+	var out listGoroutinesOut
+	if err := c.call("ListGoroutines", listGoroutinesIn{}, &out); err != nil {
+		return nil, err
+	}
+	gs := make([]Goroutine, 0, len(out.Goroutines))
+	for _, g := range out.Goroutines {
+		gs = append(gs, *g)
+	}
+	return gs, nil
+}
+
+type stacktraceIn struct {
+	Id    int64
+	Depth int
+}
+
+type stacktraceOut struct {
+	Locations []Stackframe
+}
+
+// Stacktrace returns up to depth frames of the call stack of the
+// goroutine with the passed in ID, innermost first.
+func (c *Client) Stacktrace(goroutine int64, depth int) ([]Stackframe, error) {
+	var out stacktraceOut
+	err := c.call("Stacktrace", stacktraceIn{Id: goroutine, Depth: depth}, &out)
+	return out.Locations, err
+}
+
+type varsIn struct {
+	Scope EvalScope
+	Cfg   LoadConfig
+}
+
+type localVarsOut struct {
+	Variables []Variable
+}
+
+type functionArgsOut struct {
+	Args []Variable
+}
+
+// Locals returns the arguments and local variables of frame (0
+// being the innermost) of the goroutine with the passed in ID,
+// arguments first.
+func (c *Client) Locals(goroutine int64, frame int) ([]Variable, error) {
+	in := varsIn{Scope: EvalScope{GoroutineID: goroutine, Frame: frame}, Cfg: DefaultLoadConfig}
+	var args functionArgsOut
+	if err := c.call("ListFunctionArgs", in, &args); err != nil {
+		return nil, err
+	}
+	var locals localVarsOut
+	if err := c.call("ListLocalVars", in, &locals); err != nil {
+		return nil, err
+	}
+	return append(args.Args, locals.Variables...), nil
+}
+
+type detachIn struct {
+	Kill bool
+}
+
+type detachOut struct{}
+
+// Detach detaches delve from the process, killing the process if
+// kill is true, and stops the delve server.
+func (c *Client) Detach(kill bool) error {
+	var out detachOut
+	return c.call("Detach", detachIn{Kill: kill}, &out)
+}
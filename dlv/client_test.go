@@ -0,0 +1,235 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package dlv_test
+
+import (
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"testing"
+
+	"github.com/nelsam/vidar/dlv"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+// The types and methods below stand in for delve's RPCServer; they
+// only have the fields that the client sends.
+
+type BreakpointIn struct {
+	Breakpoint dlv.Breakpoint
+}
+
+type BreakpointOut struct {
+	Breakpoint dlv.Breakpoint
+}
+
+type ClearIn struct {
+	Id int
+}
+
+type CommandIn struct {
+	Name string `json:"name"`
+}
+
+type StateOut struct {
+	State dlv.State
+}
+
+type GoroutinesIn struct {
+	Start, Count int
+}
+
+type GoroutinesOut struct {
+	Goroutines []*dlv.Goroutine
+}
+
+type StacktraceIn struct {
+	Id    int64
+	Depth int
+}
+
+type StacktraceOut struct {
+	Locations []dlv.Stackframe
+}
+
+type VarsIn struct {
+	Scope dlv.EvalScope
+}
+
+type ArgsOut struct {
+	Args []dlv.Variable
+}
+
+type LocalsOut struct {
+	Variables []dlv.Variable
+}
+
+type DetachIn struct {
+	Kill bool
+}
+
+type Empty struct{}
+
+type fakeServer struct {
+	breakpoints []dlv.Breakpoint
+	cleared     []int
+	commands    []string
+	stacks      []StacktraceIn
+	scopes      []dlv.EvalScope
+	killed      bool
+
+	state dlv.State
+}
+
+func (f *fakeServer) CreateBreakpoint(in BreakpointIn, out *BreakpointOut) error {
+	bp := in.Breakpoint
+	bp.ID = len(f.breakpoints) + 1
+	f.breakpoints = append(f.breakpoints, bp)
+	out.Breakpoint = bp
+	return nil
+}
+
+func (f *fakeServer) ClearBreakpoint(in ClearIn, out *BreakpointOut) error {
+	f.cleared = append(f.cleared, in.Id)
+	return nil
+}
+
+func (f *fakeServer) Command(in CommandIn, out *StateOut) error {
+	f.commands = append(f.commands, in.Name)
+	out.State = f.state
+	return nil
+}
+
+func (f *fakeServer) State(in Empty, out *StateOut) error {
+	out.State = f.state
+	return nil
+}
+
+func (f *fakeServer) ListGoroutines(in GoroutinesIn, out *GoroutinesOut) error {
+	out.Goroutines = []*dlv.Goroutine{{ID: 1}, {ID: 7}}
+	return nil
+}
+
+func (f *fakeServer) Stacktrace(in StacktraceIn, out *StacktraceOut) error {
+	f.stacks = append(f.stacks, in)
+	out.Locations = []dlv.Stackframe{
+		{Location: dlv.Location{File: "/foo/foo.go", Line: 12, Function: &dlv.Function{Name: "foo.Bar"}}},
+		{Location: dlv.Location{File: "/foo/foo_test.go", Line: 30}},
+	}
+	return nil
+}
+
+func (f *fakeServer) ListFunctionArgs(in VarsIn, out *ArgsOut) error {
+	f.scopes = append(f.scopes, in.Scope)
+	out.Args = []dlv.Variable{{Name: "x", Type: "int", Value: "3"}}
+	return nil
+}
+
+func (f *fakeServer) ListLocalVars(in VarsIn, out *LocalsOut) error {
+	out.Variables = []dlv.Variable{{Name: "s", Type: "string", Value: "hi"}}
+	return nil
+}
+
+func (f *fakeServer) Detach(in DetachIn, out *Empty) error {
+	f.killed = in.Kill
+	return nil
+}
+
+func TestClient(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	o.BeforeEach(func(t *testing.T) (expect.Expectation, *fakeServer, *dlv.Client) {
+		f := &fakeServer{}
+		s := rpc.NewServer()
+		if err := s.RegisterName("RPCServer", f); err != nil {
+			t.Fatal(err)
+		}
+		srv, cli := net.Pipe()
+		go s.ServeCodec(jsonrpc.NewServerCodec(srv))
+		return expect.New(t), f, dlv.NewClient(cli)
+	})
+
+	o.AfterEach(func(_ expect.Expectation, _ *fakeServer, c *dlv.Client) {
+		c.Close()
+	})
+
+	o.Spec("it creates and clears breakpoints", func(expect expect.Expectation, server *fakeServer, client *dlv.Client) {
+		bp, err := client.CreateBreakpoint("/foo/foo.go", 12)
+		expect(err).To(beNil())
+		expect(bp.ID).To(equal(1))
+		expect(server.breakpoints).To(haveLen(1))
+		expect(server.breakpoints[0].File).To(equal("/foo/foo.go"))
+		expect(server.breakpoints[0].Line).To(equal(12))
+
+		expect(client.ClearBreakpoint(bp.ID)).To(beNil())
+		expect(server.cleared).To(equal([]int{1}))
+	})
+
+	o.Spec("it sends each stepping command by name", func(expect expect.Expectation, server *fakeServer, client *dlv.Client) {
+		server.state = dlv.State{
+			CurrentThread: &dlv.Thread{File: "/foo/foo.go", Line: 12, GoroutineID: 7},
+		}
+		for _, f := range []func() (dlv.State, error){
+			client.Continue,
+			client.Next,
+			client.Step,
+			client.StepOut,
+			client.Halt,
+		} {
+			s, err := f()
+			expect(err).To(beNil())
+			loc, ok := s.Location()
+			expect(ok).To(beTrue())
+			expect(loc.File).To(equal("/foo/foo.go"))
+			expect(loc.Line).To(equal(12))
+			expect(s.GoroutineID()).To(equal(int64(7)))
+		}
+		expect(server.commands).To(equal([]string{"continue", "next", "step", "stepOut", "halt"}))
+	})
+
+	o.Spec("it reports a process that has exited as having no location", func(expect expect.Expectation, server *fakeServer, client *dlv.Client) {
+		server.state = dlv.State{Exited: true, ExitStatus: 1}
+		s, err := client.Continue()
+		expect(err).To(beNil())
+		expect(s.Exited).To(beTrue())
+		expect(s.ExitStatus).To(equal(1))
+		_, ok := s.Location()
+		expect(ok).To(beFalse())
+	})
+
+	o.Spec("it lists goroutines", func(expect expect.Expectation, server *fakeServer, client *dlv.Client) {
+		gs, err := client.Goroutines()
+		expect(err).To(beNil())
+		expect(gs).To(haveLen(2))
+		expect(gs[1].ID).To(equal(int64(7)))
+	})
+
+	o.Spec("it loads the call stack of a goroutine", func(expect expect.Expectation, server *fakeServer, client *dlv.Client) {
+		frames, err := client.Stacktrace(7, 20)
+		expect(err).To(beNil())
+		expect(server.stacks).To(equal([]StacktraceIn{{Id: 7, Depth: 20}}))
+		expect(frames).To(haveLen(2))
+		expect(frames[0].FunctionName()).To(equal("foo.Bar"))
+		expect(frames[1].FunctionName()).To(equal(""))
+	})
+
+	o.Spec("it loads arguments before local variables", func(expect expect.Expectation, server *fakeServer, client *dlv.Client) {
+		vars, err := client.Locals(7, 1)
+		expect(err).To(beNil())
+		expect(server.scopes).To(equal([]dlv.EvalScope{{GoroutineID: 7, Frame: 1}}))
+		expect(vars).To(haveLen(2))
+		expect(vars[0].Name).To(equal("x"))
+		expect(vars[0].String()).To(equal("3"))
+		expect(vars[1].Name).To(equal("s"))
+		expect(vars[1].String()).To(equal(`"hi"`))
+	})
+
+	o.Spec("it detaches, killing the process", func(expect expect.Expectation, server *fakeServer, client *dlv.Client) {
+		expect(client.Detach(true)).To(beNil())
+		expect(server.killed).To(beTrue())
+	})
+}
@@ -0,0 +1,10 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+// Package dlv talks to a headless delve debugger over its JSON-RPC
+// (version 2) API, and keeps track of the breakpoints that are set
+// in a project's files.
+//
+// Like the swap package, this package does not import any UI code.
+package dlv
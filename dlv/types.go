@@ -0,0 +1,155 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package dlv
+
+import (
+	"fmt"
+	"strings"
+)
+
+// These types mirror the parts of delve's service/api types that
+// vidar uses.  Fields that vidar doesn't need are left out; the
+// JSON decoder skips them.
+
+// A Breakpoint is a breakpoint that is set in the debugged process.
+type Breakpoint struct {
+	ID           int    `json:"id"`
+	File         string `json:"file"`
+	Line         int    `json:"line"`
+	FunctionName string `json:"functionName,omitempty"`
+}
+
+// A Function is a function in the debugged process.
+type Function struct {
+	Name string `json:"name"`
+}
+
+// A Location is a position in the debugged process's source.  Line
+// is 1-based.
+type Location struct {
+	PC       uint64    `json:"pc"`
+	File     string    `json:"file"`
+	Line     int       `json:"line"`
+	Function *Function `json:"function,omitempty"`
+}
+
+// FunctionName returns the name of the function that l is in, or ""
+// if it is unknown.
+func (l Location) FunctionName() string {
+	if l.Function == nil {
+		return ""
+	}
+	return l.Function.Name
+}
+
+// A Thread is a thread in the debugged process.
+type Thread struct {
+	ID          int         `json:"id"`
+	File        string      `json:"file"`
+	Line        int         `json:"line"`
+	Function    *Function   `json:"function,omitempty"`
+	GoroutineID int64       `json:"goroutineID"`
+	Breakpoint  *Breakpoint `json:"breakPoint,omitempty"`
+}
+
+// A Goroutine is a goroutine in the debugged process.
+// UserCurrentLoc is the location of the topmost frame that isn't in
+// the runtime.
+type Goroutine struct {
+	ID             int64    `json:"id"`
+	CurrentLoc     Location `json:"currentLoc"`
+	UserCurrentLoc Location `json:"userCurrentLoc"`
+	ThreadID       int      `json:"threadID"`
+}
+
+// A State is the state of the debugger after a command.
+type State struct {
+	Running           bool       `json:"Running"`
+	CurrentThread     *Thread    `json:"currentThread,omitempty"`
+	SelectedGoroutine *Goroutine `json:"currentGoroutine,omitempty"`
+	Exited            bool       `json:"exited"`
+	ExitStatus        int        `json:"exitStatus"`
+}
+
+// Location returns the location that s stopped at.  The returned
+// bool is false if the process isn't stopped somewhere in its
+// source.
+func (s State) Location() (Location, bool) {
+	if s.Exited || s.CurrentThread == nil || s.CurrentThread.File == "" {
+		return Location{}, false
+	}
+	t := s.CurrentThread
+	return Location{File: t.File, Line: t.Line, Function: t.Function}, true
+}
+
+// GoroutineID returns the ID of the goroutine that s stopped in, or
+// 0 if there isn't one.
+func (s State) GoroutineID() int64 {
+	if s.SelectedGoroutine != nil {
+		return s.SelectedGoroutine.ID
+	}
+	if s.CurrentThread != nil {
+		return s.CurrentThread.GoroutineID
+	}
+	return 0
+}
+
+// A Stackframe is a single frame of a goroutine's call stack.
+type Stackframe struct {
+	Location
+	Err string `json:"Err,omitempty"`
+}
+
+// A Variable is a variable (or one of the fields or elements of a
+// variable) in the debugged process.
+type Variable struct {
+	Name       string     `json:"name"`
+	Type       string     `json:"type"`
+	Value      string     `json:"value"`
+	Len        int64      `json:"len"`
+	Children   []Variable `json:"children"`
+	Unreadable string     `json:"unreadable,omitempty"`
+}
+
+// String returns a short description of v's value.
+func (v Variable) String() string {
+	switch {
+	case v.Unreadable != "":
+		return fmt.Sprintf("(unreadable: %s)", v.Unreadable)
+	case v.Value != "":
+		if strings.HasPrefix(v.Type, "string") {
+			return fmt.Sprintf("%q", v.Value)
+		}
+		return v.Value
+	case len(v.Children) > 0 || v.Len > 0:
+		return fmt.Sprintf("%s (len %d)", v.Type, v.Len)
+	default:
+		return v.Type
+	}
+}
+
+// LoadConfig decides how much of a variable delve loads.
+type LoadConfig struct {
+	FollowPointers     bool
+	MaxVariableRecurse int
+	MaxStringLen       int
+	MaxArrayValues     int
+	MaxStructFields    int
+}
+
+// DefaultLoadConfig is the LoadConfig used for locals.
+var DefaultLoadConfig = LoadConfig{
+	FollowPointers:     true,
+	MaxVariableRecurse: 1,
+	MaxStringLen:       64,
+	MaxArrayValues:     64,
+	MaxStructFields:    -1,
+}
+
+// EvalScope is the goroutine and frame that variables are read from.
+type EvalScope struct {
+	GoroutineID int64
+	Frame       int
+}
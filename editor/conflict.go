@@ -0,0 +1,51 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package editor
+
+import "time"
+
+// A Conflict is a change that was written to an editor's file on disk
+// while the editor had unsaved changes.
+type Conflict struct {
+	// Base is the text that was last loaded from or saved to disk
+	// before the file changed.
+	Base string
+
+	// Theirs is the text that was written to disk.
+	Theirs string
+
+	// ModTime is the modification time of the file once Theirs was
+	// written.
+	ModTime time.Time
+}
+
+// OnConflict sets a callback that is called on the UI goroutine when
+// e's file changes on disk while e has unsaved changes.  It is not
+// called again for further changes on disk until the conflict is
+// resolved.
+func (e *CodeEditor) OnConflict(callback func()) {
+	e.onConflict = callback
+}
+
+// Conflict returns the conflict between e's text and its file on
+// disk, if there is one.
+func (e *CodeEditor) Conflict() (Conflict, bool) {
+	if e.conflict == nil {
+		return Conflict{}, false
+	}
+	return *e.conflict, true
+}
+
+// ResolveConflict marks the conflict between e's text and its file
+// on disk as resolved, after e's text has been updated to keep
+// whichever changes should be kept.  The text written to disk becomes
+// the text that HasChanges compares against, and e may once again
+// save over the file.
+func (e *CodeEditor) ResolveConflict() {
+	if e.conflict == nil {
+		return
+	}
+	e.setBase(e.conflict.Theirs, e.conflict.ModTime)
+}
@@ -0,0 +1,464 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/gxui/math"
+	"github.com/nelsam/gxui/mixins"
+	"github.com/nelsam/gxui/themes/basic"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/diff"
+	"github.com/nelsam/vidar/theme"
+)
+
+const diffOverlayName = "diff"
+
+var diffLineNumberColor = gxui.Gray60
+
+// DiffView is a text.Editor that displays the differences between
+// two diff.Sources, either side by side (with the two sides scrolling
+// together) or inline.  Both sides are read-only; changes are moved
+// from one side to the other with MoveChange, which saves the
+// changed side to its Source.
+type DiffView struct {
+	mixins.SplitterLayout
+
+	driver gxui.Driver
+	theme  *basic.Theme
+	window gxui.Window
+
+	path        string
+	left, right diff.Source
+	leftText    string
+	rightText   string
+	diff        diff.LineDiff
+	rows        []diff.Row
+	inline      bool
+
+	lhs, rhs, unified *CodeEditor
+	active            *CodeEditor
+
+	// scroll is the scroll position that lhs and rhs were last
+	// synced to.
+	scroll math.Point
+}
+
+// NewDiffView returns a *DiffView, using path as its Filepath, that
+// compares left and right.  Refresh must be called to load the
+// sources.
+func NewDiffView(driver gxui.Driver, window gxui.Window, theme *basic.Theme, syntaxTheme theme.Theme, font gxui.Font, path string, left, right diff.Source) *DiffView {
+	v := &DiffView{
+		driver: driver,
+		theme:  theme,
+		window: window,
+		path:   path,
+		left:   left,
+		right:  right,
+	}
+	v.SplitterLayout.Init(v, theme)
+	v.SetOrientation(gxui.Horizontal)
+	v.lhs = v.newSide(syntaxTheme, font)
+	v.rhs = v.newSide(syntaxTheme, font)
+	v.unified = v.newSide(syntaxTheme, font)
+	v.AddChild(v.lhs)
+	v.AddChild(v.rhs)
+	v.active = v.rhs
+	return v
+}
+
+func (v *DiffView) newSide(syntaxTheme theme.Theme, font gxui.Font) *CodeEditor {
+	e := &CodeEditor{}
+	e.initView(v.driver, v.theme, syntaxTheme, font)
+	e.SetTabWidth(4)
+	e.SetReadOnly(true)
+	e.OnGainedFocus(func() {
+		v.active = e
+	})
+	return e
+}
+
+func (v *DiffView) CreateSplitterBar() gxui.Control {
+	b := NewSplitterBar(v.window.Viewport(), v.theme)
+	b.SetOrientation(v.Orientation())
+	b.OnSplitterDragged(func(wndPnt math.Point) { v.SplitterDragged(b, wndPnt) })
+	return b
+}
+
+// SetSources replaces the sources that v compares.  Refresh must be
+// called to load them.
+func (v *DiffView) SetSources(left, right diff.Source) {
+	v.left = left
+	v.right = right
+}
+
+// Sources returns the sources that v compares.
+func (v *DiffView) Sources() (left, right diff.Source) {
+	return v.left, v.right
+}
+
+// Refresh loads both sources and displays the differences between
+// them.  It must be called on the UI goroutine.
+func (v *DiffView) Refresh() error {
+	left, right, err := v.load()
+	if err != nil {
+		return err
+	}
+	v.leftText = left
+	v.rightText = right
+	v.render()
+	return nil
+}
+
+func (v *DiffView) load() (left, right string, err error) {
+	left, err = v.left.Load()
+	if err != nil {
+		return "", "", fmt.Errorf("could not load %s: %s", v.left.Name, err)
+	}
+	right, err = v.right.Load()
+	if err != nil {
+		return "", "", fmt.Errorf("could not load %s: %s", v.right.Name, err)
+	}
+	return left, right, nil
+}
+
+// Inline returns whether v displays both sides as a single text.
+func (v *DiffView) Inline() bool {
+	return v.inline
+}
+
+// ToggleLayout switches v between side by side and inline layouts.
+func (v *DiffView) ToggleLayout() {
+	focused := v.HasFocus()
+	v.inline = !v.inline
+	v.RemoveAll()
+	if v.inline {
+		v.AddChild(v.unified)
+		v.active = v.unified
+	} else {
+		v.AddChild(v.lhs)
+		v.AddChild(v.rhs)
+		v.active = v.rhs
+	}
+	v.render()
+	if focused {
+		gxui.SetFocus(v.active)
+	}
+}
+
+// MoveChange copies the change under the caret from one side to the
+// other: from left to right if toRight is true, otherwise from right
+// to left.  The side that changes is saved to its Source.  If either
+// source has changed since v last loaded it, v is refreshed instead
+// and an error is returned, since the change under the caret may no
+// longer be the one that was displayed.
+func (v *DiffView) MoveChange(toRight bool) error {
+	target := v.left
+	if toRight {
+		target = v.right
+	}
+	if target.Save == nil {
+		return fmt.Errorf("%s is read-only", target.Name)
+	}
+	left, right, err := v.load()
+	if err != nil {
+		return err
+	}
+	if left != v.leftText || right != v.rightText {
+		v.leftText = left
+		v.rightText = right
+		v.render()
+		return errors.New("the files changed since the diff was loaded; try again")
+	}
+	ctrl := v.active.Controller()
+	row := ctrl.LineIndex(ctrl.FirstCaret())
+	if row < 0 || row >= len(v.rows) || v.rows[row].Hunk < 0 {
+		return errors.New("there is no change under the caret")
+	}
+	h := v.diff.Hunks[v.rows[row].Hunk]
+	if !toRight {
+		return v.save(target, v.diff.Apply(h))
+	}
+	runes := []rune(v.rightText)
+	at, old, repl := v.diff.Revert(h)
+	newText := string(runes[:at]) + string(repl) + string(runes[at+len(old):])
+	return v.save(target, newText)
+}
+
+func (v *DiffView) save(target diff.Source, contents string) error {
+	if err := target.Save(contents); err != nil {
+		return fmt.Errorf("could not save %s: %s", target.Name, err)
+	}
+	return v.Refresh()
+}
+
+func (v *DiffView) render() {
+	v.diff = diff.NewLineDiff(v.leftText, v.rightText)
+	if v.inline {
+		v.rows = v.diff.Inline()
+		v.renderInline()
+		return
+	}
+	v.rows = v.diff.SideBySide()
+	v.renderSide(v.lhs, true)
+	v.renderSide(v.rhs, false)
+}
+
+// diffLayers collects the spans for the layers of a diff overlay.
+type diffLayers map[theme.LanguageConstruct][]text.Span
+
+func (l diffLayers) add(c theme.LanguageConstruct, start, end int) {
+	l[c] = append(l[c], text.Span{Start: start, End: end})
+}
+
+func (l diffLayers) addChanged(offset int, spans []diff.Span) {
+	for _, s := range spans {
+		l.add(theme.DiffChangedText, offset+s.Start, offset+s.End)
+	}
+}
+
+func (l diffLayers) layers() []text.SyntaxLayer {
+	layers := make([]text.SyntaxLayer, 0, len(l))
+	for c, spans := range l {
+		layers = append(layers, text.SyntaxLayer{Construct: c, Spans: spans})
+	}
+	return layers
+}
+
+// renderSide displays one side of a side by side diff in e, with
+// blank lines wherever the other side has lines that this side
+// doesn't.
+func (v *DiffView) renderSide(e *CodeEditor, left bool) {
+	lines, changed := v.diff.New, theme.DiffInserted
+	if left {
+		lines, changed = v.diff.Old, theme.DiffRemoved
+	}
+	var (
+		buf         []rune
+		annotations []text.Annotation
+	)
+	layers := make(diffLayers)
+	for i, r := range v.rows {
+		if i > 0 {
+			buf = append(buf, '\n')
+		}
+		start := len(buf)
+		idx, other := r.Right, r.Left
+		if left {
+			idx, other = r.Left, r.Right
+		}
+		if idx < 0 {
+			layers.add(theme.DiffFiller, start, start)
+			continue
+		}
+		line := []rune(diff.TrimNewline(lines[idx]))
+		buf = append(buf, line...)
+		annotations = append(annotations, text.Annotation{
+			Line:  i,
+			Text:  strconv.Itoa(idx + 1),
+			Color: diffLineNumberColor,
+		})
+		switch {
+		case r.Hunk < 0:
+		case other < 0:
+			layers.add(changed, start, len(buf))
+		default:
+			layers.add(theme.DiffChanged, start, len(buf))
+			oldSpans, newSpans := diff.Changed(diff.TrimNewline(v.diff.Old[r.Left]), diff.TrimNewline(v.diff.New[r.Right]))
+			if left {
+				layers.addChanged(start, oldSpans)
+				continue
+			}
+			layers.addChanged(start, newSpans)
+		}
+	}
+	e.SetText(string(buf))
+	e.SetAnnotations(annotations)
+	e.SetOverlay(diffOverlayName, layers.layers())
+}
+
+// renderInline displays both sides of the diff in v.unified, with
+// the lines that each Hunk removed followed by the lines that it
+// added.
+func (v *DiffView) renderInline() {
+	type line struct {
+		start int
+		text  string
+	}
+	var (
+		buf         []rune
+		annotations []text.Annotation
+	)
+	layers := make(diffLayers)
+	removed := make(map[int][]line)
+	added := make(map[int][]line)
+	for i, r := range v.rows {
+		if i > 0 {
+			buf = append(buf, '\n')
+		}
+		start := len(buf)
+		oldNum, newNum, marker := "", "", " "
+		var l string
+		switch {
+		case r.Right < 0:
+			l = diff.TrimNewline(v.diff.Old[r.Left])
+			oldNum, marker = strconv.Itoa(r.Left+1), "-"
+			removed[r.Hunk] = append(removed[r.Hunk], line{start: start, text: l})
+		case r.Left < 0:
+			l = diff.TrimNewline(v.diff.New[r.Right])
+			newNum, marker = strconv.Itoa(r.Right+1), "+"
+			added[r.Hunk] = append(added[r.Hunk], line{start: start, text: l})
+		default:
+			l = diff.TrimNewline(v.diff.New[r.Right])
+			oldNum, newNum = strconv.Itoa(r.Left+1), strconv.Itoa(r.Right+1)
+		}
+		buf = append(buf, []rune(l)...)
+		switch marker {
+		case "-":
+			layers.add(theme.DiffRemoved, start, len(buf))
+		case "+":
+			layers.add(theme.DiffInserted, start, len(buf))
+		}
+		annotations = append(annotations, text.Annotation{
+			Line:  i,
+			Text:  fmt.Sprintf("%4s %4s %s", oldNum, newNum, marker),
+			Color: diffLineNumberColor,
+		})
+	}
+	// Lines that a Hunk changed are paired up in order, the same
+	// way they are in the side by side layout.
+	for h, rem := range removed {
+		add := added[h]
+		for i := 0; i < len(rem) && i < len(add); i++ {
+			oldSpans, newSpans := diff.Changed(rem[i].text, add[i].text)
+			layers.addChanged(rem[i].start, oldSpans)
+			layers.addChanged(add[i].start, newSpans)
+		}
+	}
+	v.unified.SetText(string(buf))
+	v.unified.SetAnnotations(annotations)
+	v.unified.SetOverlay(diffOverlayName, layers.layers())
+}
+
+func (v *DiffView) Paint(c gxui.Canvas) {
+	v.SplitterLayout.Paint(c)
+	if !v.inline {
+		v.syncScroll()
+	}
+}
+
+// syncScroll scrolls whichever side of a side by side diff has not
+// been scrolled to match the side that has.
+func (v *DiffView) syncScroll() {
+	l := scrollPoint(v.lhs)
+	r := scrollPoint(v.rhs)
+	if l == v.scroll && r == v.scroll {
+		return
+	}
+	from, to := v.lhs, v.rhs
+	if r != v.scroll && (l == v.scroll || v.active == v.rhs) {
+		from, to = v.rhs, v.lhs
+	}
+	p := scrollPoint(from)
+	v.scroll = p
+	v.driver.Call(func() {
+		to.SetScrollOffset(p.Y)
+		to.SetHorizOffset(p.X)
+	})
+}
+
+func scrollPoint(e *CodeEditor) math.Point {
+	return math.Point{X: e.HorizOffset(), Y: e.ScrollOffset()}
+}
+
+func (v *DiffView) Filepath() string {
+	return v.path
+}
+
+func (v *DiffView) Text() string {
+	return v.active.Text()
+}
+
+func (v *DiffView) Runes() []rune {
+	return v.active.Runes()
+}
+
+// SetText does nothing; the text of a DiffView comes from its
+// sources.
+func (v *DiffView) SetText(string) {
+}
+
+func (v *DiffView) SyntaxLayers() []text.SyntaxLayer {
+	return nil
+}
+
+// SetSyntaxLayers does nothing; a DiffView is highlighted by the
+// differences between its sources.
+func (v *DiffView) SetSyntaxLayers([]text.SyntaxLayer) {
+}
+
+// Controller returns the controller for the side of v that was most
+// recently focused.
+func (v *DiffView) Controller() *gxui.TextBoxController {
+	return v.active.Controller()
+}
+
+// LineStart returns the rune offset of the start of line in the side
+// of v that was most recently focused.
+func (v *DiffView) LineStart(line int) int {
+	return v.active.LineStart(line)
+}
+
+func (v *DiffView) Elements() []interface{} {
+	return []interface{}{v.active.Controller()}
+}
+
+func (v *DiffView) KeyPress(event gxui.KeyboardEvent) bool {
+	return v.active.KeyPress(event)
+}
+
+func (v *DiffView) KeyDown(event gxui.KeyboardEvent) {
+	v.active.KeyDown(event)
+}
+
+func (v *DiffView) KeyUp(event gxui.KeyboardEvent) {
+	v.active.KeyUp(event)
+}
+
+func (v *DiffView) KeyStroke(event gxui.KeyStrokeEvent) bool {
+	return v.active.KeyStroke(event)
+}
+
+func (v *DiffView) KeyRepeat(event gxui.KeyboardEvent) {
+	v.active.KeyRepeat(event)
+}
+
+func (v *DiffView) IsFocusable() bool {
+	return v.active.IsFocusable()
+}
+
+func (v *DiffView) HasFocus() bool {
+	return v.active.HasFocus()
+}
+
+func (v *DiffView) GainedFocus() {
+	v.active.GainedFocus()
+}
+
+func (v *DiffView) LostFocus() {
+	v.active.LostFocus()
+}
+
+func (v *DiffView) OnGainedFocus(callback func()) gxui.EventSubscription {
+	return v.active.OnGainedFocus(callback)
+}
+
+func (v *DiffView) OnLostFocus(callback func()) gxui.EventSubscription {
+	return v.active.OnLostFocus(callback)
+}
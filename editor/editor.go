@@ -19,6 +19,7 @@ import (
 	"github.com/nelsam/gxui/math"
 	"github.com/nelsam/gxui/mixins"
 	"github.com/nelsam/gxui/themes/basic"
+	"github.com/nelsam/vidar/command/multicursor"
 	"github.com/nelsam/vidar/commander/text"
 	"github.com/nelsam/vidar/fsw"
 	"github.com/nelsam/vidar/theme"
@@ -33,20 +34,63 @@ type CodeEditor struct {
 
 	lock         sync.RWMutex
 	lastModified time.Time
-	hasChanges   bool
 	filepath     string
 
+	// base is the text that was last loaded from or saved to disk,
+	// and conflict is set when the file changes on disk while the
+	// text differs from base.
+	base       string
+	conflict   *Conflict
+	onConflict func()
+
 	watcher fsw.Watcher
 
 	selections      []gxui.TextSelection
 	scrollPositions math.Point
 	layers          []text.SyntaxLayer
+	overlays        map[string][]text.SyntaxLayer
+	underlines      []underline
+	gutter          map[int]gxui.Color
+
+	// annotations are displayed in a side gutter, each padded to
+	// annotationWidth runes.
+	annotations     map[int]text.Annotation
+	annotationWidth int
+
+	// boxing is true while a box selection is being dragged out
+	// from boxAnchor.
+	boxing    bool
+	boxAnchor int
+
+	// readOnly editors only handle keys that move around the
+	// text.
+	readOnly bool
 
 	renamed  bool
 	onRename func(newPath string)
+
+	onGutterClick func(line int)
 }
 
 func (e *CodeEditor) Init(driver gxui.Driver, theme *basic.Theme, syntaxTheme theme.Theme, font gxui.Font, file, headerText string) {
+	e.initView(driver, theme, syntaxTheme, font)
+	e.watcherSetup()
+
+	// TODO: move to hooks on the input.Handler
+	e.OnTextChanged(func(changes []gxui.TextBoxEdit) {
+		// Annotations describe the lines as they were when the
+		// annotations were set, so any edit makes them stale.
+		if len(e.annotations) > 0 {
+			e.SetAnnotations(nil)
+		}
+	})
+	e.filepath = file
+	e.open(headerText)
+}
+
+// initView initializes e without a file, for editors that only
+// display text (e.g. the sides of a DiffView).
+func (e *CodeEditor) initView(driver gxui.Driver, theme *basic.Theme, syntaxTheme theme.Theme, font gxui.Font) {
 	e.theme = theme
 	e.syntaxTheme = syntaxTheme
 	e.driver = driver
@@ -55,14 +99,6 @@ func (e *CodeEditor) Init(driver gxui.Driver, theme *basic.Theme, syntaxTheme th
 	e.CodeEditor.SetScrollBarEnabled(true)
 	e.CodeEditor.SetScrollRound(true)
 	e.SetDesiredWidth(math.MaxSize.W)
-	e.watcherSetup()
-
-	// TODO: move to hooks on the input.Handler
-	e.OnTextChanged(func(changes []gxui.TextBoxEdit) {
-		e.hasChanges = true
-	})
-	e.filepath = file
-	e.open(headerText)
 
 	e.SetTextColor(theme.TextBoxDefaultStyle.FontColor)
 	e.SetMargin(math.Spacing{L: 3, T: 3, R: 3, B: 3})
@@ -70,6 +106,17 @@ func (e *CodeEditor) Init(driver gxui.Driver, theme *basic.Theme, syntaxTheme th
 	e.SetBorderPen(gxui.TransparentPen)
 }
 
+// SetReadOnly sets whether e ignores typing.  Commands that edit
+// the text directly are not prevented from doing so.
+func (e *CodeEditor) SetReadOnly(readOnly bool) {
+	e.readOnly = readOnly
+}
+
+// ReadOnly returns whether e ignores typing.
+func (e *CodeEditor) ReadOnly() bool {
+	return e.readOnly
+}
+
 func (e *CodeEditor) DataChanged(recreate bool) {
 	e.List.DataChanged(recreate)
 }
@@ -94,6 +141,12 @@ func (e *CodeEditor) OnRename(callback func(newPath string)) {
 	e.onRename = callback
 }
 
+// OnGutterClick sets callback to be called with the (0-based) line
+// whose line number is clicked.
+func (e *CodeEditor) OnGutterClick(callback func(line int)) {
+	e.onGutterClick = callback
+}
+
 func (e *CodeEditor) open(headerText string) {
 	go e.watch()
 	e.load(headerText)
@@ -188,7 +241,13 @@ func (e *CodeEditor) load(headerText string) {
 	f, err := os.Open(e.filepath)
 	if os.IsNotExist(err) {
 		e.driver.Call(func() {
+			if e.HasChanges() {
+				// Saving the unsaved changes will recreate the
+				// file, so they're kept.
+				return
+			}
 			e.SetText(headerText)
+			e.setBase("", time.Time{})
 		})
 		return
 	}
@@ -202,7 +261,6 @@ func (e *CodeEditor) load(headerText string) {
 		log.Printf("Error stating file %s: %s", e.filepath, err)
 		return
 	}
-	e.setLastModified(finfo.ModTime())
 	b, err := ioutil.ReadAll(f)
 	if err != nil {
 		log.Printf("Error reading file %s: %s", e.filepath, err)
@@ -212,19 +270,46 @@ func (e *CodeEditor) load(headerText string) {
 	if !strings.HasPrefix(newText, headerText) {
 		log.Printf("%s: header text does not match requested header text", e.filepath)
 	}
+	mtime := finfo.ModTime()
 	e.driver.Call(func() {
-		if e.Text() == newText {
-			return
-		}
-		e.SetText(newText)
-		if len(e.selections) > 0 {
-			e.restorePositions()
+		switch {
+		case e.Text() == newText:
+			e.setBase(newText, mtime)
+		case newText == e.base:
+			// The file was touched, but its contents are what we
+			// last loaded or saved.
+			e.setLastModified(mtime)
+		case e.HasChanges():
+			// Reloading would throw away the unsaved changes, so
+			// LastKnownMTime is left alone (which keeps the file
+			// from being saved over) until the conflict is resolved.
+			pending := e.conflict != nil
+			e.conflict = &Conflict{Base: e.base, Theirs: newText, ModTime: mtime}
+			if !pending && e.onConflict != nil {
+				e.onConflict()
+			}
+		default:
+			e.SetText(newText)
+			e.setBase(newText, mtime)
+			if len(e.selections) > 0 {
+				e.restorePositions()
+			}
 		}
 	})
 }
 
+// setBase records text as the contents of the file on disk as of
+// mtime.
+func (e *CodeEditor) setBase(text string, mtime time.Time) {
+	e.base = text
+	e.conflict = nil
+	e.setLastModified(mtime)
+}
+
+// HasChanges returns whether e's text differs from the text that was
+// last loaded from or saved to disk.
 func (e *CodeEditor) HasChanges() bool {
-	return e.hasChanges
+	return e.Text() != e.base
 }
 
 func (e *CodeEditor) LastKnownMTime() time.Time {
@@ -244,8 +329,7 @@ func (e *CodeEditor) Filepath() string {
 }
 
 func (e *CodeEditor) FlushedChanges() {
-	e.hasChanges = false
-	e.setLastModified(time.Now())
+	e.setBase(e.Text(), time.Now())
 }
 
 func (e *CodeEditor) Elements() []interface{} {
@@ -255,11 +339,128 @@ func (e *CodeEditor) Elements() []interface{} {
 }
 
 func (e *CodeEditor) SetSyntaxLayers(layers []text.SyntaxLayer) {
-	defer e.syntaxTheme.Rainbow.Reset()
+	sortLayers(layers)
+	e.layers = layers
+	e.refreshLayers()
+}
+
+// SetOverlay sets the layers for the overlay called name.  Overlays
+// are displayed on top of the editor's syntax layers, but are not
+// returned by SyntaxLayers, so that plugins (e.g. diagnostics) can
+// highlight text without needing to coordinate with syntax
+// highlighting plugins.  A nil or empty layers removes the overlay.
+func (e *CodeEditor) SetOverlay(name string, layers []text.SyntaxLayer) {
+	if e.overlays == nil {
+		e.overlays = make(map[string][]text.SyntaxLayer)
+	}
+	if len(layers) == 0 {
+		delete(e.overlays, name)
+	} else {
+		sortLayers(layers)
+		e.overlays[name] = layers
+	}
+	e.refreshLayers()
+}
+
+// Overlay returns the layers for the overlay called name.
+func (e *CodeEditor) Overlay(name string) []text.SyntaxLayer {
+	return e.overlays[name]
+}
+
+// SetAnnotations sets the annotations to display in the side
+// gutter, replacing any that were already displayed.  The gutter is
+// removed when annotations is empty.  Annotations are removed as
+// soon as the text changes.
+func (e *CodeEditor) SetAnnotations(annotations []text.Annotation) {
+	e.annotations = make(map[int]text.Annotation, len(annotations))
+	e.annotationWidth = 0
+	for _, a := range annotations {
+		e.annotations[a.Line] = a
+		if w := len([]rune(a.Text)); w > e.annotationWidth {
+			e.annotationWidth = w
+		}
+	}
+	e.DataChanged(true)
+}
+
+// Annotations returns the annotations displayed in the side gutter,
+// in line order.
+func (e *CodeEditor) Annotations() []text.Annotation {
+	annotations := make([]text.Annotation, 0, len(e.annotations))
+	for _, a := range e.annotations {
+		annotations = append(annotations, a)
+	}
+	sort.Slice(annotations, func(i, j int) bool {
+		return annotations[i].Line < annotations[j].Line
+	})
+	return annotations
+}
+
+// clickAnnotation calls the OnClick func of the annotation at p, if
+// there is one.
+func (e *CodeEditor) clickAnnotation(p math.Point) bool {
+	if e.annotationWidth == 0 {
+		return false
+	}
+	width := e.Padding().L + e.Font().GlyphMaxSize().W*e.annotationWidth
+	if p.X >= width {
+		return false
+	}
+	idx, ok := e.RuneIndexAt(p)
+	if !ok {
+		return false
+	}
+	a, ok := e.annotations[e.Controller().LineIndex(idx)]
+	if !ok || a.OnClick == nil {
+		return false
+	}
+	a.OnClick()
+	return true
+}
+
+// clickGutter calls the OnGutterClick callback with the line whose
+// line number is at p, if there is one.
+func (e *CodeEditor) clickGutter(p math.Point) bool {
+	if e.onGutterClick == nil {
+		return false
+	}
+	glyph := e.Font().GlyphMaxSize().W
+	left := e.Padding().L
+	if e.annotationWidth > 0 {
+		left += glyph*e.annotationWidth + gutterWidth
+	}
+	if p.X < left || p.X >= left+glyph*len("0000")+gutterWidth {
+		return false
+	}
+	idx, ok := e.RuneIndexAt(p)
+	if !ok {
+		return false
+	}
+	e.onGutterClick(e.Controller().LineIndex(idx))
+	return true
+}
+
+func sortLayers(layers []text.SyntaxLayer) {
 	sort.Slice(layers, func(i, j int) bool {
 		return layers[i].Construct < layers[j].Construct
 	})
-	e.layers = layers
+}
+
+func (e *CodeEditor) refreshLayers() {
+	defer e.syntaxTheme.Rainbow.Reset()
+
+	names := make([]string, 0, len(e.overlays))
+	for name := range e.overlays {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	layers := append([]text.SyntaxLayer(nil), e.layers...)
+	for _, name := range names {
+		layers = append(layers, e.overlays[name]...)
+	}
+
+	e.underlines = nil
+	e.gutter = make(map[int]gxui.Color)
 	gLayers := make(gxui.CodeSyntaxLayers, 0, len(layers))
 	for _, l := range layers {
 		highlight, found := e.syntaxTheme.Constructs[l.Construct]
@@ -267,9 +468,24 @@ func (e *CodeEditor) SetSyntaxLayers(layers []text.SyntaxLayer) {
 			highlight = e.syntaxTheme.Rainbow.Next()
 			e.syntaxTheme.Constructs[l.Construct] = highlight
 		}
+		if highlight.Underline.A > 0 {
+			for _, s := range l.Spans {
+				e.underlines = append(e.underlines, underline{Span: s, color: gxui.Color(highlight.Underline)})
+			}
+		}
+		if highlight.Gutter.A > 0 {
+			e.markGutter(l.Spans, gxui.Color(highlight.Gutter))
+		}
+		if highlight.Foreground.A == 0 && highlight.Background.A == 0 {
+			continue
+		}
 		gLayer := gxui.CreateCodeSyntaxLayer()
-		gLayer.SetColor(gxui.Color(highlight.Foreground))
-		gLayer.SetBackgroundColor(gxui.Color(highlight.Background))
+		if highlight.Foreground.A > 0 {
+			gLayer.SetColor(gxui.Color(highlight.Foreground))
+		}
+		if highlight.Background.A > 0 {
+			gLayer.SetBackgroundColor(gxui.Color(highlight.Background))
+		}
 		for _, s := range l.Spans {
 			gLayer.Add(s.Start, s.End-s.Start)
 		}
@@ -278,6 +494,21 @@ func (e *CodeEditor) SetSyntaxLayers(layers []text.SyntaxLayer) {
 	e.CodeEditor.SetSyntaxLayers(gLayers)
 }
 
+// markGutter marks the gutter for each line that spans are on.
+// Lines that are already marked keep their color, so that lower
+// (usually more severe) constructs take precedence.
+func (e *CodeEditor) markGutter(spans []text.Span, color gxui.Color) {
+	ctrl := e.Controller()
+	for _, s := range spans {
+		last := ctrl.LineIndex(s.End)
+		for line := ctrl.LineIndex(s.Start); line <= last; line++ {
+			if _, ok := e.gutter[line]; !ok {
+				e.gutter[line] = color
+			}
+		}
+	}
+}
+
 func (e *CodeEditor) SyntaxLayers() []text.SyntaxLayer {
 	return e.layers
 }
@@ -316,6 +547,11 @@ func (e *CodeEditor) KeyPress(event gxui.KeyboardEvent) bool {
 	case gxui.KeyPageUp, gxui.KeyPageDown:
 		// These are all bindings that the TextBox handles fine.
 		return e.TextBox.KeyPress(event)
+	}
+	if e.readOnly {
+		return false
+	}
+	switch event.Key {
 	case gxui.KeyTab:
 		// TODO: Gain knowledge about scope, so we know how much to indent.
 		switch {
@@ -333,6 +569,50 @@ func (e *CodeEditor) KeyPress(event gxui.KeyboardEvent) bool {
 	return false
 }
 
+// MouseDown starts a box selection when the left button is pressed
+// while alt is held, passes clicks in the gutter to their callbacks,
+// and otherwise leaves the event to the TextBox.
+func (e *CodeEditor) MouseDown(event gxui.MouseEvent) {
+	if event.Button == gxui.MouseButtonLeft && event.Modifier == 0 && (e.clickAnnotation(event.Point) || e.clickGutter(event.Point)) {
+		return
+	}
+	if event.Button != gxui.MouseButtonLeft || !event.Modifier.Alt() {
+		e.CodeEditor.MouseDown(event)
+		return
+	}
+	idx, ok := e.RuneIndexAt(event.Point)
+	if !ok {
+		e.CodeEditor.MouseDown(event)
+		return
+	}
+	e.boxing = true
+	e.boxAnchor = idx
+	e.Controller().SetCaret(idx)
+	var sub gxui.EventSubscription
+	sub = event.Window.OnMouseUp(func(gxui.MouseEvent) {
+		e.boxing = false
+		e.storePositions()
+		sub.Unlisten()
+	})
+}
+
+// MouseMove updates the box selection, if one is being dragged.
+func (e *CodeEditor) MouseMove(event gxui.MouseEvent) {
+	if !e.boxing {
+		e.CodeEditor.MouseMove(event)
+		return
+	}
+	idx, ok := e.RuneIndexAt(event.Point)
+	if !ok {
+		return
+	}
+	var sel []gxui.TextSelection
+	for _, s := range multicursor.Box(e.Controller().TextRunes(), e.boxAnchor, idx) {
+		sel = append(sel, gxui.CreateTextSelection(s.Start, s.End, false))
+	}
+	e.Controller().SetSelections(sel)
+}
+
 func (e *CodeEditor) KeyStroke(event gxui.KeyStrokeEvent) (consume bool) {
 	return false
 }
@@ -340,13 +620,23 @@ func (e *CodeEditor) KeyStroke(event gxui.KeyStrokeEvent) (consume bool) {
 func (e *CodeEditor) CreateLine(theme gxui.Theme, index int) (mixins.TextBoxLine, gxui.Control) {
 	lineNumber := theme.CreateLabel()
 	lineNumber.SetText(fmt.Sprintf("%4d", index+1))
-	lineNumber.SetMargin(math.Spacing{L: 0, T: 0, R: 3, B: 0})
+	lineNumber.SetMargin(math.Spacing{L: 0, T: 0, R: gutterWidth, B: 0})
 
-	line := &mixins.CodeEditorLine{}
+	line := &line{editor: e, index: index}
 	line.Init(line, theme, &e.CodeEditor, index)
 
-	layout := theme.CreateLinearLayout()
+	layout := &lineLayout{editor: e, index: index, number: lineNumber}
+	layout.Init(layout, theme)
 	layout.SetDirection(gxui.LeftToRight)
+	if e.annotationWidth > 0 {
+		a := e.annotations[index]
+		layout.annotation = theme.CreateLabel()
+		layout.annotation.SetFont(e.Font())
+		layout.annotation.SetColor(a.Color)
+		layout.annotation.SetText(fmt.Sprintf("%-*s", e.annotationWidth, a.Text))
+		layout.annotation.SetMargin(math.Spacing{R: gutterWidth})
+		layout.AddChild(layout.annotation)
+	}
 	layout.AddChild(lineNumber)
 	layout.AddChild(line)
 
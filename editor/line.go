@@ -0,0 +1,92 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package editor
+
+import (
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/gxui/math"
+	"github.com/nelsam/gxui/mixins"
+	"github.com/nelsam/vidar/commander/text"
+)
+
+const (
+	// gutterWidth is the width of the gutter between line numbers
+	// and text.
+	gutterWidth = 3
+
+	underlineHeight = 2
+)
+
+type underline struct {
+	text.Span
+	color gxui.Color
+}
+
+// line is a line of text in a CodeEditor.  It draws any underlines
+// from the editor's layers on top of the text.
+type line struct {
+	mixins.CodeEditorLine
+
+	editor *CodeEditor
+	index  int
+}
+
+func (l *line) Paint(c gxui.Canvas) {
+	l.CodeEditorLine.Paint(c)
+	if len(l.editor.underlines) == 0 {
+		return
+	}
+
+	ctrl := l.editor.Controller()
+	start, end := ctrl.LineStart(l.index), ctrl.LineEnd(l.index)
+	minWidth := l.editor.Font().GlyphMaxSize().W
+	bottom := l.Size().H
+	for _, u := range l.editor.underlines {
+		if u.End < start || u.Start > end || (u.End == start && u.Start < start) {
+			continue
+		}
+		s, e := u.Start, u.End
+		if s < start {
+			s = start
+		}
+		if e > end {
+			e = end
+		}
+		left, right := l.PositionAt(s).X, l.PositionAt(e).X
+		if right-left < minWidth {
+			// Empty spans still need to be visible.
+			right = left + minWidth
+		}
+		r := math.CreateRect(left, bottom-underlineHeight, right, bottom)
+		c.DrawRect(r, gxui.CreateBrush(u.color))
+	}
+}
+
+// lineLayout lays out a line number next to its line, and marks
+// the gutter between them if the editor's layers have a gutter
+// color for the line.  If the editor has annotations, they are laid
+// out before the line number.
+type lineLayout struct {
+	mixins.LinearLayout
+
+	editor     *CodeEditor
+	index      int
+	annotation gxui.Label
+	number     gxui.Label
+}
+
+func (l *lineLayout) Paint(c gxui.Canvas) {
+	l.LinearLayout.Paint(c)
+	color, ok := l.editor.gutter[l.index]
+	if !ok {
+		return
+	}
+	left := l.number.Size().W
+	if l.annotation != nil {
+		left += l.annotation.Size().W + gutterWidth
+	}
+	r := math.CreateRect(left, 0, left+gutterWidth, l.Size().H)
+	c.DrawRect(r, gxui.CreateBrush(color))
+}
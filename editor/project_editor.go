@@ -8,7 +8,9 @@ import (
 	"github.com/nelsam/gxui"
 	"github.com/nelsam/gxui/mixins"
 	"github.com/nelsam/gxui/themes/basic"
+	"github.com/nelsam/vidar/command/focus"
 	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/diff"
 	"github.com/nelsam/vidar/setting"
 	"github.com/nelsam/vidar/theme"
 )
@@ -42,6 +44,24 @@ func (p *ProjectEditor) Open(path string) (e text.Editor, existed bool) {
 	return p.SplitEditor.Open(p.project.Path, path, p.project.LicenseHeader(), p.project.Environ())
 }
 
+// OpenDiff opens a DiffView comparing left and right, using path as
+// its Filepath.  If a DiffView for path is already open, its sources
+// are replaced and it is focused.
+func (p *ProjectEditor) OpenDiff(path string, left, right diff.Source) error {
+	v, ok := p.EditorFor(path).(*DiffView)
+	if !ok {
+		v = NewDiffView(p.driver, p.window, p.theme, p.syntaxTheme, p.font, path, left, right)
+		p.Add(relPath(p.project.Path, path), v)
+	}
+	v.SetSources(left, right)
+	if err := v.Refresh(); err != nil {
+		return err
+	}
+	opener := p.cmdr.Bindable("focus-location").(Opener)
+	p.cmdr.Execute(opener.For(focus.Path(path)))
+	return nil
+}
+
 func (p *ProjectEditor) Project() setting.Project {
 	return p.project
 }
@@ -109,6 +129,26 @@ func (e *MultiProjectEditor) CurrentProject() setting.Project {
 	return e.current.Project()
 }
 
+// EditorFor returns the open editor for path, or nil if path is not
+// open in any project.
+func (e *MultiProjectEditor) EditorFor(path string) text.Editor {
+	if ed := e.current.EditorFor(path); ed != nil {
+		return ed
+	}
+	for _, p := range e.projects {
+		if ed := p.EditorFor(path); ed != nil {
+			return ed
+		}
+	}
+	return nil
+}
+
+// OpenDiff opens a DiffView in the current project.  See
+// ProjectEditor.OpenDiff.
+func (e *MultiProjectEditor) OpenDiff(path string, left, right diff.Source) error {
+	return e.current.OpenDiff(path, left, right)
+}
+
 func (e *MultiProjectEditor) Open(file string) (ed text.Editor, existed bool) {
 	return e.current.Open(file)
 }
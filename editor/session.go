@@ -0,0 +1,293 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package editor
+
+import (
+	"errors"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/gxui/math"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/session"
+	"github.com/nelsam/vidar/setting"
+)
+
+// Session returns the layout of the editors in each of e's
+// projects, so that it can be restored with RestoreSession.  Only
+// editors for files are included; diff views are left out.
+func (e *MultiProjectEditor) Session() session.Session {
+	s := session.Session{Project: e.current.Project().Name}
+	names := make([]string, 0, len(e.projects))
+	for name := range e.projects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := e.projects[name]
+		if p.Editors() == 0 && p != e.current {
+			continue
+		}
+		s.Projects = append(s.Projects, session.Project{
+			Name:   name,
+			Layout: p.layout(),
+		})
+	}
+	return s
+}
+
+// RestoreSession replaces the layout of each project in s with the
+// layout that was saved, opening the files in it.  Projects that are
+// no longer configured are skipped, as are files that no longer
+// exist.  It does not change the current project; callers should
+// call SetProject with the project named by s.Project afterward.
+//
+// RestoreSession refuses to close editors that have unsaved changes.
+func (e *MultiProjectEditor) RestoreSession(s session.Session) error {
+	for _, p := range e.projects {
+		if p.hasChanges() {
+			return errors.New("some files have unsaved changes")
+		}
+	}
+	for _, saved := range s.Projects {
+		proj, ok := projectNamed(saved.Name)
+		if !ok {
+			log.Printf("Skipping session layout for unknown project %s", saved.Name)
+			continue
+		}
+		p, ok := e.projects[proj.Name]
+		if !ok {
+			p = NewProjectEditor(e.driver, e.window, e.cmdr, e.theme, e.syntaxTheme, e.font, proj)
+			e.projects[proj.Name] = p
+		}
+		p.restore(saved.Layout)
+	}
+	return nil
+}
+
+func projectNamed(name string) (setting.Project, bool) {
+	if name == setting.DefaultProject.Name {
+		return setting.DefaultProject, true
+	}
+	for _, p := range setting.Projects() {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return setting.Project{}, false
+}
+
+// restore replaces p's splits and tabs with those in l.
+func (p *ProjectEditor) restore(l session.Layout) {
+	open := func(t *TabbedEditor, path string) (text.Editor, bool) {
+		if _, err := os.Stat(path); err != nil {
+			log.Printf("Skipping %s while restoring the session: %s", path, err)
+			return nil, false
+		}
+		ed, _ := t.Open(p.project.Path, path, p.project.LicenseHeader(), p.project.Environ())
+		return ed, true
+	}
+	if !l.Split() {
+		// A single group of tabs is saved without a split around
+		// it.
+		l = session.Layout{Orientation: session.Horizontal, Splits: []session.Layout{l}}
+	}
+	p.SplitEditor.restore(l, open)
+}
+
+// layout returns the session.Layout of e and its children.
+func (e *SplitEditor) layout() session.Layout {
+	l := session.Layout{Orientation: session.Horizontal}
+	if e.Orientation().Vertical() {
+		l.Orientation = session.Vertical
+	}
+	for _, child := range e.Children() {
+		var cl session.Layout
+		switch src := child.Control.(type) {
+		case *SplitEditor:
+			cl = src.layout()
+		case *TabbedEditor:
+			cl = src.layout()
+			if len(cl.Tabs) == 0 {
+				continue
+			}
+		default:
+			continue
+		}
+		if child.Control == e.current {
+			l.Current = len(l.Splits)
+		}
+		cl.Weight = e.ChildWeight(child.Control)
+		l.Splits = append(l.Splits, cl)
+	}
+	return l
+}
+
+// restore replaces e's children with the splits in l, using open to
+// open each tab.
+func (e *SplitEditor) restore(l session.Layout, open func(*TabbedEditor, string) (text.Editor, bool)) {
+	var old []gxui.Control
+	for _, child := range e.Children() {
+		if _, ok := child.Control.(MultiEditor); ok {
+			old = append(old, child.Control)
+		}
+	}
+	for _, c := range old {
+		e.RemoveChild(c)
+	}
+	e.current = nil
+	o := gxui.Horizontal
+	if l.Orientation == session.Vertical {
+		o = gxui.Vertical
+	}
+	e.SetOrientation(o)
+
+	var current MultiEditor
+	for i, split := range l.Splits {
+		var child MultiEditor
+		if split.Split() {
+			s := NewSplitEditor(e.driver, e.cmdr, e.window, e.theme, e.syntaxTheme, e.font)
+			s.restore(split, open)
+			if s.Editors() == 0 {
+				continue
+			}
+			child = s
+		} else {
+			t := NewTabbedEditor(e.driver, e.cmdr, e.theme, e.syntaxTheme, e.font)
+			if !t.restore(split, open) {
+				continue
+			}
+			child = t
+		}
+		e.AddChild(child)
+		if split.Weight > 0 {
+			e.SetChildWeight(child, split.Weight)
+		}
+		if i <= l.Current || current == nil {
+			current = child
+		}
+	}
+	if current == nil {
+		// There must always be somewhere to open files.
+		t := NewTabbedEditor(e.driver, e.cmdr, e.theme, e.syntaxTheme, e.font)
+		e.AddChild(t)
+		current = t
+	}
+	e.current = current
+}
+
+// hasChanges returns whether any of the editors in e have unsaved
+// changes.
+func (e *SplitEditor) hasChanges() bool {
+	for _, child := range e.Children() {
+		switch src := child.Control.(type) {
+		case *SplitEditor:
+			if src.hasChanges() {
+				return true
+			}
+		case *TabbedEditor:
+			if src.hasChanges() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// layout returns the session.Layout of e's tabs.
+func (e *TabbedEditor) layout() session.Layout {
+	var l session.Layout
+	selected := e.SelectedPanel()
+	for i := 0; i < e.PanelCount(); i++ {
+		ce, ok := e.Panel(i).(*CodeEditor)
+		if !ok {
+			continue
+		}
+		if ce == selected {
+			l.Current = len(l.Tabs)
+		}
+		l.Tabs = append(l.Tabs, ce.sessionEditor())
+	}
+	return l
+}
+
+// restore opens the tabs in l, using open to open each of them.  It
+// returns false if none of them could be opened.
+func (e *TabbedEditor) restore(l session.Layout, open func(*TabbedEditor, string) (text.Editor, bool)) bool {
+	var selected text.Editor
+	for i, tab := range l.Tabs {
+		ed, ok := open(e, tab.Path)
+		if !ok {
+			continue
+		}
+		if ce, ok := ed.(*CodeEditor); ok {
+			ce.restoreSession(tab)
+		}
+		if i <= l.Current || selected == nil {
+			selected = ed
+		}
+	}
+	if selected == nil {
+		return false
+	}
+	e.Select(e.PanelIndex(selected.(gxui.Control)))
+	return true
+}
+
+// hasChanges returns whether any of e's editors have unsaved
+// changes.
+func (e *TabbedEditor) hasChanges() bool {
+	for _, ed := range e.editors {
+		if ce, ok := ed.(*CodeEditor); ok && ce.HasChanges() {
+			return true
+		}
+	}
+	return false
+}
+
+// sessionEditor returns the session.Editor for e's current carets
+// and scroll offsets.
+func (e *CodeEditor) sessionEditor() session.Editor {
+	s := session.Editor{
+		Path:    e.filepath,
+		ScrollX: e.HorizOffset(),
+		ScrollY: e.ScrollOffset(),
+	}
+	for _, sel := range e.Controller().SelectionSlice() {
+		s.Selections = append(s.Selections, session.Selection{
+			From:         sel.From(),
+			To:           sel.To(),
+			CaretAtStart: sel.CaretAtStart(),
+		})
+	}
+	return s
+}
+
+// restoreSession moves e's carets and scroll offsets to those in s,
+// once e has loaded its file.
+func (e *CodeEditor) restoreSession(s session.Editor) {
+	// The editor loads its file's text with a driver.Call, so this
+	// has to be queued up behind it.
+	e.driver.Call(func() {
+		max := len(e.Runes())
+		clamp := func(i int) int {
+			if i > max {
+				return max
+			}
+			return i
+		}
+		e.selections = e.selections[:0]
+		for _, sel := range s.Selections {
+			e.selections = append(e.selections, gxui.CreateTextSelection(clamp(sel.From), clamp(sel.To), sel.CaretAtStart))
+		}
+		if len(e.selections) == 0 {
+			e.selections = append(e.selections, gxui.CreateTextSelection(0, 0, false))
+		}
+		e.scrollPositions = math.Point{X: s.ScrollX, Y: s.ScrollY}
+		e.restorePositions()
+	})
+}
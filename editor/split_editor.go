@@ -52,10 +52,17 @@ type Commander interface {
 	Execute(bind.Bindable)
 }
 
+// A Runner is a Commander that can run commands that need input from
+// the user.
+type Runner interface {
+	Run(bind.Command)
+}
+
 type MultiEditor interface {
 	gxui.Control
 	outer.LayoutChildren
 	Has(hiddenPrefix, path string) bool
+	EditorFor(path string) text.Editor
 	Open(hiddenPrefix, path, headerText string, environ []string) (editor text.Editor, existed bool)
 	Editors() uint
 	CurrentEditor() text.Editor
@@ -240,6 +247,21 @@ func (e *SplitEditor) Has(hiddenPrefix, path string) bool {
 	return false
 }
 
+// EditorFor returns the open editor for path, or nil if path is not
+// open in any of e's children.
+func (e *SplitEditor) EditorFor(path string) text.Editor {
+	for _, child := range e.Children() {
+		me, ok := child.Control.(MultiEditor)
+		if !ok {
+			continue
+		}
+		if ed := me.EditorFor(path); ed != nil {
+			return ed
+		}
+	}
+	return nil
+}
+
 func (e *SplitEditor) Open(hiddenPrefix, path, headerText string, environ []string) (editor text.Editor, existed bool) {
 	for _, child := range e.Children() {
 		if me, ok := child.Control.(MultiEditor); ok && me.Has(hiddenPrefix, path) {
@@ -15,7 +15,9 @@ import (
 	"github.com/nelsam/gxui/mixins"
 	"github.com/nelsam/gxui/themes/basic"
 	"github.com/nelsam/vidar/command/focus"
+	"github.com/nelsam/vidar/commander/bind"
 	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/jumplist"
 	"github.com/nelsam/vidar/theme"
 )
 
@@ -34,6 +36,10 @@ type TabbedEditor struct {
 	syntaxTheme theme.Theme
 	font        gxui.Font
 	cur         string
+
+	// jumps is the history of locations that have been jumped
+	// between in this split.
+	jumps *jumplist.History
 }
 
 func NewTabbedEditor(driver gxui.Driver, cmdr Commander, theme *basic.Theme, syntaxTheme theme.Theme, font gxui.Font) *TabbedEditor {
@@ -44,6 +50,7 @@ func NewTabbedEditor(driver gxui.Driver, cmdr Commander, theme *basic.Theme, syn
 
 func (e *TabbedEditor) Init(outer mixins.PanelHolderOuter, driver gxui.Driver, cmdr Commander, theme *basic.Theme, syntaxTheme theme.Theme, font gxui.Font) {
 	e.editors = make(map[string]text.Editor)
+	e.jumps = jumplist.New()
 	e.driver = driver
 	e.cmdr = cmdr
 	e.theme = theme
@@ -53,11 +60,28 @@ func (e *TabbedEditor) Init(outer mixins.PanelHolderOuter, driver gxui.Driver, c
 	e.SetMargin(math.Spacing{L: 0, T: 2, R: 0, B: 0})
 }
 
+// Jumps returns the history of locations that have been jumped
+// between in e.
+func (e *TabbedEditor) Jumps() *jumplist.History {
+	return e.jumps
+}
+
 func (e *TabbedEditor) Has(hiddenPrefix, path string) bool {
 	_, ok := e.editors[relPath(hiddenPrefix, path)]
 	return ok
 }
 
+// EditorFor returns the open editor for path, or nil if path is not
+// open in e.
+func (e *TabbedEditor) EditorFor(path string) text.Editor {
+	for _, editor := range e.editors {
+		if editor.Filepath() == path {
+			return editor
+		}
+	}
+	return nil
+}
+
 func (e *TabbedEditor) Open(hiddenPrefix, path, headerText string, environ []string) (editor text.Editor, existed bool) {
 	name := relPath(hiddenPrefix, path)
 	if editor, ok := e.editors[name]; ok {
@@ -71,6 +95,7 @@ func (e *TabbedEditor) Open(hiddenPrefix, path, headerText string, environ []str
 	// in its Init method.
 	ce.OnRename(func(newPath string) {
 		e.driver.Call(func() {
+			e.jumps.Rename(path, newPath)
 			delete(e.editors, name)
 			newName := relPath(hiddenPrefix, newPath)
 			focused := e.SelectedPanel()
@@ -85,12 +110,33 @@ func (e *TabbedEditor) Open(hiddenPrefix, path, headerText string, environ []str
 			gxui.SetFocus(focused.(gxui.Focusable))
 		})
 	})
+	ce.OnConflict(func() {
+		e.conflicted(ce)
+	})
 	ce.Init(e.driver, e.theme, e.syntaxTheme, e.font, path, headerText)
 	ce.SetTabWidth(4)
 	e.Add(name, editor)
 	return editor, false
 }
 
+// conflicted focuses ce and asks how to resolve the conflict between
+// its text and its file on disk.
+func (e *TabbedEditor) conflicted(ce *CodeEditor) {
+	runner, ok := e.cmdr.(Runner)
+	if !ok {
+		log.Printf("%s changed on disk while it had unsaved changes, but %T can't ask how to resolve it", ce.Filepath(), e.cmdr)
+		return
+	}
+	resolver, ok := e.cmdr.Bindable("resolve-conflict").(bind.Command)
+	if !ok {
+		log.Printf("%s changed on disk while it had unsaved changes, but there is no resolve-conflict command", ce.Filepath())
+		return
+	}
+	opener := e.cmdr.Bindable("focus-location").(Opener)
+	e.cmdr.Execute(opener.For(focus.Path(ce.Filepath())))
+	runner.Run(resolver)
+}
+
 func (e *TabbedEditor) Add(name string, editor text.Editor) {
 	e.editors[name] = editor
 	ec := editor.(gxui.Control)
@@ -203,6 +249,11 @@ func (e *TabbedEditor) CloseCurrentEditor() (name string, editor text.Editor) {
 
 func (e *TabbedEditor) SaveAll() {
 	for name, editor := range e.editors {
+		if _, ok := editor.(*CodeEditor); !ok {
+			// Only CodeEditors are backed by the file they're named
+			// for.
+			continue
+		}
 		f, err := os.Create(name)
 		if err != nil {
 			log.Printf("Could not save %s : %s", name, err)
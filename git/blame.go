@@ -0,0 +1,121 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// uncommitted is the hash that git blame uses for lines that have
+// not been committed.
+const uncommitted = "0000000000000000000000000000000000000000"
+
+// A BlameCommit is a commit that git blame attributes lines to.
+type BlameCommit struct {
+	Hash    string
+	Author  string
+	Time    time.Time
+	Summary string
+}
+
+// Committed returns whether c is a real commit, rather than changes
+// that have not been committed yet.
+func (c *BlameCommit) Committed() bool {
+	return c.Hash != uncommitted
+}
+
+// Short returns the abbreviated hash of c.
+func (c *BlameCommit) Short() string {
+	if len(c.Hash) < 7 {
+		return c.Hash
+	}
+	return c.Hash[:7]
+}
+
+// Blame returns the commit that last changed each line of contents,
+// which are the current contents of path (e.g. an editor's buffer,
+// which may have unsaved changes).  Lines that share a commit share
+// the same *BlameCommit.
+func Blame(path string, contents []byte) ([]*BlameCommit, error) {
+	root, rel, err := Rel(path)
+	if err != nil {
+		return nil, err
+	}
+	out, err := runInput(root, contents, "blame", "--porcelain", "--contents", "-", "--", rel)
+	if err != nil {
+		return nil, err
+	}
+	return ParseBlame(out)
+}
+
+// ParseBlame parses the output of git blame --porcelain.
+func ParseBlame(out []byte) ([]*BlameCommit, error) {
+	commits := make(map[string]*BlameCommit)
+	var (
+		lines []*BlameCommit
+		curr  *BlameCommit
+		final int
+	)
+	s := bufio.NewScanner(bytes.NewReader(out))
+	s.Buffer(nil, 1<<20)
+	for s.Scan() {
+		l := s.Text()
+		if strings.HasPrefix(l, "\t") {
+			for len(lines) < final {
+				lines = append(lines, nil)
+			}
+			if final > 0 {
+				lines[final-1] = curr
+			}
+			continue
+		}
+		key, value := l, ""
+		if i := strings.IndexRune(l, ' '); i >= 0 {
+			key, value = l[:i], l[i+1:]
+		}
+		if len(key) == len(uncommitted) && isHex(key) {
+			fields := strings.Fields(value)
+			if len(fields) < 2 {
+				continue
+			}
+			final, _ = strconv.Atoi(fields[1])
+			c, ok := commits[key]
+			if !ok {
+				c = &BlameCommit{Hash: key}
+				commits[key] = c
+			}
+			curr = c
+			continue
+		}
+		if curr == nil {
+			continue
+		}
+		switch key {
+		case "author":
+			curr.Author = value
+		case "author-time":
+			sec, err := strconv.ParseInt(value, 10, 64)
+			if err == nil {
+				curr.Time = time.Unix(sec, 0)
+			}
+		case "summary":
+			curr.Summary = value
+		}
+	}
+	return lines, s.Err()
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,121 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package git
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Stage adds the contents of path on disk to the index.
+func Stage(path string) error {
+	root, rel, err := Rel(path)
+	if err != nil {
+		return err
+	}
+	_, err = run(root, "add", "--", rel)
+	return err
+}
+
+// Unstage resets path in the index to its contents at HEAD, leaving
+// the file on disk alone.
+func Unstage(path string) error {
+	root, rel, err := Rel(path)
+	if err != nil {
+		return err
+	}
+	if _, err := run(root, "rev-parse", "-q", "--verify", "HEAD"); err != nil {
+		// There are no commits yet, so there is nothing to reset
+		// to; the file just has to leave the index.
+		_, err = run(root, "rm", "-q", "--cached", "--", rel)
+		return err
+	}
+	_, err = run(root, "reset", "-q", "HEAD", "--", rel)
+	return err
+}
+
+// SetIndex replaces the contents of path in the index with contents,
+// without touching the file on disk.  This is how parts of a file
+// are staged.
+func SetIndex(path string, contents []byte) error {
+	root, rel, err := Rel(path)
+	if err != nil {
+		return err
+	}
+	mode, err := indexMode(root, rel, path)
+	if err != nil {
+		return err
+	}
+	out, err := runInput(root, contents, "hash-object", "-w", "--stdin")
+	if err != nil {
+		return err
+	}
+	hash := strings.TrimSpace(string(out))
+	_, err = run(root, "update-index", "--add", "--cacheinfo", fmt.Sprintf("%s,%s,%s", mode, hash, rel))
+	return err
+}
+
+// indexMode returns the mode that rel has in the index, or the mode
+// it should be given if it is not in the index yet.
+func indexMode(root, rel, path string) (string, error) {
+	out, err := run(root, "ls-files", "-s", "--", rel)
+	if err != nil {
+		return "", err
+	}
+	if fields := strings.Fields(string(out)); len(fields) > 0 {
+		return fields[0], nil
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode()&0111 != 0 {
+		return "100755", nil
+	}
+	return "100644", nil
+}
+
+// Commit commits the index of the repository that dir is in with
+// msg, returning the abbreviated hash of the new commit.  If amend is
+// true, the commit replaces HEAD.
+func Commit(dir, msg string, amend bool) (string, error) {
+	root, err := Root(dir)
+	if err != nil {
+		return "", err
+	}
+	args := []string{"commit", "-q", "--cleanup=strip", "-F", "-"}
+	if amend {
+		args = append(args, "--amend")
+	}
+	if _, err := runInput(root, []byte(msg), args...); err != nil {
+		return "", err
+	}
+	out, err := run(root, "rev-parse", "--short", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// LastMessage returns the message of HEAD in the repository that dir
+// is in.
+func LastMessage(dir string) (string, error) {
+	root, err := Root(dir)
+	if err != nil {
+		return "", err
+	}
+	out, err := run(root, "log", "-1", "--format=%B")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// ShowCommit returns the description of the commit hash in the
+// repository that dir is in, followed by its diff.
+func ShowCommit(dir, hash string) ([]byte, error) {
+	root, err := Root(dir)
+	if err != nil {
+		return nil, err
+	}
+	return run(root, "show", "--no-color", "--stat", "--patch", hash)
+}
@@ -0,0 +1,118 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+// Package git runs the git command line tool against local
+// repositories.  Nothing in this package talks to a remote.
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotInRepo is returned when a path is not inside of a git
+// repository.
+var ErrNotInRepo = errors.New("git: not in a repository")
+
+// ErrNotInRev is returned by Show when a file does not exist in the
+// requested revision (e.g. because it has never been committed).
+var ErrNotInRev = errors.New("git: file does not exist in revision")
+
+// run runs git with args in dir, returning its output.  If git
+// fails, the returned error includes what it printed to stderr.
+func run(dir string, args ...string) ([]byte, error) {
+	return runInput(dir, nil, args...)
+}
+
+// runInput is like run, but writes in to git's stdin.
+func runInput(dir string, in []byte, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if in != nil {
+		cmd.Stdin = bytes.NewReader(in)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			return out, fmt.Errorf("git %s: %s", args[0], err)
+		}
+		return out, fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return out, nil
+}
+
+// Root returns the top level directory of the repository that path
+// is in.  path may be a file or a directory, and does not need to
+// exist, as long as its directory does.
+func Root(path string) (string, error) {
+	out, err := run(dir(path), "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", ErrNotInRepo
+	}
+	return filepath.FromSlash(strings.TrimSpace(string(out))), nil
+}
+
+// Rel returns the root of the repository that path is in and the
+// path relative to that root, with forward slashes, the way that
+// git expects paths in revisions.
+func Rel(path string) (root, rel string, err error) {
+	root, err = Root(path)
+	if err != nil {
+		return "", "", err
+	}
+	// The root that git reports has symlinks resolved, so path has
+	// to be resolved too before they can be compared.
+	resolved := path
+	if d, err := filepath.EvalSymlinks(filepath.Dir(path)); err == nil {
+		resolved = filepath.Join(d, filepath.Base(path))
+	}
+	rel, err = filepath.Rel(root, resolved)
+	if err != nil {
+		return "", "", err
+	}
+	return root, filepath.ToSlash(rel), nil
+}
+
+// Show returns the contents of the file at path as of rev (e.g.
+// "HEAD").  An empty rev returns the contents of the file in the
+// index.
+func Show(path, rev string) ([]byte, error) {
+	root, rel, err := Rel(path)
+	if err != nil {
+		return nil, err
+	}
+	out, err := run(root, "show", rev+":"+rel)
+	if err != nil {
+		if _, lsErr := run(root, "cat-file", "-e", rev+":"+rel); lsErr != nil {
+			return nil, ErrNotInRev
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// IsIgnored returns whether path is ignored by git.
+func IsIgnored(path string) bool {
+	root, rel, err := Rel(path)
+	if err != nil {
+		return false
+	}
+	_, err = run(root, "check-ignore", "-q", rel)
+	return err == nil
+}
+
+func dir(path string) string {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return path
+	}
+	return filepath.Dir(path)
+}
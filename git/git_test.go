@@ -0,0 +1,215 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package git_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nelsam/vidar/git"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+// repo creates a repository in a temporary directory with a single
+// commit containing foo.txt.
+func repo(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "vidar-git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gitCmd(t, dir, "init", "-q")
+	write(t, filepath.Join(dir, "foo.txt"), "committed\n")
+	gitCmd(t, dir, "add", "foo.txt")
+	gitCmd(t, dir, "commit", "-q", "-m", "initial")
+	return dir
+}
+
+func gitCmd(t *testing.T, dir string, args ...string) {
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %s: %s", args, err, out)
+	}
+}
+
+// identity sets the environment up so that git can commit without
+// any user config.
+func identity() func() {
+	vars := []string{"GIT_AUTHOR_NAME", "GIT_AUTHOR_EMAIL", "GIT_COMMITTER_NAME", "GIT_COMMITTER_EMAIL"}
+	for _, v := range vars {
+		val := "test"
+		if strings.HasSuffix(v, "EMAIL") {
+			val = "test@example.com"
+		}
+		os.Setenv(v, val)
+	}
+	return func() {
+		for _, v := range vars {
+			os.Unsetenv(v)
+		}
+	}
+}
+
+func write(t *testing.T, path, contents string) {
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGit(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	o.BeforeEach(func(t *testing.T) (expect.Expectation, string) {
+		return expect.New(t), repo(t)
+	})
+
+	o.AfterEach(func(_ expect.Expectation, dir string) {
+		os.RemoveAll(dir)
+	})
+
+	o.Spec("it shows files at a revision", func(expect expect.Expectation, dir string) {
+		path := filepath.Join(dir, "foo.txt")
+		write(t, path, "changed\n")
+		b, err := git.Show(path, "HEAD")
+		expect(err).To(beNil())
+		expect(string(b)).To(equal("committed\n"))
+	})
+
+	o.Spec("it reports files that are not in a revision", func(expect expect.Expectation, dir string) {
+		path := filepath.Join(dir, "new.txt")
+		write(t, path, "new\n")
+		_, err := git.Show(path, "HEAD")
+		expect(err).To(equal(git.ErrNotInRev))
+	})
+
+	o.Spec("it reports paths outside of a repository", func(expect expect.Expectation, dir string) {
+		outside, err := ioutil.TempDir("", "vidar-nogit")
+		expect(err).To(beNil())
+		defer os.RemoveAll(outside)
+		_, err = git.Root(filepath.Join(outside, "foo.txt"))
+		expect(err).To(equal(git.ErrNotInRepo))
+	})
+
+	o.Spec("it finds ignored files", func(expect expect.Expectation, dir string) {
+		write(t, filepath.Join(dir, ".gitignore"), "*.log\n")
+		expect(git.IsIgnored(filepath.Join(dir, "foo.log"))).To(beTrue())
+		expect(git.IsIgnored(filepath.Join(dir, "foo.txt"))).To(beFalse())
+	})
+
+	o.Spec("it stages and unstages files", func(expect expect.Expectation, dir string) {
+		path := filepath.Join(dir, "foo.txt")
+		write(t, path, "changed\n")
+		expect(git.Stage(path)).To(beNil())
+		b, err := git.Show(path, "")
+		expect(err).To(beNil())
+		expect(string(b)).To(equal("changed\n"))
+
+		expect(git.Unstage(path)).To(beNil())
+		b, err = git.Show(path, "")
+		expect(err).To(beNil())
+		expect(string(b)).To(equal("committed\n"))
+	})
+
+	o.Spec("it sets the contents of files in the index", func(expect expect.Expectation, dir string) {
+		path := filepath.Join(dir, "new.txt")
+		write(t, path, "on disk\n")
+		expect(git.SetIndex(path, []byte("staged\n"))).To(beNil())
+		b, err := git.Show(path, "")
+		expect(err).To(beNil())
+		expect(string(b)).To(equal("staged\n"))
+	})
+
+	o.Spec("it commits and amends", func(expect expect.Expectation, dir string) {
+		defer identity()()
+		path := filepath.Join(dir, "foo.txt")
+		write(t, path, "changed\n")
+		expect(git.Stage(path)).To(beNil())
+		hash, err := git.Commit(dir, "change foo\n\nmore detail", false)
+		expect(err).To(beNil())
+		expect(hash).To(not(equal("")))
+		msg, err := git.LastMessage(dir)
+		expect(err).To(beNil())
+		expect(msg).To(equal("change foo\n\nmore detail"))
+
+		amended, err := git.Commit(dir, "reword foo", true)
+		expect(err).To(beNil())
+		expect(amended).To(not(equal(hash)))
+		msg, err = git.LastMessage(dir)
+		expect(err).To(beNil())
+		expect(msg).To(equal("reword foo"))
+
+		b, err := git.ShowCommit(dir, amended)
+		expect(err).To(beNil())
+		expect(strings.Contains(string(b), "+changed")).To(beTrue())
+	})
+
+	o.Spec("it blames the lines of a buffer", func(expect expect.Expectation, dir string) {
+		path := filepath.Join(dir, "foo.txt")
+		lines, err := git.Blame(path, []byte("committed\nunsaved\n"))
+		expect(err).To(beNil())
+		expect(lines).To(haveLen(2))
+		expect(lines[0].Committed()).To(beTrue())
+		expect(lines[0].Author).To(equal("test"))
+		expect(lines[0].Summary).To(equal("initial"))
+		expect(lines[1].Committed()).To(beFalse())
+	})
+}
+
+func TestStatus(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	o.BeforeEach(func(t *testing.T) expect.Expectation {
+		return expect.New(t)
+	})
+
+	o.Spec("it parses porcelain output", func(expect expect.Expectation) {
+		root := filepath.FromSlash("/repo")
+		out := " M a/mod.go\x00A  a/b/new.go\x00?? c/\x00!! build/\x00UU conflict.go\x00R  moved.go\x00orig.go\x00"
+		s := git.ParseStatus(root, []byte(out))
+		path := func(rel string) string {
+			return filepath.Join(root, filepath.FromSlash(rel))
+		}
+		expect(s.Of(path("a/mod.go"))).To(equal(git.Modified))
+		expect(s.Of(path("a/b/new.go"))).To(equal(git.Added))
+		expect(s.Of(path("c/d/e.go"))).To(equal(git.Untracked))
+		expect(s.Of(path("build/out"))).To(equal(git.Ignored))
+		expect(s.Of(path("conflict.go"))).To(equal(git.Conflicted))
+		expect(s.Of(path("moved.go"))).To(equal(git.Modified))
+		expect(s.Of(path("orig.go"))).To(equal(git.Clean))
+
+		expect(s.Rollup(path("a"))).To(equal(git.Modified))
+		expect(s.Rollup(path("a/b"))).To(equal(git.Added))
+		expect(s.Rollup(path("c"))).To(equal(git.Untracked))
+		expect(s.Rollup(path("build"))).To(equal(git.Ignored))
+		expect(s.Rollup(root)).To(equal(git.Conflicted))
+	})
+
+	o.Spec("it reads the status of a repository", func(expect expect.Expectation) {
+		dir := repo(t)
+		defer os.RemoveAll(dir)
+		write(t, filepath.Join(dir, "foo.txt"), "changed\n")
+		write(t, filepath.Join(dir, "new.txt"), "new\n")
+		write(t, filepath.Join(dir, ".gitignore"), "*.log\n")
+		write(t, filepath.Join(dir, "x.log"), "log\n")
+
+		s, err := git.Status(dir)
+		expect(err).To(beNil())
+		root, err := filepath.EvalSymlinks(dir)
+		expect(err).To(beNil())
+		expect(s.Of(filepath.Join(root, "foo.txt"))).To(equal(git.Modified))
+		expect(s.Of(filepath.Join(root, "new.txt"))).To(equal(git.Untracked))
+		expect(s.Of(filepath.Join(root, "x.log"))).To(equal(git.Ignored))
+	})
+}
@@ -0,0 +1,155 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package git
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileStatus is the state of a file in the working tree.  Values are
+// ordered by how much attention they need, so that a directory can
+// report the greatest status of its children.
+type FileStatus int
+
+const (
+	Clean FileStatus = iota
+	Ignored
+	Untracked
+	Added
+	Modified
+	Conflicted
+)
+
+func (s FileStatus) String() string {
+	switch s {
+	case Clean:
+		return "clean"
+	case Ignored:
+		return "ignored"
+	case Untracked:
+		return "untracked"
+	case Added:
+		return "added"
+	case Modified:
+		return "modified"
+	case Conflicted:
+		return "conflicted"
+	default:
+		return "unknown"
+	}
+}
+
+// Statuses is the status of the files in a working tree.  Files that
+// are not in Statuses are Clean.
+type Statuses struct {
+	Root string
+
+	files map[string]FileStatus
+
+	// dirs holds directories that git reported as a whole, because
+	// everything in them is untracked or ignored.
+	dirs map[string]FileStatus
+}
+
+// Status returns the status of the working tree that path is in.
+func Status(path string) (Statuses, error) {
+	root, err := Root(path)
+	if err != nil {
+		return Statuses{}, err
+	}
+	out, err := run(root, "status", "--porcelain", "-z", "--ignored")
+	if err != nil {
+		return Statuses{}, err
+	}
+	return ParseStatus(root, out), nil
+}
+
+// ParseStatus parses the output of git status --porcelain -z from
+// the repository at root.
+func ParseStatus(root string, out []byte) Statuses {
+	s := Statuses{
+		Root:  root,
+		files: make(map[string]FileStatus),
+		dirs:  make(map[string]FileStatus),
+	}
+	entries := bytes.Split(out, []byte{0})
+	for i := 0; i < len(entries); i++ {
+		e := string(entries[i])
+		if len(e) < 4 {
+			continue
+		}
+		xy, rel := e[:2], e[3:]
+		if xy[0] == 'R' || xy[0] == 'C' {
+			// Renames and copies are followed by the original path.
+			i++
+		}
+		status := parseXY(xy)
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if strings.HasSuffix(rel, "/") {
+			s.dirs[path] = status
+			continue
+		}
+		s.files[path] = status
+	}
+	return s
+}
+
+func parseXY(xy string) FileStatus {
+	switch xy {
+	case "??":
+		return Untracked
+	case "!!":
+		return Ignored
+	case "DD", "AU", "UD", "UA", "DU", "AA", "UU":
+		return Conflicted
+	}
+	if xy[0] == 'A' {
+		return Added
+	}
+	return Modified
+}
+
+// Of returns the status of the file or directory at path.
+func (s Statuses) Of(path string) FileStatus {
+	if status, ok := s.files[path]; ok {
+		return status
+	}
+	for p := path; strings.HasPrefix(p, s.Root) && p != s.Root; {
+		if status, ok := s.dirs[p]; ok {
+			return status
+		}
+		parent := filepath.Dir(p)
+		if parent == p {
+			break
+		}
+		p = parent
+	}
+	return Clean
+}
+
+// Rollup returns the status of the directory at dir, which is the
+// greatest status of anything in it.  Ignored files do not affect
+// the directory's status unless the directory itself is ignored.
+func (s Statuses) Rollup(dir string) FileStatus {
+	if status := s.Of(dir); status != Clean {
+		return status
+	}
+	prefix := dir + string(os.PathSeparator)
+	rollup := Clean
+	for _, m := range []map[string]FileStatus{s.files, s.dirs} {
+		for path, status := range m {
+			if status == Ignored || status <= rollup {
+				continue
+			}
+			if strings.HasPrefix(path, prefix) {
+				rollup = status
+			}
+		}
+	}
+	return rollup
+}
@@ -0,0 +1,233 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package goref
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"sort"
+)
+
+// A Call is a function in a call hierarchy, along with the places
+// that the call is made from.
+type Call struct {
+	// Name is the function's name, qualified by its package name
+	// and, for methods, its receiver type.
+	Name string
+
+	// Decl is the function's declaration.  Its Path is empty if
+	// the declaration couldn't be found.
+	Decl Ref
+
+	// Sites are the identifiers that make the call.
+	Sites []Ref
+}
+
+// Callers returns the functions that call fn, which must be a
+// *types.Func, along with where each of them calls it.  Calls made
+// outside of any function (e.g. to initialize package-level
+// variables) are not included.
+func (p *Project) Callers(fn types.Object) ([]Call, error) {
+	if _, ok := fn.(*types.Func); !ok {
+		return nil, fmt.Errorf("%s is not a function", fn.Name())
+	}
+	key := p.key(fn)
+	calls := make(map[*ast.FuncDecl]*Call)
+	var order []*ast.FuncDecl
+	for _, pk := range p.pkgs {
+		for _, f := range pk.files {
+			p.eachCall(f, func(caller *ast.FuncDecl, id *ast.Ident, callee types.Object) {
+				if p.key(callee) != key {
+					return
+				}
+				c, ok := calls[caller]
+				if !ok {
+					c = &Call{
+						Name: funcName(pk.info.Defs[caller.Name]),
+						Decl: p.ref(f, caller.Name, true),
+					}
+					calls[caller] = c
+					order = append(order, caller)
+				}
+				c.Sites = append(c.Sites, p.ref(f, id, false))
+			})
+		}
+	}
+	callers := make([]Call, 0, len(order))
+	for _, decl := range order {
+		callers = append(callers, *calls[decl])
+	}
+	sortCalls(callers)
+	return callers, nil
+}
+
+// Callees returns the functions that fn, which must be a *types.Func
+// declared in the project, calls, along with where it calls each of
+// them.
+func (p *Project) Callees(fn types.Object) ([]Call, error) {
+	if _, ok := fn.(*types.Func); !ok {
+		return nil, fmt.Errorf("%s is not a function", fn.Name())
+	}
+	decl, ok := p.Decl(fn)
+	if !ok {
+		return nil, fmt.Errorf("%s is not declared in %s", fn.Name(), p.root)
+	}
+	f := p.files[decl.Path]
+	calls := make(map[string]*Call)
+	var order []string
+	p.eachCall(f, func(caller *ast.FuncDecl, id *ast.Ident, callee types.Object) {
+		if caller.Name.Pos() != p.posOf(f, decl) {
+			return
+		}
+		key := p.key(callee)
+		c, ok := calls[key]
+		if !ok {
+			c = &Call{Name: funcName(callee)}
+			c.Decl, _ = p.Decl(callee)
+			calls[key] = c
+			order = append(order, key)
+		}
+		c.Sites = append(c.Sites, p.ref(f, id, false))
+	})
+	callees := make([]Call, 0, len(order))
+	for _, key := range order {
+		callees = append(callees, *calls[key])
+	}
+	return callees, nil
+}
+
+// Decl returns the declaration of obj.  The returned bool is false
+// if it couldn't be found.  For objects declared outside of the
+// project, only the Path, Line, and Column of the Ref are set.
+func (p *Project) Decl(obj types.Object) (Ref, bool) {
+	key := p.key(obj)
+	if key == "" {
+		return Ref{}, false
+	}
+	if p.defs == nil {
+		p.defs = make(map[string]Ref)
+		for _, pk := range p.pkgs {
+			for _, f := range pk.files {
+				tf := p.fset.File(f.ast.Pos())
+				for id, o := range pk.info.Defs {
+					if o == nil || p.fset.File(id.Pos()) != tf {
+						continue
+					}
+					k := p.key(o)
+					if _, ok := p.defs[k]; !ok {
+						p.defs[k] = p.ref(f, id, true)
+					}
+				}
+			}
+		}
+	}
+	if r, ok := p.defs[key]; ok {
+		return r, true
+	}
+	pos := p.fset.Position(obj.Pos())
+	if pos.Filename == "" {
+		return Ref{}, false
+	}
+	if _, err := os.Stat(pos.Filename); err != nil {
+		return Ref{}, false
+	}
+	return Ref{Path: pos.Filename, Line: pos.Line - 1, Column: pos.Column - 1, Name: obj.Name(), Def: true}, true
+}
+
+// posOf returns the position of the identifier that r refers to in
+// f.
+func (p *Project) posOf(f *file, r Ref) token.Pos {
+	tf := p.fset.File(f.ast.Pos())
+	return tf.Pos(byteOffset(f.src, r.Offset))
+}
+
+// eachCall calls fn with each call to a function or method in f,
+// along with the func declaration that the call is made in.
+func (p *Project) eachCall(f *file, fn func(caller *ast.FuncDecl, id *ast.Ident, callee types.Object)) {
+	for _, d := range f.ast.Decls {
+		decl, ok := d.(*ast.FuncDecl)
+		if !ok || decl.Body == nil {
+			continue
+		}
+		ast.Inspect(decl.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			var id *ast.Ident
+			switch fun := unparen(call.Fun).(type) {
+			case *ast.Ident:
+				id = fun
+			case *ast.SelectorExpr:
+				id = fun.Sel
+			case *ast.IndexExpr:
+				// A call to an instantiated generic function.
+				id = identOf(fun.X)
+			case *ast.IndexListExpr:
+				id = identOf(fun.X)
+			}
+			if id == nil {
+				return true
+			}
+			if callee, ok := f.pkg.info.Uses[id].(*types.Func); ok {
+				fn(decl, id, callee)
+			}
+			return true
+		})
+	}
+}
+
+func unparen(e ast.Expr) ast.Expr {
+	for {
+		p, ok := e.(*ast.ParenExpr)
+		if !ok {
+			return e
+		}
+		e = p.X
+	}
+}
+
+func identOf(e ast.Expr) *ast.Ident {
+	switch e := e.(type) {
+	case *ast.Ident:
+		return e
+	case *ast.SelectorExpr:
+		return e.Sel
+	default:
+		return nil
+	}
+}
+
+// funcName returns the name of obj, qualified by its package name
+// and, for methods, its receiver type.
+func funcName(obj types.Object) string {
+	if obj == nil {
+		return "?"
+	}
+	qualifier := func(p *types.Package) string {
+		return p.Name()
+	}
+	if fn, ok := obj.(*types.Func); ok {
+		if sig, ok := fn.Type().(*types.Signature); ok && sig.Recv() != nil {
+			return fmt.Sprintf("(%s).%s", types.TypeString(sig.Recv().Type(), qualifier), fn.Name())
+		}
+	}
+	if obj.Pkg() == nil {
+		return obj.Name()
+	}
+	return obj.Pkg().Name() + "." + obj.Name()
+}
+
+func sortCalls(calls []Call) {
+	sort.Slice(calls, func(i, j int) bool {
+		if calls[i].Decl.Path != calls[j].Decl.Path {
+			return calls[i].Decl.Path < calls[j].Decl.Path
+		}
+		return calls[i].Decl.Offset < calls[j].Decl.Offset
+	})
+}
@@ -0,0 +1,14 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+// Package goref finds the references to go identifiers, and the
+// callers and callees of go functions, across a project by type
+// checking every package in it with go/types.  The contents of files
+// that have unsaved changes can be passed in, so that references are
+// found in the text that is being edited rather than the text on
+// disk.
+//
+// Like the search package, this package does not import any UI
+// code.
+package goref
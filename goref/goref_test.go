@@ -0,0 +1,200 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package goref_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nelsam/vidar/goref"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+var files = map[string]string{
+	"go.mod": "module example.com/p\n",
+	"a/a.go": `package a
+
+// T is a type.
+type T struct {
+	F int
+}
+
+func (t T) M() int {
+	return t.F
+}
+
+func Foo(x int) int {
+	y := x + 1
+	return y
+}
+`,
+	"b/b.go": `package b
+
+import "example.com/p/a"
+
+func Bar() int {
+	t := a.T{F: 2}
+	return a.Foo(t.F) + t.M()
+}
+`,
+}
+
+func TestProject(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	o.BeforeEach(func(t *testing.T) (expect.Expectation, string) {
+		dir, err := ioutil.TempDir("", "vidar-goref")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, src := range files {
+			path := filepath.Join(dir, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(path, []byte(src), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return expect.New(t), dir
+	})
+
+	o.AfterEach(func(_ expect.Expectation, dir string) {
+		os.RemoveAll(dir)
+	})
+
+	load := func(t *testing.T, dir string, overlay map[string]string) *goref.Project {
+		p, err := goref.Load(context.Background(), dir, os.Environ(), overlay)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	// offset returns the offset of ident where it follows before
+	// in src.
+	offset := func(src, before, ident string) int {
+		return strings.Index(src, before+ident) + len(before)
+	}
+
+	o.Spec("it finds references in other packages", func(expect expect.Expectation, dir string) {
+		p := load(t, dir, nil)
+		path := filepath.Join(dir, "a", "a.go")
+		obj, err := p.ObjectAt(path, offset(files["a/a.go"], "func ", "Foo"))
+		expect(err).To(beNil())
+		refs := p.References(obj)
+		expect(refs).To(haveLen(2))
+		expect(refs[0].Path).To(equal(path))
+		expect(refs[0].Def).To(beTrue())
+		expect(refs[1].Path).To(equal(filepath.Join(dir, "b", "b.go")))
+		expect(refs[1].Line).To(equal(6))
+		expect(refs[1].Text).To(equal("\treturn a.Foo(t.F) + t.M()"))
+		expect(refs[1].Column).To(equal(10))
+	})
+
+	o.Spec("it finds fields and methods through any value of their type", func(expect expect.Expectation, dir string) {
+		p := load(t, dir, nil)
+		b := filepath.Join(dir, "b", "b.go")
+		obj, err := p.ObjectAt(b, offset(files["b/b.go"], "Foo(t.", "F"))
+		expect(err).To(beNil())
+		expect(p.References(obj)).To(haveLen(4))
+
+		obj, err = p.ObjectAt(b, offset(files["b/b.go"], "+ t.", "M"))
+		expect(err).To(beNil())
+		expect(p.References(obj)).To(haveLen(2))
+	})
+
+	o.Spec("it keeps local variables to their scope", func(expect expect.Expectation, dir string) {
+		p := load(t, dir, nil)
+		obj, err := p.ObjectAt(filepath.Join(dir, "a", "a.go"), offset(files["a/a.go"], "return ", "y"))
+		expect(err).To(beNil())
+		expect(p.References(obj)).To(haveLen(2))
+	})
+
+	o.Spec("it uses the overlay instead of the file on disk", func(expect expect.Expectation, dir string) {
+		b := filepath.Join(dir, "b", "b.go")
+		src := strings.Replace(files["b/b.go"], "return", "_ = a.Foo(1)\n\treturn", 1)
+		p := load(t, dir, map[string]string{b: src})
+		obj, err := p.ObjectAt(b, offset(src, "_ = a.", "Foo"))
+		expect(err).To(beNil())
+		expect(p.References(obj)).To(haveLen(3))
+	})
+
+	o.Spec("it returns the references to rename", func(expect expect.Expectation, dir string) {
+		p := load(t, dir, nil)
+		refs, err := p.Rename(filepath.Join(dir, "a", "a.go"), offset(files["a/a.go"], "type ", "T"), "U")
+		expect(err).To(beNil())
+		expect(refs).To(haveLen(3))
+		for _, r := range refs {
+			expect(r.Name).To(equal("T"))
+		}
+	})
+
+	o.Spec("it refuses conflicting names", func(expect expect.Expectation, dir string) {
+		p := load(t, dir, nil)
+		a := filepath.Join(dir, "a", "a.go")
+		_, err := p.Rename(a, offset(files["a/a.go"], "func ", "Foo"), "T")
+		expect(err).To(not(beNil()))
+
+		_, err = p.Rename(a, offset(files["a/a.go"], "T) ", "M"), "F")
+		expect(err).To(not(beNil()))
+
+		_, err = p.Rename(a, offset(files["a/a.go"], "return ", "y"), "x")
+		expect(err).To(not(beNil()))
+	})
+
+	o.Spec("it refuses to unexport names used by other packages", func(expect expect.Expectation, dir string) {
+		p := load(t, dir, nil)
+		_, err := p.Rename(filepath.Join(dir, "a", "a.go"), offset(files["a/a.go"], "func ", "Foo"), "foo")
+		expect(err).To(not(beNil()))
+	})
+
+	o.Spec("it refuses invalid identifiers", func(expect expect.Expectation, dir string) {
+		p := load(t, dir, nil)
+		_, err := p.Rename(filepath.Join(dir, "a", "a.go"), offset(files["a/a.go"], "func ", "Foo"), "1foo")
+		expect(err).To(not(beNil()))
+	})
+
+	o.Spec("it finds the callers of a function", func(expect expect.Expectation, dir string) {
+		p := load(t, dir, nil)
+		obj, err := p.ObjectAt(filepath.Join(dir, "a", "a.go"), offset(files["a/a.go"], "func ", "Foo"))
+		expect(err).To(beNil())
+		calls, err := p.Callers(obj)
+		expect(err).To(beNil())
+		expect(calls).To(haveLen(1))
+		expect(calls[0].Name).To(equal("b.Bar"))
+		expect(calls[0].Decl.Path).To(equal(filepath.Join(dir, "b", "b.go")))
+		expect(calls[0].Decl.Line).To(equal(4))
+		expect(calls[0].Sites).To(haveLen(1))
+		expect(calls[0].Sites[0].Line).To(equal(6))
+	})
+
+	o.Spec("it finds the functions that a function calls", func(expect expect.Expectation, dir string) {
+		p := load(t, dir, nil)
+		obj, err := p.ObjectAt(filepath.Join(dir, "b", "b.go"), offset(files["b/b.go"], "func ", "Bar"))
+		expect(err).To(beNil())
+		calls, err := p.Callees(obj)
+		expect(err).To(beNil())
+		expect(calls).To(haveLen(2))
+		expect(calls[0].Name).To(equal("a.Foo"))
+		expect(calls[0].Decl.Path).To(equal(filepath.Join(dir, "a", "a.go")))
+		expect(calls[1].Name).To(equal("(a.T).M"))
+		expect(calls[1].Sites).To(haveLen(1))
+	})
+
+	o.Spec("it refuses to build a call hierarchy for anything but functions", func(expect expect.Expectation, dir string) {
+		p := load(t, dir, nil)
+		obj, err := p.ObjectAt(filepath.Join(dir, "a", "a.go"), offset(files["a/a.go"], "type ", "T"))
+		expect(err).To(beNil())
+		_, err = p.Callers(obj)
+		expect(err).To(not(beNil()))
+	})
+}
@@ -0,0 +1,284 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package goref
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// A Project is every go package under a directory, type checked.
+type Project struct {
+	root    string
+	fset    *token.FileSet
+	pkgs    []*pkg
+	files   map[string]*file
+	owners  map[*types.Var]string
+	imports types.Importer
+
+	// defs maps object keys to their declarations, once Decl has
+	// needed them.
+	defs map[string]Ref
+
+	// exports maps import paths to their export data files.
+	exports map[string]string
+}
+
+type pkg struct {
+	dir   string
+	types *types.Package
+	info  *types.Info
+	files []*file
+}
+
+type file struct {
+	path string
+	src  string
+	ast  *ast.File
+	pkg  *pkg
+}
+
+// Load parses and type checks the packages under root.  overlay maps
+// file paths to their contents, for files whose contents differ from
+// what is on disk.  Directories that go ignores (vendor, testdata,
+// and those starting with "." or "_") are skipped, as are files that
+// are excluded by build constraints.
+//
+// Imported packages are loaded by running the go tool with env as
+// its environment (see setting.Project.Environ), so that GOPATH,
+// GOFLAGS, and module settings are respected.  Type errors do not
+// stop Load; identifiers that can't be resolved just won't be found
+// by References.
+func Load(ctx context.Context, root string, env []string, overlay map[string]string) (*Project, error) {
+	p := &Project{
+		root:    root,
+		fset:    token.NewFileSet(),
+		files:   make(map[string]*file),
+		owners:  make(map[*types.Var]string),
+		exports: make(map[string]string),
+	}
+	if err := p.loadExports(ctx, env); err != nil {
+		return nil, err
+	}
+	p.imports = importer.ForCompiler(p.fset, "gc", p.lookup)
+	dirs, err := packageDirs(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		p.loadDir(dir, overlay)
+	}
+	return p, nil
+}
+
+// GoFiles returns the paths of the go files that Load would read
+// under root, so that the contents of any that have unsaved changes
+// can be passed to Load.
+func GoFiles(root string) ([]string, error) {
+	dirs, err := packageDirs(root)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, dir := range dirs {
+		infos, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, info := range infos {
+			if !info.IsDir() && strings.HasSuffix(info.Name(), ".go") {
+				files = append(files, filepath.Join(dir, info.Name()))
+			}
+		}
+	}
+	return files, nil
+}
+
+// packageDirs returns root and every directory under it that go
+// doesn't ignore.
+func packageDirs(root string) ([]string, error) {
+	var dirs []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		name := info.Name()
+		if path != root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+			return filepath.SkipDir
+		}
+		dirs = append(dirs, path)
+		return nil
+	})
+	return dirs, err
+}
+
+// loadDir parses and checks the packages in dir: the package itself,
+// including its internal tests, and its external test package.
+func (p *Project) loadDir(dir string, overlay map[string]string) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	byName := make(map[string][]*file)
+	var names []string
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		if ok, err := build.Default.MatchFile(dir, name); err != nil || !ok {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		src, ok := overlay[path]
+		if !ok {
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			src = string(b)
+		}
+		f, _ := parser.ParseFile(p.fset, path, src, parser.ParseComments)
+		if f == nil || f.Name == nil {
+			continue
+		}
+		pkgName := f.Name.Name
+		if _, ok := byName[pkgName]; !ok {
+			names = append(names, pkgName)
+		}
+		byName[pkgName] = append(byName[pkgName], &file{path: path, src: src, ast: f})
+	}
+	sort.Strings(names)
+	importPath := importPath(dir)
+	for _, name := range names {
+		path := importPath
+		if strings.HasSuffix(name, "_test") {
+			path += "_test"
+		}
+		p.check(dir, path, byName[name])
+	}
+}
+
+func (p *Project) check(dir, path string, files []*file) {
+	pk := &pkg{
+		dir:   dir,
+		files: files,
+		info: &types.Info{
+			Defs: make(map[*ast.Ident]types.Object),
+			Uses: make(map[*ast.Ident]types.Object),
+		},
+	}
+	asts := make([]*ast.File, 0, len(files))
+	for _, f := range files {
+		f.pkg = pk
+		p.files[f.path] = f
+		asts = append(asts, f.ast)
+	}
+	conf := types.Config{
+		Importer: p.imports,
+		Error:    func(error) {},
+	}
+	pk.types, _ = conf.Check(path, p.fset, asts, pk.info)
+	p.pkgs = append(p.pkgs, pk)
+}
+
+// loadExports runs go list to find the export data of every package
+// that the packages under p.root depend on.
+func (p *Project) loadExports(ctx context.Context, env []string) error {
+	cmd := exec.CommandContext(ctx, "go", "list", "-e", "-export", "-deps", "-test", "-f", "{{.ImportPath}}\t{{.Export}}", "./...")
+	cmd.Dir = p.root
+	cmd.Env = env
+	var errOut bytes.Buffer
+	cmd.Stderr = &errOut
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("go list: %s: %s", err, strings.TrimSpace(errOut.String()))
+	}
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		parts := strings.SplitN(s.Text(), "\t", 2)
+		if len(parts) != 2 || parts[1] == "" || strings.Contains(parts[0], " ") {
+			// Test variants of packages (e.g. "foo [foo.test]")
+			// are skipped; importers get the package itself.
+			continue
+		}
+		if _, ok := p.exports[parts[0]]; ok {
+			continue
+		}
+		p.exports[parts[0]] = parts[1]
+	}
+	return nil
+}
+
+// lookup opens the export data for path.  It is meant to be used
+// with importer.ForCompiler.
+func (p *Project) lookup(path string) (io.ReadCloser, error) {
+	file, ok := p.exports[path]
+	if !ok {
+		return nil, fmt.Errorf("no export data found for %s", path)
+	}
+	return os.Open(file)
+}
+
+// importPath returns the import path of the package in dir, using
+// the nearest go.mod or, failing that, GOPATH.
+func importPath(dir string) string {
+	for d := dir; ; d = filepath.Dir(d) {
+		if mod, ok := modulePath(filepath.Join(d, "go.mod")); ok {
+			rel, err := filepath.Rel(d, dir)
+			if err != nil || rel == "." {
+				return mod
+			}
+			return mod + "/" + filepath.ToSlash(rel)
+		}
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	if pkg, err := build.Default.ImportDir(dir, build.FindOnly); err == nil && pkg.ImportPath != "." && pkg.ImportPath != "" {
+		return pkg.ImportPath
+	}
+	return filepath.ToSlash(dir)
+}
+
+// modulePath returns the module path declared in the go.mod file at
+// path.
+func modulePath(path string) (string, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) != 2 || fields[0] != "module" {
+			continue
+		}
+		return strings.Trim(fields[1], `"`), true
+	}
+	return "", false
+}
@@ -0,0 +1,222 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package goref
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// A Ref is an identifier that refers to an object.
+type Ref struct {
+	Path string
+
+	// Offset is the rune offset of the identifier from the start of
+	// the file, and Line and Column (both 0-based, Column in runes)
+	// are its position.
+	Offset       int
+	Line, Column int
+
+	// Name is the identifier, and Text is the line that it is on.
+	Name string
+	Text string
+
+	// Def is true if the identifier is the object's declaration.
+	Def bool
+}
+
+// ObjectAt returns the object that the identifier at offset (in
+// runes) in the file at path refers to.
+func (p *Project) ObjectAt(path string, offset int) (types.Object, error) {
+	f, ok := p.files[path]
+	if !ok {
+		return nil, fmt.Errorf("%s is not in a go package in %s", filepath.Base(path), p.root)
+	}
+	id := f.identAt(p.fset, byteOffset(f.src, offset))
+	if id == nil {
+		return nil, errors.New("there is no identifier at the caret")
+	}
+	if obj := f.pkg.info.Defs[id]; obj != nil {
+		return obj, nil
+	}
+	if obj := f.pkg.info.Uses[id]; obj != nil {
+		return obj, nil
+	}
+	return nil, fmt.Errorf("could not resolve %s", id.Name)
+}
+
+// identAt returns the identifier in f that contains the byte offset
+// off, if there is one.
+func (f *file) identAt(fset *token.FileSet, off int) *ast.Ident {
+	tf := fset.File(f.ast.Pos())
+	var found *ast.Ident
+	ast.Inspect(f.ast, func(n ast.Node) bool {
+		if found != nil || n == nil {
+			return false
+		}
+		start, end := tf.Offset(n.Pos()), tf.Offset(n.End())
+		if off < start || off > end {
+			return false
+		}
+		if id, ok := n.(*ast.Ident); ok {
+			found = id
+		}
+		return true
+	})
+	return found
+}
+
+// References returns every identifier in the project that refers
+// to obj, including its declaration, sorted by file and offset.
+func (p *Project) References(obj types.Object) []Ref {
+	key := p.key(obj)
+	if key == "" {
+		return nil
+	}
+	seen := make(map[token.Pos]bool)
+	var refs []Ref
+	add := func(f *file, id *ast.Ident, o types.Object, def bool) {
+		if seen[id.Pos()] || p.key(o) != key {
+			return
+		}
+		seen[id.Pos()] = true
+		refs = append(refs, p.ref(f, id, def))
+	}
+	for _, pk := range p.pkgs {
+		for _, f := range pk.files {
+			tf := p.fset.File(f.ast.Pos())
+			for id, o := range pk.info.Defs {
+				if o != nil && p.fset.File(id.Pos()) == tf {
+					add(f, id, o, true)
+				}
+			}
+			for id, o := range pk.info.Uses {
+				if p.fset.File(id.Pos()) == tf {
+					add(f, id, o, false)
+				}
+			}
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Path != refs[j].Path {
+			return refs[i].Path < refs[j].Path
+		}
+		return refs[i].Offset < refs[j].Offset
+	})
+	return refs
+}
+
+func (p *Project) ref(f *file, id *ast.Ident, def bool) Ref {
+	off := p.fset.Position(id.Pos()).Offset
+	lineStart := strings.LastIndexByte(f.src[:off], '\n') + 1
+	lineEnd := strings.IndexByte(f.src[off:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(f.src)
+	} else {
+		lineEnd += off
+	}
+	return Ref{
+		Path:   f.path,
+		Offset: utf8.RuneCountInString(f.src[:off]),
+		Line:   strings.Count(f.src[:off], "\n"),
+		Column: utf8.RuneCountInString(f.src[lineStart:off]),
+		Name:   id.Name,
+		Text:   strings.TrimSuffix(f.src[lineStart:lineEnd], "\r"),
+		Def:    def,
+	}
+}
+
+// key returns a string that identifies obj in every package that
+// refers to it.  Packages that import each other are checked
+// separately, so the same object is represented by a different
+// types.Object in each of them; objects are matched by their
+// package and name instead, or by their position for objects (like
+// local variables) that can only be used in one package.
+func (p *Project) key(obj types.Object) string {
+	if obj == nil || obj.Pkg() == nil {
+		// Builtins and universe objects have no package.
+		return ""
+	}
+	if _, ok := obj.(*types.PkgName); ok {
+		return ""
+	}
+	path := obj.Pkg().Path()
+	if obj.Parent() == obj.Pkg().Scope() {
+		return path + "." + obj.Name()
+	}
+	switch o := obj.(type) {
+	case *types.Func:
+		if sig, ok := o.Type().(*types.Signature); ok && sig.Recv() != nil {
+			if recv := typeName(sig.Recv().Type()); recv != "" {
+				return path + "." + recv + "." + o.Name()
+			}
+		}
+	case *types.Var:
+		if o.IsField() {
+			if owner := p.fieldOwner(o); owner != "" {
+				return path + "." + owner + "." + o.Name()
+			}
+		}
+	}
+	pos := p.fset.Position(obj.Pos())
+	return fmt.Sprintf("%s:%d", pos.Filename, pos.Offset)
+}
+
+// typeName returns the name of the named type (or pointer to a named
+// type) t, or "" if t is not named.
+func typeName(t types.Type) string {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if named, ok := t.(*types.Named); ok {
+		return named.Obj().Name()
+	}
+	return ""
+}
+
+// fieldOwner returns the name of the package-level struct type that
+// v is a field of, or "" if it isn't a field of one.
+func (p *Project) fieldOwner(v *types.Var) string {
+	if owner, ok := p.owners[v]; ok {
+		return owner
+	}
+	scope := v.Pkg().Scope()
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok {
+			continue
+		}
+		s, ok := tn.Type().Underlying().(*types.Struct)
+		if !ok {
+			continue
+		}
+		for i := 0; i < s.NumFields(); i++ {
+			p.owners[s.Field(i)] = name
+		}
+	}
+	if _, ok := p.owners[v]; !ok {
+		p.owners[v] = ""
+	}
+	return p.owners[v]
+}
+
+// byteOffset returns the byte offset of the rune offset off in src.
+func byteOffset(src string, off int) int {
+	i := 0
+	for b := range src {
+		if i == off {
+			return b
+		}
+		i++
+	}
+	return len(src)
+}
@@ -0,0 +1,128 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package goref
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strings"
+)
+
+// Rename returns the identifiers that have to change to rename the
+// object referred to by the identifier at offset (in runes) in the
+// file at path to newName.  It returns an error, without any
+// identifiers, if the object can't be renamed or if newName would
+// conflict with another name.
+//
+// Rename only checks for conflicts in the scope that the object is
+// declared in (or, for methods and fields, in its type); it doesn't
+// catch every way that a rename can break a program (e.g. a type no
+// longer implementing an interface).
+func (p *Project) Rename(path string, offset int, newName string) ([]Ref, error) {
+	if !token.IsIdentifier(newName) {
+		return nil, fmt.Errorf("%q is not a valid identifier", newName)
+	}
+	obj, err := p.ObjectAt(path, offset)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case obj.Pkg() == nil:
+		return nil, fmt.Errorf("%s is built in and cannot be renamed", obj.Name())
+	case isPkgName(obj):
+		return nil, fmt.Errorf("renaming imports is not supported")
+	case obj.Name() == newName:
+		return nil, fmt.Errorf("%s is already called %s", obj.Name(), newName)
+	case obj.Name() == "_":
+		return nil, fmt.Errorf("blank identifiers cannot be renamed")
+	}
+	decl := p.fset.Position(obj.Pos()).Filename
+	if !p.contains(decl) {
+		return nil, fmt.Errorf("%s is declared outside of %s", obj.Name(), p.root)
+	}
+	if err := p.conflict(obj, newName); err != nil {
+		return nil, err
+	}
+	refs := p.References(obj)
+	if !token.IsExported(newName) && token.IsExported(obj.Name()) {
+		for _, r := range refs {
+			if !p.samePackage(r.Path, decl) {
+				return nil, fmt.Errorf("%s is used outside of its package (in %s), so it must stay exported", obj.Name(), filepath.Base(r.Path))
+			}
+		}
+	}
+	return refs, nil
+}
+
+func isPkgName(obj types.Object) bool {
+	_, ok := obj.(*types.PkgName)
+	return ok
+}
+
+func (p *Project) contains(path string) bool {
+	rel, err := filepath.Rel(p.root, path)
+	return err == nil && !strings.HasPrefix(rel, "..")
+}
+
+// samePackage returns whether the files at a and b are in the same
+// package.
+func (p *Project) samePackage(a, b string) bool {
+	fa, ok := p.files[a]
+	if !ok {
+		return false
+	}
+	fb, ok := p.files[b]
+	if !ok {
+		return false
+	}
+	return fa.pkg == fb.pkg
+}
+
+// conflict returns an error if renaming obj to newName would clash
+// with a name that is already declared.
+func (p *Project) conflict(obj types.Object, newName string) error {
+	switch o := obj.(type) {
+	case *types.Func:
+		if sig, ok := o.Type().(*types.Signature); ok && sig.Recv() != nil {
+			return p.memberConflict(sig.Recv().Type(), obj, newName)
+		}
+	case *types.Var:
+		if o.IsField() {
+			if owner := p.fieldOwner(o); owner != "" {
+				return p.memberConflict(o.Pkg().Scope().Lookup(owner).Type(), obj, newName)
+			}
+			return nil
+		}
+	}
+	scope := obj.Parent()
+	if scope == nil {
+		return nil
+	}
+	if scope.Lookup(newName) != nil {
+		return fmt.Errorf("%s is already declared in this scope", newName)
+	}
+	if scope == obj.Pkg().Scope() {
+		// Package level names also clash with names declared in
+		// any file's scope (i.e. imports).
+		for i := 0; i < scope.NumChildren(); i++ {
+			if existing := scope.Child(i).Lookup(newName); existing != nil {
+				if _, ok := existing.(*types.PkgName); ok {
+					return fmt.Errorf("%s is already the name of an import", newName)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (p *Project) memberConflict(t types.Type, obj types.Object, newName string) error {
+	existing, _, _ := types.LookupFieldOrMethod(t, true, obj.Pkg(), newName)
+	if existing != nil {
+		return fmt.Errorf("%s already has a field or method called %s", typeName(t), newName)
+	}
+	return nil
+}
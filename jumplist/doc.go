@@ -0,0 +1,11 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+// Package jumplist keeps track of the locations that the caret has
+// jumped between, so that they can be gone back (and forward) to.
+// Locations are kept as file offsets, which are adjusted as the
+// text in their files is edited.
+//
+// Like the dlv package, this package does not import any UI code.
+package jumplist
@@ -0,0 +1,188 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package jumplist
+
+const (
+	// MaxLocations is the most locations that a History will keep.
+	// The oldest locations are dropped first.
+	MaxLocations = 100
+
+	// FarLines is the number of lines that the caret has to move
+	// past for the move to count as a jump.
+	FarLines = 10
+)
+
+// A Location is a position in a file.
+type Location struct {
+	Path   string
+	Offset int
+}
+
+// History is a stack of the locations that have been jumped
+// between, with a position in the stack that moves back and forward
+// through them.  It is not safe for concurrent use.
+type History struct {
+	locations []Location
+	current   int
+}
+
+// New returns an empty *History.
+func New() *History {
+	return &History{current: -1}
+}
+
+// Jump records a jump from one location to another.  Any locations
+// that could have been gone forward to are dropped.
+//
+// A jump to the current location is ignored, since that is what
+// happens when Back, Forward, or Go move to a location.
+func (h *History) Jump(from, to Location) {
+	if from == to {
+		return
+	}
+	if h.current >= 0 && h.locations[h.current] == to {
+		return
+	}
+	if h.current < 0 || h.locations[h.current] != from {
+		h.push(from)
+	}
+	h.push(to)
+}
+
+// Back returns the location before the current one and makes it
+// current.  here is the location that the caret is at, which
+// replaces the current location if it is in the same file; the
+// caret may have moved a little since the jump to it.  The returned
+// bool is false if there is nowhere to go back to.
+func (h *History) Back(here Location) (Location, bool) {
+	h.settle(here)
+	if h.current <= 0 {
+		return Location{}, false
+	}
+	h.current--
+	return h.locations[h.current], true
+}
+
+// Forward returns the location after the current one and makes it
+// current.  It treats here the same way that Back does.  The
+// returned bool is false if there is nowhere to go forward to.
+func (h *History) Forward(here Location) (Location, bool) {
+	h.settle(here)
+	if h.current < 0 || h.current >= len(h.locations)-1 {
+		return Location{}, false
+	}
+	h.current++
+	return h.locations[h.current], true
+}
+
+// Go returns the location at index i (as returned by Locations) and
+// makes it current.  The returned bool is false if there is no
+// location at i.
+func (h *History) Go(i int) (Location, bool) {
+	if i < 0 || i >= len(h.locations) {
+		return Location{}, false
+	}
+	h.current = i
+	return h.locations[i], true
+}
+
+// Locations returns a copy of the locations in h, oldest first,
+// along with the index of the current location.  The index is -1 if
+// h is empty.
+func (h *History) Locations() ([]Location, int) {
+	return append([]Location(nil), h.locations...), h.current
+}
+
+// Adjust moves the locations in path to account for an edit at
+// offset at, which replaced removed runes with added runes.
+// Locations inside of the removed text are moved to the start of
+// the edit.
+func (h *History) Adjust(path string, at, removed, added int) {
+	delta := added - removed
+	if delta == 0 {
+		return
+	}
+	for i, l := range h.locations {
+		if l.Path != path || l.Offset < at {
+			continue
+		}
+		l.Offset += delta
+		if l.Offset < at {
+			l.Offset = at
+		}
+		h.locations[i] = l
+	}
+}
+
+// Rename moves the locations in oldPath to newPath.
+func (h *History) Rename(oldPath, newPath string) {
+	for i, l := range h.locations {
+		if l.Path == oldPath {
+			h.locations[i].Path = newPath
+		}
+	}
+}
+
+// settle replaces the current location with here, or pushes here on
+// to the stack if it is in a different file.
+func (h *History) settle(here Location) {
+	if here.Path == "" || h.current < 0 {
+		return
+	}
+	if h.locations[h.current].Path == here.Path {
+		h.locations[h.current] = here
+		return
+	}
+	h.push(here)
+}
+
+func (h *History) push(l Location) {
+	h.locations = append(h.locations[:h.current+1], l)
+	if extra := len(h.locations) - MaxLocations; extra > 0 {
+		h.locations = append(h.locations[:0], h.locations[extra:]...)
+	}
+	h.current = len(h.locations) - 1
+}
+
+// Far returns whether there are more than FarLines lines between
+// offsets from and to in runes.
+func Far(runes []rune, from, to int) bool {
+	if from > to {
+		from, to = to, from
+	}
+	if from < 0 {
+		from = 0
+	}
+	if to > len(runes) {
+		to = len(runes)
+	}
+	if from > to {
+		return false
+	}
+	lines := 0
+	for _, r := range runes[from:to] {
+		if r == '\n' {
+			lines++
+			if lines > FarLines {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Line returns the (zero-based) line that offset is on in runes.
+func Line(runes []rune, offset int) int {
+	if offset > len(runes) {
+		offset = len(runes)
+	}
+	line := 0
+	for _, r := range runes[:offset] {
+		if r == '\n' {
+			line++
+		}
+	}
+	return line
+}
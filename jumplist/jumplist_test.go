@@ -0,0 +1,124 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package jumplist_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nelsam/vidar/jumplist"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+func TestHistory(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	o.BeforeEach(func(t *testing.T) (expect.Expectation, *jumplist.History) {
+		return expect.New(t), jumplist.New()
+	})
+
+	loc := func(path string, offset int) jumplist.Location {
+		return jumplist.Location{Path: path, Offset: offset}
+	}
+
+	o.Spec("it has nowhere to go when it is empty", func(expect expect.Expectation, h *jumplist.History) {
+		_, ok := h.Back(loc("/a.go", 0))
+		expect(ok).To(beFalse())
+		_, ok = h.Forward(loc("/a.go", 0))
+		expect(ok).To(beFalse())
+	})
+
+	o.Spec("it goes back and forward between jumps", func(expect expect.Expectation, h *jumplist.History) {
+		h.Jump(loc("/a.go", 10), loc("/b.go", 20))
+		h.Jump(loc("/b.go", 20), loc("/c.go", 30))
+
+		l, ok := h.Back(loc("/c.go", 30))
+		expect(ok).To(beTrue())
+		expect(l).To(equal(loc("/b.go", 20)))
+		l, ok = h.Back(loc("/b.go", 20))
+		expect(ok).To(beTrue())
+		expect(l).To(equal(loc("/a.go", 10)))
+		_, ok = h.Back(loc("/a.go", 10))
+		expect(ok).To(beFalse())
+
+		l, ok = h.Forward(loc("/a.go", 10))
+		expect(ok).To(beTrue())
+		expect(l).To(equal(loc("/b.go", 20)))
+	})
+
+	o.Spec("it ignores jumps to the location it just moved to", func(expect expect.Expectation, h *jumplist.History) {
+		h.Jump(loc("/a.go", 10), loc("/b.go", 20))
+		l, _ := h.Back(loc("/b.go", 20))
+		h.Jump(loc("/b.go", 20), l)
+
+		l, ok := h.Forward(l)
+		expect(ok).To(beTrue())
+		expect(l).To(equal(loc("/b.go", 20)))
+	})
+
+	o.Spec("it drops the locations ahead of a new jump", func(expect expect.Expectation, h *jumplist.History) {
+		h.Jump(loc("/a.go", 10), loc("/b.go", 20))
+		h.Back(loc("/b.go", 20))
+		h.Jump(loc("/a.go", 10), loc("/c.go", 30))
+
+		_, ok := h.Forward(loc("/c.go", 30))
+		expect(ok).To(beFalse())
+		locs, current := h.Locations()
+		expect(locs).To(equal([]jumplist.Location{loc("/a.go", 10), loc("/c.go", 30)}))
+		expect(current).To(equal(1))
+	})
+
+	o.Spec("it keeps where the caret went after a jump", func(expect expect.Expectation, h *jumplist.History) {
+		h.Jump(loc("/a.go", 10), loc("/b.go", 20))
+		h.Back(loc("/b.go", 25))
+
+		l, ok := h.Forward(loc("/a.go", 10))
+		expect(ok).To(beTrue())
+		expect(l).To(equal(loc("/b.go", 25)))
+	})
+
+	o.Spec("it adjusts locations for edits", func(expect expect.Expectation, h *jumplist.History) {
+		h.Jump(loc("/a.go", 10), loc("/a.go", 200))
+		h.Jump(loc("/a.go", 200), loc("/b.go", 50))
+
+		h.Adjust("/a.go", 5, 0, 3)
+		h.Adjust("/a.go", 100, 110, 0)
+		locs, _ := h.Locations()
+		expect(locs).To(equal([]jumplist.Location{loc("/a.go", 13), loc("/a.go", 100), loc("/b.go", 50)}))
+	})
+
+	o.Spec("it keeps no more than MaxLocations", func(expect expect.Expectation, h *jumplist.History) {
+		for i := 0; i < jumplist.MaxLocations; i++ {
+			h.Jump(loc("/a.go", i), loc("/a.go", i+1))
+		}
+		locs, current := h.Locations()
+		expect(locs).To(haveLen(jumplist.MaxLocations))
+		expect(current).To(equal(jumplist.MaxLocations - 1))
+		expect(locs[current]).To(equal(loc("/a.go", jumplist.MaxLocations)))
+	})
+}
+
+func TestFar(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	o.BeforeEach(func(t *testing.T) (expect.Expectation, []rune) {
+		return expect.New(t), []rune(strings.Repeat("line\n", 3*jumplist.FarLines))
+	})
+
+	o.Spec("it counts lines in either direction", func(expect expect.Expectation, runes []rune) {
+		near := len("line\n") * jumplist.FarLines
+		expect(jumplist.Far(runes, 0, near)).To(beFalse())
+		expect(jumplist.Far(runes, 0, near+len("line\n"))).To(beTrue())
+		expect(jumplist.Far(runes, near+len("line\n"), 0)).To(beTrue())
+	})
+
+	o.Spec("it handles offsets past the end of the text", func(expect expect.Expectation, runes []rune) {
+		expect(jumplist.Far(runes, len(runes)+10, len(runes)+20)).To(beFalse())
+		expect(jumplist.Line(runes, len(runes)+10)).To(equal(3 * jumplist.FarLines))
+	})
+}
@@ -0,0 +1,391 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// pipe combines a process's stdout and stdin into a single
+// io.ReadWriteCloser.
+type pipe struct {
+	io.ReadCloser
+	io.WriteCloser
+}
+
+func (p pipe) Close() error {
+	werr := p.WriteCloser.Close()
+	if err := p.ReadCloser.Close(); err != nil {
+		return err
+	}
+	return werr
+}
+
+// Client is a connection to a single language server.
+type Client struct {
+	conn *Conn
+	cmd  *exec.Cmd
+	caps ServerCapabilities
+
+	mu          sync.Mutex
+	docs        map[string]*document
+	diagnostics DiagnosticsHandler
+}
+
+// DiagnosticsHandler is called with the diagnostics that a server
+// publishes for path.  text is the client's copy of the document
+// that the diagnostics were published for, or nil if path is not
+// open.  It is called from the connection's read goroutine, so it
+// must not block.
+type DiagnosticsHandler func(path string, text []rune, diags []Diagnostic)
+
+// Start starts a language server by running command with args in
+// the directory root, then initializes it with root as the
+// workspace root.
+func Start(ctx context.Context, root string, env []string, command string, args ...string) (*Client, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Dir = root
+	cmd.Env = env
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("lsp: could not start %s: %s", command, err)
+	}
+	c, err := NewClient(ctx, pipe{ReadCloser: out, WriteCloser: in}, root)
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, err
+	}
+	c.cmd = cmd
+	return c, nil
+}
+
+// NewClient initializes a language server that is already
+// connected via rwc.
+func NewClient(ctx context.Context, rwc io.ReadWriteCloser, root string) (*Client, error) {
+	c := &Client{
+		docs: make(map[string]*document),
+	}
+	c.conn = NewConn(rwc, c.notified)
+
+	params := InitializeParams{
+		ProcessID: os.Getpid(),
+		RootURI:   URI(root),
+	}
+	params.Capabilities.TextDocument.Synchronization.DidSave = true
+	var result InitializeResult
+	if err := c.conn.Call(ctx, "initialize", params, &result); err != nil {
+		c.conn.Close()
+		return nil, err
+	}
+	if err := c.conn.Notify("initialized", struct{}{}); err != nil {
+		c.conn.Close()
+		return nil, err
+	}
+	c.caps = result.Capabilities
+	return c, nil
+}
+
+// Capabilities returns the capabilities that the server reported
+// during initialization.
+func (c *Client) Capabilities() ServerCapabilities {
+	return c.caps
+}
+
+// Done returns a channel that is closed when the connection to
+// the server is lost.
+func (c *Client) Done() <-chan struct{} {
+	return c.conn.Done()
+}
+
+// OnDiagnostics sets the function that will be called when the
+// server publishes diagnostics.
+func (c *Client) OnDiagnostics(h DiagnosticsHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.diagnostics = h
+}
+
+func (c *Client) notified(method string, params json.RawMessage) {
+	if method != "textDocument/publishDiagnostics" {
+		return
+	}
+	var p PublishDiagnosticsParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return
+	}
+	path := Path(p.URI)
+
+	c.mu.Lock()
+	h := c.diagnostics
+	var text []rune
+	if d, ok := c.docs[path]; ok {
+		if p.Version != nil && *p.Version != d.version {
+			// The diagnostics are for an older version of the
+			// document, so their positions are not reliable.
+			c.mu.Unlock()
+			return
+		}
+		text = append([]rune(nil), d.text...)
+	}
+	c.mu.Unlock()
+
+	if h != nil {
+		h(path, text, p.Diagnostics)
+	}
+}
+
+// Open tells the server that path has been opened with the passed
+// in text.  If path is already open, the server's copy is replaced
+// with text.
+func (c *Client) Open(path, languageID string, text []rune) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if d, ok := c.docs[path]; ok {
+		if string(d.text) == string(text) {
+			return nil
+		}
+		d.text = append([]rune(nil), text...)
+		d.version++
+		return c.conn.Notify("textDocument/didChange", DidChangeTextDocumentParams{
+			TextDocument:   VersionedTextDocumentIdentifier{URI: d.uri, Version: d.version},
+			ContentChanges: []TextDocumentContentChangeEvent{{Text: string(text)}},
+		})
+	}
+	d := &document{
+		uri:        URI(path),
+		languageID: languageID,
+		version:    1,
+		text:       append([]rune(nil), text...),
+	}
+	c.docs[path] = d
+	return c.conn.Notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{
+			URI:        d.uri,
+			LanguageID: languageID,
+			Version:    d.version,
+			Text:       string(text),
+		},
+	})
+}
+
+// Change tells the server that the text old at the rune offset at
+// in path was replaced with new.
+func (c *Client) Change(path string, at int, old, new []rune) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	d, ok := c.docs[path]
+	if !ok {
+		return fmt.Errorf("lsp: document %s is not open", path)
+	}
+	change := d.edit(at, old, new)
+	kind := c.caps.SyncKind()
+	if kind == SyncNone {
+		return nil
+	}
+	if kind == SyncFull {
+		change = TextDocumentContentChangeEvent{Text: string(d.text)}
+	}
+	return c.conn.Notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: d.uri, Version: d.version},
+		ContentChanges: []TextDocumentContentChangeEvent{change},
+	})
+}
+
+// Save tells the server that path has been saved.
+func (c *Client) Save(path string) error {
+	uri, err := c.uri(path)
+	if err != nil {
+		return err
+	}
+	return c.conn.Notify("textDocument/didSave", DidSaveTextDocumentParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+	})
+}
+
+// Close tells the server that path has been closed.
+func (c *Client) Close(path string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	d, ok := c.docs[path]
+	if !ok {
+		return nil
+	}
+	delete(c.docs, path)
+	return c.conn.Notify("textDocument/didClose", DidCloseTextDocumentParams{
+		TextDocument: TextDocumentIdentifier{URI: d.uri},
+	})
+}
+
+// Text returns the client's copy of the text in path.
+func (c *Client) Text(path string) ([]rune, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	d, ok := c.docs[path]
+	if !ok {
+		return nil, false
+	}
+	return append([]rune(nil), d.text...), true
+}
+
+// A Document is a copy of a document that is open in a Client.
+type Document struct {
+	Path, LanguageID string
+	Text             []rune
+}
+
+// Documents returns copies of the documents that are open in c,
+// e.g. so that they can be opened in a new client if c's server
+// exits.
+func (c *Client) Documents() []Document {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	docs := make([]Document, 0, len(c.docs))
+	for path, d := range c.docs {
+		docs = append(docs, Document{
+			Path:       path,
+			LanguageID: d.languageID,
+			Text:       append([]rune(nil), d.text...),
+		})
+	}
+	return docs
+}
+
+func (c *Client) uri(path string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	d, ok := c.docs[path]
+	if !ok {
+		return "", fmt.Errorf("lsp: document %s is not open", path)
+	}
+	return d.uri, nil
+}
+
+func (c *Client) positionParams(path string, offset int) (TextDocumentPositionParams, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	d, ok := c.docs[path]
+	if !ok {
+		return TextDocumentPositionParams{}, fmt.Errorf("lsp: document %s is not open", path)
+	}
+	return TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: d.uri},
+		Position:     PositionFor(d.text, offset),
+	}, nil
+}
+
+// Completion requests completion suggestions at the rune offset
+// in path.
+func (c *Client) Completion(ctx context.Context, path string, offset int) ([]CompletionItem, error) {
+	if !provides(c.caps.CompletionProvider) {
+		return nil, fmt.Errorf("lsp: server does not support completion")
+	}
+	params, err := c.positionParams(path, offset)
+	if err != nil {
+		return nil, err
+	}
+	var raw json.RawMessage
+	if err := c.conn.Call(ctx, "textDocument/completion", params, &raw); err != nil {
+		return nil, err
+	}
+	var items []CompletionItem
+	if err := json.Unmarshal(raw, &items); err == nil {
+		return items, nil
+	}
+	var list CompletionList
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, fmt.Errorf("lsp: could not parse completion result: %s", err)
+	}
+	return list.Items, nil
+}
+
+// Definition requests the location(s) of the definition of the
+// identifier at the rune offset in path.
+func (c *Client) Definition(ctx context.Context, path string, offset int) ([]Location, error) {
+	if !provides(c.caps.DefinitionProvider) {
+		return nil, fmt.Errorf("lsp: server does not support definitions")
+	}
+	params, err := c.positionParams(path, offset)
+	if err != nil {
+		return nil, err
+	}
+	var raw json.RawMessage
+	if err := c.conn.Call(ctx, "textDocument/definition", params, &raw); err != nil {
+		return nil, err
+	}
+	return parseLocations(raw)
+}
+
+// Format requests the edits needed to format path.
+func (c *Client) Format(ctx context.Context, path string, opts FormattingOptions) ([]TextEdit, error) {
+	if !provides(c.caps.DocumentFormattingProvider) {
+		return nil, fmt.Errorf("lsp: server does not support formatting")
+	}
+	uri, err := c.uri(path)
+	if err != nil {
+		return nil, err
+	}
+	var edits []TextEdit
+	err = c.conn.Call(ctx, "textDocument/formatting", DocumentFormattingParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Options:      opts,
+	}, &edits)
+	return edits, err
+}
+
+// Shutdown asks the server to shut down, then closes the
+// connection.
+func (c *Client) Shutdown(ctx context.Context) error {
+	err := c.conn.Call(ctx, "shutdown", nil, nil)
+	if err == nil {
+		err = c.conn.Notify("exit", nil)
+	}
+	c.conn.Close()
+	if c.cmd != nil {
+		c.cmd.Wait()
+	}
+	return err
+}
+
+// parseLocations parses the result of a request that may return
+// a Location, a list of Locations, or a list of LocationLinks.
+func parseLocations(raw json.RawMessage) ([]Location, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var loc Location
+	if err := json.Unmarshal(raw, &loc); err == nil {
+		return []Location{loc}, nil
+	}
+	var links []struct {
+		Location
+		TargetURI            string `json:"targetUri"`
+		TargetSelectionRange Range  `json:"targetSelectionRange"`
+	}
+	if err := json.Unmarshal(raw, &links); err != nil {
+		return nil, fmt.Errorf("lsp: could not parse locations: %s", err)
+	}
+	locs := make([]Location, 0, len(links))
+	for _, l := range links {
+		if l.TargetURI != "" {
+			l.Location = Location{URI: l.TargetURI, Range: l.TargetSelectionRange}
+		}
+		locs = append(locs, l.Location)
+	}
+	return locs, nil
+}
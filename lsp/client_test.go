@@ -0,0 +1,153 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package lsp_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nelsam/vidar/lsp"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+func TestClient(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	const path = "/tmp/foo.go"
+
+	o.BeforeEach(func(t *testing.T) (expect.Expectation, *lsp.Client) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		env := append(os.Environ(), fakeServerEnv+"=1")
+		c, err := lsp.Start(ctx, os.TempDir(), env, os.Args[0])
+		if err != nil {
+			t.Fatalf("could not start fake server: %s", err)
+		}
+		if err := c.Open(path, "go", []rune("package foo\n\nfunc Foo() {}\n")); err != nil {
+			t.Fatalf("could not open document: %s", err)
+		}
+		return expect.New(t), c
+	})
+
+	o.AfterEach(func(expect expect.Expectation, c *lsp.Client) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		expect(c.Shutdown(ctx)).To(not(haveOccurred()))
+	})
+
+	o.Spec("it sends open documents to the server", func(expect expect.Expectation, c *lsp.Client) {
+		items, err := c.Completion(context.Background(), path, len("package foo"))
+		expect(err).To(not(haveOccurred()))
+		expect(items).To(haveLen(1))
+		expect(items[0].Detail).To(equal("package foo"))
+	})
+
+	o.Spec("it sends incremental changes to the server", func(expect expect.Expectation, c *lsp.Client) {
+		expect(c.Change(path, len("package "), []rune("foo"), []rune("bar\n// ☃𝄞"))).To(not(haveOccurred()))
+		expect(c.Change(path, len([]rune("package bar\n// ☃𝄞")), nil, []rune("!"))).To(not(haveOccurred()))
+
+		items, err := c.Completion(context.Background(), path, len([]rune("package bar\n// ☃𝄞!")))
+		expect(err).To(not(haveOccurred()))
+		expect(items).To(haveLen(1))
+		expect(items[0].Detail).To(equal("// ☃𝄞!"))
+	})
+
+	o.Spec("it clamps changes that are past the end of the document", func(expect expect.Expectation, c *lsp.Client) {
+		expect(c.Change(path, 100, []rune("foo"), []rune("!"))).To(not(haveOccurred()))
+
+		text, ok := c.Text(path)
+		expect(ok).To(equal(true))
+		expect(string(text)).To(equal("package foo\n\nfunc Foo() {}\n!"))
+	})
+
+	o.Spec("it replaces the text of documents that are re-opened", func(expect expect.Expectation, c *lsp.Client) {
+		expect(c.Open(path, "go", []rune("package baz\n"))).To(not(haveOccurred()))
+
+		items, err := c.Completion(context.Background(), path, len("package baz"))
+		expect(err).To(not(haveOccurred()))
+		expect(items).To(haveLen(1))
+		expect(items[0].Detail).To(equal("package baz"))
+	})
+
+	o.Spec("it converts definitions to locations", func(expect expect.Expectation, c *lsp.Client) {
+		locs, err := c.Definition(context.Background(), path, len("package foo\n\nfunc F"))
+		expect(err).To(not(haveOccurred()))
+		expect(locs).To(haveLen(1))
+		expect(lsp.Path(locs[0].URI)).To(equal(filepath.FromSlash(path)))
+		expect(locs[0].Range.End).To(equal(lsp.Position{Line: 2, Character: 6}))
+	})
+
+	o.Spec("it requests formatting edits", func(expect expect.Expectation, c *lsp.Client) {
+		edits, err := c.Format(context.Background(), path, lsp.FormattingOptions{TabSize: 4})
+		expect(err).To(not(haveOccurred()))
+		expect(edits).To(equal([]lsp.TextEdit{{NewText: "// formatted\n"}}))
+	})
+
+	o.Spec("it passes published diagnostics to its handler", func(expect expect.Expectation, c *lsp.Client) {
+		type published struct {
+			path  string
+			text  []rune
+			diags []lsp.Diagnostic
+		}
+		ch := make(chan published, 1)
+		c.OnDiagnostics(func(path string, text []rune, diags []lsp.Diagnostic) {
+			ch <- published{path: path, text: text, diags: diags}
+		})
+		expect(c.Save(path)).To(not(haveOccurred()))
+
+		var p published
+		select {
+		case p = <-ch:
+		case <-time.After(5 * time.Second):
+		}
+		expect(p.path).To(equal(filepath.FromSlash(path)))
+		expect(string(p.text)).To(equal("package foo\n\nfunc Foo() {}\n"))
+		expect(p.diags).To(haveLen(1))
+		expect(p.diags[0].Message).To(equal("saved"))
+		expect(p.diags[0].Range.End).To(equal(lsp.Position{Character: len("package foo")}))
+	})
+
+	o.Spec("it returns copies of its open documents", func(expect expect.Expectation, c *lsp.Client) {
+		expect(c.Change(path, len("package "), []rune("foo"), []rune("bar"))).To(not(haveOccurred()))
+
+		docs := c.Documents()
+		expect(docs).To(haveLen(1))
+		expect(docs[0].Path).To(equal(path))
+		expect(docs[0].LanguageID).To(equal("go"))
+		expect(string(docs[0].Text)).To(equal("package bar\n\nfunc Foo() {}\n"))
+	})
+
+	o.Spec("it errors on documents that are not open", func(expect expect.Expectation, c *lsp.Client) {
+		_, err := c.Completion(context.Background(), "/tmp/bar.go", 0)
+		expect(err).To(haveOccurred())
+	})
+}
+
+func TestPositions(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	o.BeforeEach(func(t *testing.T) (expect.Expectation, []rune) {
+		return expect.New(t), []rune("foo\n☃𝄞 bar\nbaz")
+	})
+
+	o.Spec("it counts characters in UTF-16 code units", func(expect expect.Expectation, text []rune) {
+		expect(lsp.PositionFor(text, 9)).To(equal(lsp.Position{Line: 1, Character: 6}))
+	})
+
+	o.Spec("it converts positions back to rune offsets", func(expect expect.Expectation, text []rune) {
+		expect(lsp.Offset(text, lsp.Position{Line: 1, Character: 6})).To(equal(9))
+		expect(lsp.Offset(text, lsp.Position{Line: 2, Character: 1})).To(equal(12))
+	})
+
+	o.Spec("it clamps positions past the end of a line", func(expect expect.Expectation, text []rune) {
+		expect(lsp.Offset(text, lsp.Position{Line: 0, Character: 20})).To(equal(3))
+	})
+}
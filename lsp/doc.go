@@ -0,0 +1,15 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+// Package lsp contains a client for the Language Server Protocol.
+// It speaks JSON-RPC 2.0 over a server's stdin and stdout and
+// exposes the small subset of the protocol that vidar makes use
+// of: the document lifecycle (didOpen, didChange, didSave,
+// didClose), completion, definition, formatting, and published
+// diagnostics.
+//
+// This package does not import any UI code, so that it may be
+// tested against a fake server process and reused by plugins
+// without pulling in the rest of the editor.
+package lsp
@@ -0,0 +1,86 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package lsp
+
+import "unicode/utf8"
+
+// PositionFor converts a rune offset in text to a Position.
+func PositionFor(text []rune, offset int) Position {
+	if offset > len(text) {
+		offset = len(text)
+	}
+	var p Position
+	for _, r := range text[:offset] {
+		if r == '\n' {
+			p.Line++
+			p.Character = 0
+			continue
+		}
+		p.Character += runeLen(r)
+	}
+	return p
+}
+
+// runeLen returns the number of UTF-16 code units needed to encode
+// r.  Invalid runes are encoded as U+FFFD, which takes one.
+func runeLen(r rune) int {
+	if r >= 0x10000 && r <= utf8.MaxRune {
+		return 2
+	}
+	return 1
+}
+
+// Offset converts a Position in text to a rune offset.  Positions
+// past the end of a line are clamped to the end of that line.
+func Offset(text []rune, p Position) int {
+	i := 0
+	for line := 0; line < p.Line; line++ {
+		for i < len(text) && text[i] != '\n' {
+			i++
+		}
+		if i == len(text) {
+			return i
+		}
+		i++
+	}
+	for char := 0; char < p.Character && i < len(text) && text[i] != '\n'; i++ {
+		char += runeLen(text[i])
+	}
+	return i
+}
+
+// document is the client's copy of a document that is open on
+// the server.
+type document struct {
+	uri        string
+	languageID string
+	version    int
+	text       []rune
+}
+
+// edit applies an edit at the rune offset at to d, returning the
+// incremental change event describing it.  Edits that reach past the
+// end of d are clamped to it.
+func (d *document) edit(at int, old, new []rune) TextDocumentContentChangeEvent {
+	if at < 0 {
+		at = 0
+	}
+	if at > len(d.text) {
+		at = len(d.text)
+	}
+	end := at + len(old)
+	if end > len(d.text) {
+		end = len(d.text)
+	}
+	r := Range{Start: PositionFor(d.text, at), End: PositionFor(d.text, end)}
+
+	text := make([]rune, 0, len(d.text)-(end-at)+len(new))
+	text = append(text, d.text[:at]...)
+	text = append(text, new...)
+	text = append(text, d.text[end:]...)
+	d.text = text
+	d.version++
+	return TextDocumentContentChangeEvent{Range: &r, Text: string(new)}
+}
@@ -0,0 +1,262 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	jsonrpcVersion = "2.0"
+
+	// CodeMethodNotFound is the JSON-RPC error code for an
+	// unsupported method.
+	CodeMethodNotFound = -32601
+
+	// CodeRequestCancelled is the LSP error code for a request
+	// that the client cancelled.
+	CodeRequestCancelled = -32800
+)
+
+// ErrClosed is returned from calls on a closed *Conn.
+var ErrClosed = errors.New("lsp: connection closed")
+
+// Error is an error response from the server.
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("lsp: %s (code %d)", e.Message, e.Code)
+}
+
+// NotificationHandler is called for each notification that the
+// server sends to the client.  It is called from the *Conn's read
+// goroutine, so it must not block.
+type NotificationHandler func(method string, params json.RawMessage)
+
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *Error           `json:"error,omitempty"`
+}
+
+// Conn is a JSON-RPC 2.0 connection using the header framing
+// defined by the language server protocol.
+type Conn struct {
+	rwc     io.ReadWriteCloser
+	r       *bufio.Reader
+	handler NotificationHandler
+
+	wmu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan *message
+	err     error
+	done    chan struct{}
+}
+
+// NewConn returns a *Conn reading from and writing to rwc.  The
+// handler (which may be nil) will be called for notifications
+// from the server.
+func NewConn(rwc io.ReadWriteCloser, handler NotificationHandler) *Conn {
+	c := &Conn{
+		rwc:     rwc,
+		r:       bufio.NewReader(rwc),
+		handler: handler,
+		pending: make(map[int64]chan *message),
+		done:    make(chan struct{}),
+	}
+	go c.read()
+	return c
+}
+
+// Done returns a channel that is closed when c stops reading.
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Close closes the underlying connection.
+func (c *Conn) Close() error {
+	return c.rwc.Close()
+}
+
+// Call sends a request to the server and waits for its response,
+// decoding the result into result (if it is non-nil).  If ctx is
+// cancelled first, a $/cancelRequest notification is sent and
+// ctx.Err() is returned.
+func (c *Conn) Call(ctx context.Context, method string, params, result interface{}) error {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	c.nextID++
+	id := c.nextID
+	resp := make(chan *message, 1)
+	c.pending[id] = resp
+	c.mu.Unlock()
+
+	rawID := json.RawMessage(strconv.FormatInt(id, 10))
+	if err := c.send(&message{ID: &rawID, Method: method}, params); err != nil {
+		c.forget(id)
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		c.forget(id)
+		c.Notify("$/cancelRequest", map[string]int64{"id": id})
+		return ctx.Err()
+	case <-c.done:
+		return c.closedErr()
+	case m := <-resp:
+		if m.Error != nil {
+			return m.Error
+		}
+		if result == nil || len(m.Result) == 0 {
+			return nil
+		}
+		return json.Unmarshal(m.Result, result)
+	}
+}
+
+// Notify sends a notification to the server.
+func (c *Conn) Notify(method string, params interface{}) error {
+	return c.send(&message{Method: method}, params)
+}
+
+func (c *Conn) forget(id int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, id)
+}
+
+func (c *Conn) closedErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *Conn) send(m *message, params interface{}) error {
+	m.JSONRPC = jsonrpcVersion
+	if params != nil {
+		p, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		m.Params = p
+	}
+	body, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if _, err := fmt.Fprintf(c.rwc, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = c.rwc.Write(body)
+	return err
+}
+
+func (c *Conn) read() {
+	defer close(c.done)
+	tp := textproto.NewReader(c.r)
+	for {
+		m, err := c.readMessage(tp)
+		if err != nil {
+			c.mu.Lock()
+			c.err = ErrClosed
+			if err != io.EOF {
+				c.err = fmt.Errorf("lsp: read failed: %s", err)
+			}
+			c.mu.Unlock()
+			return
+		}
+		c.dispatch(m)
+	}
+}
+
+func (c *Conn) readMessage(tp *textproto.Reader) (*message, error) {
+	header, err := tp.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(header.Get("Content-Length")))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length header: %s", err)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return nil, err
+	}
+	m := &message{}
+	if err := json.Unmarshal(body, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *Conn) dispatch(m *message) {
+	switch {
+	case m.ID != nil && m.Method != "":
+		c.reply(m)
+	case m.ID != nil:
+		id, err := strconv.ParseInt(string(*m.ID), 10, 64)
+		if err != nil {
+			log.Printf("lsp: response with unexpected id %s", string(*m.ID))
+			return
+		}
+		c.mu.Lock()
+		resp, ok := c.pending[id]
+		delete(c.pending, id)
+		c.mu.Unlock()
+		if ok {
+			resp <- m
+		}
+	case c.handler != nil:
+		c.handler(m.Method, m.Params)
+	}
+}
+
+// reply responds to requests sent from the server to the client.
+// We don't advertise any capabilities that would require us to
+// act on these, so we only answer the ones that servers commonly
+// send regardless.
+func (c *Conn) reply(req *message) {
+	resp := &message{ID: req.ID}
+	switch req.Method {
+	case "workspace/configuration":
+		var params struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		json.Unmarshal(req.Params, &params)
+		resp.Result, _ = json.Marshal(make([]interface{}, len(params.Items)))
+	case "window/workDoneProgress/create", "client/registerCapability", "client/unregisterCapability":
+		resp.Result = json.RawMessage("null")
+	default:
+		resp.Error = &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("method %s is not supported", req.Method)}
+	}
+	if err := c.send(resp, nil); err != nil {
+		log.Printf("lsp: failed to reply to %s: %s", req.Method, err)
+	}
+}
@@ -0,0 +1,240 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package lsp
+
+import (
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// TextDocumentSyncKind is the way that a server wants document
+// changes to be sent.
+type TextDocumentSyncKind int
+
+const (
+	SyncNone TextDocumentSyncKind = iota
+	SyncFull
+	SyncIncremental
+)
+
+// Position is a zero-based line and character offset in a
+// document.  Character offsets are counted in UTF-16 code units.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a span between two positions in a document.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location is a range in a specific document.
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// TextEdit is a change to be applied to a document.
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// DiagnosticSeverity is the severity of a Diagnostic.
+type DiagnosticSeverity int
+
+const (
+	SeverityError DiagnosticSeverity = 1 + iota
+	SeverityWarning
+	SeverityInformation
+	SeverityHint
+)
+
+// Diagnostic is a message from the server about a range in a
+// document, such as a compile error.
+type Diagnostic struct {
+	Range    Range              `json:"range"`
+	Severity DiagnosticSeverity `json:"severity,omitempty"`
+	Source   string             `json:"source,omitempty"`
+	Message  string             `json:"message"`
+}
+
+// PublishDiagnosticsParams are the params of a
+// textDocument/publishDiagnostics notification.  The diagnostics
+// replace any that were previously published for the document.
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Version     *int         `json:"version,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// TextDocumentIdentifier identifies a document.
+type TextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+// VersionedTextDocumentIdentifier identifies a specific version
+// of a document.
+type VersionedTextDocumentIdentifier struct {
+	URI     string `json:"uri"`
+	Version int    `json:"version"`
+}
+
+// TextDocumentItem is a document as it is sent on open.
+type TextDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Version    int    `json:"version"`
+	Text       string `json:"text"`
+}
+
+// TextDocumentContentChangeEvent is a change to a document.  If
+// Range is nil, Text is the full text of the document.
+type TextDocumentContentChangeEvent struct {
+	Range *Range `json:"range,omitempty"`
+	Text  string `json:"text"`
+}
+
+// TextDocumentPositionParams is used by requests that act on a
+// position in a document.
+type TextDocumentPositionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+// DidOpenTextDocumentParams is sent with textDocument/didOpen.
+type DidOpenTextDocumentParams struct {
+	TextDocument TextDocumentItem `json:"textDocument"`
+}
+
+// DidChangeTextDocumentParams is sent with
+// textDocument/didChange.
+type DidChangeTextDocumentParams struct {
+	TextDocument   VersionedTextDocumentIdentifier  `json:"textDocument"`
+	ContentChanges []TextDocumentContentChangeEvent `json:"contentChanges"`
+}
+
+// DidSaveTextDocumentParams is sent with textDocument/didSave.
+type DidSaveTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Text         *string                `json:"text,omitempty"`
+}
+
+// DidCloseTextDocumentParams is sent with textDocument/didClose.
+type DidCloseTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+// CompletionItem is a single completion suggestion.
+type CompletionItem struct {
+	Label      string    `json:"label"`
+	Kind       int       `json:"kind,omitempty"`
+	Detail     string    `json:"detail,omitempty"`
+	InsertText string    `json:"insertText,omitempty"`
+	TextEdit   *TextEdit `json:"textEdit,omitempty"`
+}
+
+// CompletionList is a list of completion suggestions.
+type CompletionList struct {
+	IsIncomplete bool             `json:"isIncomplete"`
+	Items        []CompletionItem `json:"items"`
+}
+
+// FormattingOptions describe how a document should be formatted.
+type FormattingOptions struct {
+	TabSize      int  `json:"tabSize"`
+	InsertSpaces bool `json:"insertSpaces"`
+}
+
+// DocumentFormattingParams is sent with textDocument/formatting.
+type DocumentFormattingParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Options      FormattingOptions      `json:"options"`
+}
+
+// InitializeParams is sent with the initialize request.
+type InitializeParams struct {
+	ProcessID    int                `json:"processId"`
+	RootURI      string             `json:"rootUri"`
+	Capabilities ClientCapabilities `json:"capabilities"`
+}
+
+// ClientCapabilities describe what the client supports.
+type ClientCapabilities struct {
+	TextDocument TextDocumentClientCapabilities `json:"textDocument"`
+}
+
+// TextDocumentClientCapabilities describe the document features
+// that the client supports.
+type TextDocumentClientCapabilities struct {
+	Synchronization struct {
+		DidSave bool `json:"didSave"`
+	} `json:"synchronization"`
+	Completion struct {
+		CompletionItem struct {
+			SnippetSupport bool `json:"snippetSupport"`
+		} `json:"completionItem"`
+	} `json:"completion"`
+	PublishDiagnostics struct{} `json:"publishDiagnostics"`
+}
+
+// InitializeResult is the result of the initialize request.
+type InitializeResult struct {
+	Capabilities ServerCapabilities `json:"capabilities"`
+}
+
+// ServerCapabilities describe what the server supports.
+type ServerCapabilities struct {
+	// TextDocumentSync may be either a TextDocumentSyncKind or
+	// an object containing one; use SyncKind to read it.
+	TextDocumentSync           json.RawMessage `json:"textDocumentSync,omitempty"`
+	CompletionProvider         json.RawMessage `json:"completionProvider,omitempty"`
+	DefinitionProvider         json.RawMessage `json:"definitionProvider,omitempty"`
+	DocumentFormattingProvider json.RawMessage `json:"documentFormattingProvider,omitempty"`
+}
+
+// SyncKind returns the kind of document sync that the server
+// asked for.
+func (c ServerCapabilities) SyncKind() TextDocumentSyncKind {
+	if len(c.TextDocumentSync) == 0 {
+		return SyncNone
+	}
+	var kind TextDocumentSyncKind
+	if err := json.Unmarshal(c.TextDocumentSync, &kind); err == nil {
+		return kind
+	}
+	var opts struct {
+		Change TextDocumentSyncKind `json:"change"`
+	}
+	json.Unmarshal(c.TextDocumentSync, &opts)
+	return opts.Change
+}
+
+func provides(v json.RawMessage) bool {
+	s := string(v)
+	return s != "" && s != "false" && s != "null"
+}
+
+// URI converts a file path to a file:// URI.
+func URI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return u.String()
+}
+
+// Path converts a file:// URI to a file path.
+func Path(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return strings.TrimPrefix(uri, "file://")
+	}
+	return filepath.FromSlash(u.Path)
+}
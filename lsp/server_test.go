@@ -0,0 +1,161 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package lsp_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/nelsam/vidar/lsp"
+)
+
+// fakeServerEnv is set in the environment when the test binary
+// is started as a fake language server.
+const fakeServerEnv = "VIDAR_LSP_FAKE_SERVER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(fakeServerEnv) != "" {
+		os.Exit(serve(os.Stdin, os.Stdout))
+	}
+	os.Exit(m.Run())
+}
+
+type request struct {
+	ID     *json.RawMessage `json:"id"`
+	Method string           `json:"method"`
+	Params json.RawMessage  `json:"params"`
+}
+
+// fakeServer is a tiny language server.  It keeps the text of
+// each open document as a list of lines and answers requests
+// based on that text, so that tests can check what the server
+// sees.
+type fakeServer struct {
+	w    io.Writer
+	docs map[string][]string
+}
+
+func serve(r io.Reader, w io.Writer) int {
+	s := &fakeServer{w: w, docs: make(map[string][]string)}
+	br := bufio.NewReader(r)
+	tp := textproto.NewReader(br)
+	for {
+		h, err := tp.ReadMIMEHeader()
+		if err != nil {
+			return 1
+		}
+		n, _ := strconv.Atoi(h.Get("Content-Length"))
+		body := make([]byte, n)
+		if _, err := io.ReadFull(br, body); err != nil {
+			return 1
+		}
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			return 1
+		}
+		if req.Method == "exit" {
+			return 0
+		}
+		result := s.handle(req.Method, req.Params)
+		if req.ID != nil {
+			s.send(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+		}
+	}
+}
+
+func (s *fakeServer) send(v interface{}) {
+	b, _ := json.Marshal(v)
+	fmt.Fprintf(s.w, "Content-Length: %d\r\n\r\n%s", len(b), b)
+}
+
+func (s *fakeServer) handle(method string, params json.RawMessage) interface{} {
+	switch method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":           map[string]interface{}{"openClose": true, "change": 2},
+				"completionProvider":         map[string]interface{}{},
+				"definitionProvider":         true,
+				"documentFormattingProvider": true,
+			},
+		}
+	case "textDocument/didOpen":
+		var p lsp.DidOpenTextDocumentParams
+		json.Unmarshal(params, &p)
+		s.docs[p.TextDocument.URI] = strings.Split(p.TextDocument.Text, "\n")
+	case "textDocument/didChange":
+		var p lsp.DidChangeTextDocumentParams
+		json.Unmarshal(params, &p)
+		for _, c := range p.ContentChanges {
+			s.change(p.TextDocument.URI, c)
+		}
+	case "textDocument/didSave":
+		var p lsp.DidSaveTextDocumentParams
+		json.Unmarshal(params, &p)
+		s.send(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"method":  "textDocument/publishDiagnostics",
+			"params": lsp.PublishDiagnosticsParams{
+				URI: p.TextDocument.URI,
+				Diagnostics: []lsp.Diagnostic{{
+					Range:    lsp.Range{End: lsp.Position{Character: len(s.docs[p.TextDocument.URI][0])}},
+					Severity: lsp.SeverityWarning,
+					Message:  "saved",
+				}},
+			},
+		})
+	case "textDocument/completion":
+		var p lsp.TextDocumentPositionParams
+		json.Unmarshal(params, &p)
+		line := s.docs[p.TextDocument.URI][p.Position.Line]
+		return lsp.CompletionList{Items: []lsp.CompletionItem{
+			{Label: "text", Detail: string(utf16Prefix(line, p.Position.Character))},
+		}}
+	case "textDocument/definition":
+		var p lsp.TextDocumentPositionParams
+		json.Unmarshal(params, &p)
+		return []lsp.Location{{URI: p.TextDocument.URI, Range: lsp.Range{End: p.Position}}}
+	case "textDocument/formatting":
+		return []lsp.TextEdit{{NewText: "// formatted\n"}}
+	}
+	return nil
+}
+
+func (s *fakeServer) change(uri string, c lsp.TextDocumentContentChangeEvent) {
+	if c.Range == nil {
+		s.docs[uri] = strings.Split(c.Text, "\n")
+		return
+	}
+	lines := s.docs[uri]
+	start, end := c.Range.Start, c.Range.End
+	before := string(utf16Prefix(lines[start.Line], start.Character))
+	after := lines[end.Line][len(string(utf16Prefix(lines[end.Line], end.Character))):]
+	replaced := strings.Split(before+c.Text+after, "\n")
+	s.docs[uri] = append(append(append([]string(nil), lines[:start.Line]...), replaced...), lines[end.Line+1:]...)
+}
+
+// utf16Prefix returns the prefix of line that is chars UTF-16 code
+// units long.
+func utf16Prefix(line string, chars int) []rune {
+	var prefix []rune
+	for _, r := range line {
+		if chars <= 0 {
+			break
+		}
+		chars--
+		if r > 0xFFFF {
+			chars--
+		}
+		prefix = append(prefix, r)
+	}
+	return prefix
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/nelsam/vidar/command"
 	"github.com/nelsam/vidar/command/focus"
 	"github.com/nelsam/vidar/command/input"
+	"github.com/nelsam/vidar/command/recovery"
 	"github.com/nelsam/vidar/commander"
 	"github.com/nelsam/vidar/commander/bind"
 	"github.com/nelsam/vidar/controller"
@@ -85,8 +86,27 @@ func uiMain(driver gxui.Driver) {
 	projTree := navigator.NewProjectTree(cmdr, driver, window, gTheme)
 	projects := navigator.NewProjectsPane(cmdr, driver, gTheme, projTree.Frame())
 
+	results := navigator.NewSearchResultsPane(cmdr, driver, gTheme)
+	replace := navigator.NewReplacePreviewPane(cmdr, driver, gTheme)
+	output := navigator.NewOutputPane(cmdr, driver, gTheme)
+	tests := navigator.NewTestResultsPane(cmdr, driver, gTheme)
+	commit := navigator.NewCommitViewPane(driver, gTheme)
+	goroutines := navigator.NewGoroutinesPane(driver, gTheme)
+	stack := navigator.NewCallStackPane(driver, gTheme)
+	locals := navigator.NewLocalsPane(driver, gTheme)
+	refs := navigator.NewReferencesPane(cmdr, driver, gTheme)
+
 	nav.Add(projects)
 	nav.Add(projTree)
+	nav.Add(results)
+	nav.Add(replace)
+	nav.Add(output)
+	nav.Add(tests)
+	nav.Add(commit)
+	nav.Add(goroutines)
+	nav.Add(stack)
+	nav.Add(locals)
+	nav.Add(refs)
 
 	nav.Resize(window.Size().H)
 	window.OnResize(func() {
@@ -109,6 +129,12 @@ func uiMain(driver gxui.Driver) {
 		}
 	})
 
+	if open, ok := cmdr.Bindable("open-session").(*command.OpenSession); ok {
+		if latest, ok := open.Latest(); ok {
+			cmdr.Execute(latest)
+		}
+	}
+
 	opener := cmdr.Bindable("focus-location").(*focus.Location)
 	for _, file := range files {
 		filepath, err := filepath.Abs(file)
@@ -118,6 +144,15 @@ func uiMain(driver gxui.Driver) {
 		cmdr.Execute(opener.For(focus.Path(filepath)))
 	}
 
-	window.OnClose(driver.Terminate)
+	if rec, ok := cmdr.Bindable("recover-files").(*recovery.Recover); ok && rec.Pending() {
+		cmdr.Run(rec)
+	}
+
+	window.OnClose(func() {
+		if quit, ok := cmdr.Bindable("quit").(command.Quit); ok {
+			quit.Prepare()
+		}
+		driver.Terminate()
+	})
 	window.SetPadding(math.Spacing{L: 10, T: 10, R: 10, B: 10})
 }
@@ -0,0 +1,117 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package navigator
+
+import (
+	"strings"
+
+	"github.com/nelsam/gxui"
+)
+
+var (
+	addedLineColor = gxui.Color{
+		R: 0.5,
+		G: 0.9,
+		B: 0.5,
+		A: 1,
+	}
+	removedLineColor = gxui.Color{
+		R: 0.9,
+		G: 0.4,
+		B: 0.4,
+		A: 1,
+	}
+	hunkHeaderColor = gxui.Color{
+		R: 0.6,
+		G: 0.6,
+		B: 1,
+		A: 1,
+	}
+)
+
+// CommitView is a Pane that displays a commit and its diff.  It is
+// read-only; nothing in it can be edited.  Its methods may be called
+// from any goroutine.
+type CommitView struct {
+	driver gxui.Driver
+	theme  gxui.Theme
+
+	button gxui.Button
+	frame  gxui.ScrollLayout
+	title  gxui.Label
+	lines  gxui.LinearLayout
+}
+
+// NewCommitViewPane returns an empty *CommitView.
+func NewCommitViewPane(driver gxui.Driver, theme gxui.Theme) *CommitView {
+	v := &CommitView{
+		driver: driver,
+		theme:  theme,
+		button: createTextButton(theme, "±"),
+		frame:  theme.CreateScrollLayout(),
+		title:  theme.CreateLabel(),
+		lines:  theme.CreateLinearLayout(),
+	}
+	v.title.SetColor(summaryColor)
+	v.title.SetText("No commit selected")
+
+	layout := theme.CreateLinearLayout()
+	layout.SetDirection(gxui.TopToBottom)
+	layout.AddChild(v.title)
+	v.lines.SetDirection(gxui.TopToBottom)
+	layout.AddChild(v.lines)
+
+	v.frame.SetScrollAxis(true, true)
+	v.frame.SetChild(layout)
+	return v
+}
+
+func (v *CommitView) Button() gxui.Button {
+	return v.button
+}
+
+func (v *CommitView) Frame() gxui.Control {
+	return v.frame
+}
+
+// ShowCommit replaces the displayed commit with the commit called
+// title, which git described as desc.
+func (v *CommitView) ShowCommit(title, desc string) {
+	v.driver.Call(func() {
+		v.title.SetText(title)
+		v.lines.RemoveAll()
+		for _, l := range strings.Split(strings.TrimRight(desc, "\n"), "\n") {
+			v.lines.AddChild(v.line(l))
+		}
+		if !v.frame.Attached() {
+			v.button.Click(gxui.MouseEvent{
+				Button: gxui.MouseButtonLeft,
+			})
+		}
+	})
+}
+
+func (v *CommitView) line(l string) gxui.Control {
+	label := v.theme.CreateLabel()
+	label.SetFont(v.theme.DefaultMonospaceFont())
+	label.SetColor(diffLineColor(l))
+	label.SetText(strings.Replace(l, "\t", "    ", -1))
+	return label
+}
+
+func diffLineColor(l string) gxui.Color {
+	switch {
+	case strings.HasPrefix(l, "+++"), strings.HasPrefix(l, "---"):
+		return summaryColor
+	case strings.HasPrefix(l, "+"):
+		return addedLineColor
+	case strings.HasPrefix(l, "-"):
+		return removedLineColor
+	case strings.HasPrefix(l, "@@"):
+		return hunkHeaderColor
+	default:
+		return outputColor
+	}
+}
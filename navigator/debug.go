@@ -0,0 +1,224 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package navigator
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/dlv"
+)
+
+var (
+	currentColor = gxui.Color{
+		R: 0.95,
+		G: 0.8,
+		B: 0.2,
+		A: 1,
+	}
+	variableColor = gxui.Gray80
+)
+
+// debugPane is the layout shared by the panes that display the state
+// of a debugged process: a summary line above a list of nodes.
+type debugPane struct {
+	driver gxui.Driver
+	theme  gxui.Theme
+
+	button  gxui.Button
+	frame   gxui.ScrollLayout
+	summary gxui.Label
+	list    gxui.LinearLayout
+}
+
+func (p *debugPane) init(driver gxui.Driver, theme gxui.Theme, icon, empty string) {
+	p.driver = driver
+	p.theme = theme
+	p.button = createTextButton(theme, icon)
+	p.frame = theme.CreateScrollLayout()
+	p.summary = theme.CreateLabel()
+	p.list = theme.CreateLinearLayout()
+
+	p.summary.SetColor(summaryColor)
+	p.summary.SetText(empty)
+
+	layout := theme.CreateLinearLayout()
+	layout.SetDirection(gxui.TopToBottom)
+	layout.AddChild(p.summary)
+	p.list.SetDirection(gxui.TopToBottom)
+	layout.AddChild(p.list)
+
+	p.frame.SetScrollAxis(true, true)
+	p.frame.SetChild(layout)
+}
+
+func (p *debugPane) Button() gxui.Button {
+	return p.button
+}
+
+func (p *debugPane) Frame() gxui.Control {
+	return p.frame
+}
+
+// show makes p the visible pane, if it isn't already.
+func (p *debugPane) show() {
+	if p.frame.Attached() {
+		return
+	}
+	p.button.Click(gxui.MouseEvent{
+		Button: gxui.MouseButtonLeft,
+	})
+}
+
+func locationLabel(l dlv.Location) string {
+	name := l.FunctionName()
+	if name == "" {
+		name = "?"
+	}
+	if l.File == "" {
+		return name
+	}
+	return fmt.Sprintf("%s %s:%d", name, filepath.Base(l.File), l.Line)
+}
+
+// Goroutines is a Pane that displays the goroutines of a debugged
+// process.  Clicking a goroutine displays its call stack.  Its
+// methods may be called from any goroutine.
+type Goroutines struct {
+	debugPane
+}
+
+// NewGoroutinesPane returns an empty *Goroutines.
+func NewGoroutinesPane(driver gxui.Driver, theme gxui.Theme) *Goroutines {
+	g := &Goroutines{}
+	g.init(driver, theme, "⑂", "Nothing is being debugged")
+	return g
+}
+
+// ReportGoroutines replaces the displayed goroutines with gs,
+// marking the one with the ID current.
+func (g *Goroutines) ReportGoroutines(gs []dlv.Goroutine, current int64, selected func(id int64)) {
+	g.driver.Call(func() {
+		g.list.RemoveAll()
+		if len(gs) == 0 {
+			g.summary.SetText("Nothing is being debugged")
+			return
+		}
+		g.summary.SetText(fmt.Sprintf("%d goroutines", len(gs)))
+		for _, gr := range gs {
+			id := gr.ID
+			color := genericColor
+			if id == current {
+				color = currentColor
+			}
+			node := newGenericNode(g.driver, g.theme, fmt.Sprintf("%d: %s", id, locationLabel(gr.UserCurrentLoc)), color)
+			node.button.OnClick(func(gxui.MouseEvent) {
+				selected(id)
+			})
+			g.list.AddChild(node)
+		}
+	})
+}
+
+// CallStack is a Pane that displays the call stack of a goroutine
+// in a debugged process.  Clicking a frame opens its location and
+// displays its local variables.  Its methods may be called from any
+// goroutine.
+type CallStack struct {
+	debugPane
+}
+
+// NewCallStackPane returns an empty *CallStack.
+func NewCallStackPane(driver gxui.Driver, theme gxui.Theme) *CallStack {
+	c := &CallStack{}
+	c.init(driver, theme, "☰", "Nothing is being debugged")
+	return c
+}
+
+// ReportStack replaces the displayed stack with frames.  The pane is
+// shown when the process first stops.
+func (c *CallStack) ReportStack(frames []dlv.Stackframe, selected func(frame int)) {
+	c.driver.Call(func() {
+		wasEmpty := len(c.list.Children()) == 0
+		c.list.RemoveAll()
+		if len(frames) == 0 {
+			c.summary.SetText("Nothing is being debugged")
+			return
+		}
+		c.summary.SetText(fmt.Sprintf("%d frames", len(frames)))
+		for i, f := range frames {
+			i := i
+			color := genericColor
+			label := locationLabel(f.Location)
+			if f.Err != "" {
+				color = failColor
+				label = fmt.Sprintf("%s (%s)", label, f.Err)
+			}
+			node := newGenericNode(c.driver, c.theme, fmt.Sprintf("%d: %s", i, label), color)
+			node.button.OnClick(func(gxui.MouseEvent) {
+				selected(i)
+			})
+			c.list.AddChild(node)
+		}
+		if wasEmpty {
+			c.show()
+		}
+	})
+}
+
+// Locals is a Pane that displays the arguments and local variables
+// of a stack frame in a debugged process, with the fields and
+// elements of each variable nested under it.  Its methods may be
+// called from any goroutine.
+type Locals struct {
+	debugPane
+}
+
+// NewLocalsPane returns an empty *Locals.
+func NewLocalsPane(driver gxui.Driver, theme gxui.Theme) *Locals {
+	l := &Locals{}
+	l.init(driver, theme, "x=", "Nothing is being debugged")
+	return l
+}
+
+// ReportLocals replaces the displayed variables with vars.
+func (l *Locals) ReportLocals(vars []dlv.Variable) {
+	l.driver.Call(func() {
+		l.list.RemoveAll()
+		if len(vars) == 0 {
+			l.summary.SetText("No variables")
+			return
+		}
+		l.summary.SetText(fmt.Sprintf("%d variables", len(vars)))
+		for _, v := range vars {
+			l.list.AddChild(l.node(v))
+		}
+	})
+}
+
+func (l *Locals) node(v dlv.Variable) gxui.Control {
+	label := v.String()
+	switch {
+	case v.Name == "":
+		// Elements of slices and the targets of pointers don't
+		// have names.
+	case v.Value != "":
+		label = fmt.Sprintf("%s %s = %s", v.Name, v.Type, label)
+	default:
+		label = fmt.Sprintf("%s = %s", v.Name, label)
+	}
+	if len(v.Children) == 0 {
+		node := l.theme.CreateLabel()
+		node.SetColor(variableColor)
+		node.SetText(label)
+		return node
+	}
+	node := newGenericNode(l.driver, l.theme, label, genericColor)
+	for _, c := range v.Children {
+		node.AddChild(l.node(c))
+	}
+	return node
+}
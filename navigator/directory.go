@@ -58,6 +58,7 @@ func newDirectory(projTree *ProjectTree, path string, watcher Watcher) *director
 			projTree.layout.RemoveChild(projTree.tocCtl)
 		}
 		toc := NewTOC(projTree.cmdr, projTree.driver, projTree.theme, path)
+		projTree.decorateTOC(toc)
 		projTree.SetTOC(toc)
 		scrollable := theme.CreateScrollLayout()
 		// Disable horiz scrolling until we can figure out an accurate
@@ -97,6 +98,15 @@ func (d *directory) update(path string) {
 	}
 }
 
+// decorate updates the git status decorations of d and its
+// children.
+func (d *directory) decorate() {
+	d.tree.projTree.mark(d.button, d.tree.path, true)
+	for _, dir := range d.tree.Dirs() {
+		dir.decorate()
+	}
+}
+
 func (d *directory) ExpandTo(dir string) {
 	if !strings.HasPrefix(dir, d.tree.path) {
 		return
@@ -211,7 +221,11 @@ func (d *dirTree) parse(finfos []os.FileInfo, w Watcher) {
 			continue
 		}
 		fullPath := filepath.Join(d.path, finfo.Name())
+		if d.projTree.hidden(fullPath) {
+			continue
+		}
 		dir := newDirectory(d.projTree, fullPath, w)
+		d.projTree.mark(dir.button, fullPath, true)
 		d.AddChild(dir)
 	}
 }
@@ -0,0 +1,197 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package navigator
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/git"
+)
+
+// statusDelay is how long the project tree waits after a filesystem
+// event before refreshing git status, so that a burst of events
+// (e.g. from a checkout) only causes one refresh.
+const statusDelay = 300 * time.Millisecond
+
+var (
+	statusMarks = map[git.FileStatus]string{
+		git.Ignored:    "I",
+		git.Untracked:  "U",
+		git.Added:      "A",
+		git.Modified:   "M",
+		git.Conflicted: "C",
+	}
+	statusColors = map[git.FileStatus]gxui.Color{
+		git.Ignored:   gxui.Gray50,
+		git.Untracked: gxui.Gray80,
+		git.Added: {
+			R: 0.5,
+			G: 0.9,
+			B: 0.5,
+			A: 1,
+		},
+		git.Modified: {
+			R: 0.9,
+			G: 0.8,
+			B: 0.4,
+			A: 1,
+		},
+		git.Conflicted: {
+			R: 1,
+			G: 0.3,
+			B: 0.3,
+			A: 1,
+		},
+	}
+)
+
+// gitStatus keeps track of the git status of the files in the
+// project tree.
+type gitStatus struct {
+	mu          sync.RWMutex
+	root        string
+	statuses    git.Statuses
+	hideIgnored bool
+	timer       *time.Timer
+
+	// loaded is whether statuses have been loaded for root.
+	loaded bool
+}
+
+// RefreshStatus reloads the git status of the files in the tree and
+// updates their decorations.
+func (p *ProjectTree) RefreshStatus() {
+	go p.loadStatus()
+}
+
+// HideIgnored returns whether files and directories that git ignores
+// are left out of the tree.
+func (p *ProjectTree) HideIgnored() bool {
+	p.status.mu.RLock()
+	defer p.status.mu.RUnlock()
+	return p.status.hideIgnored
+}
+
+// SetHideIgnored sets whether files and directories that git ignores
+// are left out of the tree.
+func (p *ProjectTree) SetHideIgnored(hide bool) {
+	p.status.mu.Lock()
+	p.status.hideIgnored = hide
+	p.status.mu.Unlock()
+	p.driver.Call(func() {
+		if p.dirs != nil {
+			p.dirs.reload()
+		}
+		if toc := p.TOC(); toc != nil {
+			toc.Reload()
+		}
+		p.decorate()
+	})
+}
+
+// scheduleStatus refreshes the git status after statusDelay, unless
+// it is called again before then.
+func (p *ProjectTree) scheduleStatus() {
+	p.status.mu.Lock()
+	defer p.status.mu.Unlock()
+	if p.status.timer != nil {
+		p.status.timer.Stop()
+	}
+	p.status.timer = time.AfterFunc(statusDelay, p.loadStatus)
+}
+
+func (p *ProjectTree) setStatusRoot(root string) {
+	p.status.mu.Lock()
+	defer p.status.mu.Unlock()
+	p.status.root = root
+	p.status.statuses = git.Statuses{}
+	p.status.loaded = false
+}
+
+func (p *ProjectTree) loadStatus() {
+	p.status.mu.RLock()
+	root := p.status.root
+	p.status.mu.RUnlock()
+
+	// Projects that are not in a repository just don't get any
+	// decorations.
+	s, _ := git.Status(root)
+
+	p.status.mu.Lock()
+	if p.status.root != root {
+		// The root changed while we were loading.
+		p.status.mu.Unlock()
+		return
+	}
+	p.status.statuses = s
+	// Before the first statuses arrive, nothing is known to be
+	// ignored, so the directories have to be parsed again to hide
+	// the ignored ones.
+	reparse := !p.status.loaded && p.status.hideIgnored
+	p.status.loaded = true
+	p.status.mu.Unlock()
+	p.driver.Call(func() {
+		if reparse && p.dirs != nil {
+			p.dirs.reload()
+		}
+		p.decorate()
+	})
+}
+
+func (p *ProjectTree) statusOf(path string, dir bool) git.FileStatus {
+	p.status.mu.RLock()
+	defer p.status.mu.RUnlock()
+	if dir {
+		return p.status.statuses.Rollup(path)
+	}
+	return p.status.statuses.Of(path)
+}
+
+// hidden returns whether path should be left out of the tree.
+func (p *ProjectTree) hidden(path string) bool {
+	p.status.mu.RLock()
+	defer p.status.mu.RUnlock()
+	return p.status.hideIgnored && p.status.statuses.Of(path) == git.Ignored
+}
+
+// mark decorates b with the status of path.
+func (p *ProjectTree) mark(b *treeButton, path string, dir bool) {
+	status := p.statusOf(path, dir)
+	b.SetMark(statusMarks[status], statusColors[status])
+}
+
+// decorate updates the decorations of everything in the tree.  It
+// must be called on the UI goroutine.
+func (p *ProjectTree) decorate() {
+	if p.dirs != nil {
+		p.dirs.decorate()
+	}
+	if toc := p.TOC(); toc != nil {
+		p.decorateTOC(toc)
+	}
+}
+
+// decorateTOC updates the decorations of the files in toc, removing
+// any that should be hidden.  It must be called on the UI goroutine.
+func (p *ProjectTree) decorateTOC(toc *TOC) {
+	toc.lock.Lock()
+	defer toc.lock.Unlock()
+	if toc.files == nil {
+		return
+	}
+	for _, c := range toc.files.children.Children() {
+		name, ok := c.Control.(*Name)
+		if !ok {
+			continue
+		}
+		if p.hidden(name.File()) {
+			toc.files.children.RemoveChild(name)
+			continue
+		}
+		p.mark(name.button, name.File(), false)
+	}
+}
@@ -0,0 +1,195 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package navigator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/command/focus"
+	"github.com/nelsam/vidar/setting"
+	"github.com/nelsam/vidar/task"
+)
+
+var (
+	outputColor = gxui.Gray80
+	linkColor   = gxui.Color{
+		R: 0.6,
+		G: 0.8,
+		B: 1,
+		A: 1,
+	}
+)
+
+// runItem is a task.Run as displayed in the Output pane's history.
+type runItem struct {
+	*task.Run
+}
+
+func (r runItem) String() string {
+	return fmt.Sprintf("#%d %s (%s, %s)", r.ID, r.Name, r.Status(), r.Duration().Round(time.Millisecond))
+}
+
+// Output is a Pane that displays the output of tasks, like builds
+// and test runs.  Lines of output that refer to a location in a file
+// can be clicked to open that location.  The output of the last few
+// runs is kept, and any of them can be selected to view its output
+// again.  Output implements task.Observer; its methods may be called
+// from any goroutine.
+type Output struct {
+	cmdr   Commander
+	driver gxui.Driver
+	theme  gxui.Theme
+
+	button  gxui.Button
+	frame   gxui.ScrollLayout
+	runList gxui.List
+	adapter *gxui.DefaultAdapter
+	lines   gxui.LinearLayout
+
+	runs []runItem
+
+	// shown is the run whose output is displayed, and shownLines is
+	// the number of its lines that have been displayed.
+	shown      *task.Run
+	shownLines int
+	summarized bool
+}
+
+// NewOutputPane returns an empty *Output.
+func NewOutputPane(cmdr Commander, driver gxui.Driver, theme gxui.Theme) *Output {
+	o := &Output{
+		cmdr:    cmdr,
+		driver:  driver,
+		theme:   theme,
+		button:  createTextButton(theme, "▶"),
+		frame:   theme.CreateScrollLayout(),
+		runList: theme.CreateList(),
+		adapter: gxui.CreateDefaultAdapter(),
+		lines:   theme.CreateLinearLayout(),
+	}
+	o.runList.SetAdapter(o.adapter)
+	o.runList.OnSelectionChanged(func(item gxui.AdapterItem) {
+		if r, ok := item.(runItem); ok && r.Run != o.shown {
+			o.show(r.Run)
+		}
+	})
+
+	layout := theme.CreateLinearLayout()
+	layout.SetDirection(gxui.TopToBottom)
+	layout.AddChild(o.runList)
+	o.lines.SetDirection(gxui.TopToBottom)
+	layout.AddChild(o.lines)
+
+	o.frame.SetScrollAxis(true, true)
+	o.frame.SetChild(layout)
+	return o
+}
+
+func (o *Output) Button() gxui.Button {
+	return o.button
+}
+
+func (o *Output) Frame() gxui.Control {
+	return o.frame
+}
+
+// Started adds r to the history and shows its output.
+func (o *Output) Started(r *task.Run) {
+	o.driver.Call(func() {
+		o.runs = append(o.runs, runItem{Run: r})
+		if max := setting.TaskHistory(); len(o.runs) > max {
+			o.runs = o.runs[len(o.runs)-max:]
+		}
+		o.show(r)
+		if o.frame.Attached() {
+			return
+		}
+		o.button.Click(gxui.MouseEvent{
+			Button: gxui.MouseButtonLeft,
+		})
+	})
+}
+
+// Output adds l to the displayed output, if r is being shown.
+func (o *Output) Output(r *task.Run, l task.Line) {
+	idx := r.LineCount() - 1
+	o.driver.Call(func() {
+		if r != o.shown || idx < o.shownLines {
+			return
+		}
+		o.shownLines = idx + 1
+		o.lines.AddChild(o.line(l))
+	})
+}
+
+// Finished updates the history with r's final status.
+func (o *Output) Finished(r *task.Run) {
+	o.driver.Call(func() {
+		o.refresh()
+		if r != o.shown || o.summarized {
+			return
+		}
+		o.summarized = true
+		o.lines.AddChild(o.summary(r))
+	})
+}
+
+// show replaces the displayed output with the output of r.  It must
+// be called on the UI goroutine.
+func (o *Output) show(r *task.Run) {
+	o.shown = r
+	o.refresh()
+	o.lines.RemoveAll()
+	lines := r.Lines()
+	o.shownLines = len(lines)
+	for _, l := range lines {
+		o.lines.AddChild(o.line(l))
+	}
+	o.summarized = r.Status() != task.Running
+	if o.summarized {
+		o.lines.AddChild(o.summary(r))
+	}
+}
+
+// summary returns a label describing the result of r.
+func (o *Output) summary(r *task.Run) gxui.Control {
+	label := o.theme.CreateLabel()
+	label.SetColor(summaryColor)
+	summary := fmt.Sprintf("%s: %s", r.Name, r.Status())
+	if err := r.Err(); err != nil && r.Status() == task.Failed {
+		summary = fmt.Sprintf("%s (%s)", summary, err)
+	}
+	label.SetText(summary)
+	return label
+}
+
+// refresh updates the run history, keeping the shown run selected.
+func (o *Output) refresh() {
+	o.adapter.SetItems(append([]runItem(nil), o.runs...))
+	for _, r := range o.runs {
+		if r.Run == o.shown {
+			o.runList.Select(r)
+			return
+		}
+	}
+}
+
+func (o *Output) line(l task.Line) gxui.Control {
+	if l.Link == nil {
+		label := o.theme.CreateLabel()
+		label.SetColor(outputColor)
+		label.SetText(l.Text)
+		return label
+	}
+	link := *l.Link
+	node := newGenericNode(o.driver, o.theme, l.Text, linkColor)
+	node.button.OnClick(func(gxui.MouseEvent) {
+		opener := o.cmdr.Bindable("focus-location").(Opener)
+		o.cmdr.Execute(opener.For(focus.Path(link.Path), focus.Line(link.Line), focus.Column(link.Column)))
+	})
+	return node
+}
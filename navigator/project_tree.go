@@ -69,6 +69,7 @@ type ProjectTree struct {
 
 	watcher    fsw.Watcher
 	reloadLock chan struct{}
+	status     gitStatus
 
 	layout *splitterLayout
 }
@@ -82,6 +83,7 @@ func NewProjectTree(cmdr Commander, driver gxui.Driver, window gxui.Window, them
 		button:     createIconButton(driver, theme, "folder.png"),
 		layout:     newSplitterLayout(window, theme),
 	}
+	tree.status.hideIgnored = setting.HideIgnoredFiles()
 	tree.initWatcher()
 	tree.layout.SetOrientation(gxui.Vertical)
 	tree.SetRoot(setting.DefaultProject.Path)
@@ -120,6 +122,7 @@ func (p *ProjectTree) SetRoot(path string) {
 	p.layout.RemoveAll()
 	p.SetTOC(nil)
 	p.tocCtl = nil
+	p.setStatusRoot(path)
 
 	if p.watcher != nil {
 		if err := p.watcher.RemoveAll(); err != nil {
@@ -143,6 +146,7 @@ func (p *ProjectTree) SetRoot(path string) {
 		p.layout.Relayout()
 		p.layout.Redraw()
 	})
+	p.RefreshStatus()
 }
 
 // watch waits for events from p.watcher.  For each event, the tree will
@@ -164,6 +168,7 @@ func (p *ProjectTree) watch() {
 		switch e.Op {
 		case fsw.Write, fsw.Create, fsw.Remove, fsw.Rename:
 			go p.update(e.Path)
+			p.scheduleStatus()
 		}
 	}
 }
@@ -199,7 +204,10 @@ func (p *ProjectTree) update(path string) {
 	})
 	toc := p.TOC()
 	if toc != nil && strings.HasPrefix(path, toc.dir) {
-		p.driver.CallSync(toc.Reload)
+		p.driver.CallSync(func() {
+			toc.Reload()
+			p.decorateTOC(toc)
+		})
 	}
 }
 
@@ -0,0 +1,197 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package navigator
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/command/focus"
+	"github.com/nelsam/vidar/goref"
+)
+
+var declColor = gxui.Color{
+	R: 0.6,
+	G: 0.8,
+	B: 1,
+	A: 1,
+}
+
+// References is a Pane that displays the references to a go
+// identifier, grouped by file, or the callers or callees of a go
+// function.  Its methods may be called from any goroutine.
+type References struct {
+	cmdr   Commander
+	driver gxui.Driver
+	theme  gxui.Theme
+
+	button  gxui.Button
+	frame   gxui.ScrollLayout
+	summary gxui.Label
+	list    gxui.LinearLayout
+
+	// run is the token of the most recent lookup.  Reports for any
+	// other lookup are ignored.
+	run int64
+
+	root, title string
+}
+
+// NewReferencesPane returns an empty *References.
+func NewReferencesPane(cmdr Commander, driver gxui.Driver, theme gxui.Theme) *References {
+	r := &References{
+		cmdr:    cmdr,
+		driver:  driver,
+		theme:   theme,
+		button:  createTextButton(theme, "⇄"),
+		frame:   theme.CreateScrollLayout(),
+		summary: theme.CreateLabel(),
+		list:    theme.CreateLinearLayout(),
+	}
+	r.summary.SetColor(summaryColor)
+	r.summary.SetText("No references")
+
+	layout := theme.CreateLinearLayout()
+	layout.SetDirection(gxui.TopToBottom)
+	layout.AddChild(r.summary)
+	r.list.SetDirection(gxui.TopToBottom)
+	layout.AddChild(r.list)
+
+	r.frame.SetScrollAxis(false, true)
+	r.frame.SetChild(layout)
+	return r
+}
+
+func (r *References) Button() gxui.Button {
+	return r.button
+}
+
+func (r *References) Frame() gxui.Control {
+	return r.frame
+}
+
+// StartLookup clears any previous results and shows r, in
+// preparation for the results of a lookup described by title (e.g.
+// "references to Foo") in the project at root.  The returned token
+// must be passed to the report of the lookup's results.
+func (r *References) StartLookup(root, title string) int64 {
+	run := atomic.AddInt64(&r.run, 1)
+	r.driver.Call(func() {
+		if run != atomic.LoadInt64(&r.run) {
+			return
+		}
+		r.root, r.title = root, title
+		r.list.RemoveAll()
+		r.summary.SetText(fmt.Sprintf("Finding %s...", title))
+		if r.frame.Attached() {
+			return
+		}
+		r.button.Click(gxui.MouseEvent{
+			Button: gxui.MouseButtonLeft,
+		})
+	})
+	return run
+}
+
+// ReportReferences displays refs, the results of the lookup that run
+// was returned for, grouped by file.  If err is non-nil, the lookup
+// failed.
+func (r *References) ReportReferences(run int64, refs []goref.Ref, err error) {
+	r.driver.Call(func() {
+		if run != atomic.LoadInt64(&r.run) {
+			return
+		}
+		if err != nil {
+			r.summary.SetText(fmt.Sprintf("Could not find %s: %s", r.title, err))
+			return
+		}
+		var (
+			file  *genericNode
+			path  string
+			count int
+			files int
+		)
+		for _, ref := range refs {
+			if file == nil || ref.Path != path {
+				path = ref.Path
+				count = countRefs(refs, path)
+				file = newGenericNode(r.driver, r.theme, fmt.Sprintf("%s (%d)", r.rel(path), count), nameColor)
+				r.list.AddChild(file)
+				file.button.Click(gxui.MouseEvent{})
+				files++
+			}
+			file.AddChild(r.refNode(ref))
+		}
+		r.summary.SetText(fmt.Sprintf("%d %s in %d files", len(refs), r.title, files))
+	})
+}
+
+// ReportCalls displays calls, the results of the lookup that run was
+// returned for, with the places that each call is made from below
+// it.  If err is non-nil, the lookup failed.
+func (r *References) ReportCalls(run int64, calls []goref.Call, err error) {
+	r.driver.Call(func() {
+		if run != atomic.LoadInt64(&r.run) {
+			return
+		}
+		if err != nil {
+			r.summary.SetText(fmt.Sprintf("Could not find %s: %s", r.title, err))
+			return
+		}
+		for _, c := range calls {
+			node := newGenericNode(r.driver, r.theme, fmt.Sprintf("%s (%d)", c.Name, len(c.Sites)), nameColor)
+			if c.Decl.Path != "" {
+				decl := newGenericNode(r.driver, r.theme, fmt.Sprintf("declared at %s:%d", r.rel(c.Decl.Path), c.Decl.Line+1), declColor)
+				r.opens(decl, c.Decl)
+				node.AddChild(decl)
+			}
+			for _, site := range c.Sites {
+				node.AddChild(r.refNode(site))
+			}
+			r.list.AddChild(node)
+		}
+		r.summary.SetText(fmt.Sprintf("%d %s", len(calls), r.title))
+	})
+}
+
+func (r *References) refNode(ref goref.Ref) *genericNode {
+	color := matchColor
+	if ref.Def {
+		color = declColor
+	}
+	node := newGenericNode(r.driver, r.theme, fmt.Sprintf("%d: %s", ref.Line+1, clipLine(ref.Text)), color)
+	r.opens(node, ref)
+	return node
+}
+
+// opens makes a click on node open the location of ref.
+func (r *References) opens(node *genericNode, ref goref.Ref) {
+	node.button.OnClick(func(gxui.MouseEvent) {
+		opener := r.cmdr.Bindable("focus-location").(Opener)
+		r.cmdr.Execute(opener.For(focus.Path(ref.Path), focus.Line(ref.Line), focus.Column(ref.Column)))
+	})
+}
+
+// rel returns path relative to the project's root, if it is inside
+// of it.
+func (r *References) rel(path string) string {
+	if rel, err := filepath.Rel(r.root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+func countRefs(refs []goref.Ref, path string) int {
+	count := 0
+	for _, ref := range refs {
+		if ref.Path == path {
+			count++
+		}
+	}
+	return count
+}
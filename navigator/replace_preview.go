@@ -0,0 +1,254 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package navigator
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/gxui/math"
+	"github.com/nelsam/vidar/command/focus"
+	"github.com/nelsam/vidar/search"
+)
+
+var (
+	removedColor = gxui.Color{
+		R: 0.9,
+		G: 0.5,
+		B: 0.5,
+		A: 1,
+	}
+	addedColor = gxui.Color{
+		R: 0.5,
+		G: 0.9,
+		B: 0.5,
+		A: 1,
+	}
+)
+
+// replaceHit is a single replacement in the preview, along with the
+// button that decides whether it will be applied.
+type replaceHit struct {
+	rep     search.Replacement
+	include gxui.Button
+}
+
+type replaceFile struct {
+	path string
+	hits []replaceHit
+}
+
+// ReplacePreview is a Pane that displays the replacements that a
+// project-wide replace will make, allowing each of them to be
+// excluded before they are applied.  Its StartReplace,
+// AddReplacements and FinishReplace methods may be called from any
+// goroutine.
+type ReplacePreview struct {
+	cmdr   Commander
+	driver gxui.Driver
+	theme  gxui.Theme
+
+	button  gxui.Button
+	frame   gxui.ScrollLayout
+	header  gxui.LinearLayout
+	summary gxui.Label
+	apply   gxui.Button
+	files   gxui.LinearLayout
+
+	// run is the token of the most recent search for replacements.
+	// Calls for any other search are ignored.
+	run int64
+
+	root             string
+	pending          []*replaceFile
+	fileCount, count int
+}
+
+// NewReplacePreviewPane returns an empty *ReplacePreview.
+func NewReplacePreviewPane(cmdr Commander, driver gxui.Driver, theme gxui.Theme) *ReplacePreview {
+	r := &ReplacePreview{
+		cmdr:    cmdr,
+		driver:  driver,
+		theme:   theme,
+		button:  createTextButton(theme, "⇄"),
+		frame:   theme.CreateScrollLayout(),
+		header:  theme.CreateLinearLayout(),
+		summary: theme.CreateLabel(),
+		apply:   createTextButton(theme, "Apply"),
+		files:   theme.CreateLinearLayout(),
+	}
+	r.summary.SetColor(summaryColor)
+	r.summary.SetText("No pending replacements")
+	r.apply.OnClick(func(gxui.MouseEvent) {
+		r.cmdr.Execute(r.cmdr.Bindable("apply-project-replace"))
+	})
+
+	r.header.SetDirection(gxui.LeftToRight)
+	r.header.AddChild(r.summary)
+
+	layout := theme.CreateLinearLayout()
+	layout.SetDirection(gxui.TopToBottom)
+	layout.AddChild(r.header)
+	r.files.SetDirection(gxui.TopToBottom)
+	layout.AddChild(r.files)
+
+	r.frame.SetScrollAxis(false, true)
+	r.frame.SetChild(layout)
+	return r
+}
+
+func (r *ReplacePreview) Button() gxui.Button {
+	return r.button
+}
+
+func (r *ReplacePreview) Frame() gxui.Control {
+	return r.frame
+}
+
+// StartReplace clears any pending replacements and shows r, in
+// preparation for the replacements of pattern with template under
+// root.  The returned token must be passed to AddReplacements and
+// FinishReplace for the replacements; replacements from earlier
+// searches are ignored.
+func (r *ReplacePreview) StartReplace(root, pattern, template string) int64 {
+	run := atomic.AddInt64(&r.run, 1)
+	r.driver.Call(func() {
+		if run != atomic.LoadInt64(&r.run) {
+			return
+		}
+		r.clear()
+		r.root = root
+		r.summary.SetText(fmt.Sprintf("Replacing %s with %s...", pattern, template))
+		if r.frame.Attached() {
+			return
+		}
+		r.button.Click(gxui.MouseEvent{
+			Button: gxui.MouseButtonLeft,
+		})
+	})
+	return run
+}
+
+// AddReplacements adds the replacements for path, from the search
+// that run was returned for, to r.  Each of them will be applied
+// unless it is excluded.
+func (r *ReplacePreview) AddReplacements(run int64, path string, reps []search.Replacement) {
+	if len(reps) == 0 {
+		return
+	}
+	r.driver.Call(func() {
+		if run != atomic.LoadInt64(&r.run) {
+			return
+		}
+		r.fileCount++
+		r.count += len(reps)
+		name := path
+		if rel, err := filepath.Rel(r.root, path); err == nil {
+			name = rel
+		}
+		f := &replaceFile{path: path}
+		file := newGenericNode(r.driver, r.theme, fmt.Sprintf("%s (%d)", name, len(reps)), nameColor)
+		for _, rep := range reps {
+			hit := replaceHit{rep: rep}
+			var row gxui.Control
+			hit.include, row = r.hitRow(path, rep)
+			f.hits = append(f.hits, hit)
+			file.AddChild(row)
+		}
+		r.pending = append(r.pending, f)
+		r.files.AddChild(file)
+		file.button.Click(gxui.MouseEvent{})
+		r.summary.SetText(fmt.Sprintf("%d replacements in %d files...", r.count, r.fileCount))
+	})
+}
+
+// FinishReplace marks the search that run was returned for as
+// complete.  If err is non-nil, the search for replacements was
+// stopped early because of err.
+func (r *ReplacePreview) FinishReplace(run int64, err error) {
+	r.driver.Call(func() {
+		if run != atomic.LoadInt64(&r.run) {
+			return
+		}
+		summary := fmt.Sprintf("%d replacements in %d files", r.count, r.fileCount)
+		if err != nil {
+			summary = fmt.Sprintf("%s (stopped: %s)", summary, err)
+		}
+		r.summary.SetText(summary)
+		if r.count > 0 && !r.apply.Attached() {
+			r.header.AddChild(r.apply)
+		}
+	})
+}
+
+// Included returns the replacements that have not been excluded,
+// keyed by file path.  It must be called on the UI goroutine.
+func (r *ReplacePreview) Included() map[string][]search.Replacement {
+	included := make(map[string][]search.Replacement)
+	for _, f := range r.pending {
+		for _, h := range f.hits {
+			if !h.include.IsChecked() {
+				continue
+			}
+			included[f.path] = append(included[f.path], h.rep)
+		}
+	}
+	return included
+}
+
+// ClearReplace removes all pending replacements from r.  It must be
+// called on the UI goroutine.
+func (r *ReplacePreview) ClearReplace() {
+	r.clear()
+	r.summary.SetText("No pending replacements")
+}
+
+func (r *ReplacePreview) clear() {
+	r.pending = nil
+	r.fileCount, r.count = 0, 0
+	r.files.RemoveAll()
+	if r.apply.Attached() {
+		r.header.RemoveChild(r.apply)
+	}
+}
+
+func (r *ReplacePreview) hitRow(path string, rep search.Replacement) (include gxui.Button, row gxui.Control) {
+	include = r.theme.CreateButton()
+	include.SetType(gxui.ToggleButton)
+	include.SetChecked(true)
+	include.SetPadding(math.Spacing{L: 3, T: 3, R: 3, B: 3})
+
+	old := newGenericNode(r.driver, r.theme, fmt.Sprintf("%d: - %s", rep.Line+1, clipLine(rep.Text)), removedColor)
+	old.button.OnClick(func(gxui.MouseEvent) {
+		opener := r.cmdr.Bindable("focus-location").(Opener)
+		r.cmdr.Execute(opener.For(focus.Path(path), focus.Line(rep.Line), focus.Column(rep.Column)))
+	})
+	added := r.theme.CreateLabel()
+	added.SetColor(addedColor)
+	added.SetText(fmt.Sprintf("%s+ %s", strings.Repeat(" ", len(fmt.Sprint(rep.Line+1))+2), clipLine(rep.NewText)))
+
+	lines := r.theme.CreateLinearLayout()
+	lines.SetDirection(gxui.TopToBottom)
+	lines.AddChild(old)
+	lines.AddChild(added)
+
+	layout := r.theme.CreateLinearLayout()
+	layout.SetDirection(gxui.LeftToRight)
+	layout.AddChild(include)
+	layout.AddChild(lines)
+	return include, layout
+}
+
+// clipLine trims line and limits it to maxMatchText runes.
+func clipLine(line string) string {
+	text := []rune(strings.TrimSpace(line))
+	if len(text) > maxMatchText {
+		text = append(text[:maxMatchText], '…')
+	}
+	return string(text)
+}
@@ -0,0 +1,163 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package navigator
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/gxui/math"
+	"github.com/nelsam/vidar/command/focus"
+	"github.com/nelsam/vidar/search"
+)
+
+// maxMatchText is the maximum number of runes of a matching line
+// that will be displayed in the results.
+const maxMatchText = 80
+
+var (
+	summaryColor = gxui.Gray80
+	matchColor   = gxui.Color{
+		R: 0.9,
+		G: 0.9,
+		B: 0.6,
+		A: 1,
+	}
+)
+
+// SearchResults is a Pane that displays the results of a
+// project-wide search, grouped by file.  Its methods may be called
+// from any goroutine.
+type SearchResults struct {
+	cmdr   Commander
+	driver gxui.Driver
+	theme  gxui.Theme
+
+	button  gxui.Button
+	frame   gxui.ScrollLayout
+	summary gxui.Label
+	files   gxui.LinearLayout
+
+	// run is the token of the most recent search.  Calls for any
+	// other search are ignored.
+	run int64
+
+	root             string
+	fileCount, count int
+}
+
+// NewSearchResultsPane returns an empty *SearchResults.
+func NewSearchResultsPane(cmdr Commander, driver gxui.Driver, theme gxui.Theme) *SearchResults {
+	s := &SearchResults{
+		cmdr:    cmdr,
+		driver:  driver,
+		theme:   theme,
+		button:  createTextButton(theme, "⌕"),
+		frame:   theme.CreateScrollLayout(),
+		summary: theme.CreateLabel(),
+		files:   theme.CreateLinearLayout(),
+	}
+	s.summary.SetColor(summaryColor)
+	s.summary.SetText("No search results")
+
+	layout := theme.CreateLinearLayout()
+	layout.SetDirection(gxui.TopToBottom)
+	layout.AddChild(s.summary)
+	s.files.SetDirection(gxui.TopToBottom)
+	layout.AddChild(s.files)
+
+	s.frame.SetScrollAxis(false, true)
+	s.frame.SetChild(layout)
+	return s
+}
+
+func (s *SearchResults) Button() gxui.Button {
+	return s.button
+}
+
+func (s *SearchResults) Frame() gxui.Control {
+	return s.frame
+}
+
+// Start clears any previous results and shows s, in preparation
+// for the results of a search for pattern under root.  The returned
+// token must be passed to Add and Finish for the results of the
+// search; results of earlier searches are ignored.
+func (s *SearchResults) Start(root, pattern string) int64 {
+	run := atomic.AddInt64(&s.run, 1)
+	s.driver.Call(func() {
+		if run != atomic.LoadInt64(&s.run) {
+			return
+		}
+		s.root = root
+		s.fileCount, s.count = 0, 0
+		s.files.RemoveAll()
+		s.summary.SetText(fmt.Sprintf("Searching for %s...", pattern))
+		if s.frame.Attached() {
+			return
+		}
+		s.button.Click(gxui.MouseEvent{
+			Button: gxui.MouseButtonLeft,
+		})
+	})
+	return run
+}
+
+// Add adds the matches from r, a result of the search that run was
+// returned for, to s.
+func (s *SearchResults) Add(run int64, r search.Result) {
+	s.driver.Call(func() {
+		if run != atomic.LoadInt64(&s.run) {
+			return
+		}
+		s.fileCount++
+		s.count += len(r.Matches)
+		name := r.Path
+		if rel, err := filepath.Rel(s.root, r.Path); err == nil {
+			name = rel
+		}
+		file := newGenericNode(s.driver, s.theme, fmt.Sprintf("%s (%d)", name, len(r.Matches)), nameColor)
+		for _, m := range r.Matches {
+			file.AddChild(s.matchNode(r.Path, m))
+		}
+		s.files.AddChild(file)
+		file.button.Click(gxui.MouseEvent{})
+		s.summary.SetText(fmt.Sprintf("%d matches in %d files...", s.count, s.fileCount))
+	})
+}
+
+// Finish marks the search that run was returned for as complete.
+// If err is non-nil, the search was stopped early because of err.
+func (s *SearchResults) Finish(run int64, err error) {
+	s.driver.Call(func() {
+		if run != atomic.LoadInt64(&s.run) {
+			return
+		}
+		summary := fmt.Sprintf("%d matches in %d files", s.count, s.fileCount)
+		if err != nil {
+			summary = fmt.Sprintf("%s (stopped: %s)", summary, err)
+		}
+		s.summary.SetText(summary)
+	})
+}
+
+func (s *SearchResults) matchNode(path string, m search.Match) *genericNode {
+	node := newGenericNode(s.driver, s.theme, fmt.Sprintf("%d: %s", m.Line+1, clipLine(m.Text)), matchColor)
+	node.button.OnClick(func(gxui.MouseEvent) {
+		opener := s.cmdr.Bindable("focus-location").(Opener)
+		s.cmdr.Execute(opener.For(focus.Path(path), focus.Line(m.Line), focus.Column(m.Column)))
+	})
+	return node
+}
+
+func createTextButton(theme gxui.Theme, text string) gxui.Button {
+	button := theme.CreateButton()
+	button.SetType(gxui.PushButton)
+	button.SetText(text)
+	button.SetPadding(math.Spacing{L: 6, T: 3, R: 6, B: 3})
+	return button
+}
@@ -0,0 +1,193 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package navigator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/command/focus"
+	"github.com/nelsam/vidar/task"
+)
+
+var (
+	passColor = gxui.Color{
+		R: 0.5,
+		G: 0.9,
+		B: 0.5,
+		A: 1,
+	}
+	failColor = gxui.Color{
+		R: 0.9,
+		G: 0.4,
+		B: 0.4,
+		A: 1,
+	}
+	skipColor    = gxui.Gray60
+	runningColor = gxui.Color{
+		R: 0.9,
+		G: 0.9,
+		B: 0.6,
+		A: 1,
+	}
+)
+
+// TestResults is a Pane that displays the result of each test in
+// the most recent test run as a tree, with subtests nested under
+// their parents.  Output logged by a test is shown under it, and
+// lines that refer to a location in a file can be clicked to open
+// that location.  Its methods may be called from any goroutine.
+type TestResults struct {
+	cmdr   Commander
+	driver gxui.Driver
+	theme  gxui.Theme
+
+	button  gxui.Button
+	frame   gxui.ScrollLayout
+	summary gxui.Label
+	tests   gxui.LinearLayout
+
+	shown *task.Run
+}
+
+// NewTestResultsPane returns an empty *TestResults.
+func NewTestResultsPane(cmdr Commander, driver gxui.Driver, theme gxui.Theme) *TestResults {
+	t := &TestResults{
+		cmdr:    cmdr,
+		driver:  driver,
+		theme:   theme,
+		button:  createTextButton(theme, "✔"),
+		frame:   theme.CreateScrollLayout(),
+		summary: theme.CreateLabel(),
+		tests:   theme.CreateLinearLayout(),
+	}
+	t.summary.SetColor(summaryColor)
+	t.summary.SetText("No tests have been run")
+
+	layout := theme.CreateLinearLayout()
+	layout.SetDirection(gxui.TopToBottom)
+	layout.AddChild(t.summary)
+	t.tests.SetDirection(gxui.TopToBottom)
+	layout.AddChild(t.tests)
+
+	t.frame.SetScrollAxis(true, true)
+	t.frame.SetChild(layout)
+	return t
+}
+
+func (t *TestResults) Button() gxui.Button {
+	return t.button
+}
+
+func (t *TestResults) Frame() gxui.Control {
+	return t.frame
+}
+
+// ReportTests replaces the displayed results with results, which
+// are the results of r so far.
+func (t *TestResults) ReportTests(r *task.Run, results []*task.TestResult) {
+	t.driver.Call(func() {
+		if r != t.shown {
+			t.shown = r
+			if !t.frame.Attached() {
+				t.button.Click(gxui.MouseEvent{
+					Button: gxui.MouseButtonLeft,
+				})
+			}
+		}
+		t.summary.SetText(t.summarize(r, results))
+		t.tests.RemoveAll()
+		for _, res := range results {
+			t.tests.AddChild(t.node(res))
+		}
+	})
+}
+
+func (t *TestResults) summarize(r *task.Run, results []*task.TestResult) string {
+	counts := make(map[task.TestStatus]int)
+	for _, res := range results {
+		counts[res.Status]++
+	}
+	summary := fmt.Sprintf("%s: %d passed, %d failed, %d skipped", r.Name, counts[task.TestPassed], counts[task.TestFailed], counts[task.TestSkipped])
+	switch r.Status() {
+	case task.Running:
+		return summary + "..."
+	case task.Failed:
+		if counts[task.TestFailed] == 0 {
+			// Nothing failed, but the run did; the build probably
+			// failed, and the output pane will have the details.
+			return fmt.Sprintf("%s (%s)", summary, r.Status())
+		}
+	case task.Cancelled:
+		return fmt.Sprintf("%s (%s)", summary, r.Status())
+	}
+	return summary
+}
+
+func (t *TestResults) node(res *task.TestResult) *genericNode {
+	node := newGenericNode(t.driver, t.theme, t.label(res), statusColor(res.Status))
+	for _, sub := range res.Subtests {
+		node.AddChild(t.node(sub))
+	}
+	for _, l := range res.Output {
+		node.AddChild(t.line(l))
+	}
+	if res.Status == task.TestFailed && len(node.children.Children()) > 0 {
+		node.button.Click(gxui.MouseEvent{})
+	}
+	return node
+}
+
+func (t *TestResults) label(res *task.TestResult) string {
+	var icon string
+	switch res.Status {
+	case task.TestPassed:
+		icon = "✔"
+	case task.TestFailed:
+		icon = "✘"
+	case task.TestSkipped:
+		icon = "-"
+	default:
+		icon = "…"
+	}
+	label := fmt.Sprintf("%s %s", icon, res.Name)
+	if res.Detail != "" {
+		return fmt.Sprintf("%s: %s", label, res.Detail)
+	}
+	if res.Status != task.TestRunning {
+		label = fmt.Sprintf("%s (%s)", label, res.Elapsed.Round(time.Millisecond))
+	}
+	return label
+}
+
+func (t *TestResults) line(l task.Line) gxui.Control {
+	if l.Link == nil {
+		label := t.theme.CreateLabel()
+		label.SetColor(outputColor)
+		label.SetText(l.Text)
+		return label
+	}
+	link := *l.Link
+	node := newGenericNode(t.driver, t.theme, l.Text, linkColor)
+	node.button.OnClick(func(gxui.MouseEvent) {
+		opener := t.cmdr.Bindable("focus-location").(Opener)
+		t.cmdr.Execute(opener.For(focus.Path(link.Path), focus.Line(link.Line), focus.Column(link.Column)))
+	})
+	return node
+}
+
+func statusColor(s task.TestStatus) gxui.Color {
+	switch s {
+	case task.TestPassed:
+		return passColor
+	case task.TestFailed:
+		return failColor
+	case task.TestSkipped:
+		return skipColor
+	default:
+		return runningColor
+	}
+}
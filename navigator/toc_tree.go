@@ -251,6 +251,7 @@ type TOC struct {
 	dir        string
 	fileSet    *token.FileSet
 	packageMap map[string]*packageNode
+	files      *genericNode
 
 	lock sync.Mutex
 }
@@ -295,6 +296,7 @@ func (t *TOC) expandPackages() {
 
 func (t *TOC) parseFiles(dir string, files ...os.FileInfo) {
 	filesNode := newGenericNode(t.driver, t.theme, "files", skippableColor)
+	t.files = filesNode
 	t.AddChild(filesNode)
 	defer filesNode.button.Click(gxui.MouseEvent{})
 	for _, file := range files {
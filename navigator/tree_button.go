@@ -41,6 +41,7 @@ type treeButton struct {
 
 	driver gxui.Driver
 	theme  *basic.Theme
+	mark   *mixins.Label
 	drop   *mixins.Label
 
 	dropSet dropdownCharSet
@@ -54,8 +55,10 @@ func newTreeButton(driver gxui.Driver, theme *basic.Theme, name string) *treeBut
 	d := &treeButton{
 		driver: driver,
 		theme:  theme,
+		mark:   &mixins.Label{},
 		drop:   &mixins.Label{},
 	}
+	d.mark.Init(d.mark, d.theme, d.theme.DefaultMonospaceFont(), dropColor)
 	d.drop.Init(d.drop, d.theme, d.theme.DefaultMonospaceFont(), dropColor)
 	d.Init(d, theme)
 
@@ -64,6 +67,7 @@ func newTreeButton(driver gxui.Driver, theme *basic.Theme, name string) *treeBut
 	d.SetDirection(gxui.LeftToRight)
 	d.SetText(name)
 	d.Label().SetColor(dirColor)
+	d.AddChild(d.mark)
 	d.AddChild(d.drop)
 	d.SetPadding(math.Spacing{L: 1, R: 1, B: 1, T: 1})
 	d.SetMargin(math.Spacing{L: 3})
@@ -104,6 +108,16 @@ func (d *treeButton) SetExpandable(expandable bool) {
 	d.drop.SetText(text)
 }
 
+// SetMark sets a short mark to display after the button's text,
+// e.g. to show a file's status.  An empty mark removes it.
+func (d *treeButton) SetMark(mark string, color gxui.Color) {
+	if mark != "" {
+		mark = " " + mark
+	}
+	d.mark.SetText(mark)
+	d.mark.SetColor(color)
+}
+
 func (d *treeButton) Expanded() bool {
 	return d.Expandable() && d.drop.Text() == fmt.Sprintf(" %c", d.dropSet.expanded)
 }
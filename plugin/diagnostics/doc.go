@@ -0,0 +1,15 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+// Package diagnostics displays errors and warnings from compilers
+// and linters in the editor.  Diagnostics are read from
+// diagnostic.Default and displayed as underlines with markers in
+// the gutter; commands are provided to jump between them, showing
+// each message in the status bar.
+//
+// For Go files that are not handled by a language server, this
+// package also provides two sources of diagnostics: an in-process
+// type checker that runs as the file is edited, and go vet, which
+// runs after the file is saved.
+package diagnostics
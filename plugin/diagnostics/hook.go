@@ -0,0 +1,58 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package diagnostics
+
+import (
+	"strings"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/diagnostic"
+	"github.com/nelsam/vidar/plugin/langserver"
+)
+
+// Hook binds diagnostics to files as they are focused.
+type Hook struct {
+	overlay   *Overlay
+	typeCheck *TypeCheck
+	vet       *Vet
+}
+
+// NewHook returns a *Hook that displays and reports diagnostics
+// using s.
+func NewHook(d gxui.Driver, s *diagnostic.Store) *Hook {
+	return &Hook{
+		overlay:   NewOverlay(d, s),
+		typeCheck: NewTypeCheck(s),
+		vet:       NewVet(s),
+	}
+}
+
+func (h *Hook) Name() string {
+	return "diagnostics-hook"
+}
+
+func (h *Hook) OpName() string {
+	return "focus-location"
+}
+
+func (h *Hook) FileBindables(path string) []bind.Bindable {
+	b := []bind.Bindable{h.overlay}
+	if !strings.HasSuffix(path, ".go") || langserver.Handles(path) {
+		// Language servers publish their own diagnostics.
+		return b
+	}
+	return append(b, h.typeCheck, h.vet)
+}
+
+// Bindables returns the bindables that display diagnostics from s
+// and move between them.
+func Bindables(driver gxui.Driver, theme gxui.Theme, s *diagnostic.Store) []bind.Bindable {
+	return []bind.Bindable{
+		NewHook(driver, s),
+		NewNext(theme, s),
+		NewPrev(theme, s),
+	}
+}
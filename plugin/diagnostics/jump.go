@@ -0,0 +1,116 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package diagnostics
+
+import (
+	"fmt"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/diagnostic"
+	"github.com/nelsam/vidar/plugin/status"
+)
+
+type Editor interface {
+	Filepath() string
+	ScrollToRune(int)
+}
+
+type CaretController interface {
+	LastCaret() int
+	SetCaret(int)
+}
+
+// Jump is a command that moves the caret to the next or previous
+// diagnostic in the current file and displays its message.
+type Jump struct {
+	status.General
+
+	store    *diagnostic.Store
+	name     string
+	find     func(s *diagnostic.Store, path string, offset int) (diagnostic.Diagnostic, bool)
+	defaults []fmt.Stringer
+
+	editor Editor
+	ctrl   CaretController
+}
+
+// NewNext returns a *Jump that moves to the next diagnostic.
+func NewNext(theme gxui.Theme, s *diagnostic.Store) *Jump {
+	j := &Jump{
+		store: s,
+		name:  "next-diagnostic",
+		find:  (*diagnostic.Store).Next,
+		defaults: []fmt.Stringer{gxui.KeyboardEvent{
+			Key: gxui.KeyF8,
+		}},
+	}
+	j.Theme = theme
+	return j
+}
+
+// NewPrev returns a *Jump that moves to the previous diagnostic.
+func NewPrev(theme gxui.Theme, s *diagnostic.Store) *Jump {
+	j := &Jump{
+		store: s,
+		name:  "prev-diagnostic",
+		find:  (*diagnostic.Store).Prev,
+		defaults: []fmt.Stringer{gxui.KeyboardEvent{
+			Modifier: gxui.ModShift,
+			Key:      gxui.KeyF8,
+		}},
+	}
+	j.Theme = theme
+	return j
+}
+
+func (j *Jump) Name() string {
+	return j.name
+}
+
+func (j *Jump) Menu() string {
+	return "Navigation"
+}
+
+func (j *Jump) Defaults() []fmt.Stringer {
+	return j.defaults
+}
+
+func (j *Jump) Reset() {
+	j.editor = nil
+	j.ctrl = nil
+}
+
+func (j *Jump) Store(target interface{}) bind.Status {
+	switch src := target.(type) {
+	case Editor:
+		j.editor = src
+	case CaretController:
+		j.ctrl = src
+	}
+	if j.editor != nil && j.ctrl != nil {
+		return bind.Done
+	}
+	return bind.Waiting
+}
+
+func (j *Jump) Exec() error {
+	d, ok := j.find(j.store, j.editor.Filepath(), j.ctrl.LastCaret())
+	if !ok {
+		j.Info = "No diagnostics"
+		return nil
+	}
+	j.ctrl.SetCaret(d.Start)
+	j.editor.ScrollToRune(d.Start)
+	switch d.Severity {
+	case diagnostic.Error:
+		j.Err = d.String()
+	case diagnostic.Warning:
+		j.Warn = d.String()
+	default:
+		j.Info = d.String()
+	}
+	return nil
+}
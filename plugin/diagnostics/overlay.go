@@ -0,0 +1,119 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package diagnostics
+
+import (
+	"sync"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/diagnostic"
+	"github.com/nelsam/vidar/theme"
+)
+
+const overlayName = "diagnostics"
+
+// Overlayer is a type that can display layers on top of its
+// syntax layers.
+type Overlayer interface {
+	SetOverlay(name string, layers []text.SyntaxLayer)
+}
+
+// Overlay displays the diagnostics in a diagnostic.Store in the
+// editors that it is bound to.
+type Overlay struct {
+	driver gxui.Driver
+	store  *diagnostic.Store
+
+	mu      sync.Mutex
+	editors map[string]text.Editor
+}
+
+// NewOverlay returns an *Overlay that displays diagnostics from s.
+func NewOverlay(d gxui.Driver, s *diagnostic.Store) *Overlay {
+	o := &Overlay{
+		driver:  d,
+		store:   s,
+		editors: make(map[string]text.Editor),
+	}
+	s.OnChange(o.changed)
+	return o
+}
+
+func (o *Overlay) Name() string {
+	return "diagnostics-overlay"
+}
+
+func (o *Overlay) OpName() string {
+	return "input-handler"
+}
+
+func (o *Overlay) Init(e text.Editor, _ []rune) {
+	o.mu.Lock()
+	o.editors[e.Filepath()] = e
+	o.mu.Unlock()
+	o.render(e)
+}
+
+func (o *Overlay) TextChanged(text.Editor, text.Edit) {
+}
+
+func (o *Overlay) Apply(text.Editor) error {
+	return nil
+}
+
+// Applied moves the diagnostics for e to match edits, so that
+// they stay on the text that they refer to until their sources
+// have a chance to update them.
+func (o *Overlay) Applied(e text.Editor, edits []text.Edit) {
+	path := e.Filepath()
+	for _, edit := range edits {
+		o.store.Move(path, edit.At, len(edit.Old), len(edit.New))
+	}
+	o.render(e)
+}
+
+func (o *Overlay) changed(path string) {
+	o.mu.Lock()
+	e, ok := o.editors[path]
+	o.mu.Unlock()
+	if !ok {
+		return
+	}
+	o.driver.Call(func() {
+		o.render(e)
+	})
+}
+
+func (o *Overlay) render(e text.Editor) {
+	overlayer, ok := e.(Overlayer)
+	if !ok {
+		return
+	}
+	var layers []text.SyntaxLayer
+	index := make(map[theme.LanguageConstruct]int)
+	for _, d := range o.store.For(e.Filepath()) {
+		c := construct(d.Severity)
+		i, ok := index[c]
+		if !ok {
+			i = len(layers)
+			index[c] = i
+			layers = append(layers, text.SyntaxLayer{Construct: c})
+		}
+		layers[i].Spans = append(layers[i].Spans, text.Span{Start: d.Start, End: d.End})
+	}
+	overlayer.SetOverlay(overlayName, layers)
+}
+
+func construct(s diagnostic.Severity) theme.LanguageConstruct {
+	switch s {
+	case diagnostic.Error:
+		return theme.ErrorDiagnostic
+	case diagnostic.Warning:
+		return theme.WarningDiagnostic
+	default:
+		return theme.InfoDiagnostic
+	}
+}
@@ -0,0 +1,137 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package diagnostics
+
+import (
+	"context"
+	"go/importer"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"sync"
+	"unicode"
+
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/diagnostic"
+	"github.com/nelsam/vidar/syntax"
+)
+
+const typeCheckSource = "types"
+
+// TypeCheck is a source of diagnostics that type checks Go files
+// in-process as they are edited.  Other files in the same package
+// are read from disk.
+type TypeCheck struct {
+	store *diagnostic.Store
+
+	// mu guards the importer, which caches imported packages
+	// between checks and is not safe for concurrent use.
+	mu       sync.Mutex
+	importer types.Importer
+}
+
+// NewTypeCheck returns a *TypeCheck that pushes diagnostics to s.
+func NewTypeCheck(s *diagnostic.Store) *TypeCheck {
+	return &TypeCheck{
+		store:    s,
+		importer: importer.ForCompiler(token.NewFileSet(), "source", nil),
+	}
+}
+
+func (t *TypeCheck) Name() string {
+	return "go-type-check"
+}
+
+func (t *TypeCheck) OpName() string {
+	return "input-handler"
+}
+
+func (t *TypeCheck) Init(e text.Editor, contents []rune) {
+	// The first check may need to import a lot of packages, so it
+	// is kept off of the UI goroutine.
+	go t.check(context.Background(), e.Filepath(), string(contents))
+}
+
+func (t *TypeCheck) TextChanged(ctx context.Context, e text.Editor, _ []text.Edit) {
+	t.check(ctx, e.Filepath(), e.Text())
+}
+
+func (t *TypeCheck) Apply(text.Editor) error {
+	return nil
+}
+
+func (t *TypeCheck) check(ctx context.Context, path, src string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	diags := t.diagnostics(path, src)
+	select {
+	case <-ctx.Done():
+		return
+	default:
+	}
+	t.store.Set(path, typeCheckSource, diags)
+}
+
+func (t *TypeCheck) diagnostics(path, src string) []diagnostic.Diagnostic {
+	b := []byte(src)
+	runes := []rune(src)
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, b, parser.AllErrors)
+	if err != nil {
+		var diags []diagnostic.Diagnostic
+		if list, ok := err.(scanner.ErrorList); ok {
+			list.RemoveMultiples()
+			for _, e := range list {
+				diags = append(diags, newDiagnostic(b, runes, e.Pos, "syntax", e.Msg, diagnostic.Error))
+			}
+		}
+		return diags
+	}
+
+	var diags []diagnostic.Diagnostic
+	conf := types.Config{
+		Importer: t.importer,
+		Error: func(err error) {
+			terr, ok := err.(types.Error)
+			if !ok {
+				return
+			}
+			pos := terr.Fset.Position(terr.Pos)
+			if pos.Filename != path {
+				return
+			}
+			severity := diagnostic.Error
+			if terr.Soft {
+				severity = diagnostic.Warning
+			}
+			diags = append(diags, newDiagnostic(b, runes, pos, typeCheckSource, terr.Msg, severity))
+		},
+	}
+	conf.Check(f.Name.Name, fset, syntax.PackageFiles(fset, path, f), nil)
+	return diags
+}
+
+func newDiagnostic(src []byte, runes []rune, pos token.Position, source, msg string, severity diagnostic.Severity) diagnostic.Diagnostic {
+	start := diagnostic.Offset(src, pos.Line, pos.Column)
+	return diagnostic.Diagnostic{
+		Start:    start,
+		End:      wordEnd(runes, start),
+		Severity: severity,
+		Source:   source,
+		Message:  msg,
+	}
+}
+
+// wordEnd returns the end of the identifier that starts at start,
+// or start if there is no identifier there.
+func wordEnd(runes []rune, start int) int {
+	end := start
+	for end < len(runes) && (runes[end] == '_' || unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end])) {
+		end++
+	}
+	return end
+}
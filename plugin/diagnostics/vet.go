@@ -0,0 +1,124 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package diagnostics
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nelsam/vidar/diagnostic"
+	"github.com/nelsam/vidar/setting"
+)
+
+const vetSource = "vet"
+
+var vetLine = regexp.MustCompile(`^(?:vet: )?(.+\.go):(\d+):(\d+): (.*)$`)
+
+// Vet is a source of diagnostics that runs go vet on a package
+// after one of its files has been saved.
+type Vet struct {
+	store *diagnostic.Store
+
+	mu       sync.Mutex
+	reported map[string][]string
+}
+
+// NewVet returns a *Vet that pushes diagnostics to s.
+func NewVet(s *diagnostic.Store) *Vet {
+	return &Vet{
+		store:    s,
+		reported: make(map[string][]string),
+	}
+}
+
+func (v *Vet) Name() string {
+	return "go-vet-on-save"
+}
+
+func (v *Vet) OpName() string {
+	return "save-current-file"
+}
+
+func (v *Vet) AfterSave(proj setting.Project, path, contents string) error {
+	go v.vet(filepath.Dir(path), proj.Environ())
+	return nil
+}
+
+func (v *Vet) vet(dir string, env []string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	cmd := exec.Command("go", "vet", ".")
+	cmd.Dir = dir
+	cmd.Env = env
+	out, err := cmd.CombinedOutput()
+	if _, ok := err.(*exec.ExitError); err != nil && !ok {
+		log.Printf("diagnostics: could not run go vet in %s: %s", dir, err)
+		return
+	}
+
+	found := parseVet(dir, out)
+	for _, path := range v.reported[dir] {
+		if _, ok := found[path]; !ok {
+			v.store.Set(path, vetSource, nil)
+		}
+	}
+	v.reported[dir] = v.reported[dir][:0]
+	for path, lines := range found {
+		v.reported[dir] = append(v.reported[dir], path)
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Printf("diagnostics: could not read %s: %s", path, err)
+			continue
+		}
+		runes := []rune(string(src))
+		var diags []diagnostic.Diagnostic
+		for _, l := range lines {
+			start := diagnostic.Offset(src, l.line, l.col)
+			diags = append(diags, diagnostic.Diagnostic{
+				Start:    start,
+				End:      wordEnd(runes, start),
+				Severity: diagnostic.Warning,
+				Source:   vetSource,
+				Message:  l.msg,
+			})
+		}
+		v.store.Set(path, vetSource, diags)
+	}
+}
+
+type vetMessage struct {
+	line, col int
+	msg       string
+}
+
+// parseVet parses the output of go vet, run in dir, into messages
+// for each file.
+func parseVet(dir string, out []byte) map[string][]vetMessage {
+	found := make(map[string][]vetMessage)
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		match := vetLine.FindStringSubmatch(strings.TrimSpace(s.Text()))
+		if match == nil {
+			continue
+		}
+		path := match[1]
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		line, _ := strconv.Atoi(match[2])
+		col, _ := strconv.Atoi(match[3])
+		found[path] = append(found[path], vetMessage{line: line, col: col, msg: match[4]})
+	}
+	return found
+}
@@ -15,17 +15,39 @@ import (
 	"github.com/nelsam/vidar/command/caret"
 	"github.com/nelsam/vidar/commander/text"
 	"github.com/nelsam/vidar/setting"
+	"github.com/nelsam/vidar/suggestion"
 )
 
 type Projecter interface {
 	Project() setting.Project
 }
 
+// A Suggester is a type that can find completion suggestions for
+// a position in a file.
+type Suggester interface {
+	Suggestions(proj setting.Project, path string, runes []rune, pos int) ([]suggestion.Suggestion, error)
+}
+
+type gocodeSuggester struct{}
+
+func (gocodeSuggester) Suggestions(proj setting.Project, path string, runes []rune, pos int) ([]suggestion.Suggestion, error) {
+	return suggestion.For(proj.Environ(), path, string(runes), pos)
+}
+
+// New returns a *Completions and *GoCode which use the gocode
+// command to find suggestions.
 func New(theme *basic.Theme, driver gxui.Driver) (*Completions, *GoCode) {
+	return NewWithSuggester(theme, driver, gocodeSuggester{})
+}
+
+// NewWithSuggester returns a *Completions and *GoCode which use s
+// to find suggestions.
+func NewWithSuggester(theme *basic.Theme, driver gxui.Driver, s Suggester) (*Completions, *GoCode) {
 	g := GoCode{
-		driver:  driver,
-		lists:   make(map[Editor]*suggestionList),
-		cancels: make(map[Editor]func()),
+		driver:    driver,
+		suggester: s,
+		lists:     make(map[Editor]*suggestionList),
+		cancels:   make(map[Editor]func()),
 	}
 	c := Completions{
 		gocode: &g,
@@ -44,7 +66,8 @@ func ctxCancelled(ctx context.Context) bool {
 }
 
 type GoCode struct {
-	driver gxui.Driver
+	driver    gxui.Driver
+	suggester Suggester
 
 	mu      sync.RWMutex
 	lists   map[Editor]*suggestionList
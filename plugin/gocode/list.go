@@ -125,7 +125,7 @@ func (s *suggestionList) show(ctx context.Context, pos int) int {
 }
 
 func (s *suggestionList) parseSuggestions(runes []rune, start int) []suggestion.Suggestion {
-	suggestion, err := suggestion.For(s.project.Environ(), s.editor.Filepath(), string(runes), start)
+	suggestion, err := s.gocode.suggester.Suggestions(s.project, s.editor.Filepath(), runes, start)
 	if err != nil {
 		log.Printf("Failed to load suggestion: %s", err)
 		return nil
@@ -12,6 +12,7 @@ import (
 	"github.com/nelsam/vidar/commander/bind"
 	"github.com/nelsam/vidar/plugin/command"
 	"github.com/nelsam/vidar/plugin/gocode"
+	"github.com/nelsam/vidar/plugin/langserver"
 )
 
 type GolangHook struct {
@@ -28,7 +29,7 @@ func (h GolangHook) OpName() string {
 }
 
 func (h GolangHook) FileBindables(path string) []bind.Bindable {
-	if !strings.HasSuffix(path, ".go") {
+	if !strings.HasSuffix(path, ".go") || langserver.Handles(path) {
 		return nil
 	}
 	completions, gocode := gocode.New(h.Theme, h.Driver)
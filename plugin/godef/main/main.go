@@ -11,6 +11,7 @@ import (
 	"github.com/nelsam/vidar/commander/bind"
 	"github.com/nelsam/vidar/plugin/command"
 	"github.com/nelsam/vidar/plugin/godef"
+	"github.com/nelsam/vidar/plugin/langserver"
 )
 
 type GolangHook struct {
@@ -26,7 +27,7 @@ func (h GolangHook) OpName() string {
 }
 
 func (h GolangHook) FileBindables(path string) []bind.Bindable {
-	if !strings.HasSuffix(path, ".go") {
+	if !strings.HasSuffix(path, ".go") || langserver.Handles(path) {
 		return nil
 	}
 	return []bind.Bindable{
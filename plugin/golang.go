@@ -17,6 +17,7 @@ import (
 	"github.com/nelsam/vidar/plugin/godef"
 	"github.com/nelsam/vidar/plugin/goimports"
 	"github.com/nelsam/vidar/plugin/gosyntax"
+	"github.com/nelsam/vidar/plugin/langserver"
 	"github.com/nelsam/vidar/plugin/license"
 )
 
@@ -37,15 +38,22 @@ func (h GolangHook) FileBindables(path string) []bind.Bindable {
 	if !strings.HasSuffix(path, ".go") {
 		return nil
 	}
-	completions, gocode := gocode.New(h.Theme, h.Driver)
-	return []bind.Bindable{
+	b := []bind.Bindable{
 		comments.NewToggle(),
+		gosyntax.New(h.Driver),
+		license.NewHeaderUpdate(h.Theme),
+	}
+	if langserver.Handles(path) {
+		// The langserver hook provides completion, definitions,
+		// and formatting for this file.
+		return b
+	}
+	completions, gocode := gocode.New(h.Theme, h.Driver)
+	return append(b,
 		godef.New(h.Theme),
 		goimports.New(h.Theme),
 		goimports.OnSave{},
-		gosyntax.New(),
-		license.NewHeaderUpdate(h.Theme),
 		completions,
 		gocode,
-	}
+	)
 }
@@ -6,22 +6,31 @@ package gosyntax
 
 import (
 	"context"
+	"os"
 	"sync"
 
+	"github.com/nelsam/gxui"
 	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/setting"
 	"github.com/nelsam/vidar/syntax"
 )
 
 type Highlight struct {
+	driver gxui.Driver
 	ctx    context.Context
-	layers []text.SyntaxLayer
 	syntax *syntax.Syntax
 
-	mu sync.Mutex
+	// mu guards syntax, which may be slow to parse when type
+	// checking, while layersMu only guards layers, so that Apply
+	// never has to wait on a parse.
+	mu       sync.Mutex
+	parses   int
+	layersMu sync.Mutex
+	layers   []text.SyntaxLayer
 }
 
-func New() *Highlight {
-	return &Highlight{syntax: syntax.New()}
+func New(driver gxui.Driver) *Highlight {
+	return &Highlight{driver: driver, syntax: syntax.New()}
 }
 
 func (h *Highlight) Name() string {
@@ -72,30 +81,80 @@ func (h *Highlight) moveSpan(s text.Span, edits []text.Edit) text.Span {
 }
 
 func (h *Highlight) Init(e text.Editor, text []rune) {
-	h.TextChanged(context.Background(), e, nil)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.parses++
+	// Type checking may need to load imported packages, which is
+	// too slow to do while a file is being opened, so it happens in
+	// the background after a quick parse.
+	h.setLayers(h.parse(e, string(text), false))
+	if setting.TypeCheckHighlighting() {
+		go h.check(e, string(text), h.parses)
+	}
+}
+
+func (h *Highlight) check(e text.Editor, src string, parse int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if parse != h.parses {
+		// The text has changed since, so src is out of date.
+		return
+	}
+	layers := h.parse(e, src, true)
+	h.setLayers(layers)
+	h.driver.Call(func() {
+		e.SetSyntaxLayers(layers)
+	})
 }
 
 func (h *Highlight) TextChanged(ctx context.Context, editor text.Editor, _ []text.Edit) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
+	h.parses++
 	// TODO: only update layers that changed.
-	err := h.syntax.Parse(editor.Text())
-	if err != nil {
-		// TODO: Report the error in the UI
-		_ = err
-	}
+	layers := h.parse(editor, editor.Text(), setting.TypeCheckHighlighting())
 	select {
 	case <-ctx.Done():
 		return
 	default:
 	}
 
-	h.layers = h.syntax.Layers()
+	h.setLayers(layers)
+}
+
+func (h *Highlight) parse(e text.Editor, src string, typeCheck bool) []text.SyntaxLayer {
+	var err error
+	if typeCheck {
+		err = h.syntax.Check(e.Filepath(), src, environ(e.Filepath()))
+	} else {
+		err = h.syntax.Parse(src)
+	}
+	if err != nil {
+		// TODO: Report the error in the UI
+		_ = err
+	}
+	return h.syntax.Layers()
+}
+
+func (h *Highlight) setLayers(layers []text.SyntaxLayer) {
+	h.layersMu.Lock()
+	defer h.layersMu.Unlock()
+	h.layers = layers
 }
 
 func (h *Highlight) Apply(e text.Editor) error {
-	h.mu.Lock()
-	defer h.mu.Unlock()
+	h.layersMu.Lock()
+	defer h.layersMu.Unlock()
 	e.SetSyntaxLayers(h.layers)
 	return nil
 }
+
+// environ returns the environment that should be used to load
+// packages imported by path.
+func environ(path string) []string {
+	proj, ok := setting.ProjectFor(path)
+	if !ok {
+		return os.Environ()
+	}
+	return proj.Environ()
+}
@@ -14,6 +14,7 @@ import (
 )
 
 type GolangHook struct {
+	Driver gxui.Driver
 }
 
 func (h GolangHook) Name() string {
@@ -29,13 +30,13 @@ func (h GolangHook) FileBindables(path string) []bind.Bindable {
 		return nil
 	}
 	return []bind.Bindable{
-		gosyntax.New(),
+		gosyntax.New(h.Driver),
 	}
 }
 
 // Bindables is the main entry point to the command.
 func Bindables(cmdr command.Commander, driver gxui.Driver, theme gxui.Theme) []bind.Bindable {
 	return []bind.Bindable{
-		GolangHook{},
+		GolangHook{Driver: driver},
 	}
 }
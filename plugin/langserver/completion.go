@@ -0,0 +1,44 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package langserver
+
+import (
+	"context"
+
+	"github.com/nelsam/vidar/lsp"
+	"github.com/nelsam/vidar/setting"
+	"github.com/nelsam/vidar/suggestion"
+)
+
+// suggester implements gocode.Suggester using a language server.
+type suggester struct {
+	manager *Manager
+}
+
+func (s suggester) Suggestions(proj setting.Project, path string, runes []rune, pos int) ([]suggestion.Suggestion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	var items []lsp.CompletionItem
+	err := s.manager.Do(ctx, path, func(c *lsp.Client) error {
+		var err error
+		items, err = c.Completion(ctx, path, pos)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	suggestions := make([]suggestion.Suggestion, 0, len(items))
+	for _, item := range items {
+		name := item.InsertText
+		if item.TextEdit != nil {
+			name = item.TextEdit.NewText
+		}
+		if name == "" {
+			name = item.Label
+		}
+		suggestions = append(suggestions, suggestion.Suggestion{Name: name, Signature: item.Detail})
+	}
+	return suggestions, nil
+}
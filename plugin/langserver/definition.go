@@ -0,0 +1,127 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package langserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/command/focus"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/lsp"
+	"github.com/nelsam/vidar/plugin/status"
+)
+
+type Commander interface {
+	Execute(bind.Bindable)
+}
+
+type Opener interface {
+	For(...focus.Opt) bind.Bindable
+}
+
+type Editor interface {
+	Filepath() string
+	Runes() []rune
+}
+
+type CursorController interface {
+	LastCaret() int
+}
+
+// Definition is a command that moves to the definition of the
+// identifier under the caret.
+type Definition struct {
+	status.General
+
+	manager *Manager
+	cmdr    Commander
+	opener  Opener
+	editor  Editor
+	ctrl    CursorController
+}
+
+func NewDefinition(theme gxui.Theme, m *Manager) *Definition {
+	d := &Definition{manager: m}
+	d.Theme = theme
+	return d
+}
+
+func (d *Definition) Name() string {
+	return "goto-definition"
+}
+
+func (d *Definition) Menu() string {
+	return "Language"
+}
+
+func (d *Definition) Defaults() []fmt.Stringer {
+	return []fmt.Stringer{gxui.KeyboardEvent{
+		Modifier: gxui.ModControl | gxui.ModShift,
+		Key:      gxui.KeyG,
+	}}
+}
+
+func (d *Definition) Reset() {
+	d.cmdr = nil
+	d.opener = nil
+	d.editor = nil
+	d.ctrl = nil
+}
+
+func (d *Definition) Store(target interface{}) bind.Status {
+	switch src := target.(type) {
+	case Commander:
+		d.cmdr = src
+	case Editor:
+		d.editor = src
+	case CursorController:
+		d.ctrl = src
+	case Opener:
+		d.opener = src
+	}
+	if d.cmdr != nil && d.opener != nil && d.ctrl != nil && d.editor != nil {
+		return bind.Done
+	}
+	return bind.Waiting
+}
+
+func (d *Definition) Exec() error {
+	path := d.editor.Filepath()
+	offset := d.ctrl.LastCaret()
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	var locs []lsp.Location
+	err := d.manager.Do(ctx, path, func(c *lsp.Client) error {
+		var err error
+		locs, err = c.Definition(ctx, path, offset)
+		return err
+	})
+	if err != nil {
+		d.Err = err.Error()
+		return err
+	}
+	if len(locs) == 0 {
+		d.Warn = "No definition found"
+		return errors.New("langserver: no definition found")
+	}
+
+	target := lsp.Path(locs[0].URI)
+	runes := d.editor.Runes()
+	if target != path {
+		b, err := ioutil.ReadFile(target)
+		if err != nil {
+			d.Err = fmt.Sprintf("Could not read %s: %s", target, err)
+			return err
+		}
+		runes = []rune(string(b))
+	}
+	d.cmdr.Execute(d.opener.For(focus.Path(target), focus.Offset(lsp.Offset(runes, locs[0].Range.Start))))
+	return nil
+}
@@ -0,0 +1,8 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+// Package langserver contains plugins that use language servers
+// (configured per file extension in vidar's settings) to provide
+// completion, go to definition, and formatting.
+package langserver
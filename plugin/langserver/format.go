@@ -0,0 +1,145 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package langserver
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/lsp"
+	"github.com/nelsam/vidar/plugin/status"
+)
+
+type Applier interface {
+	Apply(text.Editor, ...text.Edit)
+}
+
+// Format is a command that formats the current file using its
+// language server.
+type Format struct {
+	status.General
+
+	manager *Manager
+	editor  text.Editor
+	applier Applier
+}
+
+func NewFormat(theme gxui.Theme, m *Manager) *Format {
+	f := &Format{manager: m}
+	f.Theme = theme
+	return f
+}
+
+func (f *Format) Name() string {
+	return "langserver-format"
+}
+
+func (f *Format) Menu() string {
+	return "Language"
+}
+
+func (f *Format) Defaults() []fmt.Stringer {
+	return []fmt.Stringer{gxui.KeyboardEvent{
+		Modifier: gxui.ModControl | gxui.ModShift,
+		Key:      gxui.KeyF,
+	}}
+}
+
+func (f *Format) Reset() {
+	f.editor = nil
+	f.applier = nil
+}
+
+func (f *Format) Store(target interface{}) bind.Status {
+	switch src := target.(type) {
+	case text.Editor:
+		f.editor = src
+	case Applier:
+		f.applier = src
+	}
+	if f.editor != nil && f.applier != nil {
+		return bind.Done
+	}
+	return bind.Waiting
+}
+
+func (f *Format) Exec() error {
+	path := f.editor.Filepath()
+	runes := f.editor.Runes()
+	edits, err := f.manager.format(path, nil)
+	if err != nil {
+		f.Err = err.Error()
+		return err
+	}
+	if len(edits) == 0 {
+		return nil
+	}
+	f.applier.Apply(f.editor, convert(runes, edits)...)
+	return nil
+}
+
+// format runs before (which may be nil) and then requests
+// formatting edits for path.
+func (m *Manager) format(path string, before func(*lsp.Client) error) ([]lsp.TextEdit, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	var edits []lsp.TextEdit
+	err := m.Do(ctx, path, func(c *lsp.Client) error {
+		if before != nil {
+			if err := before(c); err != nil {
+				return err
+			}
+		}
+		var err error
+		edits, err = c.Format(ctx, path, lsp.FormattingOptions{TabSize: 4})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return edits, nil
+}
+
+// convert converts edits from a language server to text.Edits
+// against runes.
+func convert(runes []rune, edits []lsp.TextEdit) []text.Edit {
+	converted := make([]text.Edit, 0, len(edits))
+	for _, e := range edits {
+		start := lsp.Offset(runes, e.Range.Start)
+		end := lsp.Offset(runes, e.Range.End)
+		converted = append(converted, text.Edit{
+			At:  start,
+			Old: runes[start:end],
+			New: []rune(e.NewText),
+		})
+	}
+	return converted
+}
+
+// apply applies edits from a language server to runes, returning
+// the result.
+func apply(runes []rune, edits []lsp.TextEdit) []rune {
+	converted := convert(runes, edits)
+
+	// Apply edits from the end of the text backward, so that
+	// offsets stay valid.  Edits at the same offset must end up in
+	// the order the server sent them, so those are also reversed.
+	for i, j := 0, len(converted)-1; i < j; i, j = i+1, j-1 {
+		converted[i], converted[j] = converted[j], converted[i]
+	}
+	sort.SliceStable(converted, func(i, j int) bool {
+		return converted[i].At > converted[j].At
+	})
+	result := append([]rune(nil), runes...)
+	for _, e := range converted {
+		end := e.At + len(e.Old)
+		result = append(result[:e.At], append(append([]rune(nil), e.New...), result[end:]...)...)
+	}
+	return result
+}
@@ -0,0 +1,193 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package langserver
+
+import (
+	"context"
+	"log"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/gxui/themes/basic"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/lsp"
+	"github.com/nelsam/vidar/plugin/gocode"
+	"github.com/nelsam/vidar/setting"
+)
+
+// Hook binds language server commands to files that have a
+// language server available.
+type Hook struct {
+	Theme   *basic.Theme
+	Driver  gxui.Driver
+	Manager *Manager
+}
+
+func (h Hook) Name() string {
+	return "langserver-hook"
+}
+
+func (h Hook) OpName() string {
+	return "focus-location"
+}
+
+func (h Hook) FileBindables(path string) []bind.Bindable {
+	if !Handles(path) {
+		return nil
+	}
+	s, _ := setting.LanguageServerFor(path)
+	completions, updates := gocode.NewWithSuggester(h.Theme, h.Driver, suggester{manager: h.Manager})
+	return []bind.Bindable{
+		&Sync{manager: h.Manager, languageID: s.LanguageID},
+		NewDefinition(h.Theme, h.Manager),
+		NewFormat(h.Theme, h.Manager),
+		OnSave{manager: h.Manager, languageID: s.LanguageID},
+		AfterSave{manager: h.Manager},
+		completions,
+		updates,
+	}
+}
+
+// Launcher starts language servers as soon as a file that needs
+// them is focused, so that they are ready by the time they are
+// needed.
+type Launcher struct {
+	Manager *Manager
+}
+
+func (l Launcher) Name() string {
+	return "langserver-launcher"
+}
+
+func (l Launcher) OpName() string {
+	return "focus-location"
+}
+
+func (l Launcher) FileChanged(oldPath, newPath string) {
+	if !Handles(newPath) {
+		return
+	}
+	l.Manager.Go(newPath, func(*lsp.Client) error { return nil })
+}
+
+// Closer tells language servers when files are closed.
+type Closer struct {
+	Manager *Manager
+}
+
+func (c Closer) Name() string {
+	return "langserver-did-close"
+}
+
+func (c Closer) OpName() string {
+	return "close-current-tab"
+}
+
+func (c Closer) FileClosed(path string) {
+	if !Handles(path) {
+		return
+	}
+	c.Manager.Close(path)
+}
+
+// Shutdown shuts language servers down before vidar quits.
+type Shutdown struct {
+	Manager *Manager
+}
+
+func (s Shutdown) Name() string {
+	return "langserver-shutdown"
+}
+
+func (s Shutdown) OpName() string {
+	return "quit"
+}
+
+func (s Shutdown) BeforeQuit() {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	if err := s.Manager.Shutdown(ctx); err != nil {
+		log.Printf("langserver: %s", err)
+	}
+}
+
+// Sync keeps a language server's copy of a document in sync with
+// the editor.
+type Sync struct {
+	manager    *Manager
+	languageID string
+}
+
+func (s *Sync) Name() string {
+	return "langserver-sync"
+}
+
+func (s *Sync) OpName() string {
+	return "input-handler"
+}
+
+func (s *Sync) Init(e text.Editor, contents []rune) {
+	path := e.Filepath()
+	contents = append([]rune(nil), contents...)
+	s.manager.Go(path, func(c *lsp.Client) error {
+		return c.Open(path, s.languageID, contents)
+	})
+}
+
+func (s *Sync) TextChanged(e text.Editor, edit text.Edit) {
+	path := e.Filepath()
+	s.manager.Go(path, func(c *lsp.Client) error {
+		return c.Change(path, edit.At, edit.Old, edit.New)
+	})
+}
+
+func (s *Sync) Apply(text.Editor) error {
+	return nil
+}
+
+// OnSave formats files before they are saved.
+type OnSave struct {
+	manager    *Manager
+	languageID string
+}
+
+func (o OnSave) Name() string {
+	return "langserver-format-on-save"
+}
+
+func (o OnSave) OpName() string {
+	return "save-current-file"
+}
+
+func (o OnSave) BeforeSave(proj setting.Project, path, contents string) (newContents string, err error) {
+	runes := []rune(contents)
+	edits, err := o.manager.format(path, func(c *lsp.Client) error {
+		return c.Open(path, o.languageID, runes)
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(apply(runes, edits)), nil
+}
+
+// AfterSave tells language servers when files have been saved.
+type AfterSave struct {
+	manager *Manager
+}
+
+func (a AfterSave) Name() string {
+	return "langserver-did-save"
+}
+
+func (a AfterSave) OpName() string {
+	return "save-current-file"
+}
+
+func (a AfterSave) AfterSave(proj setting.Project, path, contents string) error {
+	a.manager.Go(path, func(c *lsp.Client) error {
+		return c.Save(path)
+	})
+	return nil
+}
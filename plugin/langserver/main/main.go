@@ -0,0 +1,28 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package main
+
+import (
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/gxui/themes/basic"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/plugin/command"
+	"github.com/nelsam/vidar/plugin/langserver"
+)
+
+// Bindables is the main entry point to the command.
+func Bindables(cmdr command.Commander, driver gxui.Driver, theme gxui.Theme) []bind.Bindable {
+	m := langserver.NewManager()
+	return []bind.Bindable{
+		langserver.Hook{
+			Theme:   theme.(*basic.Theme),
+			Driver:  driver,
+			Manager: m,
+		},
+		langserver.Launcher{Manager: m},
+		langserver.Closer{Manager: m},
+		langserver.Shutdown{Manager: m},
+	}
+}
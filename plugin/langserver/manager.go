@@ -0,0 +1,369 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package langserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nelsam/vidar/diagnostic"
+	"github.com/nelsam/vidar/lsp"
+	"github.com/nelsam/vidar/setting"
+)
+
+const (
+	startTimeout   = 30 * time.Second
+	requestTimeout = 5 * time.Second
+
+	// minRetryDelay and maxRetryDelay bound how long the Manager
+	// waits after a language server fails to start before trying
+	// to start it again.
+	minRetryDelay = 5 * time.Second
+	maxRetryDelay = 5 * time.Minute
+
+	// diagnosticSource is the name that language servers'
+	// diagnostics are stored under in the diagnostic store.
+	diagnosticSource = "langserver"
+)
+
+// Handles returns whether or not there is a language server
+// configured and installed for path.
+func Handles(path string) bool {
+	s, ok := setting.LanguageServerFor(path)
+	if !ok {
+		return false
+	}
+	_, err := exec.LookPath(s.Command)
+	return err == nil
+}
+
+type clientKey struct {
+	command, root string
+}
+
+type op struct {
+	fn   func(*lsp.Client) error
+	done chan<- error
+}
+
+// failure records a language server that could not be started, so
+// that it isn't started again until after retry.
+type failure struct {
+	err   error
+	delay time.Duration
+	retry time.Time
+}
+
+var errStopped = errors.New("langserver: operations for the file have stopped")
+
+// Manager starts language servers as they are needed and runs
+// operations against them.  Operations for a single file are run
+// in the order they are queued, so that requests always see the
+// document as it was when they were made.
+type Manager struct {
+	startMu  sync.Mutex
+	mu       sync.Mutex
+	clients  map[clientKey]*lsp.Client
+	failures map[clientKey]failure
+	queues   map[string]*queue
+}
+
+// NewManager returns a new *Manager.
+func NewManager() *Manager {
+	return &Manager{
+		clients:  make(map[clientKey]*lsp.Client),
+		failures: make(map[clientKey]failure),
+		queues:   make(map[string]*queue),
+	}
+}
+
+// Go queues fn to be run against the language server for path,
+// without waiting for it to run.
+func (m *Manager) Go(path string, fn func(*lsp.Client) error) {
+	m.queue(path).push(op{fn: fn})
+}
+
+// Do queues fn to be run against the language server for path
+// and waits for it to finish.
+func (m *Manager) Do(ctx context.Context, path string, fn func(*lsp.Client) error) error {
+	done := make(chan error, 1)
+	m.queue(path).push(op{fn: fn, done: done})
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-done:
+		return err
+	}
+}
+
+func (m *Manager) queue(path string) *queue {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	q, ok := m.queues[path]
+	if !ok {
+		q = newQueue()
+		m.queues[path] = q
+		go m.run(path, q)
+	}
+	return q
+}
+
+// Close tells the language server for path that it has been closed,
+// once the operations that are already queued for path have run,
+// then stops running operations for path.
+func (m *Manager) Close(path string) {
+	m.mu.Lock()
+	q, ok := m.queues[path]
+	delete(m.queues, path)
+	m.mu.Unlock()
+	if !ok {
+		return
+	}
+	q.push(op{fn: func(c *lsp.Client) error {
+		return c.Close(path)
+	}})
+	q.close()
+}
+
+func (m *Manager) run(path string, q *queue) {
+	for {
+		o, ok := q.pop()
+		if !ok {
+			return
+		}
+		c, err := m.client(path)
+		if err == nil {
+			err = o.fn(c)
+		}
+		if o.done != nil {
+			o.done <- err
+			continue
+		}
+		if err != nil {
+			log.Printf("langserver: %s: %s", path, err)
+		}
+	}
+}
+
+// Shutdown drops any operations that are still queued, then shuts
+// down every language server that m has started.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.startMu.Lock()
+	defer m.startMu.Unlock()
+
+	m.mu.Lock()
+	clients, queues := m.clients, m.queues
+	m.clients = make(map[clientKey]*lsp.Client)
+	m.queues = make(map[string]*queue)
+	m.mu.Unlock()
+
+	for _, q := range queues {
+		q.stop()
+	}
+
+	var errs []string
+	for k, c := range clients {
+		select {
+		case <-c.Done():
+			// The server has already exited.
+			continue
+		default:
+		}
+		if err := c.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", k.command, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("could not shut down language servers: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (m *Manager) client(path string) (*lsp.Client, error) {
+	s, ok := setting.LanguageServerFor(path)
+	if !ok {
+		return nil, fmt.Errorf("no language server is configured for %s", path)
+	}
+	k := clientKey{command: s.Command, root: s.Root(path)}
+
+	m.startMu.Lock()
+	defer m.startMu.Unlock()
+
+	m.mu.Lock()
+	old, ok := m.clients[k]
+	f, failed := m.failures[k]
+	m.mu.Unlock()
+	if ok {
+		select {
+		case <-old.Done():
+		default:
+			return old, nil
+		}
+	}
+	if failed && time.Now().Before(f.retry) {
+		return nil, f.err
+	}
+	if ok {
+		log.Printf("langserver: %s exited; restarting", s.Command)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), startTimeout)
+	defer cancel()
+	c, err := lsp.Start(ctx, k.root, environ(path), s.Command, s.Args...)
+	if err != nil {
+		f.delay *= 2
+		if f.delay < minRetryDelay {
+			f.delay = minRetryDelay
+		}
+		if f.delay > maxRetryDelay {
+			f.delay = maxRetryDelay
+		}
+		f.err, f.retry = err, time.Now().Add(f.delay)
+		m.mu.Lock()
+		m.failures[k] = f
+		m.mu.Unlock()
+		return nil, err
+	}
+	c.OnDiagnostics(publish)
+	if ok {
+		// The new server doesn't know about the documents that
+		// were open in the one that exited.
+		for _, d := range old.Documents() {
+			if err := c.Open(d.Path, d.LanguageID, d.Text); err != nil {
+				log.Printf("langserver: could not re-open %s: %s", d.Path, err)
+			}
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.failures, k)
+	m.clients[k] = c
+	return c, nil
+}
+
+// publish converts diagnostics from a language server and pushes
+// them to the default diagnostic store.
+func publish(path string, text []rune, diags []lsp.Diagnostic) {
+	if text == nil && len(diags) > 0 {
+		// Servers may publish diagnostics for files that are not
+		// open, so we need to read the text from disk.
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Printf("langserver: could not read %s for diagnostics: %s", path, err)
+			return
+		}
+		text = []rune(string(b))
+	}
+	converted := make([]diagnostic.Diagnostic, 0, len(diags))
+	for _, d := range diags {
+		severity := diagnostic.Severity(d.Severity)
+		if severity == 0 {
+			severity = diagnostic.Error
+		}
+		converted = append(converted, diagnostic.Diagnostic{
+			Start:    lsp.Offset(text, d.Range.Start),
+			End:      lsp.Offset(text, d.Range.End),
+			Severity: severity,
+			Source:   d.Source,
+			Message:  d.Message,
+		})
+	}
+	diagnostic.Default.Set(path, diagnosticSource, converted)
+}
+
+// environ returns the environment of the project that path is in,
+// or the current process's environment if path is not part of a
+// project.
+func environ(path string) []string {
+	proj, ok := setting.ProjectFor(path)
+	if !ok {
+		return os.Environ()
+	}
+	return proj.Environ()
+}
+
+// queue is an unbounded FIFO queue of ops, so that queueing an op
+// never blocks the UI goroutine while a server is starting.
+type queue struct {
+	mu     sync.Mutex
+	ready  chan struct{}
+	ops    []op
+	closed bool
+}
+
+func newQueue() *queue {
+	return &queue{ready: make(chan struct{}, 1)}
+}
+
+func (q *queue) push(o op) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		if o.done != nil {
+			o.done <- errStopped
+		}
+		return
+	}
+	q.ops = append(q.ops, o)
+	q.signal()
+}
+
+// close stops q from accepting ops.  Ops that are already queued
+// are still returned by pop.
+func (q *queue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.signal()
+}
+
+// stop closes q and drops any ops that are queued.
+func (q *queue) stop() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, o := range q.ops {
+		if o.done != nil {
+			o.done <- errStopped
+		}
+	}
+	q.ops = nil
+	q.closed = true
+	q.signal()
+}
+
+func (q *queue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// pop returns the next op in q, waiting for one to be pushed if q is
+// empty.  It returns false once q is closed and empty.
+func (q *queue) pop() (op, bool) {
+	for {
+		q.mu.Lock()
+		if len(q.ops) > 0 {
+			o := q.ops[0]
+			q.ops = q.ops[1:]
+			q.mu.Unlock()
+			return o, true
+		}
+		closed := q.closed
+		q.mu.Unlock()
+		if closed {
+			return op{}, false
+		}
+		<-q.ready
+	}
+}
@@ -11,10 +11,19 @@ import (
 	"github.com/nelsam/gxui/themes/basic"
 	"github.com/nelsam/vidar/commander"
 	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/diagnostic"
+	"github.com/nelsam/vidar/plugin/diagnostics"
+	"github.com/nelsam/vidar/plugin/langserver"
 )
 
 func Bindables(cmdr *commander.Commander, driver gxui.Driver, theme *basic.Theme) []bind.Bindable {
-	return []bind.Bindable{
+	m := langserver.NewManager()
+	b := []bind.Bindable{
 		GolangHook{Theme: theme, Driver: driver},
+		langserver.Hook{Theme: theme, Driver: driver, Manager: m},
+		langserver.Launcher{Manager: m},
+		langserver.Closer{Manager: m},
+		langserver.Shutdown{Manager: m},
 	}
+	return append(b, diagnostics.Bindables(driver, theme, diagnostic.Default)...)
 }
@@ -0,0 +1,14 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package vi_test
+
+import "github.com/poy/onpar/matchers"
+
+// matcher aliases to avoid dot-importing matchers.
+var (
+	equal   = matchers.Equal
+	beTrue  = matchers.BeTrue
+	beFalse = matchers.BeFalse
+)
@@ -0,0 +1,13 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+// Package vi contains a modal, vi-style text.Handler.  It supports
+// normal, insert, and visual modes, the d, c, and y operators with
+// motions and text objects, counts, registers, and repeating changes
+// with the . command.
+//
+// Keys typed in insert mode are passed along to vidar's default
+// input.Handler, so hooks written for the default handler continue
+// to work.
+package vi
@@ -0,0 +1,180 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package vi
+
+import (
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/command/input"
+	"github.com/nelsam/vidar/commander/bind"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/editor"
+	"github.com/nelsam/vidar/plugin/command"
+)
+
+// Handler is a modal text.Handler.  It wraps vidar's default
+// input.Handler, passing keys to it in insert mode, so that all of
+// the hooks that bind to the default handler continue to work.
+//
+// Since it uses the same name as the default handler, it replaces
+// the default handler when the plugin is loaded.
+type Handler struct {
+	*input.Handler
+
+	cmdr    command.Commander
+	machine *Machine
+
+	// cancellers and confirmers are kept alongside the ones in
+	// input.Handler, so that keys they consume (e.g. Esc and Enter
+	// in a completion list) don't leave insert mode or get recorded
+	// as typed text.
+	cancellers []input.Canceler
+	confirmers []input.Confirmer
+}
+
+// New returns a Handler in normal mode.
+func New(driver gxui.Driver, cmdr command.Commander) *Handler {
+	return &Handler{
+		Handler: input.New(driver, cmdr),
+		cmdr:    cmdr,
+		machine: NewMachine(),
+	}
+}
+
+func (h *Handler) New() text.Handler {
+	return &Handler{
+		Handler: h.Handler.New().(*input.Handler),
+		cmdr:    h.cmdr,
+		machine: h.machine,
+	}
+}
+
+func (h *Handler) Bind(b bind.Bindable) (text.Handler, error) {
+	bound, err := h.Handler.Bind(b)
+	if err != nil {
+		return nil, err
+	}
+	newH := &Handler{
+		Handler:    bound.(*input.Handler),
+		cmdr:       h.cmdr,
+		machine:    h.machine,
+		cancellers: append([]input.Canceler(nil), h.cancellers...),
+		confirmers: append([]input.Confirmer(nil), h.confirmers...),
+	}
+	if c, ok := b.(input.Canceler); ok {
+		newH.cancellers = append(newH.cancellers, c)
+	}
+	if c, ok := b.(input.Confirmer); ok {
+		newH.confirmers = append(newH.confirmers, c)
+	}
+	return newH, nil
+}
+
+// Mode returns the mode that h is in.
+func (h *Handler) Mode() Mode {
+	return h.machine.Mode()
+}
+
+func (h *Handler) HandleEvent(focused text.Editor, ev gxui.KeyboardEvent) {
+	if ev.Modifier&^gxui.ModShift != 0 || !editable(focused) {
+		return
+	}
+	var key rune
+	switch ev.Key {
+	case gxui.KeyEscape:
+		key = Esc
+	case gxui.KeyEnter:
+		key = Enter
+	case gxui.KeyBackspace:
+		key = Backspace
+	case gxui.KeyDelete:
+		key = Delete
+	default:
+		return
+	}
+	if h.machine.Mode() != Insert {
+		if key == Delete {
+			key = 'x'
+		}
+		h.machine.Feed(h.buffer(focused), key)
+		return
+	}
+	switch key {
+	case Esc:
+		for _, c := range h.cancellers {
+			if c.Cancel(focused) {
+				return
+			}
+		}
+	case Enter:
+		for _, c := range h.confirmers {
+			if c.Confirm(focused) {
+				return
+			}
+		}
+	}
+	h.Handler.HandleEvent(focused, ev)
+	h.machine.Feed(h.buffer(focused), key)
+}
+
+func (h *Handler) HandleInput(focused text.Editor, ev gxui.KeyStrokeEvent) {
+	if ev.Modifier&^gxui.ModShift != 0 || !editable(focused) {
+		return
+	}
+	if h.machine.Mode() == Insert {
+		h.Handler.HandleInput(focused, ev)
+	}
+	h.machine.Feed(h.buffer(focused), ev.Character)
+}
+
+// editable returns whether e is an editor that h can edit.  Views
+// that aren't backed by a CodeEditor (e.g. diff views) are left alone.
+func editable(e text.Editor) bool {
+	_, ok := e.(*editor.CodeEditor)
+	return ok
+}
+
+func (h *Handler) buffer(e text.Editor) Buffer {
+	return &editorBuffer{handler: h, editor: e.(*editor.CodeEditor)}
+}
+
+// editorBuffer is a Buffer that edits an editor.CodeEditor.
+type editorBuffer struct {
+	handler *Handler
+	editor  *editor.CodeEditor
+}
+
+func (b *editorBuffer) Runes() []rune {
+	return b.editor.Controller().TextRunes()
+}
+
+func (b *editorBuffer) Caret() int {
+	return b.editor.Controller().LastCaret()
+}
+
+func (b *editorBuffer) SetCaret(caret int) {
+	b.editor.Controller().SetCaret(caret)
+	b.editor.ScrollToRune(caret)
+}
+
+func (b *editorBuffer) Select(start, end int, caretAtStart bool) {
+	b.editor.Controller().SetSelection(gxui.CreateTextSelection(start, end, caretAtStart))
+	if caretAtStart {
+		b.editor.ScrollToRune(start)
+		return
+	}
+	b.editor.ScrollToRune(end)
+}
+
+func (b *editorBuffer) Apply(edits ...text.Edit) {
+	b.handler.Apply(b.editor, edits...)
+}
+
+func (b *editorBuffer) Undo() {
+	undo := b.handler.cmdr.Bindable("undo-last-edit")
+	if undo == nil {
+		return
+	}
+	b.handler.cmdr.Execute(undo)
+}
@@ -0,0 +1,76 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package vi_test
+
+import (
+	"sort"
+
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/plugin/vi"
+)
+
+type buffer struct {
+	text       []rune
+	caret      int
+	start, end int
+	undos      int
+}
+
+func newBuffer(t string, caret int) *buffer {
+	return &buffer{text: []rune(t), caret: caret}
+}
+
+func (b *buffer) Runes() []rune {
+	return b.text
+}
+
+func (b *buffer) Caret() int {
+	return b.caret
+}
+
+func (b *buffer) SetCaret(c int) {
+	b.caret = c
+	b.start, b.end = c, c
+}
+
+func (b *buffer) Select(start, end int, caretAtStart bool) {
+	b.start, b.end = start, end
+	b.caret = end
+	if caretAtStart {
+		b.caret = start
+	}
+}
+
+func (b *buffer) Apply(edits ...text.Edit) {
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].At < edits[j].At
+	})
+	var (
+		t    []rune
+		last int
+	)
+	for _, e := range edits {
+		t = append(t, b.text[last:e.At]...)
+		t = append(t, e.New...)
+		last = e.At + len(e.Old)
+	}
+	b.text = append(t, b.text[last:]...)
+}
+
+func (b *buffer) Undo() {
+	b.undos++
+}
+
+// typed simulates typing keys in insert mode, which vi.Machine
+// expects the caller to do.
+func (b *buffer) typed(m *vi.Machine, keys string) {
+	for _, k := range keys {
+		if m.Mode() == vi.Insert && k != vi.Esc {
+			b.Apply(text.Edit{At: b.caret, New: []rune{k}})
+			b.caret++
+		}
+		m.Feed(b, k)
+	}
+}
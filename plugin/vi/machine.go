@@ -0,0 +1,700 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package vi
+
+import (
+	"strconv"
+	"unicode"
+
+	"github.com/nelsam/vidar/commander/text"
+)
+
+// Keys that are not printable are fed to a Machine as control
+// characters.
+const (
+	Esc       = '\x1b'
+	Enter     = '\n'
+	Backspace = '\b'
+	Delete    = '\x7f'
+)
+
+// Mode is the mode that a Machine is in.
+type Mode int
+
+const (
+	Normal Mode = iota
+	Insert
+	Visual
+	VisualLine
+)
+
+func (m Mode) String() string {
+	switch m {
+	case Insert:
+		return "INSERT"
+	case Visual:
+		return "VISUAL"
+	case VisualLine:
+		return "VISUAL LINE"
+	default:
+		return "NORMAL"
+	}
+}
+
+// Buffer is the text that a Machine edits.
+type Buffer interface {
+	Runes() []rune
+	Caret() int
+	SetCaret(int)
+
+	// Select selects the text from start to end, leaving the caret at
+	// start if caretAtStart is true.
+	Select(start, end int, caretAtStart bool)
+
+	// Apply applies edits to the text.  As with text.Handler, the
+	// positions of all edits are relative to the text before any of
+	// them are applied.
+	Apply(edits ...text.Edit)
+
+	// Undo undoes the most recent edit.
+	Undo()
+}
+
+// Machine is the state machine behind vi's modes.  It is fed keys
+// one at a time, parsing them into commands and running them against
+// a Buffer.
+//
+// A Machine doesn't know anything about gxui, so that it is easy to
+// test; see Handler for the text.Handler that uses it.
+type Machine struct {
+	mode    Mode
+	pending []rune
+	regs    registers
+
+	// anchor and cursor are the ends of the selection in visual
+	// mode.  Both are inclusive.
+	anchor, cursor int
+
+	// change holds the keys of the change that is being made, while
+	// last holds the keys of the most recent complete change, for the
+	// . command.
+	recording bool
+	change    []rune
+	last      []rune
+	replaying bool
+
+	// inserted holds the keys typed since entering insert mode, so
+	// that they can be repeated for commands with a count.
+	inserted     []rune
+	insertCount  int
+	insertPrefix []rune
+}
+
+// NewMachine returns a Machine in normal mode.
+func NewMachine() *Machine {
+	return &Machine{regs: make(registers)}
+}
+
+// Mode returns the mode that m is in.
+func (m *Machine) Mode() Mode {
+	return m.mode
+}
+
+// Register returns the text held by the named register.
+func (m *Machine) Register(name rune) []rune {
+	return m.regs.load(name).text
+}
+
+// Feed processes a single key.  In insert mode, keys other than Esc
+// are only recorded, since the caller is expected to insert them
+// itself.
+func (m *Machine) Feed(b Buffer, key rune) {
+	if m.mode == Insert {
+		m.insert(b, key)
+		return
+	}
+	if key == Esc {
+		m.pending = nil
+		if m.mode != Normal {
+			m.leaveVisual(b, m.cursor)
+		}
+		return
+	}
+	m.pending = append(m.pending, key)
+	visual := m.mode == Visual || m.mode == VisualLine
+	c, err := parse(m.pending, visual)
+	if err == errIncomplete {
+		return
+	}
+	m.pending = nil
+	if err != nil {
+		return
+	}
+	if visual {
+		m.visual(b, c)
+		return
+	}
+	m.normal(b, c)
+}
+
+func (m *Machine) insert(b Buffer, key rune) {
+	if key == Esc {
+		m.finishInsert(b)
+		return
+	}
+	if m.recording {
+		m.change = append(m.change, key)
+	}
+	m.inserted = append(m.inserted, key)
+}
+
+func (m *Machine) startInsert(count int, prefix ...rune) {
+	m.mode = Insert
+	m.inserted = nil
+	m.insertCount = count
+	m.insertPrefix = prefix
+}
+
+func (m *Machine) finishInsert(b Buffer) {
+	keys := m.inserted
+	if m.replaying {
+		typeKeys(b, keys)
+	}
+	if len(keys) > 0 {
+		repeat := append(append([]rune(nil), m.insertPrefix...), keys...)
+		for i := 1; i < m.insertCount; i++ {
+			typeKeys(b, repeat)
+		}
+	}
+	m.mode = Normal
+	m.inserted = nil
+	if m.recording {
+		m.last = append(m.change, Esc)
+		m.change = nil
+		m.recording = false
+	}
+	t, caret := b.Runes(), b.Caret()
+	if caret > lineStart(t, caret) {
+		caret--
+	}
+	b.SetCaret(clamp(t, caret))
+}
+
+// typeKeys inserts keys at the caret as if they were typed in insert
+// mode.
+func typeKeys(b Buffer, keys []rune) {
+	var typed []rune
+	flush := func() {
+		if len(typed) == 0 {
+			return
+		}
+		at := b.Caret()
+		b.Apply(text.Edit{At: at, New: typed})
+		b.SetCaret(at + len(typed))
+		typed = nil
+	}
+	for _, k := range keys {
+		switch k {
+		case Backspace:
+			if len(typed) > 0 {
+				typed = typed[:len(typed)-1]
+				continue
+			}
+			at := b.Caret()
+			if at == 0 {
+				continue
+			}
+			b.Apply(text.Edit{At: at - 1, Old: b.Runes()[at-1 : at]})
+			b.SetCaret(at - 1)
+		case Delete:
+			flush()
+			at := b.Caret()
+			if t := b.Runes(); at < len(t) {
+				b.Apply(text.Edit{At: at, Old: t[at : at+1]})
+				b.SetCaret(at)
+			}
+		default:
+			typed = append(typed, k)
+		}
+	}
+	flush()
+}
+
+// repeat runs the most recent change again.  If count is non-zero,
+// it replaces the count that the change was made with.
+func (m *Machine) repeat(b Buffer, count int) {
+	keys := m.last
+	if len(keys) == 0 {
+		return
+	}
+	if count > 0 {
+		c, _ := parse(keys, false)
+		n := []rune(strconv.Itoa(count))
+		keys = append(append(append([]rune(nil), keys[:c.countAt]...), n...), keys[c.countEnd:]...)
+	}
+	m.replaying = true
+	defer func() { m.replaying = false }()
+	for _, k := range keys {
+		m.Feed(b, k)
+	}
+}
+
+func (m *Machine) normal(b Buffer, c cmd) {
+	t, caret := b.Runes(), b.Caret()
+	count := c.count
+	if count == 0 {
+		count = 1
+	}
+	if c.op == 'd' || c.op == 'c' || changes[c.key] {
+		m.recording = true
+		m.change = c.keys
+		defer func() {
+			if m.mode != Insert && m.recording {
+				m.last = m.change
+				m.change = nil
+				m.recording = false
+			}
+		}()
+	}
+	if a, ok := aliases[c.key]; ok {
+		c.op, c.key = a[0], a[1]
+	}
+	if c.op != 0 {
+		m.operate(b, c)
+		return
+	}
+	switch c.key {
+	case 'u':
+		for i := 0; i < count; i++ {
+			b.Undo()
+		}
+		b.SetCaret(clamp(b.Runes(), b.Caret()))
+	case '.':
+		m.repeat(b, c.count)
+	case 'v', 'V':
+		m.mode = Visual
+		if c.key == 'V' {
+			m.mode = VisualLine
+		}
+		m.anchor, m.cursor = caret, caret
+		m.selectVisual(b)
+	case 'i':
+		m.startInsert(count)
+	case 'a':
+		if caret < lineEnd(t, caret) {
+			b.SetCaret(caret + 1)
+		}
+		m.startInsert(count)
+	case 'I':
+		b.SetCaret(nonBlank(t, lineStart(t, caret)))
+		m.startInsert(count)
+	case 'A':
+		b.SetCaret(lineEnd(t, caret))
+		m.startInsert(count)
+	case 'o', 'O':
+		start := lineStart(t, caret)
+		indent := t[start:nonBlank(t, start)]
+		nl := append([]rune{'\n'}, indent...)
+		if c.key == 'o' {
+			end := lineEnd(t, caret)
+			b.Apply(text.Edit{At: end, New: nl})
+			b.SetCaret(end + len(nl))
+		} else {
+			b.Apply(text.Edit{At: start, New: append(append([]rune(nil), indent...), '\n')})
+			b.SetCaret(start + len(indent))
+		}
+		m.startInsert(count, nl...)
+	case 'p', 'P':
+		m.put(b, c.register, count, c.key == 'P')
+	case 'r':
+		m.replace(b, count, c.arg)
+	case 'J':
+		if count < 2 {
+			count = 2
+		}
+		m.join(b, caret, count-1)
+	default:
+		mo := c.motion()
+		n := c.n()
+		if c.key != 'G' && n == 0 {
+			n = 1
+		}
+		if tg, ok := mo(t, caret, n, false); ok {
+			b.SetCaret(clamp(t, tg.pos))
+		}
+	}
+}
+
+// operate runs the operator in c over the text that its motion or
+// text object covers.
+func (m *Machine) operate(b Buffer, c cmd) {
+	t, caret := b.Runes(), b.Caret()
+	n := c.n()
+	count := n
+	if count == 0 {
+		count = 1
+	}
+	var (
+		r  region
+		ok bool
+	)
+	switch c.key {
+	case c.op:
+		r, ok = lines(t, caret, lineAt(t, caret, count-1)), true
+	case 'i', 'a':
+		r, ok = objects[c.arg](t, caret, c.key == 'a')
+	default:
+		mo := c.motion()
+		if c.key != 'G' {
+			n = count
+		}
+		if c.op == 'c' && (c.key == 'w' || c.key == 'W') {
+			mo = changeWord(c.key)
+		}
+		var tg target
+		if tg, ok = mo(t, caret, n, true); ok {
+			if c.key == 'w' || c.key == 'W' {
+				tg = stopAtLineEnd(t, caret, tg)
+			}
+			r = toRegion(t, caret, tg)
+		}
+	}
+	if !ok || (r.start == r.end && !r.linewise) {
+		return
+	}
+	m.apply(b, c.op, c.register, r)
+}
+
+// changeWord returns the motion used by cw and cW, which act like ce
+// and cE, except that they only change one rune at the end of a word.
+func changeWord(key rune) motion {
+	cls := class(wordClass)
+	if key == 'W' {
+		cls = bigWordClass
+	}
+	end := wordEnd(cls)
+	start := wordStart(cls)
+	return func(t []rune, caret, count int, op bool) (target, bool) {
+		if caret >= len(t) || unicode.IsSpace(t[caret]) {
+			return start(t, caret, count, op)
+		}
+		if caret+1 >= len(t) || cls(t[caret+1]) != cls(t[caret]) {
+			if count == 1 {
+				return target{pos: caret, kind: inclusive}, true
+			}
+			return end(t, caret, count-1, op)
+		}
+		return end(t, caret, count, op)
+	}
+}
+
+// stopAtLineEnd keeps operators using word motions from moving past
+// the end of the line that the last word is on.
+func stopAtLineEnd(t []rune, caret int, tg target) target {
+	start := lineStart(t, tg.pos)
+	if start <= caret || tg.kind != exclusive {
+		return tg
+	}
+	if end := start - 1; end > caret {
+		tg.pos = end
+	}
+	return tg
+}
+
+func toRegion(t []rune, caret int, tg target) region {
+	start, end := caret, tg.pos
+	if end < start {
+		start, end = end, start
+	}
+	switch tg.kind {
+	case linewise:
+		return lines(t, start, end)
+	case inclusive:
+		if end < len(t) {
+			end++
+		}
+	}
+	return region{start: start, end: end}
+}
+
+// lines returns a linewise region covering the lines containing
+// start and end.
+func lines(t []rune, start, end int) region {
+	start = lineStart(t, start)
+	end = lineEnd(t, end)
+	if end < len(t) {
+		end++
+	}
+	return region{start: start, end: end, linewise: true}
+}
+
+// apply runs an operator over r.
+func (m *Machine) apply(b Buffer, op, reg rune, r region) {
+	t := b.Runes()
+	yanked := append([]rune(nil), t[r.start:r.end]...)
+	if r.linewise && (len(yanked) == 0 || yanked[len(yanked)-1] != '\n') {
+		yanked = append(yanked, '\n')
+	}
+	m.regs.store(reg, register{text: yanked, linewise: r.linewise}, op == 'y')
+	switch op {
+	case 'y':
+		if !r.linewise || r.start < lineStart(t, b.Caret()) {
+			b.SetCaret(clamp(t, nonBlankIf(t, r.start, r.linewise)))
+		}
+	case 'd':
+		start, end := r.start, r.end
+		if r.linewise && end == len(t) && start > 0 && (end == 0 || t[end-1] != '\n') {
+			// The last line has no newline to delete, so delete the
+			// newline before it instead.
+			start--
+		}
+		b.Apply(text.Edit{At: start, Old: t[start:end]})
+		t = b.Runes()
+		if r.linewise {
+			start = lineStart(t, start)
+		}
+		b.SetCaret(clamp(t, nonBlankIf(t, start, r.linewise)))
+	case 'c':
+		start, end := r.start, r.end
+		if r.linewise {
+			// Keep the indentation and newline of linewise changes,
+			// so that there is a line to insert text on.
+			start = nonBlank(t, start)
+			if end > start && t[end-1] == '\n' {
+				end--
+			}
+		}
+		b.Apply(text.Edit{At: start, Old: t[start:end]})
+		b.SetCaret(start)
+		m.startInsert(1)
+	}
+}
+
+func nonBlankIf(t []rune, pos int, cond bool) int {
+	if !cond {
+		return pos
+	}
+	return nonBlank(t, pos)
+}
+
+// put pastes the contents of a register count times, after the caret
+// or, if before is true, at the caret.
+func (m *Machine) put(b Buffer, name rune, count int, before bool) {
+	reg := m.regs.load(name)
+	if len(reg.text) == 0 {
+		return
+	}
+	var paste []rune
+	for i := 0; i < count; i++ {
+		paste = append(paste, reg.text...)
+	}
+	t, caret := b.Runes(), b.Caret()
+	if !reg.linewise {
+		at := caret
+		if !before && at < lineEnd(t, at) {
+			at++
+		}
+		b.Apply(text.Edit{At: at, New: paste})
+		b.SetCaret(clamp(b.Runes(), at+len(paste)-1))
+		return
+	}
+	at := lineStart(t, caret)
+	start := at
+	if !before {
+		at = lineEnd(t, caret)
+		start = at + 1
+		if at < len(t) {
+			at++
+		} else {
+			// The last line has no newline to paste after.
+			paste = append([]rune{'\n'}, paste[:len(paste)-1]...)
+		}
+	}
+	b.Apply(text.Edit{At: at, New: paste})
+	t = b.Runes()
+	b.SetCaret(clamp(t, nonBlank(t, start)))
+}
+
+// replace replaces count runes at the caret with r.
+func (m *Machine) replace(b Buffer, count int, r rune) {
+	t, caret := b.Runes(), b.Caret()
+	if r == Esc || caret+count > lineEnd(t, caret) {
+		return
+	}
+	if r == Enter {
+		b.Apply(text.Edit{At: caret, Old: t[caret : caret+count], New: []rune{'\n'}})
+		b.SetCaret(caret + 1)
+		return
+	}
+	n := make([]rune, count)
+	for i := range n {
+		n[i] = r
+	}
+	b.Apply(text.Edit{At: caret, Old: t[caret : caret+count], New: n})
+	b.SetCaret(caret + count - 1)
+}
+
+// join joins the line containing pos with the n lines following it.
+func (m *Machine) join(b Buffer, pos, n int) {
+	t := b.Runes()
+	var (
+		edits []text.Edit
+		delta int
+		caret = -1
+	)
+	end := lineEnd(t, pos)
+	for i := 0; i < n && end < len(t); i++ {
+		next := nonBlank(t, end+1)
+		sep := []rune{' '}
+		if next == len(t) || t[next] == '\n' || t[next] == ')' || (end > 0 && t[end-1] == ' ') {
+			sep = nil
+		}
+		edits = append(edits, text.Edit{At: end, Old: t[end:next], New: sep})
+		caret = end + delta
+		delta += len(sep) - (next - end)
+		end = lineEnd(t, next)
+	}
+	if len(edits) == 0 {
+		return
+	}
+	b.Apply(edits...)
+	b.SetCaret(clamp(b.Runes(), caret))
+}
+
+func (m *Machine) visualRegion(t []rune) region {
+	start, end := m.anchor, m.cursor
+	if end < start {
+		start, end = end, start
+	}
+	if m.mode == VisualLine {
+		return lines(t, start, end)
+	}
+	end++
+	if end > len(t) {
+		end = len(t)
+	}
+	return region{start: start, end: end}
+}
+
+func (m *Machine) selectVisual(b Buffer) {
+	r := m.visualRegion(b.Runes())
+	b.Select(r.start, r.end, m.cursor < m.anchor)
+}
+
+func (m *Machine) leaveVisual(b Buffer, caret int) {
+	m.mode = Normal
+	b.SetCaret(clamp(b.Runes(), caret))
+}
+
+func (m *Machine) visual(b Buffer, c cmd) {
+	t := b.Runes()
+	r := m.visualRegion(t)
+	whole := r
+	if !r.linewise {
+		// An empty selection (e.g. in an empty buffer) has no last
+		// rune, so its lines are the lines around its start.
+		last := r.end - 1
+		if last < r.start {
+			last = r.start
+		}
+		whole = lines(t, r.start, last)
+	}
+	switch c.key {
+	case 'i', 'a':
+		obj, ok := objects[c.arg](t, m.cursor, c.key == 'a')
+		if !ok || obj.start == obj.end {
+			return
+		}
+		m.anchor, m.cursor = obj.start, obj.end-1
+		m.selectVisual(b)
+	case 'o':
+		m.anchor, m.cursor = m.cursor, m.anchor
+		m.selectVisual(b)
+	case 'v', 'V':
+		mode := Visual
+		if c.key == 'V' {
+			mode = VisualLine
+		}
+		if m.mode == mode {
+			m.leaveVisual(b, m.cursor)
+			return
+		}
+		m.mode = mode
+		m.selectVisual(b)
+	case 'd', 'x':
+		m.leaveVisual(b, r.start)
+		m.apply(b, 'd', c.register, r)
+	case 'X', 'D':
+		m.leaveVisual(b, r.start)
+		m.apply(b, 'd', c.register, whole)
+	case 'c', 's':
+		m.leaveVisual(b, r.start)
+		m.apply(b, 'c', c.register, r)
+	case 'C', 'S', 'R':
+		m.leaveVisual(b, r.start)
+		m.apply(b, 'c', c.register, whole)
+	case 'y':
+		m.leaveVisual(b, r.start)
+		m.apply(b, 'y', c.register, r)
+	case 'Y':
+		m.leaveVisual(b, r.start)
+		m.apply(b, 'y', c.register, whole)
+	case 'p', 'P':
+		reg := m.regs.load(c.register)
+		m.leaveVisual(b, r.start)
+		m.apply(b, 'd', 0, r)
+		if len(reg.text) == 0 {
+			return
+		}
+		at, paste := r.start, reg.text
+		if n := len(b.Runes()); at > n {
+			// Deleting the last line also deleted the newline before
+			// it, so the text goes on a new line at the end instead.
+			at = n
+			if reg.linewise {
+				paste = append([]rune{'\n'}, paste[:len(paste)-1]...)
+			}
+		}
+		b.Apply(text.Edit{At: at, New: paste})
+		b.SetCaret(clamp(b.Runes(), at+len(paste)-1))
+	case 'J':
+		m.leaveVisual(b, r.start)
+		n := 0
+		for i := r.start; i < r.end-1; i++ {
+			if t[i] == '\n' {
+				n++
+			}
+		}
+		if n == 0 {
+			n = 1
+		}
+		m.join(b, r.start, n)
+	case 'r':
+		m.leaveVisual(b, r.start)
+		if c.arg == Esc {
+			return
+		}
+		n := append([]rune(nil), t[r.start:r.end]...)
+		for i := range n {
+			if n[i] != '\n' {
+				n[i] = c.arg
+			}
+		}
+		b.Apply(text.Edit{At: r.start, Old: t[r.start:r.end], New: n})
+		b.SetCaret(r.start)
+	default:
+		mo := c.motion()
+		n := c.n()
+		if c.key != 'G' && n == 0 {
+			n = 1
+		}
+		tg, ok := mo(t, m.cursor, n, false)
+		if !ok {
+			return
+		}
+		m.cursor = clamp(t, tg.pos)
+		m.selectVisual(b)
+	}
+}
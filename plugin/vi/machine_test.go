@@ -0,0 +1,324 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package vi_test
+
+import (
+	"testing"
+
+	"github.com/nelsam/vidar/plugin/vi"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+func TestMachine(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	o.BeforeEach(func(t *testing.T) (expect.Expectation, *vi.Machine) {
+		return expect.New(t), vi.NewMachine()
+	})
+
+	o.Group("motions", func() {
+		for _, test := range []struct {
+			name, text, keys string
+			caret, want      int
+		}{
+			{name: "w", text: "foo.bar baz", keys: "w", want: 3},
+			{name: "W", text: "foo.bar baz", keys: "W", want: 8},
+			{name: "count w", text: "foo.bar baz", keys: "3w", want: 8},
+			{name: "e", text: "foo bar", keys: "e", want: 2},
+			{name: "b", text: "foo bar", keys: "b", caret: 5, want: 4},
+			{name: "$", text: "foo bar\nbaz", keys: "$", want: 6},
+			{name: "0", text: "foo bar", keys: "0", caret: 5, want: 0},
+			{name: "^", text: "\t foo", keys: "^", want: 2},
+			{name: "j", text: "foo bar\nbaz\n", keys: "j", caret: 5, want: 10},
+			{name: "k", text: "foo\nbar", keys: "k", caret: 5, want: 1},
+			{name: "gg", text: "foo\nbar\nbaz", keys: "gg", caret: 9, want: 0},
+			{name: "G", text: "foo\nbar\n  baz", keys: "G", want: 10},
+			{name: "count G", text: "foo\nbar\nbaz", keys: "2G", want: 4},
+			{name: "f", text: "foo(bar, baz)", keys: "f,", want: 7},
+			{name: "count f", text: "a.b.c.d", keys: "3f.", want: 5},
+			{name: "t", text: "foo(bar, baz)", keys: "t,", want: 6},
+			{name: "F", text: "foo(bar, baz)", keys: "F(", caret: 10, want: 3},
+			{name: "T", text: "foo(bar, baz)", keys: "T(", caret: 10, want: 4},
+		} {
+			test := test
+			o.Spec(test.name, func(expect expect.Expectation, m *vi.Machine) {
+				b := newBuffer(test.text, test.caret)
+				b.typed(m, test.keys)
+				expect(b.caret).To(equal(test.want))
+				expect(string(b.text)).To(equal(test.text))
+			})
+		}
+	})
+
+	o.Group("operators", func() {
+		for _, test := range []struct {
+			name, text, keys string
+			caret            int
+			want             string
+			wantCaret        int
+		}{
+			{name: "dw", text: "foo bar baz", keys: "dw", want: "bar baz"},
+			{name: "dw at the end of a line", text: "foo bar\n  baz", keys: "dw", caret: 4, want: "foo \n  baz", wantCaret: 3},
+			{name: "d2w", text: "foo bar baz", keys: "d2w", want: "baz"},
+			{name: "2dw", text: "foo bar baz", keys: "2dw", want: "baz"},
+			{name: "de", text: "foo bar", keys: "de", want: " bar"},
+			{name: "db", text: "foo bar", keys: "db", caret: 4, want: "bar"},
+			{name: "d$", text: "foo bar\nbaz", keys: "d$", caret: 3, want: "foo\nbaz", wantCaret: 2},
+			{name: "D", text: "foo bar\nbaz", keys: "D", caret: 3, want: "foo\nbaz", wantCaret: 2},
+			{name: "d0", text: "foo bar", keys: "d0", caret: 4, want: "bar"},
+			{name: "dd", text: "foo\n  bar\nbaz", keys: "dd", want: "  bar\nbaz", wantCaret: 2},
+			{name: "dd on the last line", text: "foo\nbar", keys: "dd", caret: 5, want: "foo"},
+			{name: "2dd", text: "foo\nbar\nbaz", keys: "2dd", want: "baz"},
+			{name: "dj", text: "foo\nbar\nbaz", keys: "dj", want: "baz"},
+			{name: "dG", text: "foo\nbar\nbaz", keys: "dG", caret: 4, want: "foo"},
+			{name: "dgg", text: "foo\nbar\nbaz", keys: "dgg", caret: 4, want: "baz"},
+			{name: "df", text: "foo(bar, baz)", keys: "df,", want: " baz)"},
+			{name: "dt", text: "foo(bar, baz)", keys: "dt,", want: ", baz)"},
+			{name: "x", text: "foo", keys: "x", want: "oo"},
+			{name: "3x", text: "foo bar", keys: "3x", want: " bar"},
+			{name: "X", text: "foo", keys: "X", caret: 2, want: "fo", wantCaret: 1},
+			{name: "diw", text: "foo bar baz", keys: "diw", caret: 5, want: "foo  baz", wantCaret: 4},
+			{name: "daw", text: "foo bar baz", keys: "daw", caret: 5, want: "foo baz", wantCaret: 4},
+			{name: "di(", text: "foo(bar, baz)", keys: "di(", caret: 6, want: "foo()", wantCaret: 4},
+			{name: "da(", text: "foo(bar, baz)", keys: "da(", caret: 6, want: "foo", wantCaret: 2},
+			{name: "dib nested", text: "f(a(b), c)", keys: "dib", caret: 8, want: "f()", wantCaret: 2},
+			{name: "di{", text: "{\n\tfoo\n}", keys: "di{", caret: 3, want: "{}", wantCaret: 1},
+			{name: "di\"", text: `x := "foo bar"`, keys: `di"`, caret: 8, want: `x := ""`, wantCaret: 6},
+			{name: "da\"", text: `x := "foo bar"`, keys: `da"`, caret: 8, want: `x := `, wantCaret: 4},
+			{name: "J", text: "foo\n\tbar\nbaz", keys: "J", want: "foo bar\nbaz", wantCaret: 3},
+			{name: "3J", text: "foo\nbar\nbaz", keys: "3J", want: "foo bar baz", wantCaret: 7},
+			{name: "r", text: "foo", keys: "2rx", want: "xxo", wantCaret: 1},
+		} {
+			test := test
+			o.Spec(test.name, func(expect expect.Expectation, m *vi.Machine) {
+				b := newBuffer(test.text, test.caret)
+				b.typed(m, test.keys)
+				expect(string(b.text)).To(equal(test.want))
+				expect(b.caret).To(equal(test.wantCaret))
+				expect(m.Mode()).To(equal(vi.Normal))
+			})
+		}
+	})
+
+	o.Group("insert mode", func() {
+		for _, test := range []struct {
+			name, text, keys string
+			caret            int
+			want             string
+			wantCaret        int
+		}{
+			{name: "i", text: "foo", keys: "ibar\x1b", caret: 1, want: "fbaroo", wantCaret: 3},
+			{name: "a", text: "foo", keys: "abar\x1b", want: "fbaroo", wantCaret: 3},
+			{name: "I", text: "\tfoo", keys: "Ibar\x1b", caret: 3, want: "\tbarfoo", wantCaret: 3},
+			{name: "A", text: "foo\nbar", keys: "Abaz\x1b", want: "foobaz\nbar", wantCaret: 5},
+			{name: "o", text: "\tfoo\nbar", keys: "obaz\x1b", want: "\tfoo\n\tbaz\nbar", wantCaret: 8},
+			{name: "O", text: "foo\n\tbar", keys: "Obaz\x1b", caret: 5, want: "foo\n\tbaz\n\tbar", wantCaret: 7},
+			{name: "count i", text: "", keys: "3ifoo\x1b", want: "foofoofoo", wantCaret: 8},
+			{name: "cw", text: "foo bar", keys: "cwbaz\x1b", want: "baz bar", wantCaret: 2},
+			{name: "cw at the end of a word", text: "foo bar", keys: "cwx\x1b", caret: 2, want: "fox bar", wantCaret: 2},
+			{name: "cc", text: "foo\n\tbar\nbaz", keys: "ccx\x1b", caret: 5, want: "foo\n\tx\nbaz", wantCaret: 5},
+			{name: "ciw", text: "foo bar baz", keys: "ciwx\x1b", caret: 5, want: "foo x baz", wantCaret: 4},
+			{name: "ci(", text: "foo(bar)", keys: "ci(x, y\x1b", caret: 5, want: "foo(x, y)", wantCaret: 7},
+			{name: "C", text: "foo bar", keys: "Cx\x1b", caret: 4, want: "foo x", wantCaret: 4},
+			{name: "s", text: "foo", keys: "sb\x1b", want: "boo"},
+			{name: "S", text: "\tfoo\nbar", keys: "Sx\x1b", caret: 2, want: "\tx\nbar", wantCaret: 1},
+		} {
+			test := test
+			o.Spec(test.name, func(expect expect.Expectation, m *vi.Machine) {
+				b := newBuffer(test.text, test.caret)
+				b.typed(m, test.keys)
+				expect(string(b.text)).To(equal(test.want))
+				expect(b.caret).To(equal(test.wantCaret))
+				expect(m.Mode()).To(equal(vi.Normal))
+			})
+		}
+
+		o.Spec("it leaves keys for the caller to insert", func(expect expect.Expectation, m *vi.Machine) {
+			b := newBuffer("foo", 0)
+			m.Feed(b, 'i')
+			expect(m.Mode()).To(equal(vi.Insert))
+			m.Feed(b, 'x')
+			expect(string(b.text)).To(equal("foo"))
+		})
+	})
+
+	o.Group("registers", func() {
+		o.Spec("it puts yanked words", func(expect expect.Expectation, m *vi.Machine) {
+			b := newBuffer("foo bar", 0)
+			b.typed(m, "yw$p")
+			expect(string(b.text)).To(equal("foo barfoo "))
+			expect(b.caret).To(equal(10))
+		})
+
+		o.Spec("it puts yanked lines below and above", func(expect expect.Expectation, m *vi.Machine) {
+			b := newBuffer("foo\nbar", 0)
+			b.typed(m, "yyjp")
+			expect(string(b.text)).To(equal("foo\nbar\nfoo"))
+			expect(b.caret).To(equal(8))
+
+			b.typed(m, "ggP")
+			expect(string(b.text)).To(equal("foo\nfoo\nbar\nfoo"))
+			expect(b.caret).To(equal(0))
+		})
+
+		o.Spec("it puts deleted lines", func(expect expect.Expectation, m *vi.Machine) {
+			b := newBuffer("foo\nbar\nbaz", 0)
+			b.typed(m, "ddp")
+			expect(string(b.text)).To(equal("bar\nfoo\nbaz"))
+		})
+
+		o.Spec("it puts with a count", func(expect expect.Expectation, m *vi.Machine) {
+			b := newBuffer("ab", 0)
+			b.typed(m, "x2P")
+			expect(string(b.text)).To(equal("aab"))
+		})
+
+		o.Spec("it uses named registers", func(expect expect.Expectation, m *vi.Machine) {
+			b := newBuffer("foo bar baz", 0)
+			b.typed(m, `"ayiww"Ayiwwx"aP`)
+			expect(string(m.Register('a'))).To(equal("foobar"))
+			expect(string(m.Register('"'))).To(equal("b"))
+			expect(string(b.text)).To(equal("foo bar foobaraz"))
+		})
+
+		o.Spec("it keeps yanks in register 0", func(expect expect.Expectation, m *vi.Machine) {
+			b := newBuffer("foo bar baz", 0)
+			b.typed(m, `yiwwdiw"0P`)
+			expect(string(b.text)).To(equal("foo foo baz"))
+		})
+
+		o.Spec("it discards deletes to the black hole register", func(expect expect.Expectation, m *vi.Machine) {
+			b := newBuffer("foo bar baz", 0)
+			b.typed(m, `yiww"_diwP`)
+			expect(string(b.text)).To(equal("foo foo baz"))
+		})
+	})
+
+	o.Group("repeat", func() {
+		o.Spec("it repeats deletes", func(expect expect.Expectation, m *vi.Machine) {
+			b := newBuffer("foo bar baz qux", 0)
+			b.typed(m, "dw..")
+			expect(string(b.text)).To(equal("qux"))
+		})
+
+		o.Spec("it repeats inserts", func(expect expect.Expectation, m *vi.Machine) {
+			b := newBuffer("foo\nbar", 0)
+			b.typed(m, "A;\x1bj.")
+			expect(string(b.text)).To(equal("foo;\nbar;"))
+		})
+
+		o.Spec("it repeats changes", func(expect expect.Expectation, m *vi.Machine) {
+			b := newBuffer("foo bar", 0)
+			b.typed(m, "cwbaz\x1bw.")
+			expect(string(b.text)).To(equal("baz baz"))
+		})
+
+		o.Spec("it replaces the count of the repeated change", func(expect expect.Expectation, m *vi.Machine) {
+			b := newBuffer("abcdefg", 0)
+			b.typed(m, "x3.")
+			expect(string(b.text)).To(equal("efg"))
+		})
+
+		o.Spec("it does not repeat yanks or motions", func(expect expect.Expectation, m *vi.Machine) {
+			b := newBuffer("foo bar baz", 0)
+			b.typed(m, "xywl.")
+			expect(string(b.text)).To(equal("o bar baz"))
+		})
+	})
+
+	o.Group("visual mode", func() {
+		o.Spec("it selects with motions", func(expect expect.Expectation, m *vi.Machine) {
+			b := newBuffer("foo bar baz", 4)
+			b.typed(m, "ve")
+			expect(m.Mode()).To(equal(vi.Visual))
+			expect(b.start).To(equal(4))
+			expect(b.end).To(equal(7))
+		})
+
+		o.Spec("it deletes the selection", func(expect expect.Expectation, m *vi.Machine) {
+			b := newBuffer("foo bar baz", 4)
+			b.typed(m, "vwd")
+			expect(string(b.text)).To(equal("foo az"))
+			expect(m.Mode()).To(equal(vi.Normal))
+		})
+
+		o.Spec("it selects text objects", func(expect expect.Expectation, m *vi.Machine) {
+			b := newBuffer("foo(bar, baz)", 5)
+			b.typed(m, "vi(c")
+			expect(m.Mode()).To(equal(vi.Insert))
+			expect(string(b.text)).To(equal("foo()"))
+		})
+
+		o.Spec("it yanks lines", func(expect expect.Expectation, m *vi.Machine) {
+			b := newBuffer("foo\nbar\nbaz", 1)
+			b.typed(m, "Vjy")
+			expect(string(m.Register('"'))).To(equal("foo\nbar\n"))
+			b.typed(m, "Gp")
+			expect(string(b.text)).To(equal("foo\nbar\nbaz\nfoo\nbar"))
+		})
+
+		o.Spec("it pastes over the last line", func(expect expect.Expectation, m *vi.Machine) {
+			b := newBuffer("a\nb", 0)
+			b.typed(m, "yyjVp")
+			expect(string(b.text)).To(equal("a\na"))
+			expect(m.Mode()).To(equal(vi.Normal))
+		})
+
+		o.Spec("it swaps ends of the selection", func(expect expect.Expectation, m *vi.Machine) {
+			b := newBuffer("foo bar baz", 4)
+			b.typed(m, "veohd")
+			expect(string(b.text)).To(equal("foo baz"))
+		})
+
+		o.Spec("it deletes the selection in an empty buffer", func(expect expect.Expectation, m *vi.Machine) {
+			b := newBuffer("", 0)
+			b.typed(m, "vd")
+			expect(string(b.text)).To(equal(""))
+			expect(m.Mode()).To(equal(vi.Normal))
+		})
+
+		o.Spec("it deletes a selection of the only rune", func(expect expect.Expectation, m *vi.Machine) {
+			b := newBuffer("a", 0)
+			b.typed(m, "vd")
+			expect(string(b.text)).To(equal(""))
+		})
+
+		o.Spec("it deletes a selection to the end of the line", func(expect expect.Expectation, m *vi.Machine) {
+			b := newBuffer("x\n", 0)
+			b.typed(m, "v$d")
+			expect(m.Mode()).To(equal(vi.Normal))
+			expect(string(b.text)).To(equal("\n"))
+		})
+
+		o.Spec("it leaves visual mode on Esc", func(expect expect.Expectation, m *vi.Machine) {
+			b := newBuffer("foo bar", 0)
+			b.typed(m, "vw\x1b")
+			expect(m.Mode()).To(equal(vi.Normal))
+			expect(b.start).To(equal(b.end))
+		})
+	})
+
+	o.Spec("it undoes with a count", func(expect expect.Expectation, m *vi.Machine) {
+		b := newBuffer("foo", 0)
+		b.typed(m, "2u")
+		expect(b.undos).To(equal(2))
+	})
+
+	o.Spec("it drops invalid commands", func(expect expect.Expectation, m *vi.Machine) {
+		b := newBuffer("foo bar", 0)
+		b.typed(m, "dqw")
+		expect(string(b.text)).To(equal("foo bar"))
+		expect(b.caret).To(equal(4))
+	})
+
+	o.Spec("it cancels pending commands on Esc", func(expect expect.Expectation, m *vi.Machine) {
+		b := newBuffer("foo bar", 0)
+		b.typed(m, "d\x1bw")
+		expect(string(b.text)).To(equal("foo bar"))
+		expect(b.caret).To(equal(4))
+	})
+}
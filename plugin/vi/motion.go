@@ -0,0 +1,508 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package vi
+
+import "unicode"
+
+// kind is the way that a motion's target is turned into a region
+// of text for an operator.
+type kind int
+
+const (
+	// exclusive regions stop just before the motion's target.
+	exclusive kind = iota
+
+	// inclusive regions include the rune at the motion's target.
+	inclusive
+
+	// linewise regions include every line between the caret and
+	// the motion's target, including the trailing newline.
+	linewise
+)
+
+// region is a range of text that an operator acts on.  End is
+// exclusive, regardless of the kind of motion used to find it.
+type region struct {
+	start, end int
+	linewise   bool
+}
+
+// target is the result of a motion.
+type target struct {
+	pos  int
+	kind kind
+}
+
+// motion moves from the caret count times.  The op argument will be
+// true when the motion is being used by an operator, which can allow
+// it past the end of a line.
+type motion func(t []rune, caret, count int, op bool) (target, bool)
+
+// motions maps keys to the motions that they perform.  Motions that
+// require a second key (e.g. f, t, and gg) are handled separately.
+var motions = map[rune]motion{
+	'h':  left,
+	'\b': left,
+	'l':  right,
+	' ':  right,
+	'j':  down,
+	'k':  up,
+	'\n': nextLine,
+	'+':  nextLine,
+	'-':  prevLine,
+	'w':  wordStart(wordClass),
+	'W':  wordStart(bigWordClass),
+	'e':  wordEnd(wordClass),
+	'E':  wordEnd(bigWordClass),
+	'b':  wordBack(wordClass),
+	'B':  wordBack(bigWordClass),
+	'0':  startOfLine,
+	'^':  firstNonBlank,
+	'$':  endOfLine,
+	'G':  lastLine,
+}
+
+func lineStart(t []rune, i int) int {
+	if i > len(t) {
+		i = len(t)
+	}
+	for i > 0 && t[i-1] != '\n' {
+		i--
+	}
+	return i
+}
+
+func lineEnd(t []rune, i int) int {
+	if i < 0 {
+		i = 0
+	}
+	for i < len(t) && t[i] != '\n' {
+		i++
+	}
+	return i
+}
+
+// lastCol returns the last position that the caret may occupy in
+// normal mode on the line containing i.
+func lastCol(t []rune, i int) int {
+	start, end := lineStart(t, i), lineEnd(t, i)
+	if end > start {
+		return end - 1
+	}
+	return start
+}
+
+// clamp keeps pos within the bounds that the caret may occupy in
+// normal mode.
+func clamp(t []rune, pos int) int {
+	if pos < 0 {
+		return 0
+	}
+	if pos > len(t) {
+		pos = len(t)
+	}
+	if l := lastCol(t, pos); pos > l {
+		return l
+	}
+	return pos
+}
+
+func nonBlank(t []rune, i int) int {
+	for i < len(t) && (t[i] == ' ' || t[i] == '\t') {
+		i++
+	}
+	return i
+}
+
+// lineAt returns the start of the line n lines away from the line
+// containing i.  It stops at the first or last line.
+func lineAt(t []rune, i, n int) int {
+	start := lineStart(t, i)
+	for ; n > 0; n-- {
+		end := lineEnd(t, start)
+		if end == len(t) {
+			break
+		}
+		start = end + 1
+	}
+	for ; n < 0; n++ {
+		if start == 0 {
+			break
+		}
+		start = lineStart(t, start-1)
+	}
+	return start
+}
+
+// nthLine returns the start of the 1-based nth line of t, or the
+// last line if t has fewer than n lines.
+func nthLine(t []rune, n int) int {
+	return lineAt(t, 0, n-1)
+}
+
+func left(t []rune, caret, count int, op bool) (target, bool) {
+	start := lineStart(t, caret)
+	if caret == start {
+		return target{}, false
+	}
+	pos := caret - count
+	if pos < start {
+		pos = start
+	}
+	return target{pos: pos, kind: exclusive}, true
+}
+
+func right(t []rune, caret, count int, op bool) (target, bool) {
+	end := lineEnd(t, caret)
+	last := end
+	if !op {
+		last = lastCol(t, caret)
+	}
+	if caret >= last {
+		return target{}, false
+	}
+	pos := caret + count
+	if pos > last {
+		pos = last
+	}
+	return target{pos: pos, kind: exclusive}, true
+}
+
+func vertical(t []rune, caret, lines int) (target, bool) {
+	start := lineStart(t, caret)
+	newStart := lineAt(t, caret, lines)
+	if newStart == start {
+		return target{}, false
+	}
+	pos := newStart + (caret - start)
+	if end := lineEnd(t, newStart); pos > end {
+		pos = end
+	}
+	return target{pos: clamp(t, pos), kind: linewise}, true
+}
+
+func down(t []rune, caret, count int, op bool) (target, bool) {
+	return vertical(t, caret, count)
+}
+
+func up(t []rune, caret, count int, op bool) (target, bool) {
+	return vertical(t, caret, -count)
+}
+
+func nextLine(t []rune, caret, count int, op bool) (target, bool) {
+	start := lineAt(t, caret, count)
+	if start == lineStart(t, caret) {
+		return target{}, false
+	}
+	return target{pos: clamp(t, nonBlank(t, start)), kind: linewise}, true
+}
+
+func prevLine(t []rune, caret, count int, op bool) (target, bool) {
+	start := lineAt(t, caret, -count)
+	if start == lineStart(t, caret) {
+		return target{}, false
+	}
+	return target{pos: clamp(t, nonBlank(t, start)), kind: linewise}, true
+}
+
+func startOfLine(t []rune, caret, count int, op bool) (target, bool) {
+	return target{pos: lineStart(t, caret), kind: exclusive}, true
+}
+
+func firstNonBlank(t []rune, caret, count int, op bool) (target, bool) {
+	return target{pos: clamp(t, nonBlank(t, lineStart(t, caret))), kind: exclusive}, true
+}
+
+func endOfLine(t []rune, caret, count int, op bool) (target, bool) {
+	end := lineEnd(t, lineAt(t, caret, count-1))
+	if op {
+		// Using an exclusive target at the newline keeps empty
+		// lines from deleting their newline.
+		return target{pos: end, kind: exclusive}, true
+	}
+	return target{pos: lastCol(t, end), kind: inclusive}, true
+}
+
+// lastLine moves to the line number given by count, or the last line
+// if there is no count.  It is also used for gg, which passes a count
+// of 1 when none was given.
+func lastLine(t []rune, caret, count int, op bool) (target, bool) {
+	start := lineStart(t, len(t))
+	if count > 0 {
+		start = nthLine(t, count)
+	}
+	return target{pos: clamp(t, nonBlank(t, start)), kind: linewise}, true
+}
+
+// find returns a motion which searches the current line for r.  The
+// till argument stops one rune before r, like t and T.
+func find(r rune, forward, till bool) motion {
+	return func(t []rune, caret, count int, op bool) (target, bool) {
+		start, end := lineStart(t, caret), lineEnd(t, caret)
+		pos := caret
+		for i := 0; i < count; i++ {
+			next := pos
+			step := 1
+			if !forward {
+				step = -1
+			}
+			next += step
+			if till && i == 0 && next >= start && next < end && t[next] == r {
+				// t and T skip over a match that the caret is
+				// already next to.
+				next += step
+			}
+			for next >= start && next < end && t[next] != r {
+				next += step
+			}
+			if next < start || next >= end {
+				return target{}, false
+			}
+			pos = next
+		}
+		if !forward {
+			if till {
+				pos++
+			}
+			return target{pos: pos, kind: exclusive}, true
+		}
+		if till {
+			pos--
+		}
+		return target{pos: pos, kind: inclusive}, true
+	}
+}
+
+// class returns a category for a rune, so that word motions can tell
+// where words start and end.  Blank runes must be 0.
+type class func(rune) int
+
+func wordClass(r rune) int {
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+		return 2
+	default:
+		return 1
+	}
+}
+
+func bigWordClass(r rune) int {
+	if unicode.IsSpace(r) {
+		return 0
+	}
+	return 1
+}
+
+// emptyLine returns whether the line at i is empty.  Empty lines are
+// treated as words by vi's word motions.
+func emptyLine(t []rune, i int) bool {
+	return i < len(t) && t[i] == '\n' && (i == 0 || t[i-1] == '\n')
+}
+
+func wordStart(cls class) motion {
+	return func(t []rune, caret, count int, op bool) (target, bool) {
+		if caret >= len(t) {
+			return target{}, false
+		}
+		pos := caret
+		for i := 0; i < count && pos < len(t); i++ {
+			c := cls(t[pos])
+			if c != 0 {
+				for pos < len(t) && cls(t[pos]) == c {
+					pos++
+				}
+			} else if emptyLine(t, pos) {
+				pos++
+			}
+			for pos < len(t) && cls(t[pos]) == 0 && !emptyLine(t, pos) {
+				pos++
+			}
+		}
+		return target{pos: pos, kind: exclusive}, true
+	}
+}
+
+func wordEnd(cls class) motion {
+	return func(t []rune, caret, count int, op bool) (target, bool) {
+		pos := caret
+		for i := 0; i < count; i++ {
+			if pos+1 >= len(t) {
+				return target{}, false
+			}
+			pos++
+			for pos < len(t)-1 && cls(t[pos]) == 0 {
+				pos++
+			}
+			c := cls(t[pos])
+			for pos+1 < len(t) && cls(t[pos+1]) == c {
+				pos++
+			}
+		}
+		return target{pos: pos, kind: inclusive}, true
+	}
+}
+
+func wordBack(cls class) motion {
+	return func(t []rune, caret, count int, op bool) (target, bool) {
+		pos := caret
+		for i := 0; i < count; i++ {
+			if pos == 0 {
+				return target{}, false
+			}
+			pos--
+			for pos > 0 && cls(t[pos]) == 0 && !emptyLine(t, pos) {
+				pos--
+			}
+			if c := cls(t[pos]); c != 0 {
+				for pos > 0 && cls(t[pos-1]) == c {
+					pos--
+				}
+			}
+		}
+		return target{pos: pos, kind: exclusive}, true
+	}
+}
+
+// object finds a text object around the caret.  The around argument
+// is true for the "a" objects and false for the "i" objects.
+type object func(t []rune, caret int, around bool) (region, bool)
+
+// objects maps the key following i or a to the text object that it
+// selects.
+var objects = map[rune]object{
+	'w':  word(wordClass),
+	'W':  word(bigWordClass),
+	'"':  quote('"'),
+	'\'': quote('\''),
+	'`':  quote('`'),
+	'(':  block('(', ')'),
+	')':  block('(', ')'),
+	'b':  block('(', ')'),
+	'{':  block('{', '}'),
+	'}':  block('{', '}'),
+	'B':  block('{', '}'),
+	'[':  block('[', ']'),
+	']':  block('[', ']'),
+	'<':  block('<', '>'),
+	'>':  block('<', '>'),
+}
+
+func word(cls class) object {
+	return func(t []rune, caret int, around bool) (region, bool) {
+		if caret >= len(t) || t[caret] == '\n' {
+			return region{}, false
+		}
+		c := cls(t[caret])
+		start, end := caret, caret+1
+		for start > 0 && t[start-1] != '\n' && cls(t[start-1]) == c {
+			start--
+		}
+		for end < len(t) && t[end] != '\n' && cls(t[end]) == c {
+			end++
+		}
+		if !around {
+			return region{start: start, end: end}, true
+		}
+		if c == 0 {
+			// Around a blank, aw includes the following word.
+			for end < len(t) && t[end] != '\n' && cls(t[end]) == cls(t[end-1]) {
+				end++
+			}
+			if end < len(t) && t[end] != '\n' {
+				nc := cls(t[end])
+				for end < len(t) && t[end] != '\n' && cls(t[end]) == nc {
+					end++
+				}
+			}
+			return region{start: start, end: end}, true
+		}
+		trailing := end
+		for trailing < len(t) && (t[trailing] == ' ' || t[trailing] == '\t') {
+			trailing++
+		}
+		if trailing > end {
+			return region{start: start, end: trailing}, true
+		}
+		for start > 0 && (t[start-1] == ' ' || t[start-1] == '\t') {
+			start--
+		}
+		return region{start: start, end: end}, true
+	}
+}
+
+func quote(q rune) object {
+	return func(t []rune, caret int, around bool) (region, bool) {
+		start, end := lineStart(t, caret), lineEnd(t, caret)
+		open := -1
+		for i := start; i < end; i++ {
+			if t[i] != q || (i > start && t[i-1] == '\\') {
+				continue
+			}
+			if open < 0 {
+				open = i
+				continue
+			}
+			if caret <= i {
+				if around {
+					return region{start: open, end: i + 1}, true
+				}
+				return region{start: open + 1, end: i}, true
+			}
+			open = -1
+		}
+		return region{}, false
+	}
+}
+
+func block(open, close rune) object {
+	return func(t []rune, caret int, around bool) (region, bool) {
+		if caret >= len(t) {
+			return region{}, false
+		}
+		start := caret
+		if t[start] != open {
+			depth := 0
+			if t[start] == close {
+				depth--
+			}
+			for ; start >= 0; start-- {
+				switch t[start] {
+				case close:
+					depth++
+				case open:
+					depth--
+				}
+				if depth < 0 {
+					break
+				}
+			}
+			if start < 0 {
+				return region{}, false
+			}
+		}
+		depth := 0
+		end := start
+		for ; end < len(t); end++ {
+			switch t[end] {
+			case open:
+				depth++
+			case close:
+				depth--
+			}
+			if depth == 0 {
+				break
+			}
+		}
+		if end == len(t) {
+			return region{}, false
+		}
+		if around {
+			return region{start: start, end: end + 1}, true
+		}
+		return region{start: start + 1, end: end}, true
+	}
+}
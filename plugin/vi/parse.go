@@ -0,0 +1,225 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package vi
+
+import "errors"
+
+var (
+	errIncomplete = errors.New("vi: command is incomplete")
+	errInvalid    = errors.New("vi: invalid command")
+)
+
+// cmd is a parsed normal or visual mode command.  Commands take
+// the form:
+//
+//	["x][count]command
+//	["x][count]operator[count]motion
+//	["x][count]operator[count](i|a)object
+type cmd struct {
+	register rune
+	count    int
+	op       rune
+	key      rune
+	arg      rune
+	mcount   int
+
+	// countAt and countEnd are the indexes of the leading count in
+	// keys, so that . can replace it.
+	countAt, countEnd int
+	keys              []rune
+}
+
+// commands are the keys which are commands on their own in normal
+// mode.  Keys that are not commands or operators are parsed as
+// motions.
+var commands = map[rune]bool{
+	'i': true, 'a': true, 'I': true, 'A': true, 'o': true, 'O': true,
+	'x': true, 'X': true, 'D': true, 'C': true, 's': true, 'S': true,
+	'Y': true, 'p': true, 'P': true, 'r': true, 'J': true, 'u': true,
+	'.': true, 'v': true, 'V': true,
+}
+
+// visualCommands are the keys which are commands on their own in
+// visual mode.
+var visualCommands = map[rune]bool{
+	'd': true, 'x': true, 'X': true, 'D': true, 'c': true, 's': true,
+	'C': true, 'S': true, 'R': true, 'y': true, 'Y': true, 'p': true,
+	'P': true, 'J': true, 'r': true, 'o': true, 'v': true, 'V': true,
+}
+
+// changes are the commands that . is able to repeat.
+var changes = map[rune]bool{
+	'i': true, 'a': true, 'I': true, 'A': true, 'o': true, 'O': true,
+	'x': true, 'X': true, 'D': true, 'C': true, 's': true, 'S': true,
+	'p': true, 'P': true, 'r': true, 'J': true,
+}
+
+// aliases are commands which are shorthand for an operator and a
+// motion.
+var aliases = map[rune][2]rune{
+	'x': {'d', 'l'},
+	'X': {'d', 'h'},
+	'D': {'d', '$'},
+	'C': {'c', '$'},
+	's': {'c', 'l'},
+	'S': {'c', 'c'},
+	'Y': {'y', 'y'},
+}
+
+func isOperator(r rune) bool {
+	return r == 'd' || r == 'c' || r == 'y'
+}
+
+type scanner struct {
+	keys []rune
+	i    int
+}
+
+func (s *scanner) next() (rune, error) {
+	if s.i >= len(s.keys) {
+		return 0, errIncomplete
+	}
+	r := s.keys[s.i]
+	s.i++
+	return r, nil
+}
+
+func (s *scanner) peek() (rune, error) {
+	if s.i >= len(s.keys) {
+		return 0, errIncomplete
+	}
+	return s.keys[s.i], nil
+}
+
+func (s *scanner) count() (int, error) {
+	n := 0
+	for {
+		r, err := s.peek()
+		if err != nil {
+			return 0, err
+		}
+		if r < '0' || r > '9' || (n == 0 && r == '0') {
+			return n, nil
+		}
+		n = n*10 + int(r-'0')
+		s.i++
+	}
+}
+
+// parse parses keys as a command.  It returns errIncomplete if keys
+// is the start of a valid command.
+func parse(keys []rune, visual bool) (cmd, error) {
+	s := &scanner{keys: keys}
+	var c cmd
+	r, err := s.peek()
+	if err != nil {
+		return c, err
+	}
+	if r == '"' {
+		s.i++
+		if c.register, err = s.next(); err != nil {
+			return c, err
+		}
+		if !validRegister(c.register) {
+			return c, errInvalid
+		}
+	}
+	c.countAt = s.i
+	if c.count, err = s.count(); err != nil {
+		return c, err
+	}
+	c.countEnd = s.i
+	if c.key, err = s.next(); err != nil {
+		return c, err
+	}
+	switch {
+	case visual && (c.key == 'i' || c.key == 'a'):
+		err = parseObject(s, &c)
+	case visual && visualCommands[c.key]:
+		if c.key == 'r' {
+			c.arg, err = s.next()
+		}
+	case !visual && isOperator(c.key):
+		c.op = c.key
+		if c.mcount, err = s.count(); err != nil {
+			return c, err
+		}
+		if c.key, err = s.next(); err != nil {
+			return c, err
+		}
+		switch c.key {
+		case c.op:
+		case 'i', 'a':
+			err = parseObject(s, &c)
+		default:
+			err = parseMotion(s, &c)
+		}
+	case !visual && commands[c.key]:
+		if c.key == 'r' {
+			c.arg, err = s.next()
+		}
+	default:
+		err = parseMotion(s, &c)
+	}
+	c.keys = append([]rune(nil), keys[:s.i]...)
+	return c, err
+}
+
+func parseObject(s *scanner, c *cmd) (err error) {
+	if c.arg, err = s.next(); err != nil {
+		return err
+	}
+	if _, ok := objects[c.arg]; !ok {
+		return errInvalid
+	}
+	return nil
+}
+
+func parseMotion(s *scanner, c *cmd) (err error) {
+	switch c.key {
+	case 'f', 't', 'F', 'T':
+		c.arg, err = s.next()
+		return err
+	case 'g':
+		c.arg, err = s.next()
+		if err == nil && c.arg != 'g' {
+			return errInvalid
+		}
+		return err
+	}
+	if _, ok := motions[c.key]; !ok {
+		return errInvalid
+	}
+	return nil
+}
+
+// motion returns the motion for c.
+func (c cmd) motion() motion {
+	switch c.key {
+	case 'f':
+		return find(c.arg, true, false)
+	case 't':
+		return find(c.arg, true, true)
+	case 'F':
+		return find(c.arg, false, false)
+	case 'T':
+		return find(c.arg, false, true)
+	case 'g':
+		return lastLine
+	}
+	return motions[c.key]
+}
+
+// n returns the total count given to c, or 0 if no count was given.
+func (c cmd) n() int {
+	switch {
+	case c.count == 0:
+		return c.mcount
+	case c.mcount == 0:
+		return c.count
+	default:
+		return c.count * c.mcount
+	}
+}
@@ -0,0 +1,70 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package vi
+
+import "unicode"
+
+const (
+	// unnamed is the register that is used when no register is
+	// given.  It always holds the most recent yank or delete.
+	unnamed = '"'
+
+	// yanked holds the most recent yank that was not made to a
+	// named register.
+	yanked = '0'
+
+	// blackHole discards anything written to it.
+	blackHole = '_'
+)
+
+type register struct {
+	text     []rune
+	linewise bool
+}
+
+// registers holds text that has been yanked or deleted.
+type registers map[rune]register
+
+func validRegister(r rune) bool {
+	switch {
+	case r == unnamed, r == blackHole:
+		return true
+	case r >= '0' && r <= '9':
+		return true
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		return true
+	}
+	return false
+}
+
+// store writes text to the register named name, as well as any
+// registers that vi would also write to.  Upper case names append to
+// their lower case register.
+func (r registers) store(name rune, reg register, yank bool) {
+	if name == blackHole {
+		return
+	}
+	if name == 0 {
+		name = unnamed
+	}
+	if unicode.IsUpper(name) {
+		name = unicode.ToLower(name)
+		prev := r[name]
+		text := append(append([]rune(nil), prev.text...), reg.text...)
+		reg = register{text: text, linewise: prev.linewise || reg.linewise}
+	}
+	r[name] = reg
+	r[unnamed] = reg
+	if yank && name == unnamed {
+		r[yanked] = reg
+	}
+}
+
+func (r registers) load(name rune) register {
+	if name == 0 {
+		name = unnamed
+	}
+	return r[unicode.ToLower(name)]
+}
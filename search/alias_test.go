@@ -0,0 +1,17 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package search_test
+
+import "github.com/poy/onpar/matchers"
+
+// matcher aliases to avoid dot-importing matchers.
+var (
+	not          = matchers.Not
+	equal        = matchers.Equal
+	haveLen      = matchers.HaveLen
+	beTrue       = matchers.BeTrue
+	beFalse      = matchers.BeFalse
+	haveOccurred = matchers.HaveOccurred
+)
@@ -0,0 +1,12 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+// Package search finds text in the files under a directory.  It
+// skips files that are ignored by .gitignore files and by a list of
+// exclude patterns, and streams its results back as each file is
+// searched.
+//
+// This package does not import any UI code, so that it can be used
+// by any command that needs to search a project.
+package search
@@ -0,0 +1,167 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package search
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFilename is the name of the files that ignore patterns are
+// loaded from.
+const IgnoreFilename = ".gitignore"
+
+type pattern struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// Ignore is a list of patterns, in the format used by .gitignore
+// files, which match paths under a base directory.
+type Ignore struct {
+	base     string
+	patterns []pattern
+}
+
+// NewIgnore parses lines as the lines of a .gitignore file in base.
+// Lines which are not valid patterns are skipped.
+func NewIgnore(base string, lines ...string) *Ignore {
+	i := &Ignore{base: base}
+	for _, l := range lines {
+		if p, ok := parsePattern(l); ok {
+			i.patterns = append(i.patterns, p)
+		}
+	}
+	return i
+}
+
+// LoadIgnore loads the ignore file in dir.  It returns nil if dir
+// has no ignore file.
+func LoadIgnore(dir string) *Ignore {
+	f, err := os.Open(filepath.Join(dir, IgnoreFilename))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var lines []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		lines = append(lines, s.Text())
+	}
+	return NewIgnore(dir, lines...)
+}
+
+// Match checks path against i's patterns.  The last pattern that
+// matches path decides whether it is ignored.  If no pattern
+// matches, ok will be false.
+func (i *Ignore) Match(path string, isDir bool) (ignored, ok bool) {
+	rel, err := filepath.Rel(i.base, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false, false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, p := range i.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if p.re.MatchString(rel) {
+			ignored, ok = !p.negate, true
+		}
+	}
+	return ignored, ok
+}
+
+// ignored checks path against each Ignore in stack, in order, so
+// that later (deeper) ignore files override earlier ones.
+func ignored(stack []*Ignore, path string, isDir bool) bool {
+	result := false
+	for _, i := range stack {
+		if ig, ok := i.Match(path, isDir); ok {
+			result = ig
+		}
+	}
+	return result
+}
+
+func parsePattern(line string) (pattern, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || line[0] == '#' {
+		return pattern{}, false
+	}
+	var p pattern
+	if line[0] == '!' {
+		p.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return pattern{}, false
+	}
+	if strings.Contains(line, "/") {
+		line = strings.TrimPrefix(line, "/")
+	} else {
+		// Patterns without a slash match at any depth.
+		line = "**/" + line
+	}
+	re, err := compileGlob(line)
+	if err != nil {
+		return pattern{}, false
+	}
+	p.re = re
+	return p, true
+}
+
+// compileGlob converts a glob, as used in .gitignore files, to a
+// regular expression.
+func compileGlob(glob string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			switch {
+			case strings.HasPrefix(glob[i:], "**/"):
+				b.WriteString("(?:.*/)?")
+				i += 2
+			case glob[i:] == "**":
+				b.WriteString(".*")
+				i++
+			default:
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,85 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package search
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// A Replacement is a single match in a file, along with the text it
+// will be replaced with.
+type Replacement struct {
+	Match
+
+	// Offset is the rune offset of the match from the start of the
+	// file.
+	Offset int
+
+	// Old is the text that was matched and New is the text that it
+	// will be replaced with.
+	Old, New string
+
+	// NewText is the text of the line that the match is on, after
+	// only this replacement has been made.
+	NewText string
+}
+
+// Replacements returns a Replacement for each match of re in text.
+// Each match is replaced with template, which may refer to capture
+// groups in re using the syntax supported by regexp.Expand.
+func Replacements(text string, re *regexp.Regexp, template string) []Replacement {
+	var reps []Replacement
+	offset := 0
+	for i, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSuffix(line, "\r")
+		for _, loc := range re.FindAllStringSubmatchIndex(trimmed, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			old := trimmed[loc[0]:loc[1]]
+			repl := string(re.ExpandString(nil, template, trimmed, loc))
+			col := utf8.RuneCountInString(trimmed[:loc[0]])
+			reps = append(reps, Replacement{
+				Match: Match{
+					Line:   i,
+					Column: col,
+					Length: utf8.RuneCountInString(old),
+					Text:   trimmed,
+				},
+				Offset:  offset + col,
+				Old:     old,
+				New:     repl,
+				NewText: trimmed[:loc[0]] + repl + trimmed[loc[1]:],
+			})
+		}
+		offset += utf8.RuneCountInString(line) + 1
+	}
+	return reps
+}
+
+// Replace returns text with each of reps applied to it.  reps must
+// be sorted by Offset and must not overlap.  Replacements whose Old
+// text does not match text at their Offset are skipped, and the
+// number of skipped replacements is returned.
+func Replace(text string, reps []Replacement) (result string, skipped int) {
+	runes := []rune(text)
+	var b strings.Builder
+	last := 0
+	for _, r := range reps {
+		old := []rune(r.Old)
+		end := r.Offset + len(old)
+		if r.Offset < last || end > len(runes) || string(runes[r.Offset:end]) != r.Old {
+			skipped++
+			continue
+		}
+		b.WriteString(string(runes[last:r.Offset]))
+		b.WriteString(r.New)
+		last = end
+	}
+	b.WriteString(string(runes[last:]))
+	return b.String(), skipped
+}
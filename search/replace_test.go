@@ -0,0 +1,51 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package search_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/nelsam/vidar/search"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+func TestReplace(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	const text = "héllo foo(1)\nbar foo(22)\n"
+
+	o.BeforeEach(func(t *testing.T) expect.Expectation {
+		return expect.New(t)
+	})
+
+	o.Spec("it expands capture groups", func(expect expect.Expectation) {
+		reps := search.Replacements(text, regexp.MustCompile(`foo\((\d+)\)`), "baz($1, 0)")
+		expect(reps).To(haveLen(2))
+		expect(reps[0].Offset).To(equal(6))
+		expect(reps[0].Old).To(equal("foo(1)"))
+		expect(reps[0].New).To(equal("baz(1, 0)"))
+		expect(reps[0].NewText).To(equal("héllo baz(1, 0)"))
+		expect(reps[1].Line).To(equal(1))
+		expect(reps[1].Offset).To(equal(17))
+		expect(reps[1].New).To(equal("baz(22, 0)"))
+	})
+
+	o.Spec("it applies a subset of replacements", func(expect expect.Expectation) {
+		reps := search.Replacements(text, regexp.MustCompile(`foo`), "x")
+		result, skipped := search.Replace(text, reps[1:])
+		expect(skipped).To(equal(0))
+		expect(result).To(equal("héllo foo(1)\nbar x(22)\n"))
+	})
+
+	o.Spec("it skips replacements that no longer match", func(expect expect.Expectation) {
+		reps := search.Replacements(text, regexp.MustCompile(`foo`), "x")
+		result, skipped := search.Replace("héllo bar(1)\nbar foo(22)\n", reps)
+		expect(skipped).To(equal(1))
+		expect(result).To(equal("héllo bar(1)\nbar x(22)\n"))
+	})
+}
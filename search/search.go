@@ -0,0 +1,171 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package search
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	// MaxFileSize is the size of the largest file that will be
+	// searched.
+	MaxFileSize = 8 << 20
+
+	// binarySniffLen is the number of bytes at the start of a file
+	// that are checked for NUL bytes to decide whether the file is
+	// binary.
+	binarySniffLen = 8000
+)
+
+// Match is a single match in a file.
+type Match struct {
+	// Line and Column are the zero-based position of the start of
+	// the match.  Column and Length are counted in runes.
+	Line, Column, Length int
+
+	// Text is the text of the line that the match is on.
+	Text string
+}
+
+// Result is the list of matches in a single file.
+type Result struct {
+	Path    string
+	Matches []Match
+}
+
+// A Finder searches the files under a directory.
+type Finder struct {
+	// Excludes is a list of patterns, in the same format as the
+	// lines of a .gitignore file, for files and directories that
+	// should never be searched.
+	Excludes []string
+
+	// Workers is the number of files that will be searched
+	// concurrently.  If it is zero, runtime.NumCPU() is used.
+	Workers int
+}
+
+// Find searches the files under root for re.  Results are sent on
+// the returned channel as each file is searched; files without any
+// matches are not sent.  The channel is closed when the search is
+// complete or ctx is done.
+func (f Finder) Find(ctx context.Context, root string, re *regexp.Regexp) <-chan Result {
+	workers := f.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	paths := make(chan string)
+	results := make(chan Result)
+	go func() {
+		defer close(paths)
+		f.walk(ctx, root, nil, NewIgnore(root, f.Excludes...), paths)
+	}()
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for p := range paths {
+				matches := searchFile(p, re)
+				if len(matches) == 0 {
+					continue
+				}
+				select {
+				case results <- Result{Path: p, Matches: matches}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
+
+// walk sends the path of each file under dir that is not ignored
+// on paths.  It returns false if ctx is done before it finishes.
+func (f Finder) walk(ctx context.Context, dir string, stack []*Ignore, excl *Ignore, paths chan<- string) bool {
+	if i := LoadIgnore(dir); i != nil {
+		stack = append(stack[:len(stack):len(stack)], i)
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return true
+	}
+	for _, info := range infos {
+		path := filepath.Join(dir, info.Name())
+		isDir := info.IsDir()
+		if isDir && info.Name() == ".git" {
+			continue
+		}
+		if ig, _ := excl.Match(path, isDir); ig || ignored(stack, path, isDir) {
+			continue
+		}
+		if isDir {
+			if !f.walk(ctx, path, stack, excl, paths) {
+				return false
+			}
+			continue
+		}
+		if !info.Mode().IsRegular() || info.Size() > MaxFileSize {
+			continue
+		}
+		select {
+		case paths <- path:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// searchFile returns all matches for re in the file at path.  Files
+// which cannot be read or appear to be binary have no matches.
+func searchFile(path string, re *regexp.Regexp) []Match {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	sniff := b
+	if len(sniff) > binarySniffLen {
+		sniff = sniff[:binarySniffLen]
+	}
+	if bytes.IndexByte(sniff, 0) >= 0 {
+		return nil
+	}
+	return Lines(string(b), re)
+}
+
+// Lines returns all matches for re in text, which is searched one
+// line at a time.  Empty matches are skipped.
+func Lines(text string, re *regexp.Regexp) []Match {
+	var matches []Match
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		for _, loc := range re.FindAllStringIndex(line, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			matches = append(matches, Match{
+				Line:   i,
+				Column: utf8.RuneCountInString(line[:loc[0]]),
+				Length: utf8.RuneCountInString(line[loc[0]:loc[1]]),
+				Text:   line,
+			})
+		}
+	}
+	return matches
+}
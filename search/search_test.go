@@ -0,0 +1,156 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package search_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/nelsam/vidar/search"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+func TestFind(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	files := map[string]string{
+		".gitignore":        "*.log\nbuild/\n!keep.log\n",
+		"main.go":           "package main\n\nfunc main() { foo() }\n",
+		"foo.go":            "package main\n\nfunc foo() {}\n",
+		"debug.log":         "foo\n",
+		"keep.log":          "foo\n",
+		"build/out.go":      "foo\n",
+		"vendor/dep/dep.go": "foo\n",
+		"sub/.gitignore":    "!debug.log\n",
+		"sub/debug.log":     "foo\n",
+		"bin/data":          "foo\x00\n",
+	}
+
+	o.BeforeEach(func(t *testing.T) (expect.Expectation, string) {
+		dir, err := ioutil.TempDir("", "vidar-search")
+		if err != nil {
+			t.Fatalf("could not create temp dir: %s", err)
+		}
+		for name, body := range files {
+			path := filepath.Join(dir, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				t.Fatalf("could not create dir: %s", err)
+			}
+			if err := ioutil.WriteFile(path, []byte(body), 0600); err != nil {
+				t.Fatalf("could not write file: %s", err)
+			}
+		}
+		return expect.New(t), dir
+	})
+
+	o.AfterEach(func(_ expect.Expectation, dir string) {
+		os.RemoveAll(dir)
+	})
+
+	find := func(f search.Finder, dir, pattern string) map[string][]search.Match {
+		found := make(map[string][]search.Match)
+		for r := range f.Find(context.Background(), dir, regexp.MustCompile(pattern)) {
+			rel, _ := filepath.Rel(dir, r.Path)
+			found[filepath.ToSlash(rel)] = r.Matches
+		}
+		return found
+	}
+
+	keys := func(m map[string][]search.Match) []string {
+		var k []string
+		for key := range m {
+			k = append(k, key)
+		}
+		sort.Strings(k)
+		return k
+	}
+
+	o.Spec("it honours .gitignore files", func(expect expect.Expectation, dir string) {
+		found := find(search.Finder{}, dir, "foo")
+		expect(keys(found)).To(equal([]string{
+			"foo.go",
+			"keep.log",
+			"main.go",
+			"sub/debug.log",
+			"vendor/dep/dep.go",
+		}))
+	})
+
+	o.Spec("it skips excluded paths", func(expect expect.Expectation, dir string) {
+		found := find(search.Finder{Excludes: []string{"vendor/", "*.log"}}, dir, "foo")
+		expect(keys(found)).To(equal([]string{"foo.go", "main.go"}))
+	})
+
+	o.Spec("it reports the position of each match", func(expect expect.Expectation, dir string) {
+		found := find(search.Finder{Workers: 1}, dir, `foo\(\)`)
+		expect(found["main.go"]).To(equal([]search.Match{
+			{Line: 2, Column: 14, Length: 5, Text: "func main() { foo() }"},
+		}))
+	})
+
+	o.Spec("it stops when the context is cancelled", func(expect expect.Expectation, dir string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		results := search.Finder{}.Find(ctx, dir, regexp.MustCompile("foo"))
+		for range results {
+		}
+	})
+}
+
+func TestIgnore(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	o.BeforeEach(func(t *testing.T) expect.Expectation {
+		return expect.New(t)
+	})
+
+	o.Spec("it matches basenames at any depth", func(expect expect.Expectation) {
+		i := search.NewIgnore("/p", "*.o")
+		ig, ok := i.Match("/p/a/b/c.o", false)
+		expect(ok).To(beTrue())
+		expect(ig).To(beTrue())
+	})
+
+	o.Spec("it anchors patterns containing a slash", func(expect expect.Expectation) {
+		i := search.NewIgnore("/p", "/docs", "a/**/z")
+		ig, _ := i.Match("/p/docs", true)
+		expect(ig).To(beTrue())
+		ig, _ = i.Match("/p/sub/docs", true)
+		expect(ig).To(beFalse())
+		ig, _ = i.Match("/p/a/b/c/z", false)
+		expect(ig).To(beTrue())
+		ig, _ = i.Match("/p/a/z", false)
+		expect(ig).To(beTrue())
+	})
+
+	o.Spec("it only matches directories with dir-only patterns", func(expect expect.Expectation) {
+		i := search.NewIgnore("/p", "out/")
+		_, ok := i.Match("/p/out", false)
+		expect(ok).To(beFalse())
+		ig, _ := i.Match("/p/out", true)
+		expect(ig).To(beTrue())
+	})
+
+	o.Spec("it lets later patterns negate earlier ones", func(expect expect.Expectation) {
+		i := search.NewIgnore("/p", "# comment", "*.log", "!important.log")
+		ig, ok := i.Match("/p/important.log", false)
+		expect(ok).To(beTrue())
+		expect(ig).To(beFalse())
+	})
+
+	o.Spec("it ignores paths outside of its base", func(expect expect.Expectation) {
+		i := search.NewIgnore("/p", "*")
+		_, ok := i.Match("/q/foo", false)
+		expect(ok).To(beFalse())
+	})
+}
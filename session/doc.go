@@ -0,0 +1,10 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+// Package session saves and loads named sessions, which record the
+// projects, splits, and tabs that were open, along with the caret
+// and scroll positions in each tab.
+//
+// Like the swap package, this package does not import any UI code.
+package session
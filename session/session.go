@@ -0,0 +1,213 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nelsam/vidar/atomicfile"
+)
+
+const (
+	// DefaultName is the name of the session that is used when no
+	// other session has been saved.
+	DefaultName = "default"
+
+	sessionExt = ".json"
+)
+
+// Orientations of a split Layout.
+const (
+	Horizontal = "horizontal"
+	Vertical   = "vertical"
+)
+
+// A Session is a snapshot of the open projects and the editors in
+// each of them.
+type Session struct {
+	Name string `json:"name"`
+
+	// Saved is the time that the session was saved.
+	Saved time.Time `json:"saved"`
+
+	// Project is the name of the project that was focused.
+	Project string `json:"project"`
+
+	Projects []Project `json:"projects"`
+}
+
+// A Project is the layout of a single project's editors.
+type Project struct {
+	Name   string `json:"name"`
+	Layout Layout `json:"layout"`
+}
+
+// A Layout is either a split, with Splits laid out in Orientation,
+// or a group of Tabs.
+type Layout struct {
+	Orientation string   `json:"orientation,omitempty"`
+	Splits      []Layout `json:"splits,omitempty"`
+
+	Tabs []Editor `json:"tabs,omitempty"`
+
+	// Current is the index of the focused split or the selected tab.
+	Current int `json:"current"`
+
+	// Weight is the share of its parent split's space that the
+	// layout takes up.  Zero means the share wasn't recorded.
+	Weight float32 `json:"weight,omitempty"`
+}
+
+// Split reports whether l is a split rather than a group of tabs.
+func (l Layout) Split() bool {
+	return len(l.Splits) > 0
+}
+
+// Files returns the paths of all the tabs in l and its splits.
+func (l Layout) Files() []string {
+	var files []string
+	for _, t := range l.Tabs {
+		files = append(files, t.Path)
+	}
+	for _, s := range l.Splits {
+		files = append(files, s.Files()...)
+	}
+	return files
+}
+
+// An Editor is the state of a single tab.
+type Editor struct {
+	Path       string      `json:"path"`
+	Selections []Selection `json:"selections,omitempty"`
+	ScrollX    int         `json:"scroll_x,omitempty"`
+	ScrollY    int         `json:"scroll_y,omitempty"`
+}
+
+// A Selection is a range of runes in an editor, with the caret at
+// one end.  A plain caret has From == To.
+type Selection struct {
+	From         int  `json:"from"`
+	To           int  `json:"to"`
+	CaretAtStart bool `json:"caret_at_start,omitempty"`
+}
+
+// Store saves sessions as files in a directory.
+type Store struct {
+	// Dir is the directory that session files are saved to.
+	Dir string
+}
+
+// NewStore returns a Store that saves sessions to dir.
+func NewStore(dir string) *Store {
+	return &Store{Dir: dir}
+}
+
+func (s *Store) file(name string) (string, error) {
+	if err := validName(name); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.Dir, name+sessionExt), nil
+}
+
+// validName returns an error if name can't be used as a session
+// name.
+func validName(name string) error {
+	switch {
+	case strings.TrimSpace(name) == "":
+		return errors.New("session names can't be empty")
+	case strings.HasPrefix(name, "."):
+		return fmt.Errorf("session name %q can't start with a dot", name)
+	case strings.ContainsAny(name, `/\`):
+		return fmt.Errorf("session name %q can't contain path separators", name)
+	}
+	return nil
+}
+
+// Save saves sess under sess.Name, replacing any session that was
+// saved with the same name.  sess.Saved is set to the current time.
+func (s *Store) Save(sess Session) error {
+	path, err := s.file(sess.Name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return err
+	}
+	sess.Saved = time.Now()
+	b, err := json.MarshalIndent(sess, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, b, 0600)
+}
+
+// Load loads the session that was saved as name.
+func (s *Store) Load(name string) (Session, error) {
+	path, err := s.file(name)
+	if err != nil {
+		return Session{}, err
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Session{}, err
+	}
+	var sess Session
+	if err := json.Unmarshal(b, &sess); err != nil {
+		return Session{}, fmt.Errorf("could not parse session %s: %s", name, err)
+	}
+	sess.Name = name
+	return sess, nil
+}
+
+// Remove removes the session that was saved as name.
+func (s *Store) Remove(name string) error {
+	path, err := s.file(name)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// Names returns the names of all saved sessions, most recently
+// saved first.
+func (s *Store) Names() ([]string, error) {
+	finfos, err := ioutil.ReadDir(s.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(finfos, func(i, j int) bool {
+		return finfos[i].ModTime().After(finfos[j].ModTime())
+	})
+	var names []string
+	for _, finfo := range finfos {
+		name := finfo.Name()
+		if finfo.IsDir() || !strings.HasSuffix(name, sessionExt) {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(name, sessionExt))
+	}
+	return names, nil
+}
+
+// Latest returns the name of the most recently saved session, or
+// DefaultName if no sessions have been saved.
+func (s *Store) Latest() string {
+	names, err := s.Names()
+	if err != nil || len(names) == 0 {
+		return DefaultName
+	}
+	return names[0]
+}
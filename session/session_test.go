@@ -0,0 +1,92 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package session_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nelsam/vidar/session"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+func TestStore(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	o.BeforeEach(func(t *testing.T) (expect.Expectation, *session.Store) {
+		dir, err := ioutil.TempDir("", "vidar-session")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return expect.New(t), session.NewStore(dir)
+	})
+
+	o.AfterEach(func(_ expect.Expectation, s *session.Store) {
+		os.RemoveAll(s.Dir)
+	})
+
+	o.Spec("it loads saved sessions", func(expect expect.Expectation, s *session.Store) {
+		saved := session.Session{
+			Name:    "work",
+			Project: "vidar",
+			Projects: []session.Project{{
+				Name: "vidar",
+				Layout: session.Layout{
+					Orientation: session.Horizontal,
+					Current:     1,
+					Splits: []session.Layout{
+						{Tabs: []session.Editor{{Path: "/tmp/a.go"}}, Weight: 0.25},
+						{
+							Current: 1,
+							Tabs: []session.Editor{
+								{Path: "/tmp/b.go", ScrollY: 40},
+								{Path: "/tmp/c.go", Selections: []session.Selection{{From: 3, To: 7, CaretAtStart: true}}},
+							},
+						},
+					},
+				},
+			}},
+		}
+		expect(s.Save(saved)).To(beNil())
+
+		loaded, err := s.Load("work")
+		expect(err).To(beNil())
+		expect(loaded.Saved.IsZero()).To(beFalse())
+		loaded.Saved = time.Time{}
+		expect(loaded).To(equal(saved))
+		expect(loaded.Projects[0].Layout.Files()).To(equal([]string{"/tmp/a.go", "/tmp/b.go", "/tmp/c.go"}))
+	})
+
+	o.Spec("it lists sessions with the most recently saved first", func(expect expect.Expectation, s *session.Store) {
+		expect(s.Latest()).To(equal(session.DefaultName))
+
+		expect(s.Save(session.Session{Name: "old"})).To(beNil())
+		expect(s.Save(session.Session{Name: "new"})).To(beNil())
+		past := time.Now().Add(-time.Hour)
+		expect(os.Chtimes(filepath.Join(s.Dir, "old.json"), past, past)).To(beNil())
+
+		names, err := s.Names()
+		expect(err).To(beNil())
+		expect(names).To(equal([]string{"new", "old"}))
+		expect(s.Latest()).To(equal("new"))
+
+		expect(s.Remove("new")).To(beNil())
+		expect(s.Latest()).To(equal("old"))
+	})
+
+	o.Spec("it refuses names that aren't plain file names", func(expect expect.Expectation, s *session.Store) {
+		expect(s.Save(session.Session{Name: ""})).To(not(beNil()))
+		expect(s.Save(session.Session{Name: "../escape"})).To(not(beNil()))
+		expect(s.Save(session.Session{Name: ".hidden"})).To(not(beNil()))
+
+		_, err := s.Load("a/b")
+		expect(err).To(not(beNil()))
+	})
+}
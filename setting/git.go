@@ -0,0 +1,14 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package setting
+
+const hideIgnoredKey = "hide_ignored_files"
+
+// HideIgnoredFiles returns whether files that git ignores should be
+// left out of the project tree.
+func HideIgnoredFiles() bool {
+	hide, _ := settings.Get(hideIgnoredKey).(bool)
+	return hide
+}
@@ -0,0 +1,83 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package setting
+
+import (
+	"os"
+	"path/filepath"
+)
+
+const languageServersKey = "language_servers"
+
+// LanguageServer is the configuration for a language server that
+// should be used for files with any of a list of extensions.
+type LanguageServer struct {
+	// Extensions is the list of file extensions (including the
+	// leading dot) that this server handles.
+	Extensions []string
+
+	// Command and Args are used to start the server.  The server
+	// must communicate over stdin and stdout.
+	Command string
+	Args    []string
+
+	// LanguageID is the language identifier sent to the server
+	// when documents are opened.
+	LanguageID string
+
+	// RootMarkers is a list of file names that mark the root of
+	// a workspace.  The closest parent directory of a file that
+	// contains one of these will be used as the server's root.
+	RootMarkers []string
+}
+
+// Root returns the workspace root that should be used for path.
+func (s LanguageServer) Root(path string) string {
+	dir := filepath.Dir(path)
+	for d := dir; ; {
+		for _, m := range s.RootMarkers {
+			if _, err := os.Stat(filepath.Join(d, m)); err == nil {
+				return d
+			}
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
+
+var defaultLanguageServers = []LanguageServer{
+	{
+		Extensions:  []string{".go"},
+		Command:     "gopls",
+		LanguageID:  "go",
+		RootMarkers: []string{"go.mod", ".git"},
+	},
+}
+
+// LanguageServers returns the configured language servers.
+func LanguageServers() []LanguageServer {
+	servers, ok := settings.Get(languageServersKey).([]LanguageServer)
+	if !ok {
+		return nil
+	}
+	return servers
+}
+
+// LanguageServerFor returns the language server that is configured
+// for path's extension.
+func LanguageServerFor(path string) (LanguageServer, bool) {
+	ext := filepath.Ext(path)
+	for _, s := range LanguageServers() {
+		for _, e := range s.Extensions {
+			if e == ext {
+				return s, true
+			}
+		}
+	}
+	return LanguageServer{}, false
+}
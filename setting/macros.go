@@ -0,0 +1,99 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package setting
+
+import (
+	"log"
+	"os"
+
+	"github.com/nelsam/gxui"
+	"github.com/nelsam/vidar/setting/config"
+)
+
+const (
+	macrosFilename = "macros"
+	macrosKey      = "macros"
+)
+
+var macros *config.Config
+
+func init() {
+	var err error
+	macros, err = config.New(opener{}, macrosFilename, defaultConfigDir)
+	if os.IsNotExist(err) {
+		err = nil
+	}
+	if err != nil {
+		log.Printf("Error reading macros: %s", err)
+		return
+	}
+	macros.SetDefault(macrosKey, []Macro(nil))
+}
+
+// Macro is a named, recorded sequence of input.
+type Macro struct {
+	Name  string
+	Steps []MacroStep
+}
+
+// MacroStep is a single step in a Macro.  Only one of its fields
+// should be set.
+type MacroStep struct {
+	// Key is a key press, in the same format as key bindings.
+	Key string `toml:",omitempty" json:",omitempty" yaml:",omitempty"`
+
+	// Text is text that was typed.
+	Text string `toml:",omitempty" json:",omitempty" yaml:",omitempty"`
+
+	// Command is the name of a command that was executed.
+	Command string `toml:",omitempty" json:",omitempty" yaml:",omitempty"`
+}
+
+// KeyEvent parses s.Key as a gxui.KeyboardEvent.
+func (s MacroStep) KeyEvent() (gxui.KeyboardEvent, bool) {
+	if s.Key == "" {
+		return gxui.KeyboardEvent{}, false
+	}
+	events := parseBinding(s.Key)
+	if len(events) == 0 {
+		return gxui.KeyboardEvent{}, false
+	}
+	return events[0], true
+}
+
+// Macros returns all saved macros.
+func Macros() []Macro {
+	if macros == nil {
+		return nil
+	}
+	m, _ := macros.Get(macrosKey).([]Macro)
+	return m
+}
+
+// MacroNamed returns the saved macro named name.
+func MacroNamed(name string) (Macro, bool) {
+	for _, m := range Macros() {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return Macro{}, false
+}
+
+// SaveMacro saves m, replacing any macro with the same name.
+func SaveMacro(m Macro) error {
+	if macros == nil {
+		return os.ErrInvalid
+	}
+	all := Macros()
+	saved := make([]Macro, 0, len(all)+1)
+	for _, old := range all {
+		if old.Name != m.Name {
+			saved = append(saved, old)
+		}
+	}
+	macros.Set(macrosKey, append(saved, m))
+	return macros.Write()
+}
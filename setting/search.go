@@ -0,0 +1,22 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package setting
+
+const searchExcludesKey = "search_excludes"
+
+var defaultSearchExcludes = []string{
+	"node_modules/",
+}
+
+// SearchExcludes returns the patterns for files and directories that
+// project-wide searches should skip.  Patterns use the same format as
+// lines in a .gitignore file.
+func SearchExcludes() []string {
+	excludes, ok := settings.Get(searchExcludesKey).([]string)
+	if !ok {
+		return nil
+	}
+	return excludes
+}
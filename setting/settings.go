@@ -14,6 +14,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/OpenPeeDeeP/xdg"
@@ -38,6 +39,14 @@ const (
 
 	projectsFilename = "projects"
 	settingsFilename = "settings"
+
+	typeCheckKey = "type_check_highlighting"
+
+	undoGroupPauseKey = "undo_group_pause_ms"
+
+	// DefaultUndoGroupPause is the default value of UndoGroupPause,
+	// in milliseconds.
+	DefaultUndoGroupPause = 1000
 )
 
 var (
@@ -87,6 +96,12 @@ func init() {
 		log.Printf("Error reading settings: %s", err)
 	}
 	settings.SetDefault("fonts", []Font(nil))
+	settings.SetDefault(languageServersKey, defaultLanguageServers)
+	settings.SetDefault(typeCheckKey, false)
+	settings.SetDefault(undoGroupPauseKey, DefaultUndoGroupPause)
+	settings.SetDefault(searchExcludesKey, defaultSearchExcludes)
+	settings.SetDefault(taskHistoryKey, DefaultTaskHistory)
+	settings.SetDefault(hideIgnoredKey, false)
 }
 
 func updateDeprecatedGopath(c *config.Config) error {
@@ -246,6 +261,54 @@ func Projects() (projs []Project) {
 	return projs
 }
 
+// ProjectFor returns the project that path is in.  If path is in
+// multiple (nested) projects, the innermost project is returned.
+func ProjectFor(path string) (Project, bool) {
+	var (
+		proj  Project
+		found bool
+	)
+	for _, p := range Projects() {
+		if !inDir(path, p.Path) {
+			continue
+		}
+		if !found || len(p.Path) > len(proj.Path) {
+			proj = p
+			found = true
+		}
+	}
+	return proj, found
+}
+
+// inDir returns whether path is dir or is inside of it.
+func inDir(path, dir string) bool {
+	if path == dir {
+		return true
+	}
+	if !strings.HasSuffix(dir, string(filepath.Separator)) {
+		dir += string(filepath.Separator)
+	}
+	return strings.HasPrefix(path, dir)
+}
+
+// TypeCheckHighlighting returns whether or not syntax highlighters
+// should type check files to highlight identifiers by what they
+// refer to.  This is more accurate, but much slower.
+func TypeCheckHighlighting() bool {
+	enabled, ok := settings.Get(typeCheckKey).(bool)
+	return ok && enabled
+}
+
+// UndoGroupPause returns the longest pause between typed edits that
+// will still be grouped into a single undo step.
+func UndoGroupPause() time.Duration {
+	ms, ok := settings.Get(undoGroupPauseKey).(int)
+	if !ok {
+		ms = DefaultUndoGroupPause
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
 func AddProject(project Project) {
 	projects.Set("projects", append(Projects(), project))
 	if err := projects.Write(); err != nil {
@@ -0,0 +1,22 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package setting
+
+const (
+	taskHistoryKey = "task_history"
+
+	// DefaultTaskHistory is the default value of TaskHistory.
+	DefaultTaskHistory = 10
+)
+
+// TaskHistory returns the number of task runs (builds, tests, etc)
+// whose output is kept after they finish.
+func TaskHistory() int {
+	n, ok := settings.Get(taskHistoryKey).(int)
+	if !ok || n < 1 {
+		return DefaultTaskHistory
+	}
+	return n
+}
@@ -0,0 +1,18 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package swap
+
+import "os"
+
+func processAlive(pid int) bool {
+	// On windows, FindProcess opens the process, which fails if it
+	// isn't running.
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
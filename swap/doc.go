@@ -0,0 +1,10 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+// Package swap keeps copies of unsaved text on disk, so that it can
+// be recovered if vidar crashes before the text is saved.
+//
+// Like the coverage package, this package does not import any UI
+// code.
+package swap
@@ -0,0 +1,159 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package swap
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nelsam/vidar/atomicfile"
+)
+
+const swapExt = ".swap"
+
+// A Swap is the unsaved text of a file, as it was written to a swap
+// file.
+type Swap struct {
+	// Path is the path of the file that the text belongs to.
+	Path string `json:"path"`
+
+	// PID is the process ID of the vidar process that wrote the
+	// swap file.
+	PID int `json:"pid"`
+
+	// Saved is the time that the swap file was written.
+	Saved time.Time `json:"saved"`
+
+	// Contents is the unsaved text.
+	Contents string `json:"contents"`
+
+	file string
+}
+
+// Discard removes the swap file that s was read from.
+func (s Swap) Discard() error {
+	err := os.Remove(s.file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Store writes swap files to a directory.  Each process writes its
+// own swap files, so that swap files left behind by a process that
+// crashed are not overwritten by the next one.
+type Store struct {
+	// Dir is the directory that swap files are written to.
+	Dir string
+
+	// Alive reports whether the process with the passed in ID is
+	// still running.  Swap files written by processes that are not
+	// running are orphans.
+	Alive func(pid int) bool
+
+	// PID is the process ID that swaps are written for.
+	PID int
+}
+
+// NewStore returns a Store that writes swap files to dir for the
+// current process.
+func NewStore(dir string) *Store {
+	return &Store{Dir: dir, Alive: processAlive, PID: os.Getpid()}
+}
+
+func (s *Store) file(path string, pid int) string {
+	sum := sha256.Sum256([]byte(path))
+	name := fmt.Sprintf("%s-%d%s", hex.EncodeToString(sum[:16]), pid, swapExt)
+	return filepath.Join(s.Dir, name)
+}
+
+// Write writes contents to the swap file for path, replacing any
+// swap file that this process already wrote for it.
+func (s *Store) Write(path, contents string) error {
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return err
+	}
+	b, err := json.Marshal(Swap{
+		Path:     path,
+		PID:      s.PID,
+		Saved:    time.Now(),
+		Contents: contents,
+	})
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(s.file(path, s.PID), b, 0600)
+}
+
+// Remove removes the swap file that this process wrote for path, if
+// there is one.
+func (s *Store) Remove(path string) error {
+	err := os.Remove(s.file(path, s.PID))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Orphans returns the swaps that were written by processes that are
+// no longer running, oldest first.
+func (s *Store) Orphans() ([]Swap, error) {
+	finfos, err := ioutil.ReadDir(s.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var orphans []Swap
+	for _, finfo := range finfos {
+		name := finfo.Name()
+		if finfo.IsDir() || !strings.HasSuffix(name, swapExt) {
+			continue
+		}
+		pid, ok := filePID(name)
+		if !ok || pid == s.PID || s.Alive(pid) {
+			continue
+		}
+		file := filepath.Join(s.Dir, name)
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Printf("swap: could not read %s: %s", file, err)
+			continue
+		}
+		var sw Swap
+		if err := json.Unmarshal(b, &sw); err != nil {
+			log.Printf("swap: could not parse %s: %s", file, err)
+			continue
+		}
+		sw.file = file
+		orphans = append(orphans, sw)
+	}
+	sort.Slice(orphans, func(i, j int) bool {
+		return orphans[i].Saved.Before(orphans[j].Saved)
+	})
+	return orphans, nil
+}
+
+// filePID returns the process ID in the name of a swap file.
+func filePID(name string) (int, bool) {
+	name = strings.TrimSuffix(name, swapExt)
+	i := strings.LastIndex(name, "-")
+	if i < 0 {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(name[i+1:])
+	return pid, err == nil
+}
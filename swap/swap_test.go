@@ -0,0 +1,106 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package swap_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/nelsam/vidar/swap"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+func TestStore(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	const path = "/tmp/foo.go"
+
+	o.BeforeEach(func(t *testing.T) (expect.Expectation, string) {
+		dir, err := ioutil.TempDir("", "vidar-swap")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return expect.New(t), dir
+	})
+
+	o.AfterEach(func(_ expect.Expectation, dir string) {
+		os.RemoveAll(dir)
+	})
+
+	store := func(dir string, pid int, alive ...int) *swap.Store {
+		s := swap.NewStore(dir)
+		s.PID = pid
+		s.Alive = func(pid int) bool {
+			for _, a := range alive {
+				if a == pid {
+					return true
+				}
+			}
+			return false
+		}
+		return s
+	}
+
+	o.Spec("it returns swaps from processes that are not running", func(expect expect.Expectation, dir string) {
+		crashed := store(dir, 1)
+		expect(crashed.Write(path, "unsaved")).To(beNil())
+		expect(crashed.Write("/tmp/bar.go", "also unsaved")).To(beNil())
+
+		orphans, err := store(dir, 2).Orphans()
+		expect(err).To(beNil())
+		expect(orphans).To(haveLen(2))
+		expect(orphans[0].Path).To(equal(path))
+		expect(orphans[0].Contents).To(equal("unsaved"))
+		expect(orphans[0].PID).To(equal(1))
+	})
+
+	o.Spec("it skips its own swaps and those of running processes", func(expect expect.Expectation, dir string) {
+		expect(store(dir, 1).Write(path, "running")).To(beNil())
+		s := store(dir, 2, 1)
+		expect(s.Write(path, "mine")).To(beNil())
+
+		orphans, err := s.Orphans()
+		expect(err).To(beNil())
+		expect(orphans).To(haveLen(0))
+	})
+
+	o.Spec("it replaces and removes its own swaps", func(expect expect.Expectation, dir string) {
+		s := store(dir, 1)
+		expect(s.Write(path, "first")).To(beNil())
+		expect(s.Write(path, "second")).To(beNil())
+
+		orphans, err := store(dir, 2).Orphans()
+		expect(err).To(beNil())
+		expect(orphans).To(haveLen(1))
+		expect(orphans[0].Contents).To(equal("second"))
+
+		expect(s.Remove(path)).To(beNil())
+		orphans, err = store(dir, 2).Orphans()
+		expect(err).To(beNil())
+		expect(orphans).To(haveLen(0))
+	})
+
+	o.Spec("it discards orphans", func(expect expect.Expectation, dir string) {
+		expect(store(dir, 1).Write(path, "unsaved")).To(beNil())
+		s := store(dir, 2)
+		orphans, err := s.Orphans()
+		expect(err).To(beNil())
+		expect(orphans).To(haveLen(1))
+
+		expect(orphans[0].Discard()).To(beNil())
+		orphans, err = s.Orphans()
+		expect(err).To(beNil())
+		expect(orphans).To(haveLen(0))
+	})
+
+	o.Spec("it has no orphans before anything is written", func(expect expect.Expectation, dir string) {
+		orphans, err := store(dir+"/missing", 2).Orphans()
+		expect(err).To(beNil())
+		expect(orphans).To(haveLen(0))
+	})
+}
@@ -0,0 +1,100 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package syntax
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// exportCache holds the export data that the go tool has built for
+// each directory and environment that packages have been checked
+// in, so that the go tool doesn't need to be run on every check.
+var exportCache = struct {
+	sync.Mutex
+	m map[string]*exports
+}{m: make(map[string]*exports)}
+
+// exports keeps track of the export data files for packages, as
+// reported by go list.
+type exports struct {
+	dir string
+	env []string
+
+	mu    sync.Mutex
+	files map[string]string
+}
+
+func exportsFor(dir string, env []string) *exports {
+	key := dir + "\x00" + strings.Join(env, "\x00")
+	exportCache.Lock()
+	defer exportCache.Unlock()
+	e, ok := exportCache.m[key]
+	if !ok {
+		e = &exports{dir: dir, env: env, files: make(map[string]string)}
+		exportCache.m[key] = e
+	}
+	return e
+}
+
+// load runs go list for any of paths that have not already been
+// loaded, along with their dependencies.
+func (e *exports) load(paths ...string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var missing []string
+	for _, p := range paths {
+		if _, ok := e.files[p]; !ok && p != "unsafe" && p != "C" {
+			missing = append(missing, p)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	args := append([]string{"list", "-e", "-export", "-deps", "-f", "{{.ImportPath}}\t{{.Export}}"}, missing...)
+	cmd := exec.Command("go", args...)
+	cmd.Dir = e.dir
+	cmd.Env = e.env
+	var errOut bytes.Buffer
+	cmd.Stderr = &errOut
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("go list: %s: %s", err, strings.TrimSpace(errOut.String()))
+	}
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		parts := strings.SplitN(s.Text(), "\t", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		e.files[parts[0]] = parts[1]
+	}
+	for _, p := range missing {
+		if _, ok := e.files[p]; !ok {
+			// Remember that we couldn't find it, so that we don't
+			// run go list for it on every check.
+			e.files[p] = ""
+		}
+	}
+	return nil
+}
+
+// lookup opens the export data for path.  It is meant to be used
+// with importer.ForCompiler.
+func (e *exports) lookup(path string) (io.ReadCloser, error) {
+	e.mu.Lock()
+	file := e.files[path]
+	e.mu.Unlock()
+	if file == "" {
+		return nil, fmt.Errorf("no export data found for %s", path)
+	}
+	return os.Open(file)
+}
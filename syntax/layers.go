@@ -33,6 +33,11 @@ func New() *Syntax {
 // encountered while parsing source, but will still store as much
 // information as possible.
 func (s *Syntax) Parse(source string) error {
+	_, err := s.parse("", source)
+	return err
+}
+
+func (s *Syntax) parse(filename, source string) (*ast.File, error) {
 	s.runeOffsets = make([]int, len(source))
 	byteOffset := 0
 	for runeIdx, r := range []rune(source) {
@@ -47,7 +52,7 @@ func (s *Syntax) Parse(source string) error {
 	s.fileSet = token.NewFileSet()
 	s.scope = theme.ScopePair
 	s.layers = make(map[theme.LanguageConstruct]*text.SyntaxLayer)
-	f, err := parser.ParseFile(s.fileSet, "", source, parser.ParseComments)
+	f, err := parser.ParseFile(s.fileSet, filename, source, parser.ParseComments)
 
 	// Parse everything we can before returning the error.
 	if f.Package.IsValid() {
@@ -65,7 +70,7 @@ func (s *Syntax) Parse(source string) error {
 	for _, unresolved := range f.Unresolved {
 		s.addUnresolved(unresolved)
 	}
-	return err
+	return f, err
 }
 
 // Layers returns a gxui.CodeSyntaxLayer for each construct used from
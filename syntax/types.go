@@ -0,0 +1,194 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package syntax
+
+import (
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nelsam/vidar/theme"
+)
+
+// Check parses source the same way that Parse does, then type
+// checks it along with the other files in its package, which are
+// read from path's directory.  Identifiers are then highlighted by
+// the kind of object that they refer to: types, constants, package
+// names, fields, methods, functions, and unused variables.
+//
+// Imported packages are loaded by running the go tool with env as
+// its environment (see setting.Project.Environ), so that GOPATH,
+// GOFLAGS, and module settings are respected.
+//
+// Parse errors are returned, followed by errors loading imported
+// packages.  Type errors are ignored, since they only mean that some
+// identifiers can't be resolved.
+func (s *Syntax) Check(path, source string, env []string) error {
+	f, err := s.parse(path, source)
+	if f == nil || f.Name == nil {
+		return err
+	}
+
+	files := PackageFiles(s.fileSet, path, f)
+	dir := filepath.Dir(path)
+	exports := exportsFor(dir, env)
+	// Packages that fail to load only leave their identifiers
+	// without types, so the check goes on without them.
+	loadErr := exports.load(imports(files)...)
+
+	unused := make(map[token.Pos]bool)
+	info := &types.Info{
+		Defs: make(map[*ast.Ident]types.Object),
+		Uses: make(map[*ast.Ident]types.Object),
+	}
+	conf := types.Config{
+		Importer: importer.ForCompiler(s.fileSet, "gc", exports.lookup),
+		Error: func(err error) {
+			terr, ok := err.(types.Error)
+			if ok && terr.Soft && strings.Contains(terr.Msg, "declared") && strings.Contains(terr.Msg, "not used") {
+				unused[terr.Pos] = true
+			}
+		},
+	}
+	conf.Check(f.Name.Name, s.fileSet, files, info)
+
+	file := s.fileSet.File(f.Pos())
+	type typedIdent struct {
+		id        *ast.Ident
+		construct theme.LanguageConstruct
+	}
+	var idents []typedIdent
+	typed := make(map[int]bool)
+	add := func(id *ast.Ident, obj types.Object) {
+		if s.fileSet.File(id.Pos()) != file {
+			return
+		}
+		c, ok := objectConstruct(obj, unused[id.Pos()])
+		if !ok {
+			return
+		}
+		typed[s.runePos(file.Offset(id.Pos()))] = true
+		idents = append(idents, typedIdent{id: id, construct: c})
+	}
+	for id, obj := range info.Defs {
+		add(id, obj)
+	}
+	for id, obj := range info.Uses {
+		add(id, obj)
+	}
+	s.removeUntyped(typed)
+	sort.Slice(idents, func(i, j int) bool {
+		return idents[i].id.Pos() < idents[j].id.Pos()
+	})
+	for _, i := range idents {
+		s.add(i.construct, i.id.Pos(), len(i.id.Name))
+	}
+	if err != nil {
+		return err
+	}
+	return loadErr
+}
+
+// removeUntyped removes the spans that were added without type
+// information for identifiers that were found by the type checker.
+func (s *Syntax) removeUntyped(typed map[int]bool) {
+	for _, c := range []theme.LanguageConstruct{theme.Ident, theme.Type, theme.Func, theme.Builtin} {
+		layer, ok := s.layers[c]
+		if !ok {
+			continue
+		}
+		spans := layer.Spans[:0]
+		for _, span := range layer.Spans {
+			if !typed[span.Start] {
+				spans = append(spans, span)
+			}
+		}
+		layer.Spans = spans
+	}
+}
+
+// objectConstruct returns the construct that identifiers referring
+// to obj should be highlighted with.  It returns false for objects
+// that are already highlighted well without type information, like
+// builtins.
+func objectConstruct(obj types.Object, unused bool) (theme.LanguageConstruct, bool) {
+	if obj == nil || obj.Pkg() == nil {
+		// Universe objects (builtins, nil, etc) have no package.
+		return 0, false
+	}
+	switch o := obj.(type) {
+	case *types.PkgName:
+		return theme.Package, true
+	case *types.Const:
+		return theme.Const, true
+	case *types.TypeName:
+		return theme.Type, true
+	case *types.Var:
+		if o.IsField() {
+			return theme.Field, true
+		}
+		if unused {
+			return theme.Unused, true
+		}
+		return theme.Ident, true
+	case *types.Func:
+		if sig, ok := o.Type().(*types.Signature); ok && sig.Recv() != nil {
+			return theme.Method, true
+		}
+		return theme.Func, true
+	default:
+		return 0, false
+	}
+}
+
+// PackageFiles parses the other files in f's package from disk and
+// returns them along with f.  Test files are only included if f is
+// a test file.
+func PackageFiles(fset *token.FileSet, path string, f *ast.File) []*ast.File {
+	files := []*ast.File{f}
+	dir := filepath.Dir(path)
+	matches, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return files
+	}
+	test := strings.HasSuffix(path, "_test.go")
+	for _, m := range matches {
+		if m == path || (!test && strings.HasSuffix(m, "_test.go")) {
+			continue
+		}
+		if ok, err := build.Default.MatchFile(dir, filepath.Base(m)); err != nil || !ok {
+			continue
+		}
+		other, err := parser.ParseFile(fset, m, nil, 0)
+		if err != nil || other.Name.Name != f.Name.Name {
+			continue
+		}
+		files = append(files, other)
+	}
+	return files
+}
+
+func imports(files []*ast.File) []string {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, f := range files {
+		for _, spec := range f.Imports {
+			path, err := strconv.Unquote(spec.Path.Value)
+			if err != nil || seen[path] {
+				continue
+			}
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
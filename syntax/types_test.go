@@ -0,0 +1,110 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package syntax_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/apoydence/onpar"
+	"github.com/apoydence/onpar/expect"
+	. "github.com/apoydence/onpar/matchers"
+	"github.com/nelsam/vidar/commander/text"
+	"github.com/nelsam/vidar/syntax"
+	"github.com/nelsam/vidar/theme"
+)
+
+func TestCheck(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	const src = `package foo
+
+import "strings"
+
+const prefix = "foo"
+
+type Foo struct {
+	Name string
+}
+
+func (f Foo) HasPrefix() bool {
+	return strings.HasPrefix(f.Name, prefix+Bar)
+}
+
+func baz() {
+	unused := 1
+	len := func(string) int { return 0 }
+	_ = len(prefix)
+}
+`
+
+	o.BeforeEach(func(t *testing.T) (expect.Expectation, []text.SyntaxLayer) {
+		expect := expect.New(t)
+
+		dir, err := ioutil.TempDir("", "vidar-syntax")
+		expect(err).To(BeNil())
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "foo.go")
+		expect(ioutil.WriteFile(path, []byte(src), 0600)).To(BeNil())
+		bar := []byte("package foo\n\nconst Bar = \"bar\"\n")
+		expect(ioutil.WriteFile(filepath.Join(dir, "bar.go"), bar, 0600)).To(BeNil())
+
+		s := syntax.New()
+		expect(s.Check(path, src, os.Environ())).To(BeNil())
+		return expect, s.Layers()
+	})
+
+	o.Spec("it highlights package names", func(expect expect.Expectation, layers []text.SyntaxLayer) {
+		pkgs := findLayer(theme.Package, layers)
+		expect(pkgs.Spans).To(HaveLen(1))
+		expect(pkgs.Spans[0]).To(matchPosition{src: src, match: "strings", idx: 1})
+	})
+
+	o.Spec("it highlights constants, including those in other files", func(expect expect.Expectation, layers []text.SyntaxLayer) {
+		consts := findLayer(theme.Const, layers)
+		expect(consts.Spans).To(HaveLen(4))
+		expect(consts.Spans[0]).To(matchPosition{src: src, match: "prefix"})
+		expect(consts.Spans[1]).To(matchPosition{src: src, match: "prefix", idx: 1})
+		expect(consts.Spans[2]).To(matchPosition{src: src, match: "Bar"})
+		expect(consts.Spans[3]).To(matchPosition{src: src, match: "prefix", idx: 2})
+	})
+
+	o.Spec("it highlights fields and methods", func(expect expect.Expectation, layers []text.SyntaxLayer) {
+		fields := findLayer(theme.Field, layers)
+		expect(fields.Spans).To(HaveLen(2))
+		expect(fields.Spans[0]).To(matchPosition{src: src, match: "Name"})
+		expect(fields.Spans[1]).To(matchPosition{src: src, match: "Name", idx: 1})
+
+		methods := findLayer(theme.Method, layers)
+		expect(methods.Spans).To(HaveLen(1))
+		expect(methods.Spans[0]).To(matchPosition{src: src, match: "HasPrefix"})
+	})
+
+	o.Spec("it highlights types and functions", func(expect expect.Expectation, layers []text.SyntaxLayer) {
+		types := findLayer(theme.Type, layers)
+		expect(types.Spans).To(HaveLen(6))
+		expect(types.Spans[4]).To(matchPosition{src: src, match: "Foo"})
+		expect(types.Spans[5]).To(matchPosition{src: src, match: "Foo", idx: 1})
+
+		funcs := findLayer(theme.Func, layers)
+		expect(funcs.Spans).To(HaveLen(2))
+		expect(funcs.Spans[0]).To(matchPosition{src: src, match: "HasPrefix", idx: 1})
+		expect(funcs.Spans[1]).To(matchPosition{src: src, match: "baz"})
+	})
+
+	o.Spec("it highlights unused variables", func(expect expect.Expectation, layers []text.SyntaxLayer) {
+		unused := findLayer(theme.Unused, layers)
+		expect(unused.Spans).To(HaveLen(1))
+		expect(unused.Spans[0]).To(matchPosition{src: src, match: "unused"})
+	})
+
+	o.Spec("it does not highlight shadowed builtins as builtins", func(expect expect.Expectation, layers []text.SyntaxLayer) {
+		builtins := findLayer(theme.Builtin, layers)
+		expect(builtins.Spans).To(HaveLen(0))
+	})
+}
@@ -0,0 +1,286 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package task
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TestKind is the kind of function that go test runs.
+type TestKind int
+
+const (
+	Test TestKind = iota
+	Benchmark
+	Example
+)
+
+var testPrefixes = []struct {
+	prefix string
+	kind   TestKind
+}{
+	{"Test", Test},
+	{"Benchmark", Benchmark},
+	{"Example", Example},
+}
+
+// A TestFunc is a function in a _test.go file that go test runs.
+type TestFunc struct {
+	Name string
+	Kind TestKind
+
+	// Start and End are the rune offsets of the function's
+	// declaration, including its doc comment.
+	Start, End int
+}
+
+// TestFuncs parses src, the contents of a _test.go file, and returns
+// the test, benchmark, and example functions in it.
+func TestFuncs(src string) ([]TestFunc, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if f == nil {
+		return nil, err
+	}
+	var funcs []TestFunc
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil {
+			continue
+		}
+		kind, ok := testKind(fn.Name.Name)
+		if !ok {
+			continue
+		}
+		start := fn.Pos()
+		if fn.Doc != nil {
+			start = fn.Doc.Pos()
+		}
+		funcs = append(funcs, TestFunc{
+			Name:  fn.Name.Name,
+			Kind:  kind,
+			Start: runeOffset(src, fset.Position(start).Offset),
+			End:   runeOffset(src, fset.Position(fn.End()).Offset),
+		})
+	}
+	return funcs, err
+}
+
+// TestFuncAt returns the TestFunc in src that contains the rune
+// offset pos.
+func TestFuncAt(src string, pos int) (TestFunc, bool) {
+	funcs, _ := TestFuncs(src)
+	for _, f := range funcs {
+		if pos >= f.Start && pos <= f.End {
+			return f, true
+		}
+	}
+	return TestFunc{}, false
+}
+
+// TestArgs returns the arguments to go test that will run only
+// funcs, which must all be of the same kind.  Output is always
+// verbose, so that results can be parsed with TestResults.
+func TestArgs(funcs ...TestFunc) []string {
+	if len(funcs) == 0 {
+		return []string{"test", "-v", "."}
+	}
+	names := make([]string, 0, len(funcs))
+	for _, f := range funcs {
+		names = append(names, regexp.QuoteMeta(f.Name))
+	}
+	pattern := "^(" + strings.Join(names, "|") + ")$"
+	if funcs[0].Kind == Benchmark {
+		return []string{"test", "-v", "-run", "^$", "-bench", pattern, "."}
+	}
+	return []string{"test", "-v", "-run", pattern, "."}
+}
+
+func testKind(name string) (TestKind, bool) {
+	for _, p := range testPrefixes {
+		if !strings.HasPrefix(name, p.prefix) {
+			continue
+		}
+		// This matches the rule that go test uses: the prefix must
+		// not be followed by a lower case letter.
+		rest := name[len(p.prefix):]
+		if rest == "" {
+			return p.kind, true
+		}
+		r, _ := utf8.DecodeRuneInString(rest)
+		return p.kind, !unicode.IsLower(r)
+	}
+	return 0, false
+}
+
+func runeOffset(src string, byteOffset int) int {
+	if byteOffset > len(src) {
+		byteOffset = len(src)
+	}
+	return utf8.RuneCountInString(src[:byteOffset])
+}
+
+// TestStatus is the result of a single test.
+type TestStatus int
+
+const (
+	TestRunning TestStatus = iota
+	TestPassed
+	TestFailed
+	TestSkipped
+)
+
+func (s TestStatus) String() string {
+	switch s {
+	case TestRunning:
+		return "running"
+	case TestPassed:
+		return "passed"
+	case TestFailed:
+		return "failed"
+	case TestSkipped:
+		return "skipped"
+	default:
+		return "unknown"
+	}
+}
+
+// A TestResult is the result of a single test, benchmark, or
+// example.
+type TestResult struct {
+	Name    string
+	Status  TestStatus
+	Elapsed time.Duration
+
+	// Detail holds the measurements reported by a benchmark.
+	Detail string
+
+	// Output holds the lines that were logged by the test.
+	Output []Line
+
+	Subtests []*TestResult
+}
+
+var (
+	runLine    = regexp.MustCompile(`^=== (?:RUN|CONT)\s+(\S+)`)
+	resultLine = regexp.MustCompile(`^(\s*)--- (PASS|FAIL|SKIP): (\S+) \(([\d.]+)s\)`)
+	benchLine  = regexp.MustCompile(`^(Benchmark\S+?)(?:-\d+)?\s+(\d+\s+.*)$`)
+)
+
+// TestResults collects the results of a go test -v run from its
+// output.
+type TestResults struct {
+	byName map[string]*TestResult
+	roots  []*TestResult
+
+	// last is the test that indented output belongs to.
+	last *TestResult
+}
+
+// Add parses l as a line of go test -v output, returning whether it
+// changed the results.
+func (r *TestResults) Add(l Line) bool {
+	if r.byName == nil {
+		r.byName = make(map[string]*TestResult)
+	}
+	if m := runLine.FindStringSubmatch(l.Text); m != nil {
+		// Since go 1.14, a test's output is printed while it runs,
+		// before its result.
+		r.last = r.result(m[1])
+		return true
+	}
+	if m := resultLine.FindStringSubmatch(l.Text); m != nil {
+		res := r.result(m[3])
+		switch m[2] {
+		case "PASS":
+			res.Status = TestPassed
+		case "FAIL":
+			res.Status = TestFailed
+		case "SKIP":
+			res.Status = TestSkipped
+		}
+		if secs, err := time.ParseDuration(m[4] + "s"); err == nil {
+			res.Elapsed = secs
+		}
+		r.last = res
+		return true
+	}
+	if m := benchLine.FindStringSubmatch(l.Text); m != nil {
+		res := r.result(m[1])
+		res.Status = TestPassed
+		res.Detail = strings.Join(strings.Fields(m[2]), " ")
+		r.last = nil
+		return true
+	}
+	if r.last != nil && strings.HasPrefix(l.Text, "    ") {
+		l.Text = strings.TrimSpace(l.Text)
+		r.last.Output = append(r.last.Output, l)
+		return true
+	}
+	return false
+}
+
+// Results returns the results of the top level tests, in the order
+// that they were run.
+func (r *TestResults) Results() []*TestResult {
+	return r.roots
+}
+
+// Failed returns the number of top level tests that failed.
+func (r *TestResults) Failed() int {
+	failed := 0
+	for _, res := range r.roots {
+		if res.Status == TestFailed {
+			failed++
+		}
+	}
+	return failed
+}
+
+func (r *TestResults) result(name string) *TestResult {
+	if res, ok := r.byName[name]; ok {
+		return res
+	}
+	res := &TestResult{Name: name}
+	r.byName[name] = res
+	parent := ""
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		parent = name[:i]
+	}
+	if parent == "" {
+		r.roots = append(r.roots, res)
+		return res
+	}
+	p := r.result(parent)
+	p.Subtests = append(p.Subtests, res)
+	return res
+}
+
+// Snapshot returns a copy of the results of the top level tests,
+// which is safe to read while more output is added to r.
+func (r *TestResults) Snapshot() []*TestResult {
+	return copyResults(r.roots)
+}
+
+func copyResults(results []*TestResult) []*TestResult {
+	if results == nil {
+		return nil
+	}
+	cp := make([]*TestResult, 0, len(results))
+	for _, res := range results {
+		c := *res
+		c.Output = append([]Line(nil), res.Output...)
+		c.Subtests = copyResults(res.Subtests)
+		cp = append(cp, &c)
+	}
+	return cp
+}
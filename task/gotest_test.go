@@ -0,0 +1,150 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package task_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nelsam/vidar/task"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+const testSrc = `package foo_test
+
+import "testing"
+
+// TestFoo tests foo.
+func TestFoo(t *testing.T) {
+	t.Log("héllo")
+}
+
+func Testable(t *testing.T) {}
+
+func BenchmarkFoo(b *testing.B) {}
+
+func ExampleFoo() {}
+
+func (s suite) TestMethod(t *testing.T) {}
+
+func BenchmarkBar(b *testing.B) {}
+`
+
+func TestTestFuncs(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	o.BeforeEach(func(t *testing.T) expect.Expectation {
+		return expect.New(t)
+	})
+
+	o.Spec("it finds the funcs that go test runs", func(expect expect.Expectation) {
+		funcs, err := task.TestFuncs(testSrc)
+		expect(err).To(beNil())
+		expect(funcs).To(haveLen(4))
+		var names []string
+		for _, f := range funcs {
+			names = append(names, f.Name)
+		}
+		expect(names).To(equal([]string{"TestFoo", "BenchmarkFoo", "ExampleFoo", "BenchmarkBar"}))
+		expect(funcs[1].Kind).To(equal(task.Benchmark))
+		expect(funcs[2].Kind).To(equal(task.Example))
+	})
+
+	o.Spec("it finds the func containing a rune offset", func(expect expect.Expectation) {
+		doc := strings.Index(testSrc, "// TestFoo")
+		f, ok := task.TestFuncAt(testSrc, doc)
+		expect(ok).To(beTrue())
+		expect(f.Name).To(equal("TestFoo"))
+
+		// The offset is in runes, so the multi-byte rune in TestFoo
+		// must not push the end of the func forward.
+		end := len([]rune(testSrc[:strings.Index(testSrc, "func Testable")])) - 2
+		f, ok = task.TestFuncAt(testSrc, end)
+		expect(ok).To(beTrue())
+		expect(f.Name).To(equal("TestFoo"))
+
+		_, ok = task.TestFuncAt(testSrc, end+1)
+		expect(ok).To(beFalse())
+
+		_, ok = task.TestFuncAt(testSrc, strings.Index(testSrc, "TestMethod"))
+		expect(ok).To(beFalse())
+	})
+
+	o.Spec("it builds go test arguments", func(expect expect.Expectation) {
+		expect(task.TestArgs(task.TestFunc{Name: "TestFoo", Kind: task.Test})).To(equal(
+			[]string{"test", "-v", "-run", "^(TestFoo)$", "."},
+		))
+		expect(task.TestArgs(
+			task.TestFunc{Name: "BenchmarkFoo", Kind: task.Benchmark},
+			task.TestFunc{Name: "BenchmarkBar", Kind: task.Benchmark},
+		)).To(equal(
+			[]string{"test", "-v", "-run", "^$", "-bench", "^(BenchmarkFoo|BenchmarkBar)$", "."},
+		))
+	})
+}
+
+func TestTestResults(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	o.BeforeEach(func(t *testing.T) expect.Expectation {
+		return expect.New(t)
+	})
+
+	o.Spec("it parses verbose go test output", func(expect expect.Expectation) {
+		var r task.TestResults
+		out := []string{
+			"=== RUN   TestFoo",
+			"=== RUN   TestFoo/sub",
+			"    foo_test.go:12: bad",
+			"--- FAIL: TestFoo (0.02s)",
+			"    --- FAIL: TestFoo/sub (0.01s)",
+			"=== RUN   TestBar",
+			"    bar_test.go:4: not today",
+			"--- SKIP: TestBar (0.00s)",
+			"FAIL",
+		}
+		for _, l := range out {
+			r.Add(task.Line{Text: l})
+		}
+		results := r.Results()
+		expect(results).To(haveLen(2))
+		expect(results[0].Name).To(equal("TestFoo"))
+		expect(results[0].Status).To(equal(task.TestFailed))
+		expect(results[0].Elapsed).To(equal(20 * time.Millisecond))
+		expect(results[0].Subtests).To(haveLen(1))
+		sub := results[0].Subtests[0]
+		expect(sub.Status).To(equal(task.TestFailed))
+		expect(sub.Output).To(haveLen(1))
+		expect(sub.Output[0].Text).To(equal("foo_test.go:12: bad"))
+		expect(results[0].Output).To(haveLen(0))
+		expect(results[1].Status).To(equal(task.TestSkipped))
+		expect(results[1].Output).To(haveLen(1))
+		expect(r.Failed()).To(equal(1))
+	})
+
+	o.Spec("it parses benchmark results", func(expect expect.Expectation) {
+		var r task.TestResults
+		expect(r.Add(task.Line{Text: "goos: linux"})).To(beFalse())
+		expect(r.Add(task.Line{Text: "BenchmarkFoo-8   \t 1000000\t      1234 ns/op"})).To(beTrue())
+		results := r.Results()
+		expect(results).To(haveLen(1))
+		expect(results[0].Name).To(equal("BenchmarkFoo"))
+		expect(results[0].Status).To(equal(task.TestPassed))
+		expect(results[0].Detail).To(equal("1000000 1234 ns/op"))
+	})
+
+	o.Spec("its snapshots are not changed by later output", func(expect expect.Expectation) {
+		var r task.TestResults
+		r.Add(task.Line{Text: "=== RUN   TestFoo"})
+		snap := r.Snapshot()
+		r.Add(task.Line{Text: "--- PASS: TestFoo (0.00s)"})
+		expect(snap[0].Status).To(equal(task.TestRunning))
+		expect(r.Results()[0].Status).To(equal(task.TestPassed))
+	})
+}
@@ -0,0 +1,46 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package task
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var linkPattern = regexp.MustCompile(`^\s*(?:vet: )?((?:[A-Za-z]:)?[^\s:]+\.go):(\d+)(?::(\d+))?:`)
+
+// A Link is a location in a file that a line of output refers to.
+// Line and Column are 0-based, to match focus.Line and focus.Column.
+type Link struct {
+	Path         string
+	Line, Column int
+}
+
+// ParseLink parses a file:line:col (or file:line) reference at the
+// start of text, as printed by the go tool and most linters.
+// Relative paths are resolved relative to dir.
+func ParseLink(dir, text string) (Link, bool) {
+	match := linkPattern.FindStringSubmatch(text)
+	if match == nil {
+		return Link{}, false
+	}
+	path := strings.TrimPrefix(match[1], "./")
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	line, err := strconv.Atoi(match[2])
+	if err != nil || line < 1 {
+		return Link{}, false
+	}
+	l := Link{Path: path, Line: line - 1}
+	if match[3] != "" {
+		if col, err := strconv.Atoi(match[3]); err == nil && col > 0 {
+			l.Column = col - 1
+		}
+	}
+	return l, true
+}
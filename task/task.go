@@ -0,0 +1,318 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+// Package task runs external tools (like go build and go test) on
+// behalf of the editor, streaming their output and keeping a short
+// history of previous runs.
+package task
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Status is the state of a Run.
+type Status int
+
+const (
+	// Running means that the Run has not finished yet.
+	Running Status = iota
+
+	// Passed means that the Run's command exited successfully.
+	Passed
+
+	// Failed means that the Run's command exited with an error, or
+	// could not be started.
+	Failed
+
+	// Cancelled means that the Run was cancelled before its command
+	// finished.
+	Cancelled
+)
+
+func (s Status) String() string {
+	switch s {
+	case Running:
+		return "running"
+	case Passed:
+		return "passed"
+	case Failed:
+		return "failed"
+	case Cancelled:
+		return "cancelled"
+	default:
+		return "unknown"
+	}
+}
+
+// A Line is a single line of output from a Run.
+type Line struct {
+	Text string
+
+	// Link is the location in a file that Text refers to, or nil if
+	// Text does not refer to a location.
+	Link *Link
+}
+
+// An Observer is notified as a Run progresses.  Its methods are
+// called from the goroutine that reads the Run's output, never
+// concurrently for the same Run.
+type Observer interface {
+	Started(*Run)
+	Output(*Run, Line)
+	Finished(*Run)
+}
+
+// A Run is a single execution of a command.
+type Run struct {
+	// ID is unique to each Run started by a Runner.
+	ID int
+
+	// Name is a human-readable name for the Run.
+	Name string
+
+	// Dir is the directory that the command runs in.
+	Dir string
+
+	// Args is the command and its arguments.
+	Args []string
+
+	// Env is the environment that the command runs with.
+	Env []string
+
+	Started time.Time
+
+	cancel func()
+	done   chan struct{}
+
+	mu       sync.Mutex
+	lines    []Line
+	status   Status
+	err      error
+	finished time.Time
+}
+
+// Lines returns the output that r has produced so far.
+func (r *Run) Lines() []Line {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Line(nil), r.lines...)
+}
+
+// LineCount returns the number of lines of output that r has
+// produced so far.
+func (r *Run) LineCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.lines)
+}
+
+// Status returns the current Status of r.
+func (r *Run) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+// Err returns the error that r failed with, if any.
+func (r *Run) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Duration returns how long r ran for, or how long it has been
+// running if it has not finished.
+func (r *Run) Duration() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.finished.IsZero() {
+		return time.Since(r.Started)
+	}
+	return r.finished.Sub(r.Started)
+}
+
+// Cancel stops r's command, if it is still running.
+func (r *Run) Cancel() {
+	r.cancel()
+}
+
+// Wait blocks until r has finished.
+func (r *Run) Wait() {
+	<-r.done
+}
+
+func (r *Run) addLine(l Line) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines = append(r.lines, l)
+}
+
+func (r *Run) finish(s Status, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = s
+	r.err = err
+	r.finished = time.Now()
+}
+
+// Runner starts Runs, keeping track of the most recent ones.
+type Runner struct {
+	// Max is the number of Runs kept in the history.  If it is zero
+	// or less, only the most recent Run is kept.
+	Max int
+
+	mu   sync.Mutex
+	next int
+	runs []*Run
+}
+
+// Start starts args[0] with the remaining args as its arguments,
+// running in dir with env as its environment.  Output is parsed
+// into Lines and sent to obs, which may be nil, as it is read.
+func (r *Runner) Start(obs Observer, name, dir string, env []string, args ...string) *Run {
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &Run{
+		Name:    name,
+		Dir:     dir,
+		Args:    args,
+		Env:     env,
+		Started: time.Now(),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	r.add(run)
+	go r.exec(ctx, obs, run)
+	return run
+}
+
+// History returns the Runs that r remembers, oldest first.
+func (r *Runner) History() []*Run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Run(nil), r.runs...)
+}
+
+// Last returns the most recent Run, or nil if nothing has been run.
+func (r *Runner) Last() *Run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.runs) == 0 {
+		return nil
+	}
+	return r.runs[len(r.runs)-1]
+}
+
+// CancelAll cancels every Run that is still running, returning the
+// number of Runs that were cancelled.
+func (r *Runner) CancelAll() int {
+	cancelled := 0
+	for _, run := range r.History() {
+		if run.Status() != Running {
+			continue
+		}
+		run.Cancel()
+		cancelled++
+	}
+	return cancelled
+}
+
+func (r *Runner) add(run *Run) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.next++
+	run.ID = r.next
+	r.runs = append(r.runs, run)
+	max := r.Max
+	if max < 1 {
+		max = 1
+	}
+	if len(r.runs) > max {
+		r.runs = append(r.runs[:0], r.runs[len(r.runs)-max:]...)
+	}
+}
+
+func (r *Runner) exec(ctx context.Context, obs Observer, run *Run) {
+	defer close(run.done)
+	defer run.cancel()
+	if obs != nil {
+		obs.Started(run)
+	}
+	status, err := r.wait(ctx, obs, run)
+	run.finish(status, err)
+	if obs != nil {
+		obs.Finished(run)
+	}
+}
+
+func (r *Runner) wait(ctx context.Context, obs Observer, run *Run) (Status, error) {
+	if len(run.Args) == 0 {
+		return Failed, errors.New("task: no command to run")
+	}
+	cmd := exec.CommandContext(ctx, run.Args[0], run.Args[1:]...)
+	cmd.Dir = run.Dir
+	cmd.Env = run.Env
+	out, w := io.Pipe()
+	cmd.Stdout = w
+	cmd.Stderr = w
+	if err := cmd.Start(); err != nil {
+		return Failed, err
+	}
+	go func() {
+		w.CloseWithError(cmd.Wait())
+	}()
+
+	// The command's output is read in a separate goroutine so that
+	// a cancelled Run finishes right away, even if a child process
+	// is still holding the output open.
+	lines := make(chan string)
+	var scanErr error
+	go func() {
+		defer close(lines)
+		s := bufio.NewScanner(out)
+		s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for s.Scan() {
+			select {
+			case lines <- strings.TrimRight(s.Text(), "\r"):
+			case <-ctx.Done():
+			}
+		}
+		scanErr = s.Err()
+		// If the scanner stopped early (e.g. on a very long line),
+		// the command may still be writing; drain it so that it can
+		// exit.
+		io.Copy(ioutil.Discard, out)
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return Cancelled, ctx.Err()
+		case text, ok := <-lines:
+			if !ok {
+				if ctx.Err() != nil {
+					return Cancelled, ctx.Err()
+				}
+				if scanErr != nil {
+					return Failed, scanErr
+				}
+				return Passed, nil
+			}
+			l := Line{Text: text}
+			if link, ok := ParseLink(run.Dir, text); ok {
+				l.Link = &link
+			}
+			run.addLine(l)
+			if obs != nil {
+				obs.Output(run, l)
+			}
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// This is free and unencumbered software released into the public
+// domain.  For more information, see <http://unlicense.org> or the
+// accompanying UNLICENSE file.
+
+package task_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/nelsam/vidar/task"
+	"github.com/poy/onpar"
+	"github.com/poy/onpar/expect"
+)
+
+type observer struct {
+	mu       sync.Mutex
+	started  int
+	lines    []task.Line
+	finished int
+	onOutput func(*task.Run)
+}
+
+func (o *observer) Started(*task.Run) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.started++
+}
+
+func (o *observer) Output(r *task.Run, l task.Line) {
+	o.mu.Lock()
+	o.lines = append(o.lines, l)
+	o.mu.Unlock()
+	if o.onOutput != nil {
+		o.onOutput(r)
+	}
+}
+
+func (o *observer) Finished(*task.Run) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.finished++
+}
+
+func TestRunner(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	o.BeforeEach(func(t *testing.T) expect.Expectation {
+		return expect.New(t)
+	})
+
+	o.Spec("it streams output and links", func(expect expect.Expectation) {
+		obs := &observer{}
+		var r task.Runner
+		run := r.Start(obs, "test", "/", nil, "sh", "-c", "echo ok; echo './a.go:3:4: bad' >&2")
+		run.Wait()
+		expect(run.Status()).To(equal(task.Passed))
+		expect(obs.started).To(equal(1))
+		expect(obs.finished).To(equal(1))
+		expect(obs.lines).To(haveLen(2))
+		expect(obs.lines[0].Link).To(beNil())
+		expect(*obs.lines[1].Link).To(equal(task.Link{Path: "/a.go", Line: 2, Column: 3}))
+		expect(run.Lines()).To(equal(obs.lines))
+	})
+
+	o.Spec("it reports failures", func(expect expect.Expectation) {
+		var r task.Runner
+		run := r.Start(nil, "test", "", nil, "sh", "-c", "exit 2")
+		run.Wait()
+		expect(run.Status()).To(equal(task.Failed))
+		expect(run.Err()).To(not(beNil()))
+
+		run = r.Start(nil, "test", "", nil)
+		run.Wait()
+		expect(run.Status()).To(equal(task.Failed))
+	})
+
+	o.Spec("it cancels runs", func(expect expect.Expectation) {
+		var r task.Runner
+		obs := &observer{onOutput: func(run *task.Run) { run.Cancel() }}
+		run := r.Start(obs, "test", "", nil, "sh", "-c", "echo start; sleep 10; echo end")
+		run.Wait()
+		expect(run.Status()).To(equal(task.Cancelled))
+		expect(run.Lines()).To(haveLen(1))
+	})
+
+	o.Spec("it keeps the last Max runs", func(expect expect.Expectation) {
+		r := task.Runner{Max: 2}
+		for i := 0; i < 3; i++ {
+			r.Start(nil, "test", "", nil, "true").Wait()
+		}
+		history := r.History()
+		expect(history).To(haveLen(2))
+		expect(history[0].ID).To(equal(2))
+		expect(r.Last().ID).To(equal(3))
+	})
+}
+
+func TestParseLink(t *testing.T) {
+	o := onpar.New()
+	defer o.Run(t)
+
+	o.BeforeEach(func(t *testing.T) expect.Expectation {
+		return expect.New(t)
+	})
+
+	o.Spec("it parses go tool output", func(expect expect.Expectation) {
+		l, ok := task.ParseLink("/p", "./main.go:10:2: undefined: foo")
+		expect(ok).To(beTrue())
+		expect(l).To(equal(task.Link{Path: "/p/main.go", Line: 9, Column: 1}))
+
+		l, ok = task.ParseLink("/p", "    foo_test.go:12: expected 1")
+		expect(ok).To(beTrue())
+		expect(l).To(equal(task.Link{Path: "/p/foo_test.go", Line: 11}))
+
+		l, ok = task.ParseLink("/p", "vet: /q/bar.go:1:1: oops")
+		expect(ok).To(beTrue())
+		expect(l).To(equal(task.Link{Path: "/q/bar.go"}))
+	})
+
+	o.Spec("it ignores other lines", func(expect expect.Expectation) {
+		_, ok := task.ParseLink("/p", "ok  	github.com/foo/bar	0.01s")
+		expect(ok).To(beFalse())
+		_, ok = task.ParseLink("/p", "--- FAIL: TestFoo (0.00s)")
+		expect(ok).To(beFalse())
+	})
+}
@@ -21,6 +21,56 @@ const (
 
 	Bad
 
+	// ErrorDiagnostic, WarningDiagnostic, and InfoDiagnostic are
+	// used for messages from compilers and linters.  They are
+	// usually highlighted with underlines and gutter markers
+	// rather than text colors, so that they can be displayed
+	// on top of syntax highlighting.
+	ErrorDiagnostic
+	WarningDiagnostic
+	InfoDiagnostic
+
+	// Const, Package, Field, Method, and Unused are constructs
+	// that can only be found with type information, so they will
+	// only be used by syntax highlighters that perform type
+	// checking.
+	Const
+	Package
+	Field
+	Method
+	Unused
+
+	// Covered and Uncovered are used for code that was or was not
+	// run by tests, as reported by a coverage profile.  They are
+	// usually highlighted with background colors, so that they can
+	// be displayed on top of syntax highlighting.
+	Covered
+	Uncovered
+
+	// Added, Modified, and Deleted are used for lines that differ
+	// from the version of a file in version control.  They are
+	// usually highlighted with gutter markers.
+	Added
+	Modified
+	Deleted
+
+	// DiffInserted, DiffRemoved, and DiffChanged are used for lines
+	// that differ between the two sides of a diff view, and
+	// DiffChangedText for the text that changed within a changed
+	// line.  DiffFiller marks the blank lines that keep the two sides
+	// lined up.
+	DiffInserted
+	DiffRemoved
+	DiffChanged
+	DiffChangedText
+	DiffFiller
+
+	// Breakpoint marks the lines that have debugger breakpoints,
+	// and DebugLine marks the line that the debugged process is
+	// stopped at.
+	Breakpoint
+	DebugLine
+
 	// ScopePair is a much higher value to provide extra space
 	// for other language constructs (e.g. for languages that
 	// have constructs that Go doesn't).  Because ScopePairs are
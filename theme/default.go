@@ -75,5 +75,175 @@ var Default = Theme{
 			B: 0.6,
 			A: 1.0,
 		}},
+		Const: Highlight{Foreground: Color{
+			R: 0.8,
+			G: 0.4,
+			B: 0.6,
+			A: 1,
+		}},
+		Package: Highlight{Foreground: Color{
+			R: 0.5,
+			G: 0.7,
+			B: 0.9,
+			A: 1,
+		}},
+		Field: Highlight{Foreground: Color{
+			R: 0.7,
+			G: 0.8,
+			B: 0.6,
+			A: 1,
+		}},
+		Method: Highlight{Foreground: Color{
+			R: 0.5,
+			G: 0.8,
+			B: 0.2,
+			A: 1,
+		}},
+		Unused: Highlight{Foreground: Color{
+			R: 0.5,
+			G: 0.5,
+			B: 0.5,
+			A: 1,
+		}},
+		ErrorDiagnostic: Highlight{
+			Underline: Color{
+				R: 1,
+				G: 0.2,
+				B: 0,
+				A: 1,
+			},
+			Gutter: Color{
+				R: 1,
+				G: 0.2,
+				B: 0,
+				A: 1,
+			},
+		},
+		WarningDiagnostic: Highlight{
+			Underline: Color{
+				R: 0.8,
+				G: 0.7,
+				B: 0.1,
+				A: 1,
+			},
+			Gutter: Color{
+				R: 0.8,
+				G: 0.7,
+				B: 0.1,
+				A: 1,
+			},
+		},
+		InfoDiagnostic: Highlight{
+			Underline: Color{
+				R: 0.1,
+				G: 0.6,
+				B: 0.8,
+				A: 1,
+			},
+		},
+		Covered: Highlight{Background: Color{
+			R: 0.1,
+			G: 0.25,
+			B: 0.1,
+			A: 1,
+		}},
+		Uncovered: Highlight{Background: Color{
+			R: 0.3,
+			G: 0.1,
+			B: 0.1,
+			A: 1,
+		}},
+		Added: Highlight{Gutter: Color{
+			R: 0.3,
+			G: 0.8,
+			B: 0.3,
+			A: 1,
+		}},
+		Modified: Highlight{Gutter: Color{
+			R: 0.3,
+			G: 0.5,
+			B: 0.9,
+			A: 1,
+		}},
+		Deleted: Highlight{Gutter: Color{
+			R: 0.9,
+			G: 0.3,
+			B: 0.3,
+			A: 1,
+		}},
+		DiffInserted: Highlight{
+			Background: Color{
+				R: 0.1,
+				G: 0.25,
+				B: 0.1,
+				A: 1,
+			},
+			Gutter: Color{
+				R: 0.3,
+				G: 0.8,
+				B: 0.3,
+				A: 1,
+			},
+		},
+		DiffRemoved: Highlight{
+			Background: Color{
+				R: 0.3,
+				G: 0.1,
+				B: 0.1,
+				A: 1,
+			},
+			Gutter: Color{
+				R: 0.9,
+				G: 0.3,
+				B: 0.3,
+				A: 1,
+			},
+		},
+		DiffChanged: Highlight{
+			Background: Color{
+				R: 0.1,
+				G: 0.15,
+				B: 0.3,
+				A: 1,
+			},
+			Gutter: Color{
+				R: 0.3,
+				G: 0.5,
+				B: 0.9,
+				A: 1,
+			},
+		},
+		DiffChangedText: Highlight{Background: Color{
+			R: 0.2,
+			G: 0.3,
+			B: 0.55,
+			A: 1,
+		}},
+		DiffFiller: Highlight{Gutter: Color{
+			R: 0.35,
+			G: 0.35,
+			B: 0.35,
+			A: 1,
+		}},
+		Breakpoint: Highlight{Gutter: Color{
+			R: 0.9,
+			G: 0.2,
+			B: 0.2,
+			A: 1,
+		}},
+		DebugLine: Highlight{
+			Background: Color{
+				R: 0.35,
+				G: 0.3,
+				B: 0.05,
+				A: 1,
+			},
+			Gutter: Color{
+				R: 0.95,
+				G: 0.8,
+				B: 0.2,
+				A: 1,
+			},
+		},
 	},
 }
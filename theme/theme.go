@@ -10,6 +10,13 @@ type Color struct {
 
 type Highlight struct {
 	Foreground, Background Color
+
+	// Underline, if set, is the color used to underline text.
+	Underline Color
+
+	// Gutter, if set, is the color used to mark the gutter next
+	// to any line that the highlighted text is on.
+	Gutter Color
 }
 
 type ConstructHighlights map[LanguageConstruct]Highlight